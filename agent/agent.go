@@ -0,0 +1,428 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/model"
+)
+
+// DefaultHeartbeatInterval is the interval at which agents publish heartbeats unless configured otherwise.
+const DefaultHeartbeatInterval = 5 * time.Second
+
+// stopGracePeriod is how long an instance gets to drain after SIGTERM before it is killed.
+const stopGracePeriod = 30 * time.Second
+
+// Config configures an Agent.
+type Config struct {
+	// Id identifies the agent. It must be a valid subject token other than HEARTBEAT, PING and METRICS.
+	Id string
+	// Namespace is the account the agent runs instances for.
+	Namespace string
+	// Tags are matched against the placement tags of the instances to start.
+	Tags []string
+	// Backend launches the instances.
+	Backend Backend
+	// HeartbeatInterval defaults to DefaultHeartbeatInterval.
+	HeartbeatInterval time.Duration
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Agent runs connector instances on a node.
+type Agent struct {
+	cfg  Config
+	nc   *nats.Conn
+	subs []*nats.Subscription
+
+	mu        sync.Mutex
+	instances map[string]*instance
+	closing   bool
+	stopping  sync.WaitGroup
+
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type instance struct {
+	workload  Workload
+	cmd       *exec.Cmd
+	cancel    context.CancelFunc
+	startedAt time.Time
+	logLines  atomic.Int64
+
+	// set once the command exited
+	state    string
+	exitCode int
+	err      error
+	exited   chan struct{}
+}
+
+// New creates an agent running instances once started.
+func New(nc *nats.Conn, cfg Config) (*Agent, error) {
+	if cfg.Id == "" {
+		return nil, fmt.Errorf("agent id is required")
+	}
+	if reservedTokens[cfg.Id] {
+		return nil, fmt.Errorf("agent id %s is reserved", cfg.Id)
+	}
+	if cfg.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if cfg.Backend == nil {
+		return nil, fmt.Errorf("backend is required")
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &Agent{
+		cfg:       cfg,
+		nc:        nc,
+		instances: map[string]*instance{},
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Id returns the id of the agent.
+func (a *Agent) Id() string {
+	return a.cfg.Id
+}
+
+// Start subscribes to the commands of the agent and starts publishing heartbeats.
+func (a *Agent) Start() error {
+	handlers := map[string]nats.MsgHandler{
+		commandSubject(a.cfg.Namespace, a.cfg.Id, "START"): a.handleStart,
+		commandSubject(a.cfg.Namespace, a.cfg.Id, "STOP"):  a.handleStop,
+		pingSubject(a.cfg.Namespace):                       func(*nats.Msg) { a.publishHeartbeat() },
+	}
+	for subject, h := range handlers {
+		sub, err := a.nc.Subscribe(subject, h)
+		if err != nil {
+			a.unsubscribe()
+			return fmt.Errorf("could not subscribe to %s: %w", subject, err)
+		}
+		a.subs = append(a.subs, sub)
+	}
+
+	if err := a.nc.Flush(); err != nil {
+		a.unsubscribe()
+		return fmt.Errorf("could not flush subscriptions: %w", err)
+	}
+
+	a.wg.Add(1)
+	go a.heartbeatLoop()
+
+	a.publishHeartbeat()
+	return nil
+}
+
+// Stop stops accepting commands and stops all instances, waiting for them to drain.
+func (a *Agent) Stop() {
+	a.stopOnce.Do(a.stop)
+}
+
+func (a *Agent) stop() {
+	a.unsubscribe()
+
+	a.mu.Lock()
+	a.closing = true
+	instances := make([]*instance, 0, len(a.instances))
+	for _, inst := range a.instances {
+		instances = append(instances, inst)
+	}
+	a.mu.Unlock()
+
+	for _, inst := range instances {
+		a.stopInstance(inst)
+	}
+	a.stopping.Wait()
+
+	close(a.done)
+	a.wg.Wait()
+
+	a.publishHeartbeat()
+}
+
+func (a *Agent) unsubscribe() {
+	for _, sub := range a.subs {
+		_ = sub.Unsubscribe()
+	}
+	a.subs = nil
+}
+
+// Heartbeat returns the current state of the agent.
+func (a *Agent) Heartbeat() Heartbeat {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	hb := Heartbeat{
+		AgentId:   a.cfg.Id,
+		Tags:      a.cfg.Tags,
+		Backend:   a.cfg.Backend.Name(),
+		Interval:  a.cfg.HeartbeatInterval,
+		Time:      time.Now().UTC(),
+		Instances: []InstanceState{},
+	}
+	for id, inst := range a.instances {
+		st := InstanceState{
+			ConnectorId: inst.workload.ConnectorId,
+			InstanceId:  id,
+			State:       inst.state,
+			StartedAt:   inst.startedAt,
+			ExitCode:    inst.exitCode,
+		}
+		if inst.err != nil {
+			st.Error = inst.err.Error()
+		}
+		hb.Instances = append(hb.Instances, st)
+	}
+	return hb
+}
+
+func (a *Agent) heartbeatLoop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			a.publishHeartbeat()
+			a.publishMetrics()
+		}
+	}
+}
+
+func (a *Agent) publishHeartbeat() {
+	b, err := json.Marshal(a.Heartbeat())
+	if err != nil {
+		a.cfg.Logger.Error("could not marshal heartbeat", "error", err)
+		return
+	}
+
+	if err := a.nc.Publish(heartbeatSubject(a.cfg.Namespace, a.cfg.Id), b); err != nil {
+		a.cfg.Logger.Warn("could not publish heartbeat", "error", err)
+	}
+}
+
+// publishMetrics publishes the metrics of the running instances on their metrics log subject.
+func (a *Agent) publishMetrics() {
+	a.mu.Lock()
+	var metrics []Metrics
+	for id, inst := range a.instances {
+		if inst.state != StateRunning {
+			continue
+		}
+		metrics = append(metrics, Metrics{
+			ConnectorId:   inst.workload.ConnectorId,
+			InstanceId:    id,
+			UptimeSeconds: time.Since(inst.startedAt).Seconds(),
+			LogLines:      inst.logLines.Load(),
+		})
+	}
+	a.mu.Unlock()
+
+	for _, m := range metrics {
+		b, err := json.Marshal(m)
+		if err != nil {
+			continue
+		}
+		_ = a.nc.Publish(MetricsSubject(a.cfg.Namespace, m.InstanceId), b)
+	}
+}
+
+func (a *Agent) handleStart(msg *nats.Msg) {
+	var cmd StartCommand
+	if err := json.Unmarshal(msg.Data, &cmd); err != nil {
+		controlplane.RespondError(msg, 400, fmt.Sprintf("invalid request: %s", err))
+		return
+	}
+
+	w := cmd.Workload
+	if w.InstanceId == "" || w.ConnectorId == "" {
+		controlplane.RespondError(msg, 400, "connector and instance id are required")
+		return
+	}
+	if w.Namespace == "" {
+		w.Namespace = a.cfg.Namespace
+	}
+
+	if err := a.startInstance(w); err != nil {
+		controlplane.RespondError(msg, 500, err.Error())
+		return
+	}
+
+	respond(msg, StartResult{Instance: model.Instance{ConnectorId: w.ConnectorId, Id: w.InstanceId}})
+}
+
+func (a *Agent) handleStop(msg *nats.Msg) {
+	var cmd StopCommand
+	if err := json.Unmarshal(msg.Data, &cmd); err != nil {
+		controlplane.RespondError(msg, 400, fmt.Sprintf("invalid request: %s", err))
+		return
+	}
+
+	// The instance may take up to the grace period to drain, so it is stopped once the command is answered.
+	a.mu.Lock()
+	inst, ok := a.instances[cmd.InstanceId]
+	if ok && !a.closing && inst.state != StateStopping {
+		inst.state = StateStopping
+		a.stopping.Add(1)
+		go func() {
+			defer a.stopping.Done()
+			a.stopInstance(inst)
+			a.publishHeartbeat()
+		}()
+	}
+	a.mu.Unlock()
+
+	respond(msg, StopResult{Existed: ok})
+}
+
+// startInstance launches the workload and publishes its output until the command exits.
+func (a *Agent) startInstance(w Workload) error {
+	a.mu.Lock()
+	_, exists := a.instances[w.InstanceId]
+	a.mu.Unlock()
+	if exists {
+		return fmt.Errorf("instance %s already exists", w.InstanceId)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := a.cfg.Backend.Prepare(ctx, w); err != nil {
+		cancel()
+		return fmt.Errorf("could not prepare instance %s: %w", w.InstanceId, err)
+	}
+
+	cmd, err := a.cfg.Backend.Command(ctx, w)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("could not create command for instance %s: %w", w.InstanceId, err)
+	}
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = stopGracePeriod
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("could not capture output of instance %s: %w", w.InstanceId, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return fmt.Errorf("could not capture output of instance %s: %w", w.InstanceId, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("could not start instance %s: %w", w.InstanceId, err)
+	}
+
+	inst := &instance{
+		workload:  w,
+		cmd:       cmd,
+		cancel:    cancel,
+		startedAt: time.Now().UTC(),
+		state:     StateRunning,
+		exited:    make(chan struct{}),
+	}
+
+	a.mu.Lock()
+	a.instances[w.InstanceId] = inst
+	a.mu.Unlock()
+
+	var output sync.WaitGroup
+	output.Add(2)
+	go a.publishOutput(&output, inst, stdout, LevelInfo)
+	go a.publishOutput(&output, inst, stderr, LevelError)
+
+	go a.wait(&output, inst)
+
+	a.cfg.Logger.Info("started instance", "connector", w.ConnectorId, "instance", w.InstanceId)
+	a.publishHeartbeat()
+	return nil
+}
+
+// wait records the exit of the instance once its output has been published.
+func (a *Agent) wait(output *sync.WaitGroup, inst *instance) {
+	output.Wait()
+	err := inst.cmd.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopGracePeriod)
+	defer cancel()
+	if cerr := a.cfg.Backend.Cleanup(ctx, inst.workload); cerr != nil {
+		a.cfg.Logger.Warn("could not clean up instance", "instance", inst.workload.InstanceId, "error", cerr)
+	}
+
+	a.mu.Lock()
+	if inst.state != StateStopping {
+		inst.state = StateStopped
+	}
+	inst.exitCode = inst.cmd.ProcessState.ExitCode()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		inst.err = err
+	}
+	a.mu.Unlock()
+
+	close(inst.exited)
+
+	a.cfg.Logger.Info("instance exited", "connector", inst.workload.ConnectorId, "instance", inst.workload.InstanceId, "exit_code", inst.exitCode)
+	a.publishHeartbeat()
+}
+
+// stopInstance sends SIGTERM to the instance, waits for it to exit and forgets about it.
+func (a *Agent) stopInstance(inst *instance) {
+	inst.cancel()
+	<-inst.exited
+
+	a.mu.Lock()
+	delete(a.instances, inst.workload.InstanceId)
+	a.mu.Unlock()
+}
+
+func (a *Agent) publishOutput(wg *sync.WaitGroup, inst *instance, r io.Reader, fallback string) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		inst.logLines.Add(1)
+
+		subject := LogSubject(inst.workload.Namespace, inst.workload.InstanceId, logLevel(line, fallback))
+		if err := a.nc.Publish(subject, []byte(line)); err != nil {
+			a.cfg.Logger.Warn("could not publish log line", "instance", inst.workload.InstanceId, "error", err)
+		}
+	}
+}
+
+func respond(msg *nats.Msg, resp any) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		controlplane.RespondError(msg, 500, fmt.Sprintf("could not marshal response: %s", err))
+		return
+	}
+	_ = msg.Respond(b)
+}
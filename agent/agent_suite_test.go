@@ -0,0 +1,61 @@
+package agent_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAgent(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent Suite")
+}
+
+// connect runs an embedded NATS server until the spec ends and connects to it.
+func connect() *nats.Conn {
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	Expect(err).ToNot(HaveOccurred())
+
+	go ns.Start()
+	Expect(ns.ReadyForConnections(10 * time.Second)).To(BeTrue())
+	DeferCleanup(func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+	})
+
+	nc, err := nats.Connect(ns.ClientURL())
+	Expect(err).ToNot(HaveOccurred())
+	DeferCleanup(nc.Close)
+
+	return nc
+}
+
+// writeRuntime writes a shell script standing in for a runtime binary.
+func writeRuntime(script string) string {
+	file := filepath.Join(GinkgoT().TempDir(), "runtime.sh")
+	Expect(os.WriteFile(file, []byte("#!/bin/sh\n"+script), 0755)).To(Succeed())
+	return file
+}
+
+// longRunning logs a couple of lines and runs until it is terminated.
+const longRunning = `echo '{"level":"INFO","msg":"started","instance":"'$NEX_WORKLOAD_ID'"}'
+echo 'time=now level=WARN msg="slow down"'
+echo "something broke" >&2
+exec sleep 30
+`
+
+// draining takes a couple of seconds to exit once it is terminated.
+const draining = `trap 'sleep 2; exit 0' TERM
+while true; do sleep 0.1; done
+`
+
+// crashing exits right away.
+const crashing = `echo "giving up" >&2
+exit 3
+`
@@ -0,0 +1,204 @@
+package agent_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/agent"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("Agent", func() {
+	var (
+		nc *nats.Conn
+		a  *agent.Agent
+		t  *client.Transport
+	)
+
+	workload := func(runtimeId string) agent.Workload {
+		return agent.Workload{
+			ConnectorId: "test",
+			InstanceId:  "inst1",
+			RuntimeId:   runtimeId,
+			Steps:       model.Steps{Producer: &model.ProducerStep{Core: &model.ProducerStepCore{Subject: "test"}}},
+			Env:         map[string]string{"NEX_WORKLOAD_ID": "inst1"},
+		}
+	}
+
+	heartbeats := func() chan agent.Heartbeat {
+		ch := make(chan agent.Heartbeat, 64)
+		sub, err := nc.Subscribe("$CONAGENT.$G.HEARTBEAT.node1", func(msg *nats.Msg) {
+			var hb agent.Heartbeat
+			Expect(json.Unmarshal(msg.Data, &hb)).To(Succeed())
+			ch <- hb
+		})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(sub.Unsubscribe)
+		return ch
+	}
+
+	BeforeEach(func() {
+		nc = connect()
+		t = client.NewTransportForAccount(nc, "$G", false)
+
+		var err error
+		a, err = agent.New(nc, agent.Config{
+			Id:                "node1",
+			Namespace:         "$G",
+			Tags:              []string{"region:test"},
+			HeartbeatInterval: 100 * time.Millisecond,
+			Backend: agent.NewProcessBackend(map[string]string{
+				"long":  writeRuntime(longRunning),
+				"drain": writeRuntime(draining),
+				"crash": writeRuntime(crashing),
+			}),
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should validate its configuration", func() {
+		_, err := agent.New(nc, agent.Config{Namespace: "$G", Backend: agent.NewProcessBackend(nil)})
+		Expect(err).To(HaveOccurred())
+
+		_, err = agent.New(nc, agent.Config{Id: "node1", Namespace: "$G"})
+		Expect(err).To(HaveOccurred())
+
+		for _, id := range []string{"HEARTBEAT", "PING", "METRICS"} {
+			_, err = agent.New(nc, agent.Config{Id: id, Namespace: "$G", Backend: agent.NewProcessBackend(nil)})
+			Expect(err).To(MatchError(ContainSubstring("reserved")), id)
+		}
+	})
+
+	It("should announce itself with its tags", func() {
+		hbs := heartbeats()
+		Expect(a.Start()).To(Succeed())
+		DeferCleanup(a.Stop)
+
+		var hb agent.Heartbeat
+		Eventually(hbs).Should(Receive(&hb))
+		Expect(hb.AgentId).To(Equal("node1"))
+		Expect(hb.Tags).To(ConsistOf("region:test"))
+		Expect(hb.Backend).To(Equal("process"))
+		Expect(hb.Instances).To(BeEmpty())
+	})
+
+	It("should run instances and publish their logs", func() {
+		logs := make(chan *nats.Msg, 16)
+		sub, err := nc.ChanSubscribe("$NEX.FEED.$G.logs.inst1.*", logs)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(sub.Unsubscribe)
+
+		metrics := make(chan *nats.Msg, 16)
+		msub, err := nc.ChanSubscribe("$CONAGENT.$G.METRICS.inst1", metrics)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(msub.Unsubscribe)
+
+		Expect(a.Start()).To(Succeed())
+		DeferCleanup(a.Stop)
+
+		var started agent.StartResult
+		_, err = t.RequestJson("$CONAGENT.$G.node1.START", agent.StartCommand{Workload: workload("long")}, &started, client.WithTimeout(5*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(started.Instance).To(Equal(model.Instance{ConnectorId: "test", Id: "inst1"}))
+
+		received := map[string]string{}
+		for range 3 {
+			var msg *nats.Msg
+			Eventually(logs, 5*time.Second).Should(Receive(&msg))
+			received[msg.Subject] = string(msg.Data)
+		}
+		Expect(received).To(HaveKeyWithValue("$NEX.FEED.$G.logs.inst1.info", ContainSubstring(`"instance":"inst1"`)))
+		Expect(received).To(HaveKeyWithValue("$NEX.FEED.$G.logs.inst1.warn", ContainSubstring("slow down")))
+		Expect(received).To(HaveKeyWithValue("$NEX.FEED.$G.logs.inst1.error", "something broke"))
+
+		Eventually(metrics, 2*time.Second).Should(Receive(HaveField("Data", ContainSubstring(`"instance_id":"inst1"`))))
+		Expect(logs).ToNot(Receive(HaveField("Subject", "$NEX.FEED.$G.logs.inst1.metrics")))
+
+		hb := a.Heartbeat()
+		Expect(hb.Instances).To(HaveLen(1))
+		Expect(hb.Instances[0].State).To(Equal(agent.StateRunning))
+
+		var stopped agent.StopResult
+		_, err = t.RequestJson("$CONAGENT.$G.node1.STOP", agent.StopCommand{InstanceId: "inst1"}, &stopped, client.WithTimeout(5*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stopped.Existed).To(BeTrue())
+		Eventually(func() []agent.InstanceState { return a.Heartbeat().Instances }, 5*time.Second).Should(BeEmpty())
+	})
+
+	It("should answer a stop before the instance drained", func() {
+		Expect(a.Start()).To(Succeed())
+		DeferCleanup(a.Stop)
+
+		_, err := t.RequestJson("$CONAGENT.$G.node1.START", agent.StartCommand{Workload: workload("drain")}, &agent.StartResult{}, client.WithTimeout(5*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+
+		var stopped agent.StopResult
+		_, err = t.RequestJson("$CONAGENT.$G.node1.STOP", agent.StopCommand{InstanceId: "inst1"}, &stopped, client.WithTimeout(time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stopped.Existed).To(BeTrue())
+		Expect(a.Heartbeat().Instances).To(ConsistOf(HaveField("State", agent.StateStopping)))
+
+		Eventually(func() []agent.InstanceState { return a.Heartbeat().Instances }, 10*time.Second).Should(BeEmpty())
+	})
+
+	It("should report instances which exited on their own", func() {
+		Expect(a.Start()).To(Succeed())
+		DeferCleanup(a.Stop)
+
+		_, err := t.RequestJson("$CONAGENT.$G.node1.START", agent.StartCommand{Workload: workload("crash")}, &agent.StartResult{}, client.WithTimeout(5*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() []agent.InstanceState { return a.Heartbeat().Instances }, 5*time.Second).Should(ConsistOf(And(
+			HaveField("State", agent.StateStopped),
+			HaveField("ExitCode", 3),
+		)))
+	})
+
+	It("should refuse workloads it cannot run", func() {
+		Expect(a.Start()).To(Succeed())
+		DeferCleanup(a.Stop)
+
+		_, err := t.RequestJson("$CONAGENT.$G.node1.START", agent.StartCommand{Workload: workload("unknown")}, &agent.StartResult{}, client.WithTimeout(5*time.Second))
+		Expect(err).To(MatchError(ContainSubstring("no binary configured for runtime unknown")))
+	})
+
+	It("should stop its instances when stopped", func() {
+		Expect(a.Start()).To(Succeed())
+
+		_, err := t.RequestJson("$CONAGENT.$G.node1.START", agent.StartCommand{Workload: workload("long")}, &agent.StartResult{}, client.WithTimeout(5*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+
+		a.Stop()
+		Expect(a.Heartbeat().Instances).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ContainerBackend", func() {
+	It("should mount the files referenced by the steps", func() {
+		creds := filepath.Join(GinkgoT().TempDir(), "user.creds")
+		Expect(os.WriteFile(creds, []byte("creds"), 0600)).To(Succeed())
+
+		w := agent.Workload{
+			ConnectorId: "test",
+			InstanceId:  "inst1",
+			Image:       "synadia/connect-runtime-wombat",
+			Steps: model.Steps{Producer: &model.ProducerStep{
+				Core: &model.ProducerStepCore{Subject: "test"},
+				Nats: model.NatsConfig{Creds: &model.NatsConfigCreds{File: &creds}},
+			}},
+		}
+
+		cmd, err := agent.NewContainerBackend("").Command(context.Background(), w)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmd.Args).To(ContainElements("-v", creds+":"+docker.MountDir+"/0-user.creds:ro"))
+		Expect(*w.Steps.Producer.Nats.Creds.File).To(Equal(creds))
+	})
+})
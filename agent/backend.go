@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Backend launches the instances of an agent. Every instance is run by a command in the foreground, whose
+// output is published as the logs of the instance.
+type Backend interface {
+	// Name identifies the backend in heartbeats.
+	Name() string
+	// Prepare is called before the command of a workload is created, e.g. to pull its image.
+	Prepare(ctx context.Context, w Workload) error
+	// Command returns the command running the workload. The command is stopped by a SIGTERM.
+	Command(ctx context.Context, w Workload) (*exec.Cmd, error)
+	// Cleanup removes whatever is left of the workload after its command exited.
+	Cleanup(ctx context.Context, w Workload) error
+}
+
+// encodeSteps encodes the steps the way runtimes expect them as their first argument.
+func encodeSteps(steps model.Steps) (string, error) {
+	b, err := yaml.Marshal(steps)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal steps: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// ContainerBackend runs each instance as a container on a docker daemon.
+type ContainerBackend struct {
+	runner *docker.Runner
+}
+
+// NewContainerBackend creates a backend running containers on the given docker daemon; empty for the local
+// daemon.
+func NewContainerBackend(host string) *ContainerBackend {
+	return &ContainerBackend{runner: docker.NewRunnerForHost(host)}
+}
+
+func (b *ContainerBackend) Name() string {
+	return "container"
+}
+
+func (b *ContainerBackend) Prepare(ctx context.Context, w Workload) error {
+	if w.Image == "" {
+		return fmt.Errorf("no image to run for runtime %s", w.RuntimeId)
+	}
+
+	if !w.Pull {
+		return nil
+	}
+	return b.runner.Pull(ctx, w.Image)
+}
+
+// Command runs the workload in a container, with the files referenced by its steps mounted read-only.
+func (b *ContainerBackend) Command(ctx context.Context, w Workload) (*exec.Cmd, error) {
+	mounted, volumes, err := docker.MountFiles(w.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := encodeSteps(mounted)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{}
+	if host := b.runner.Host(); host != "" {
+		args = append(args, "-H", host)
+	}
+	args = append(args, "run", "--rm", "--name", containerName(w))
+
+	for _, env := range sortedEnv(w.Env) {
+		args = append(args, "-e", env)
+	}
+	for _, volume := range volumes {
+		args = append(args, "-v", volume)
+	}
+	args = append(args, w.Image, steps)
+
+	return exec.CommandContext(ctx, "docker", args...), nil
+}
+
+func (b *ContainerBackend) Cleanup(ctx context.Context, w Workload) error {
+	status, err := b.runner.GetContainerStatus(ctx, containerName(w))
+	if err != nil || !status.Exists {
+		return err
+	}
+	return b.runner.ForceRemove(ctx, containerName(w))
+}
+
+func containerName(w Workload) string {
+	return fmt.Sprintf("connect_%s_%s", w.ConnectorId, w.InstanceId)
+}
+
+// ProcessBackend runs each instance as a process of a runtime binary installed on the node.
+type ProcessBackend struct {
+	runtimes map[string]string
+}
+
+// NewProcessBackend creates a backend running the given binaries, by runtime id.
+func NewProcessBackend(runtimes map[string]string) *ProcessBackend {
+	return &ProcessBackend{runtimes: runtimes}
+}
+
+func (b *ProcessBackend) Name() string {
+	return "process"
+}
+
+func (b *ProcessBackend) Prepare(_ context.Context, w Workload) error {
+	_, err := b.binary(w)
+	return err
+}
+
+func (b *ProcessBackend) Command(ctx context.Context, w Workload) (*exec.Cmd, error) {
+	binary, err := b.binary(w)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := encodeSteps(w.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, binary, steps)
+	cmd.Env = append(os.Environ(), sortedEnv(w.Env)...)
+	return cmd, nil
+}
+
+func (b *ProcessBackend) Cleanup(_ context.Context, _ Workload) error {
+	return nil
+}
+
+// binary returns the binary of the runtime, ignoring a version suffix like in "wombat:v1.0.3".
+func (b *ProcessBackend) binary(w Workload) (string, error) {
+	if binary, ok := b.runtimes[w.RuntimeId]; ok {
+		return binary, nil
+	}
+
+	id, _, _ := strings.Cut(w.RuntimeId, ":")
+	if binary, ok := b.runtimes[id]; ok {
+		return binary, nil
+	}
+
+	return "", fmt.Errorf("no binary configured for runtime %s", w.RuntimeId)
+}
+
+func sortedEnv(env map[string]string) []string {
+	result := make([]string, 0, len(env))
+	for k, v := range env {
+		result = append(result, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Log levels used in the log subjects.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// logLevel determines the level of a line of output. JSON lines with a "level" field and logfmt lines with a
+// level= pair are recognized; other lines get the fallback level.
+func logLevel(line string, fallback string) string {
+	trimmed := strings.TrimSpace(line)
+
+	if strings.HasPrefix(trimmed, "{") {
+		var entry struct {
+			Level string `json:"level"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &entry); err == nil && entry.Level != "" {
+			return normalizeLevel(entry.Level, fallback)
+		}
+	}
+
+	for _, field := range strings.Fields(trimmed) {
+		if value, ok := strings.CutPrefix(field, "level="); ok {
+			return normalizeLevel(strings.Trim(value, `"`), fallback)
+		}
+	}
+
+	return fallback
+}
+
+func normalizeLevel(level string, fallback string) string {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return LevelDebug
+	case "info":
+		return LevelInfo
+	case "warn", "warning":
+		return LevelWarn
+	case "error", "fatal", "panic":
+		return LevelError
+	default:
+		return fallback
+	}
+}
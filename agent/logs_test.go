@@ -0,0 +1,27 @@
+package agent
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("logLevel", func() {
+	DescribeTable("should determine the level of a line",
+		func(line string, expected string) {
+			Expect(logLevel(line, LevelInfo)).To(Equal(expected))
+		},
+		Entry("json", `{"level":"ERROR","msg":"failed"}`, LevelError),
+		Entry("json with lowercase level", `{"level":"debug","msg":"details"}`, LevelDebug),
+		Entry("json without level", `{"msg":"hello"}`, LevelInfo),
+		Entry("logfmt", `time=now level=WARN msg="slow down"`, LevelWarn),
+		Entry("logfmt with quoted level", `level="warning" msg=x`, LevelWarn),
+		Entry("trace is debug", `level=trace msg=x`, LevelDebug),
+		Entry("fatal is error", `level=fatal msg=x`, LevelError),
+		Entry("unknown level", `level=chatty msg=x`, LevelInfo),
+		Entry("plain text", `hello world`, LevelInfo),
+	)
+
+	It("should use the fallback for plain text", func() {
+		Expect(logLevel("something broke", LevelError)).To(Equal(LevelError))
+	})
+})
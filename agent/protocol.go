@@ -0,0 +1,109 @@
+// Package agent runs connector instances on a node on behalf of a self-hosted control plane.
+//
+// An Agent announces itself and the instances it runs with heartbeats on $CONAGENT.<account>.HEARTBEAT.<agent>
+// and accepts start and stop commands on $CONAGENT.<account>.<agent>.START and .STOP. The output of the
+// instances is published on the $NEX.FEED.<account>.logs.<instance>.<level> subjects read by `connect logs`.
+//
+// The Scheduler is the control plane side: it places instances on the agents matching their placement tags
+// and reports the instances from the heartbeats of the agents.
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/synadia-io/connect/model"
+)
+
+// Instance states reported in heartbeats. An instance is stopping from the moment a stop command was answered until
+// it drained and is forgotten; it is stopped when it exited on its own.
+const (
+	StateRunning  = "running"
+	StateStopping = "stopping"
+	StateStopped  = "stopped"
+)
+
+// Workload holds everything needed to run a connector instance.
+type Workload struct {
+	Namespace   string            `json:"namespace"`
+	ConnectorId string            `json:"connector_id"`
+	InstanceId  string            `json:"instance_id"`
+	RuntimeId   string            `json:"runtime_id"`
+	Image       string            `json:"image,omitempty"`
+	Steps       model.Steps       `json:"steps"`
+	Env         map[string]string `json:"env,omitempty"`
+	Pull        bool              `json:"pull,omitempty"`
+}
+
+// StartCommand asks an agent to start an instance.
+type StartCommand struct {
+	Workload Workload `json:"workload"`
+}
+
+// StartResult is the answer to a StartCommand.
+type StartResult struct {
+	Instance model.Instance `json:"instance"`
+}
+
+// StopCommand asks an agent to stop an instance.
+type StopCommand struct {
+	InstanceId string `json:"instance_id"`
+}
+
+// StopResult is the answer to a StopCommand.
+type StopResult struct {
+	Existed bool `json:"existed"`
+}
+
+// InstanceState describes an instance run by an agent.
+type InstanceState struct {
+	ConnectorId string    `json:"connector_id"`
+	InstanceId  string    `json:"instance_id"`
+	State       string    `json:"state"`
+	StartedAt   time.Time `json:"started_at"`
+	ExitCode    int       `json:"exit_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Heartbeat is published by an agent at a regular interval and whenever the state of an instance changes.
+type Heartbeat struct {
+	AgentId   string          `json:"agent_id"`
+	Tags      []string        `json:"tags,omitempty"`
+	Backend   string          `json:"backend"`
+	Interval  time.Duration   `json:"interval"`
+	Time      time.Time       `json:"time"`
+	Instances []InstanceState `json:"instances"`
+}
+
+// Metrics are published for every running instance together with the heartbeat.
+type Metrics struct {
+	ConnectorId   string  `json:"connector_id"`
+	InstanceId    string  `json:"instance_id"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	LogLines      int64   `json:"log_lines"`
+}
+
+// reservedTokens are the tokens following the account in the subjects which are not agent ids.
+var reservedTokens = map[string]bool{"HEARTBEAT": true, "PING": true, "METRICS": true}
+
+func heartbeatSubject(namespace, agentId string) string {
+	return fmt.Sprintf("$CONAGENT.%s.HEARTBEAT.%s", namespace, agentId)
+}
+
+func pingSubject(namespace string) string {
+	return fmt.Sprintf("$CONAGENT.%s.PING", namespace)
+}
+
+func commandSubject(namespace, agentId, command string) string {
+	return fmt.Sprintf("$CONAGENT.%s.%s.%s", namespace, agentId, command)
+}
+
+// LogSubject returns the subject the output of an instance is published on.
+func LogSubject(namespace, instanceId, level string) string {
+	return fmt.Sprintf("$NEX.FEED.%s.logs.%s.%s", namespace, instanceId, level)
+}
+
+// MetricsSubject returns the subject the metrics of an instance are published on.
+func MetricsSubject(namespace, instanceId string) string {
+	return fmt.Sprintf("$CONAGENT.%s.METRICS.%s", namespace, instanceId)
+}
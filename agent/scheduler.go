@@ -0,0 +1,307 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// ErrNoAgent is returned when no live agent matches the placement tags of an instance.
+var ErrNoAgent = errors.New("no agent available")
+
+// Scheduler places connector instances on the agents of a namespace. It implements controlplane.Scheduler.
+type Scheduler struct {
+	namespace string
+	nc        *nats.Conn
+	t         *client.Transport
+	resolve   controlplane.ImageResolver
+	conn      controlplane.WorkloadConnection
+	sub       *nats.Subscription
+
+	mu     sync.Mutex
+	agents map[string]*agentState
+}
+
+type agentState struct {
+	heartbeat Heartbeat
+	seen      time.Time
+}
+
+// alive reports whether the agent sent a heartbeat within the last three intervals.
+func (s *agentState) alive(now time.Time) bool {
+	interval := s.heartbeat.Interval
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+	return now.Sub(s.seen) <= 3*interval
+}
+
+// NewScheduler creates a scheduler for the agents of the namespace and asks the running agents to announce
+// themselves.
+func NewScheduler(nc *nats.Conn, namespace string, resolve controlplane.ImageResolver, conn controlplane.WorkloadConnection) (*Scheduler, error) {
+	s := &Scheduler{
+		namespace: namespace,
+		nc:        nc,
+		t:         client.NewTransportForAccount(nc, namespace, false),
+		resolve:   resolve,
+		conn:      conn,
+		agents:    map[string]*agentState{},
+	}
+
+	sub, err := nc.Subscribe(heartbeatSubject(namespace, "*"), s.handleHeartbeat)
+	if err != nil {
+		return nil, fmt.Errorf("could not subscribe to heartbeats: %w", err)
+	}
+	s.sub = sub
+
+	if err := nc.Publish(pingSubject(namespace), nil); err != nil {
+		_ = sub.Unsubscribe()
+		return nil, fmt.Errorf("could not ping agents: %w", err)
+	}
+
+	if err := nc.Flush(); err != nil {
+		_ = sub.Unsubscribe()
+		return nil, fmt.Errorf("could not flush: %w", err)
+	}
+
+	return s, nil
+}
+
+// Close stops tracking the agents.
+func (s *Scheduler) Close() {
+	_ = s.sub.Unsubscribe()
+}
+
+// Agents returns the last heartbeat of every live agent, sorted by agent id.
+func (s *Scheduler) Agents() []Heartbeat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var result []Heartbeat
+	for _, st := range s.agents {
+		if st.alive(now) {
+			result = append(result, st.heartbeat)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].AgentId < result[j].AgentId })
+	return result
+}
+
+func (s *Scheduler) handleHeartbeat(msg *nats.Msg) {
+	var hb Heartbeat
+	if err := json.Unmarshal(msg.Data, &hb); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.agents[hb.AgentId] = &agentState{heartbeat: hb, seen: time.Now()}
+}
+
+func (s *Scheduler) Start(ctx context.Context, req controlplane.StartRequest) ([]model.Instance, error) {
+	image, err := s.resolve(req.Connector.RuntimeId)
+	if err != nil {
+		return nil, err
+	}
+
+	started := make([]model.Instance, 0, req.Replicas)
+	for range req.Replicas {
+		agentId, err := s.place(req.Options.PlacementTags)
+		if err != nil {
+			return started, err
+		}
+
+		inst := model.Instance{
+			ConnectorId: req.Connector.ConnectorId,
+			Id:          nuid.Next(),
+		}
+
+		env := s.conn.Env()
+		for k, v := range req.Options.EnvVars {
+			env[k] = v
+		}
+		env[runtime.NamespaceEnvVar] = req.Namespace
+		env[runtime.GroupEnvVar] = inst.ConnectorId
+		env[runtime.InstanceEnvVar] = inst.Id
+
+		cmd := StartCommand{Workload: Workload{
+			Namespace:   req.Namespace,
+			ConnectorId: inst.ConnectorId,
+			InstanceId:  inst.Id,
+			RuntimeId:   req.Connector.RuntimeId,
+			Image:       image,
+			Steps:       req.Connector.Steps,
+			Env:         env,
+			Pull:        req.Options.Pull,
+		}}
+
+		var resp StartResult
+		if _, err := s.t.RequestJsonWithContext(ctx, commandSubject(s.namespace, agentId, "START"), cmd, &resp); err != nil {
+			return started, fmt.Errorf("could not start instance on agent %s: %w", agentId, err)
+		}
+
+		s.track(agentId, InstanceState{
+			ConnectorId: inst.ConnectorId,
+			InstanceId:  inst.Id,
+			State:       StateRunning,
+			StartedAt:   time.Now().UTC(),
+		})
+		started = append(started, inst)
+	}
+
+	return started, nil
+}
+
+func (s *Scheduler) Stop(ctx context.Context, instances []model.Instance) error {
+	var errs []error
+	for _, inst := range instances {
+		agentId, ok := s.agentOf(inst.Id)
+		if !ok {
+			continue
+		}
+
+		var resp StopResult
+		if _, err := s.t.RequestJsonWithContext(ctx, commandSubject(s.namespace, agentId, "STOP"), StopCommand{InstanceId: inst.Id}, &resp); err != nil {
+			errs = append(errs, fmt.Errorf("could not stop instance %s on agent %s: %w", inst.Id, agentId, err))
+			continue
+		}
+
+		s.untrack(agentId, inst.Id)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scheduler) Instances(_ context.Context, connectorId string) ([]model.Instance, error) {
+	return toInstances(s.states(connectorId, StateRunning)), nil
+}
+
+// Exited returns the instances of the connector which exited on their own and have not been stopped yet.
+func (s *Scheduler) Exited(_ context.Context, connectorId string) ([]model.Instance, error) {
+	return toInstances(s.states(connectorId, StateStopped)), nil
+}
+
+// states returns the instances in the given state on live agents, in the order they were started.
+func (s *Scheduler) states(connectorId string, state string) []InstanceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var result []InstanceState
+	for _, st := range s.agents {
+		if !st.alive(now) {
+			continue
+		}
+		for _, inst := range st.heartbeat.Instances {
+			if inst.State == state && (connectorId == "" || inst.ConnectorId == connectorId) {
+				result = append(result, inst)
+			}
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b InstanceState) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return result
+}
+
+// place picks the live agent with all the tags which runs the fewest instances.
+func (s *Scheduler) place(tags []string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	best, load := "", 0
+	for id, st := range s.agents {
+		if !st.alive(now) || !hasTags(st.heartbeat.Tags, tags) {
+			continue
+		}
+
+		running := 0
+		for _, inst := range st.heartbeat.Instances {
+			if inst.State == StateRunning {
+				running++
+			}
+		}
+
+		if best == "" || running < load || (running == load && id < best) {
+			best, load = id, running
+		}
+	}
+
+	if best == "" {
+		if len(tags) > 0 {
+			return "", fmt.Errorf("%w with tags %v", ErrNoAgent, tags)
+		}
+		return "", ErrNoAgent
+	}
+	return best, nil
+}
+
+func (s *Scheduler) agentOf(instanceId string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, st := range s.agents {
+		for _, inst := range st.heartbeat.Instances {
+			if inst.InstanceId == instanceId {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// track records a started instance until the next heartbeat of the agent reports it.
+func (s *Scheduler) track(agentId string, inst InstanceState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.agents[agentId]; ok && !slices.ContainsFunc(st.heartbeat.Instances, func(i InstanceState) bool {
+		return i.InstanceId == inst.InstanceId
+	}) {
+		st.heartbeat.Instances = append(st.heartbeat.Instances, inst)
+	}
+}
+
+// untrack forgets a stopped instance until the next heartbeat of the agent.
+func (s *Scheduler) untrack(agentId string, instanceId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.agents[agentId]; ok {
+		st.heartbeat.Instances = slices.DeleteFunc(st.heartbeat.Instances, func(i InstanceState) bool {
+			return i.InstanceId == instanceId
+		})
+	}
+}
+
+func toInstances(states []InstanceState) []model.Instance {
+	instances := make([]model.Instance, 0, len(states))
+	for _, st := range states {
+		instances = append(instances, model.Instance{ConnectorId: st.ConnectorId, Id: st.InstanceId})
+	}
+	return instances
+}
+
+func hasTags(agentTags []string, required []string) bool {
+	for _, tag := range required {
+		if !slices.Contains(agentTags, tag) {
+			return false
+		}
+	}
+	return true
+}
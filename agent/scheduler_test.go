@@ -0,0 +1,137 @@
+package agent_test
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/agent"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("Scheduler", func() {
+	var (
+		nc        *nats.Conn
+		scheduler *agent.Scheduler
+		agents    map[string]*agent.Agent
+		cl        client.Client
+	)
+
+	lib := controlplane.Library{Runtimes: []model.Runtime{
+		{Id: "long", Image: "example/long", DefaultVersion: "latest"},
+		{Id: "crash", Image: "example/crash", DefaultVersion: "latest"},
+	}}
+
+	steps := model.Steps{Producer: &model.ProducerStep{Core: &model.ProducerStepCore{Subject: "test"}}}
+
+	startAgent := func(id string, tags ...string) *agent.Agent {
+		a, err := agent.New(nc, agent.Config{
+			Id:                id,
+			Namespace:         "$G",
+			Tags:              tags,
+			HeartbeatInterval: 100 * time.Millisecond,
+			Backend: agent.NewProcessBackend(map[string]string{
+				"long":  writeRuntime(longRunning),
+				"crash": writeRuntime(crashing),
+			}),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(a.Start()).To(Succeed())
+		DeferCleanup(a.Stop)
+		return a
+	}
+
+	BeforeEach(func() {
+		nc = connect()
+
+		agents = map[string]*agent.Agent{
+			"east": startAgent("east", "region:east"),
+			"west": startAgent("west", "region:west", "gpu"),
+		}
+
+		var err error
+		scheduler, err = agent.NewScheduler(nc, "$G", lib.ResolveImage, controlplane.WorkloadConnection{Servers: nc.ConnectedUrl()})
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(scheduler.Close)
+
+		Eventually(scheduler.Agents, 2*time.Second).Should(HaveLen(2))
+
+		svc, err := controlplane.New(nc, controlplane.Config{Account: "$G", Scheduler: scheduler, Library: lib})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(svc.Start()).To(Succeed())
+		DeferCleanup(svc.Stop)
+
+		cl, err = client.NewClient(nc, false)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should place instances on the agents matching the placement tags", func() {
+		_, err := cl.CreateConnector("test", "", "long", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		instances, err := cl.StartConnector("test", &model.ConnectorStartOptions{Replicas: 2, PlacementTags: []string{"gpu"}}, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(HaveLen(2))
+
+		Expect(agents["west"].Heartbeat().Instances).To(HaveLen(2))
+		Expect(agents["east"].Heartbeat().Instances).To(BeEmpty())
+
+		_, err = cl.StartConnector("test", &model.ConnectorStartOptions{PlacementTags: []string{"region:north"}}, 5*time.Second)
+		Expect(err).To(MatchError(ContainSubstring("no agent available")))
+	})
+
+	It("should spread instances over the least loaded agents", func() {
+		_, err := cl.CreateConnector("test", "", "long", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = cl.StartConnector("test", &model.ConnectorStartOptions{Replicas: 2}, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(agents["east"].Heartbeat().Instances).To(HaveLen(1))
+		Expect(agents["west"].Heartbeat().Instances).To(HaveLen(1))
+	})
+
+	It("should report the instance counts from the heartbeats", func() {
+		_, err := cl.CreateConnector("test", "", "long", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cl.CreateConnector("crashing", "", "crash", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = cl.StartConnector("test", &model.ConnectorStartOptions{Replicas: 2}, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = cl.StartConnector("crashing", nil, 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(func() []model.ConnectorSummary {
+			list, err := cl.ListConnectors(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			return list
+		}, 5*time.Second).Should(ConsistOf(
+			And(HaveField("ConnectorId", "crashing"), HaveField("Instances", model.ConnectorSummaryInstances{Running: 0, Stopped: 1})),
+			And(HaveField("ConnectorId", "test"), HaveField("Instances", model.ConnectorSummaryInstances{Running: 2, Stopped: 0})),
+		))
+
+		stopped, err := cl.StopConnector("test", 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stopped).To(HaveLen(2))
+
+		_, err = cl.StopConnector("crashing", 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		status, err := cl.GetConnectorStatus("crashing", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Stopped).To(Equal(1))
+
+		Eventually(func() int {
+			return len(agents["east"].Heartbeat().Instances) + len(agents["west"].Heartbeat().Instances)
+		}).Should(BeZero())
+	})
+
+	It("should forget agents which stopped sending heartbeats", func() {
+		agents["east"].Stop()
+		Eventually(scheduler.Agents, 2*time.Second).Should(HaveLen(1))
+	})
+})
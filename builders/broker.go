@@ -0,0 +1,61 @@
+package builders
+
+import "github.com/synadia-io/connect/model"
+
+type BrokerStepBuilder struct {
+	res *model.BrokerStep
+}
+
+func BrokerStep() *BrokerStepBuilder {
+	return &BrokerStepBuilder{
+		res: &model.BrokerStep{
+			Pattern: model.BrokerStepPatternFanOut,
+		},
+	}
+}
+
+func (b *BrokerStepBuilder) Pattern(pattern model.BrokerStepPattern) *BrokerStepBuilder {
+	b.res.Pattern = pattern
+	return b
+}
+
+func (b *BrokerStepBuilder) Output(v *OutputStepBuilder) *BrokerStepBuilder {
+	b.res.Outputs = append(b.res.Outputs, v.Build())
+	return b
+}
+
+func (b *BrokerStepBuilder) Build() model.BrokerStep {
+	return *b.res
+}
+
+type OutputStepBuilder struct {
+	res *model.OutputStep
+}
+
+func OutputStep() *OutputStepBuilder {
+	return &OutputStepBuilder{
+		res: &model.OutputStep{},
+	}
+}
+
+func (b *OutputStepBuilder) Transformer(v *TransformerStepBuilder) *OutputStepBuilder {
+	r := v.Build()
+	b.res.Transformer = &r
+	return b
+}
+
+func (b *OutputStepBuilder) Sink(v *SinkStepBuilder) *OutputStepBuilder {
+	r := v.Build()
+	b.res.Sink = &r
+	return b
+}
+
+func (b *OutputStepBuilder) Producer(v *ProducerStepBuilder) *OutputStepBuilder {
+	r := v.Build()
+	b.res.Producer = &r
+	return b
+}
+
+func (b *OutputStepBuilder) Build() model.OutputStep {
+	return *b.res
+}
@@ -32,6 +32,18 @@ func (b *ConsumerStepBuilder) Stream(v *ConsumerStepStreamBuilder) *ConsumerStep
 	return b
 }
 
+func (b *ConsumerStepBuilder) Headers(v *ConsumerStepHeadersBuilder) *ConsumerStepBuilder {
+	c := v.Build()
+	b.res.Headers = &c
+	return b
+}
+
+func (b *ConsumerStepBuilder) Schema(v *MessageSchemaBuilder) *ConsumerStepBuilder {
+	c := v.Build()
+	b.res.Schema = &c
+	return b
+}
+
 func (b *ConsumerStepBuilder) Build() model.ConsumerStep {
 	return *b.res
 }
@@ -0,0 +1,30 @@
+package builders
+
+import "github.com/synadia-io/connect/model"
+
+type ConsumerStepHeadersBuilder struct {
+	res *model.ConsumerStepHeaders
+}
+
+func ConsumerStepHeaders() *ConsumerStepHeadersBuilder {
+	return &ConsumerStepHeadersBuilder{
+		res: &model.ConsumerStepHeaders{},
+	}
+}
+
+func (b *ConsumerStepHeadersBuilder) Propagate(names ...string) *ConsumerStepHeadersBuilder {
+	b.res.Propagate = append([]string{}, names...)
+	return b
+}
+
+func (b *ConsumerStepHeadersBuilder) Static(key string, value string) *ConsumerStepHeadersBuilder {
+	if b.res.Static == nil {
+		b.res.Static = make(map[string]string)
+	}
+	b.res.Static[key] = value
+	return b
+}
+
+func (b *ConsumerStepHeadersBuilder) Build() model.ConsumerStepHeaders {
+	return *b.res
+}
@@ -1,6 +1,10 @@
 package builders
 
-import "github.com/synadia-io/connect/model"
+import (
+	"time"
+
+	"github.com/synadia-io/connect/model"
+)
 
 type ConsumerStepStreamBuilder struct {
 	res *model.ConsumerStepStream
@@ -9,11 +13,76 @@ type ConsumerStepStreamBuilder struct {
 func ConsumerStepStream(subject string) *ConsumerStepStreamBuilder {
 	return &ConsumerStepStreamBuilder{
 		res: &model.ConsumerStepStream{
-			Subject: subject,
+			Subject:       subject,
+			DeliverPolicy: model.ConsumerStepStreamDeliverPolicyAll,
+			AckPolicy:     model.ConsumerStepStreamAckPolicyExplicit,
 		},
 	}
 }
 
+func (b *ConsumerStepStreamBuilder) Stream(v string) *ConsumerStepStreamBuilder {
+	b.res.Stream = &v
+	return b
+}
+
+func (b *ConsumerStepStreamBuilder) Durable(v string) *ConsumerStepStreamBuilder {
+	b.res.Durable = &v
+	return b
+}
+
+// Bind uses the existing durable consumer instead of creating or updating it.
+func (b *ConsumerStepStreamBuilder) Bind(durable string) *ConsumerStepStreamBuilder {
+	b.res.Durable = &durable
+	b.res.Bind = true
+	return b
+}
+
+func (b *ConsumerStepStreamBuilder) FilterSubjects(v ...string) *ConsumerStepStreamBuilder {
+	b.res.FilterSubjects = append(b.res.FilterSubjects, v...)
+	return b
+}
+
+func (b *ConsumerStepStreamBuilder) DeliverPolicy(v model.ConsumerStepStreamDeliverPolicy) *ConsumerStepStreamBuilder {
+	b.res.DeliverPolicy = v
+	return b
+}
+
+// StartSequence delivers the messages starting at the stream sequence.
+func (b *ConsumerStepStreamBuilder) StartSequence(seq int) *ConsumerStepStreamBuilder {
+	b.res.DeliverPolicy = model.ConsumerStepStreamDeliverPolicyByStartSequence
+	b.res.OptStartSeq = &seq
+	return b
+}
+
+// StartTime delivers the messages stored since the time.
+func (b *ConsumerStepStreamBuilder) StartTime(t time.Time) *ConsumerStepStreamBuilder {
+	v := t.UTC().Format(time.RFC3339)
+	b.res.DeliverPolicy = model.ConsumerStepStreamDeliverPolicyByStartTime
+	b.res.OptStartTime = &v
+	return b
+}
+
+func (b *ConsumerStepStreamBuilder) AckPolicy(v model.ConsumerStepStreamAckPolicy) *ConsumerStepStreamBuilder {
+	b.res.AckPolicy = v
+	return b
+}
+
+func (b *ConsumerStepStreamBuilder) AckWait(d time.Duration) *ConsumerStepStreamBuilder {
+	v := d.String()
+	b.res.AckWait = &v
+	return b
+}
+
+func (b *ConsumerStepStreamBuilder) MaxDeliver(v int) *ConsumerStepStreamBuilder {
+	b.res.MaxDeliver = &v
+	return b
+}
+
+func (b *ConsumerStepStreamBuilder) MaxAckPending(v int) *ConsumerStepStreamBuilder {
+	b.res.MaxAckPending = &v
+	return b
+}
+
 func (b *ConsumerStepStreamBuilder) Build() model.ConsumerStepStream {
 	return *b.res
 }
@@ -1,8 +1,11 @@
 package builders
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/model"
 )
 
 var _ = Describe("ConsumerStepBuilder", func() {
@@ -75,6 +78,42 @@ var _ = Describe("ConsumerStepBuilder", func() {
 			Expect(result.Stream).ToNot(BeNil())
 			Expect(result.Stream.Subject).To(Equal("test.stream.subject"))
 		})
+
+		It("should configure the jetstream consumer", func() {
+			stream := ConsumerStepStream("orders.>").
+				Stream("ORDERS").
+				Durable("outlet").
+				FilterSubjects("returns.>").
+				StartSequence(42).
+				AckPolicy(model.ConsumerStepStreamAckPolicyAll).
+				AckWait(30 * time.Second).
+				MaxDeliver(5).
+				MaxAckPending(100).
+				Build()
+
+			Expect(*stream.Stream).To(Equal("ORDERS"))
+			Expect(*stream.Durable).To(Equal("outlet"))
+			Expect(stream.Bind).To(BeFalse())
+			Expect(stream.FilterSubjects).To(Equal([]string{"returns.>"}))
+			Expect(stream.DeliverPolicy).To(Equal(model.ConsumerStepStreamDeliverPolicyByStartSequence))
+			Expect(*stream.OptStartSeq).To(Equal(42))
+			Expect(stream.AckPolicy).To(Equal(model.ConsumerStepStreamAckPolicyAll))
+			Expect(*stream.AckWait).To(Equal("30s"))
+			Expect(*stream.MaxDeliver).To(Equal(5))
+			Expect(*stream.MaxAckPending).To(Equal(100))
+		})
+
+		It("should default to delivering all messages with explicit acks", func() {
+			stream := ConsumerStepStream("orders.>").Build()
+			Expect(stream.DeliverPolicy).To(Equal(model.ConsumerStepStreamDeliverPolicyAll))
+			Expect(stream.AckPolicy).To(Equal(model.ConsumerStepStreamAckPolicyExplicit))
+		})
+
+		It("should bind to an existing durable consumer", func() {
+			stream := ConsumerStepStream("orders.>").Bind("existing").Build()
+			Expect(stream.Bind).To(BeTrue())
+			Expect(*stream.Durable).To(Equal("existing"))
+		})
 	})
 
 	Describe("Build", func() {
@@ -113,4 +152,50 @@ var _ = Describe("ConsumerStepBuilder", func() {
 			Expect(result.Stream).ToNot(BeNil())
 		})
 	})
+
+	Describe("Headers", func() {
+		It("should set the propagated and static headers", func() {
+			result := builder.
+				Core(ConsumerStepCore("orders")).
+				Headers(ConsumerStepHeaders().Propagate("Trace-Id", "X-*").Static("Source", "crm")).
+				Build()
+
+			Expect(result.Headers).ToNot(BeNil())
+			Expect(result.Headers.Propagate).To(Equal([]string{"Trace-Id", "X-*"}))
+			Expect(result.Headers.Static).To(HaveKeyWithValue("Source", "crm"))
+		})
+
+		It("should drop all headers when none are propagated", func() {
+			headers := ConsumerStepHeaders().Propagate().Build()
+			Expect(headers.Propagate).ToNot(BeNil())
+			Expect(headers.Propagate).To(BeEmpty())
+		})
+	})
+
+	Describe("Schema", func() {
+		It("should dead-letter messages by default", func() {
+			result := builder.
+				Core(ConsumerStepCore("orders")).
+				Schema(MessageSchema(model.MessageSchemaFormatAvro).ObjectStore("schemas", "order.avsc")).
+				Build()
+
+			Expect(result.Schema).To(Equal(&model.MessageSchema{
+				Format:      model.MessageSchemaFormatAvro,
+				ObjectStore: &model.MessageSchemaObjectStore{Bucket: "schemas", Name: "order.avsc"},
+				OnFailure:   model.MessageSchemaOnFailureDeadLetter,
+			}))
+		})
+
+		It("should set the protobuf message and failure policy", func() {
+			schema := MessageSchema(model.MessageSchemaFormatProtobuf).
+				File("order.proto").
+				Message("shop.Order").
+				OnFailure(model.MessageSchemaOnFailureDrop).
+				Build()
+
+			Expect(*schema.File).To(Equal("order.proto"))
+			Expect(*schema.Message).To(Equal("shop.Order"))
+			Expect(schema.OnFailure).To(Equal(model.MessageSchemaOnFailureDrop))
+		})
+	})
 })
@@ -0,0 +1,47 @@
+package builders
+
+import (
+	"time"
+
+	"github.com/synadia-io/connect/model"
+)
+
+type ErrorPolicyBuilder struct {
+	res *model.ErrorPolicy
+}
+
+func ErrorPolicy() *ErrorPolicyBuilder {
+	maxRetries := 3
+	return &ErrorPolicyBuilder{
+		res: &model.ErrorPolicy{
+			MaxRetries: &maxRetries,
+			Backoff:    "1s",
+			MaxBackoff: "30s",
+		},
+	}
+}
+
+func (b *ErrorPolicyBuilder) MaxRetries(v int) *ErrorPolicyBuilder {
+	b.res.MaxRetries = &v
+	return b
+}
+
+func (b *ErrorPolicyBuilder) Backoff(d time.Duration) *ErrorPolicyBuilder {
+	b.res.Backoff = d.String()
+	return b
+}
+
+func (b *ErrorPolicyBuilder) MaxBackoff(d time.Duration) *ErrorPolicyBuilder {
+	b.res.MaxBackoff = d.String()
+	return b
+}
+
+func (b *ErrorPolicyBuilder) DeadLetter(v *ProducerStepBuilder) *ErrorPolicyBuilder {
+	r := v.Build()
+	b.res.DeadLetter = &r
+	return b
+}
+
+func (b *ErrorPolicyBuilder) Build() model.ErrorPolicy {
+	return *b.res
+}
@@ -0,0 +1,50 @@
+package builders
+
+import (
+	"github.com/synadia-io/connect/model"
+)
+
+type MessageSchemaBuilder struct {
+	res *model.MessageSchema
+}
+
+func MessageSchema(format model.MessageSchemaFormat) *MessageSchemaBuilder {
+	return &MessageSchemaBuilder{
+		res: &model.MessageSchema{
+			Format:    format,
+			OnFailure: model.MessageSchemaOnFailureDeadLetter,
+		},
+	}
+}
+
+func (b *MessageSchemaBuilder) Inline(v string) *MessageSchemaBuilder {
+	b.res.Inline = &v
+	return b
+}
+
+func (b *MessageSchemaBuilder) File(v string) *MessageSchemaBuilder {
+	b.res.File = &v
+	return b
+}
+
+func (b *MessageSchemaBuilder) ObjectStore(bucket string, name string) *MessageSchemaBuilder {
+	b.res.ObjectStore = &model.MessageSchemaObjectStore{
+		Bucket: bucket,
+		Name:   name,
+	}
+	return b
+}
+
+func (b *MessageSchemaBuilder) Message(v string) *MessageSchemaBuilder {
+	b.res.Message = &v
+	return b
+}
+
+func (b *MessageSchemaBuilder) OnFailure(v model.MessageSchemaOnFailure) *MessageSchemaBuilder {
+	b.res.OnFailure = v
+	return b
+}
+
+func (b *MessageSchemaBuilder) Build() model.MessageSchema {
+	return *b.res
+}
@@ -28,6 +28,61 @@ func (b *NatsConfigBuilder) Auth(jwt string, seed string) *NatsConfigBuilder {
 	return b
 }
 
+// RootCAs verifies the server certificate with the certificate authorities in the file.
+func (b *NatsConfigBuilder) RootCAs(file string) *NatsConfigBuilder {
+	b.tls().CaFile = &file
+	return b
+}
+
+// ClientCert presents the certificate in the files to the server, for mutual TLS.
+func (b *NatsConfigBuilder) ClientCert(certFile string, keyFile string) *NatsConfigBuilder {
+	t := b.tls()
+	t.CertFile = &certFile
+	t.KeyFile = &keyFile
+	return b
+}
+
+func (b *NatsConfigBuilder) InsecureSkipVerify() *NatsConfigBuilder {
+	b.tls().InsecureSkipVerify = true
+	return b
+}
+
+func (b *NatsConfigBuilder) tls() *model.NatsConfigTls {
+	if b.nats.Tls == nil {
+		b.nats.Tls = &model.NatsConfigTls{}
+	}
+	return b.nats.Tls
+}
+
+func (b *NatsConfigBuilder) CredsFile(file string) *NatsConfigBuilder {
+	b.nats.Creds = &model.NatsConfigCreds{File: &file}
+	return b
+}
+
+// CredsSecret authenticates with the credentials in the secret, which the connector receives as an environment
+// variable of the same name.
+func (b *NatsConfigBuilder) CredsSecret(name string) *NatsConfigBuilder {
+	b.nats.Creds = &model.NatsConfigCreds{Secret: &name}
+	return b
+}
+
+// Nkey authenticates with the nkey seed in the file.
+func (b *NatsConfigBuilder) Nkey(file string) *NatsConfigBuilder {
+	b.nats.Nkey = &file
+	return b
+}
+
+func (b *NatsConfigBuilder) UserInfo(user string, password string) *NatsConfigBuilder {
+	b.nats.User = &user
+	b.nats.Password = &password
+	return b
+}
+
+func (b *NatsConfigBuilder) Token(token string) *NatsConfigBuilder {
+	b.nats.Token = &token
+	return b
+}
+
 func (b *NatsConfigBuilder) Build() model.NatsConfig {
 	return *b.nats
 }
@@ -84,6 +84,43 @@ var _ = Describe("NatsConfigBuilder", func() {
 		})
 	})
 
+	Describe("TLS", func() {
+		It("should set the certificate authorities and client certificate", func() {
+			result := builder.RootCAs("ca.pem").ClientCert("client.pem", "client-key.pem").Build()
+
+			Expect(result.Tls).ToNot(BeNil())
+			Expect(*result.Tls.CaFile).To(Equal("ca.pem"))
+			Expect(*result.Tls.CertFile).To(Equal("client.pem"))
+			Expect(*result.Tls.KeyFile).To(Equal("client-key.pem"))
+			Expect(result.Tls.InsecureSkipVerify).To(BeFalse())
+		})
+
+		It("should skip verifying the server certificate", func() {
+			result := builder.InsecureSkipVerify().Build()
+			Expect(result.Tls.InsecureSkipVerify).To(BeTrue())
+			Expect(result.Tls.CaFile).To(BeNil())
+		})
+	})
+
+	Describe("Authentication", func() {
+		It("should use either a credentials file or secret", func() {
+			result := builder.CredsFile("user.creds").Build()
+			Expect(*result.Creds.File).To(Equal("user.creds"))
+
+			result = builder.CredsSecret("NATS_CREDS").Build()
+			Expect(result.Creds.File).To(BeNil())
+			Expect(*result.Creds.Secret).To(Equal("NATS_CREDS"))
+		})
+
+		It("should set the nkey, user and token", func() {
+			result := builder.Nkey("user.nk").UserInfo("app", "s3cret").Token("t0ken").Build()
+			Expect(*result.Nkey).To(Equal("user.nk"))
+			Expect(*result.User).To(Equal("app"))
+			Expect(*result.Password).To(Equal("s3cret"))
+			Expect(*result.Token).To(Equal("t0ken"))
+		})
+	})
+
 	Describe("Fluent API", func() {
 		It("should support full configuration chain", func() {
 			config := NatsConfig().
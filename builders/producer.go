@@ -33,6 +33,30 @@ func (b *ProducerStepBuilder) Kv(v *ProducerStepKvBuilder) *ProducerStepBuilder
 	return b
 }
 
+func (b *ProducerStepBuilder) Headers(v *ProducerStepHeadersBuilder) *ProducerStepBuilder {
+	r := v.Build()
+	b.res.Headers = &r
+	return b
+}
+
+func (b *ProducerStepBuilder) RateLimit(v *RateLimitBuilder) *ProducerStepBuilder {
+	r := v.Build()
+	b.res.RateLimit = &r
+	return b
+}
+
+func (b *ProducerStepBuilder) Batching(v *BatchingBuilder) *ProducerStepBuilder {
+	r := v.Build()
+	b.res.Batching = &r
+	return b
+}
+
+func (b *ProducerStepBuilder) Schema(v *MessageSchemaBuilder) *ProducerStepBuilder {
+	c := v.Build()
+	b.res.Schema = &c
+	return b
+}
+
 func (b *ProducerStepBuilder) Build() model.ProducerStep {
 	return *b.res
 }
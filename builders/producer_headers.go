@@ -0,0 +1,35 @@
+package builders
+
+import "github.com/synadia-io/connect/model"
+
+type ProducerStepHeadersBuilder struct {
+	res *model.ProducerStepHeaders
+}
+
+func ProducerStepHeaders() *ProducerStepHeadersBuilder {
+	return &ProducerStepHeadersBuilder{
+		res: &model.ProducerStepHeaders{},
+	}
+}
+
+func (b *ProducerStepHeadersBuilder) Propagate(names ...string) *ProducerStepHeadersBuilder {
+	b.res.Propagate = append([]string{}, names...)
+	return b
+}
+
+func (b *ProducerStepHeadersBuilder) Static(key string, value string) *ProducerStepHeadersBuilder {
+	if b.res.Static == nil {
+		b.res.Static = make(map[string]string)
+	}
+	b.res.Static[key] = value
+	return b
+}
+
+func (b *ProducerStepHeadersBuilder) MsgId(v string) *ProducerStepHeadersBuilder {
+	b.res.MsgId = &v
+	return b
+}
+
+func (b *ProducerStepHeadersBuilder) Build() model.ProducerStepHeaders {
+	return *b.res
+}
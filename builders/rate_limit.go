@@ -0,0 +1,34 @@
+package builders
+
+import (
+	"time"
+
+	"github.com/synadia-io/connect/model"
+)
+
+type RateLimitBuilder struct {
+	res *model.RateLimit
+}
+
+func RateLimit(count int) *RateLimitBuilder {
+	return &RateLimitBuilder{
+		res: &model.RateLimit{
+			Count:  count,
+			Period: "1s",
+		},
+	}
+}
+
+func (b *RateLimitBuilder) Period(d time.Duration) *RateLimitBuilder {
+	b.res.Period = d.String()
+	return b
+}
+
+func (b *RateLimitBuilder) Burst(v int) *RateLimitBuilder {
+	b.res.Burst = &v
+	return b
+}
+
+func (b *RateLimitBuilder) Build() model.RateLimit {
+	return *b.res
+}
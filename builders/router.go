@@ -0,0 +1,31 @@
+package builders
+
+import "github.com/synadia-io/connect/model"
+
+type RouterStepBuilder struct {
+	res *model.RouterStep
+}
+
+func RouterStep() *RouterStepBuilder {
+	return &RouterStepBuilder{
+		res: &model.RouterStep{},
+	}
+}
+
+func (b *RouterStepBuilder) Route(condition string, v *OutputStepBuilder) *RouterStepBuilder {
+	b.res.Routes = append(b.res.Routes, model.RouteStep{
+		Condition: condition,
+		Output:    v.Build(),
+	})
+	return b
+}
+
+func (b *RouterStepBuilder) Default(v *OutputStepBuilder) *RouterStepBuilder {
+	r := v.Build()
+	b.res.Default = &r
+	return b
+}
+
+func (b *RouterStepBuilder) Build() model.RouterStep {
+	return *b.res
+}
@@ -1,6 +1,8 @@
 package builders
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -60,6 +62,24 @@ var _ = Describe("SinkStepBuilder", func() {
 		})
 	})
 
+	Describe("Throttling", func() {
+		It("should set the rate limit with the default period", func() {
+			result := builder.RateLimit(RateLimit(10).Burst(20)).Build()
+			Expect(result.RateLimit).ToNot(BeNil())
+			Expect(result.RateLimit.Count).To(Equal(10))
+			Expect(result.RateLimit.Period).To(Equal("1s"))
+			Expect(*result.RateLimit.Burst).To(Equal(20))
+		})
+
+		It("should set the batching", func() {
+			result := builder.Batching(Batching().Count(50).ByteSize(1024).Period(500 * time.Millisecond)).Build()
+			Expect(result.Batching).ToNot(BeNil())
+			Expect(*result.Batching.Count).To(Equal(50))
+			Expect(*result.Batching.ByteSize).To(Equal(1024))
+			Expect(*result.Batching.Period).To(Equal("500ms"))
+		})
+	})
+
 	Describe("Edge cases", func() {
 		It("should handle nil config initialization", func() {
 			builder.res.Config = nil
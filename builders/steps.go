@@ -42,6 +42,24 @@ func (b *StepsBuilder) Transformer(v *TransformerStepBuilder) *StepsBuilder {
 	return b
 }
 
+func (b *StepsBuilder) Broker(v *BrokerStepBuilder) *StepsBuilder {
+	r := v.Build()
+	b.steps.Broker = &r
+	return b
+}
+
+func (b *StepsBuilder) Router(v *RouterStepBuilder) *StepsBuilder {
+	r := v.Build()
+	b.steps.Router = &r
+	return b
+}
+
+func (b *StepsBuilder) Errors(v *ErrorPolicyBuilder) *StepsBuilder {
+	r := v.Build()
+	b.steps.Errors = &r
+	return b
+}
+
 func (b *StepsBuilder) Build() model.Steps {
 	return *b.steps
 }
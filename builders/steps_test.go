@@ -1,8 +1,11 @@
 package builders
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/model"
 )
 
 var _ = Describe("StepsBuilder", func() {
@@ -63,6 +66,66 @@ var _ = Describe("StepsBuilder", func() {
 		})
 	})
 
+	Describe("Errors", func() {
+		It("should add an errors section", func() {
+			deadLetter := ProducerStep(NatsConfig().Url("nats://localhost:4222")).Core(ProducerStepCore("orders.dlq"))
+			builder.Errors(ErrorPolicy().MaxRetries(5).Backoff(100 * time.Millisecond).MaxBackoff(time.Minute).DeadLetter(deadLetter))
+
+			steps := builder.Build()
+			Expect(steps.Errors).ToNot(BeNil())
+			Expect(steps.Errors.MaxRetries).To(HaveValue(Equal(5)))
+			Expect(steps.Errors.Backoff).To(Equal("100ms"))
+			Expect(steps.Errors.MaxBackoff).To(Equal("1m0s"))
+			Expect(steps.Errors.DeadLetter.Core.Subject).To(Equal("orders.dlq"))
+		})
+
+		It("should default to the schema defaults", func() {
+			policy := ErrorPolicy().Build()
+			Expect(policy.MaxRetries).To(HaveValue(Equal(3)))
+			Expect(policy.Backoff).To(Equal("1s"))
+			Expect(policy.MaxBackoff).To(Equal("30s"))
+			Expect(policy.DeadLetter).To(BeNil())
+		})
+	})
+
+	Describe("Broker", func() {
+		It("should add a broker step", func() {
+			mirror := ProducerStep(NatsConfig().Url("nats://localhost:4222")).Core(ProducerStepCore("orders.copy"))
+			builder.Broker(BrokerStep().
+				Pattern(model.BrokerStepPatternRoundRobin).
+				Output(OutputStep().Sink(SinkStep("aws_s3"))).
+				Output(OutputStep().Transformer(TransformerStep().Mapping(MappingTransformerStep("root = this"))).Producer(mirror)))
+
+			steps := builder.Build()
+			Expect(steps.Broker).ToNot(BeNil())
+			Expect(steps.Broker.Pattern).To(Equal(model.BrokerStepPatternRoundRobin))
+			Expect(steps.Broker.Outputs).To(HaveLen(2))
+			Expect(steps.Broker.Outputs[0].Sink.Type).To(Equal("aws_s3"))
+			Expect(steps.Broker.Outputs[1].Transformer.Mapping.Sourcecode).To(Equal("root = this"))
+			Expect(steps.Broker.Outputs[1].Producer.Core.Subject).To(Equal("orders.copy"))
+		})
+
+		It("should fan out by default", func() {
+			Expect(BrokerStep().Build().Pattern).To(Equal(model.BrokerStepPatternFanOut))
+		})
+	})
+
+	Describe("Router", func() {
+		It("should add a router step", func() {
+			orders := ProducerStep(NatsConfig().Url("nats://localhost:4222")).Core(ProducerStepCore("orders"))
+			builder.Router(RouterStep().
+				Route(`this.type == "order"`, OutputStep().Producer(orders)).
+				Default(OutputStep().Sink(SinkStep("stdout"))))
+
+			steps := builder.Build()
+			Expect(steps.Router).ToNot(BeNil())
+			Expect(steps.Router.Routes).To(HaveLen(1))
+			Expect(steps.Router.Routes[0].Condition).To(Equal(`this.type == "order"`))
+			Expect(steps.Router.Routes[0].Output.Producer.Core.Subject).To(Equal("orders"))
+			Expect(steps.Router.Default.Sink.Type).To(Equal("stdout"))
+		})
+	})
+
 	Describe("Transformer", func() {
 		It("should add a transformer step", func() {
 			transformer := TransformerStep()
@@ -21,6 +21,18 @@ func (b *ServiceTransformerStepBuilder) Timeout(timeout string) *ServiceTransfor
 	return b
 }
 
+func (b *ServiceTransformerStepBuilder) RateLimit(v *RateLimitBuilder) *ServiceTransformerStepBuilder {
+	r := v.Build()
+	b.res.RateLimit = &r
+	return b
+}
+
+func (b *ServiceTransformerStepBuilder) Batching(v *BatchingBuilder) *ServiceTransformerStepBuilder {
+	r := v.Build()
+	b.res.Batching = &r
+	return b
+}
+
 func (b *ServiceTransformerStepBuilder) Build() model.ServiceTransformerStep {
 	return *b.res
 }
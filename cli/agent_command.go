@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/synadia-io/connect/agent"
+)
+
+type agentCommand struct {
+	opts *Options
+
+	name       string
+	account    string
+	tags       []string
+	backend    string
+	dockerHost string
+	runtimes   map[string]string
+	heartbeat  time.Duration
+}
+
+func ConfigureAgentCommand(parentCmd commandHost, opts *Options) {
+	c := &agentCommand{
+		opts:     opts,
+		runtimes: map[string]string{},
+	}
+
+	agentCmd := parentCmd.Command("agent", "Run connector instances on this node for a self-hosted control plane").Action(c.run)
+	agentCmd.Flag("name", "Name of the agent (defaults to the hostname)").StringVar(&c.name)
+	agentCmd.Flag("account", "Account to run instances for (defaults to the account of the connection)").StringVar(&c.account)
+	agentCmd.Flag("tag", "Placement tag of the agent (can be repeated)").StringsVar(&c.tags)
+	agentCmd.Flag("backend", "How instances are launched").Default("container").EnumVar(&c.backend, "container", "process")
+	agentCmd.Flag("docker-host", "Docker daemon to run containers on (container backend)").PlaceHolder("HOST").StringVar(&c.dockerHost)
+	agentCmd.Flag("runtime", "Binary to run for a runtime, as id=path (process backend, can be repeated)").PlaceHolder("ID=PATH").StringMapVar(&c.runtimes)
+	agentCmd.Flag("heartbeat", "Interval at which the state of the instances is published").Default("5s").DurationVar(&c.heartbeat)
+}
+
+func (c *agentCommand) run(pc *fisk.ParseContext) error {
+	appCtx, err := LoadOptions(c.opts)
+	fisk.FatalIfError(err, "failed to load options")
+	defer appCtx.Close()
+
+	cfg, err := c.agentConfig(appCtx)
+	fisk.FatalIfError(err, "failed to configure agent")
+
+	a, err := agent.New(appCtx.Nc, cfg)
+	fisk.FatalIfError(err, "failed to create agent")
+
+	fisk.FatalIfError(a.Start(), "failed to start agent")
+
+	fmt.Printf("Agent %s running %s instances of account %s. Press Ctrl+C to stop.\n", cfg.Id, cfg.Backend.Name(), cfg.Namespace)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	<-sigs
+
+	fmt.Println("Stopping instances...")
+	a.Stop()
+
+	return nil
+}
+
+func (c *agentCommand) agentConfig(appCtx *AppContext) (agent.Config, error) {
+	name := c.name
+	if name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return agent.Config{}, fmt.Errorf("failed to determine hostname: %w", err)
+		}
+		name = hostname
+	}
+
+	account := c.account
+	if account == "" {
+		account = appCtx.Client.Account()
+	}
+
+	cfg := agent.Config{
+		Id:                agentId(name),
+		Namespace:         account,
+		Tags:              c.tags,
+		HeartbeatInterval: c.heartbeat,
+	}
+
+	switch c.backend {
+	case "process":
+		if len(c.runtimes) == 0 {
+			return agent.Config{}, fmt.Errorf("the process backend needs at least one --runtime")
+		}
+		cfg.Backend = agent.NewProcessBackend(c.runtimes)
+	default:
+		cfg.Backend = agent.NewContainerBackend(c.dockerHost)
+	}
+
+	return cfg, nil
+}
+
+// agentId turns a name into a valid subject token.
+func agentId(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t':
+			return '-'
+		}
+		return r
+	}, name)
+}
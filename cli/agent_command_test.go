@@ -0,0 +1,42 @@
+package cli
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AgentCommand", func() {
+	DescribeTable("agentId",
+		func(name string, expected string) {
+			Expect(agentId(name)).To(Equal(expected))
+		},
+		Entry("plain name", "worker-1", "worker-1"),
+		Entry("fully qualified hostname", "worker-1.example.com", "worker-1-example-com"),
+		Entry("wildcards", "a*b>c", "a-b-c"),
+	)
+
+	Describe("agentConfig", func() {
+		It("should require a runtime for the process backend", func() {
+			c := &agentCommand{name: "worker", account: "ACC", backend: "process", runtimes: map[string]string{}}
+			_, err := c.agentConfig(nil)
+			Expect(err).To(MatchError(ContainSubstring("--runtime")))
+		})
+
+		It("should use the process backend", func() {
+			c := &agentCommand{name: "worker.local", account: "ACC", backend: "process", runtimes: map[string]string{"wombat": "/usr/bin/wombat"}, tags: []string{"gpu"}}
+			cfg, err := c.agentConfig(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.Id).To(Equal("worker-local"))
+			Expect(cfg.Namespace).To(Equal("ACC"))
+			Expect(cfg.Tags).To(ConsistOf("gpu"))
+			Expect(cfg.Backend.Name()).To(Equal("process"))
+		})
+
+		It("should default to the container backend", func() {
+			c := &agentCommand{name: "worker", account: "ACC", backend: "container"}
+			cfg, err := c.agentConfig(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.Backend.Name()).To(Equal("container"))
+		})
+	})
+})
@@ -3,11 +3,11 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
-	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/joho/godotenv"
 	"github.com/synadia-io/connect/client"
 	"github.com/synadia-io/connect/convert"
@@ -89,6 +89,17 @@ func ConfigureConnectorCommand(parentCmd commandHost, opts *Options) {
 	startCmd.Flag("env-file", "Read environment variables from file").Default(".env").IsSetByUser(&c.envFileSetByUser).StringVar(&c.envFile)
 	startCmd.Flag("start-timeout", "How long to wait for the component to be started").Default("1m").StringVar(&c.startTimeout)
 
+	scaleCmd := connectorCmd.Command("scale", "Change the number of running instances of a connector").Action(c.scaleConnector)
+	scaleCmd.Arg("id", "The id of the connector to scale").Required().StringVar(&c.id)
+	scaleCmd.Flag("replicas", "Number of instances that should be running").Required().IntVar(&c.replicas)
+	scaleCmd.Flag("no-pull", "Whether to skip pulling the image").Default("false").UnNegatableBoolVar(&c.noPull)
+	scaleCmd.Flag("pull-username", "Username for pulling the image").IsSetByUser(&c.pullUsernameSetByUser).StringVar(&c.pullUsername)
+	scaleCmd.Flag("pull-password", "Password for pulling the image").IsSetByUser(&c.pullPasswordSetByUser).StringVar(&c.pullPassword)
+	scaleCmd.Flag("tag", "Placement tag to use").StringsVar(&c.placementTags)
+	scaleCmd.Flag("env", "Environment variables to set").Short('e').StringMapVar(&c.envVars)
+	scaleCmd.Flag("env-file", "Read environment variables from file").Default(".env").IsSetByUser(&c.envFileSetByUser).StringVar(&c.envFile)
+	scaleCmd.Flag("start-timeout", "How long to wait for the instances to be started").Default("1m").StringVar(&c.startTimeout)
+
 	stopCmd := connectorCmd.Command("stop", "Stop a connector").Action(c.stopConnector)
 	stopCmd.Arg("id", "The id of the connector to stop").Required().StringVar(&c.id)
 
@@ -101,49 +112,7 @@ func (c *connectorCommand) listConnectors(pc *fisk.ParseContext) error {
 	fisk.FatalIfError(err, "failed to load options")
 	defer appCtx.Close()
 
-	resp, err := appCtx.Client.ListConnectors(c.opts.Timeout)
-	fisk.FatalIfError(err, "failed to list connectors")
-
-	if len(resp) == 0 {
-		fmt.Println("No connectors found")
-		return nil
-	}
-
-	tbl := table.NewWriter()
-	tbl.SetStyle(table.StyleRounded)
-	title := "Connectors"
-	tbl.SetTitle(title)
-	tbl.SetColumnConfigs([]table.ColumnConfig{
-		{Number: 1, Name: "Name"},
-		{Number: 2, Name: "Description"},
-		{Number: 3, Name: "Runtime"},
-		{Number: 4, Name: color.GreenString("\u25B6"), WidthMin: 3, WidthMax: 5, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
-		{Number: 5, Name: color.RedString("\u25FC"), WidthMin: 3, WidthMax: 5, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
-	})
-
-	tbl.AppendHeader(table.Row{"Name", "Description", "Runtime", color.GreenString("\u25B6"), color.RedString("\u25FC")}, table.RowConfig{AutoMerge: true})
-
-	for _, c := range resp {
-		running := ""
-		if c.Instances.Running > 0 {
-			running = color.GreenString("%d", c.Instances.Running)
-		}
-
-		stopped := ""
-		if c.Instances.Stopped > 0 {
-			stopped = color.RedString("%d", c.Instances.Stopped)
-		}
-
-		tbl.AppendRow(table.Row{
-			c.ConnectorId,
-			text.WrapSoft(c.Description, 50),
-			c.RuntimeId,
-			running,
-			stopped,
-		})
-	}
-
-	fmt.Println(tbl.Render())
+	fisk.FatalIfError(c.listConnectorsWithClient(appCtx), "failed to list connectors")
 
 	return nil
 }
@@ -154,6 +123,9 @@ func (c *connectorCommand) getConnector(pc *fisk.ParseContext) error {
 	defer appCtx.Close()
 
 	resp, err := appCtx.Client.GetConnector(c.id, c.opts.Timeout)
+	if errors.Is(err, client.ErrNotFound) {
+		resp, err = nil, nil
+	}
 	fisk.FatalIfError(err, "failed to list instances for %s", c.id)
 
 	if resp == nil {
@@ -262,6 +234,25 @@ func (c *connectorCommand) startConnector(pc *fisk.ParseContext) error {
 	return nil
 }
 
+func (c *connectorCommand) scaleConnector(pc *fisk.ParseContext) error {
+	appCtx, err := LoadOptions(c.opts)
+	fisk.FatalIfError(err, "failed to load options")
+	defer appCtx.Close()
+
+	envVars, err := LoadEnvFile(c.envFile, c.envFileSetByUser)
+	if err != nil {
+		return fmt.Errorf("failed to load env file: %w", err)
+	}
+
+	for k, v := range envVars {
+		if _, exists := c.envVars[k]; !exists {
+			c.envVars[k] = v
+		}
+	}
+
+	return c.scaleConnectorWithClient(appCtx)
+}
+
 // LoadEnvFile loads environment variables from a file
 func LoadEnvFile(file string, shouldExist bool) (map[string]string, error) {
 	envVars := make(map[string]string)
@@ -335,6 +326,9 @@ func (c *connectorCommand) saveConnector(pc *fisk.ParseContext) error {
 
 	// -- check if the connector exists
 	conn, err := appCtx.Client.GetConnector(c.id, c.opts.Timeout)
+	if errors.Is(err, client.ErrNotFound) {
+		conn, err = nil, nil
+	}
 	fisk.FatalIfError(err, "failed to get connector %s: %v", c.id, err)
 	exists := conn != nil
 
@@ -417,6 +411,9 @@ func (c *connectorCommand) copyConnector(context *fisk.ParseContext) error {
 
 	// -- check if the connector exists
 	conn, err := appCtx.Client.GetConnector(c.id, c.opts.Timeout)
+	if errors.Is(err, client.ErrNotFound) {
+		conn, err = nil, nil
+	}
 	fisk.FatalIfError(err, "failed to get connector %s: %v", c.id, err)
 	exists := conn != nil
 
@@ -1,43 +1,37 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/synadia-io/connect/client"
 	"github.com/synadia-io/connect/model"
 )
 
 // These are testable helper functions that can be called with a provided AppContext
 
 func (c *connectorCommand) listConnectorsWithClient(appCtx *AppContext) error {
-	resp, err := appCtx.Client.ListConnectors(c.opts.Timeout)
-	if err != nil {
-		return fmt.Errorf("failed to list connectors: %w", err)
-	}
-
-	if len(resp) == 0 {
-		fmt.Println("No connectors found")
-		return nil
-	}
-
-	tbl := table.NewWriter()
-	tbl.SetStyle(table.StyleRounded)
-	title := "Connectors"
-	tbl.SetTitle(title)
-	tbl.SetColumnConfigs([]table.ColumnConfig{
-		{Number: 1, Name: "Name"},
-		{Number: 2, Name: "Description"},
-		{Number: 3, Name: "Runtime"},
-		{Number: 4, Name: color.GreenString("\u25B6"), WidthMin: 3, WidthMax: 5, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
-		{Number: 5, Name: color.RedString("\u25FC"), WidthMin: 3, WidthMax: 5, Align: text.AlignCenter, AlignHeader: text.AlignCenter},
-	})
-
-	tbl.AppendHeader(table.Row{"Name", "Description", "Runtime", color.GreenString("\u25B6"), color.RedString("\u25FC")}, table.RowConfig{AutoMerge: true})
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+
+	tbl := newStreamTable(os.Stdout, "Connectors",
+		streamColumn{Name: "Name", Width: 30},
+		streamColumn{Name: "Description", Width: 50},
+		streamColumn{Name: "Runtime", Width: 15},
+		streamColumn{Name: color.GreenString("\u25B6"), Width: 3, Align: text.AlignCenter},
+		streamColumn{Name: color.RedString("\u25FC"), Width: 3, Align: text.AlignCenter},
+	)
+
+	for c, err := range appCtx.Client.IterConnectors(ctx) {
+		if err != nil {
+			tbl.Close()
+			return fmt.Errorf("failed to list connectors: %w", err)
+		}
 
-	for _, c := range resp {
 		running := ""
 		if c.Instances.Running > 0 {
 			running = color.GreenString("%d", c.Instances.Running)
@@ -48,16 +42,14 @@ func (c *connectorCommand) listConnectorsWithClient(appCtx *AppContext) error {
 			stopped = color.RedString("%d", c.Instances.Stopped)
 		}
 
-		tbl.AppendRow(table.Row{
-			c.ConnectorId,
-			text.WrapSoft(c.Description, 50),
-			c.RuntimeId,
-			running,
-			stopped,
-		})
+		tbl.AppendRow(c.ConnectorId, c.Description, c.RuntimeId, running, stopped)
+	}
+	tbl.Close()
+
+	if tbl.Rows() == 0 {
+		fmt.Println("No connectors found")
 	}
 
-	fmt.Println(tbl.Render())
 	return nil
 }
 
@@ -66,6 +58,9 @@ func (c *connectorCommand) getConnectorWithClient(appCtx *AppContext) error {
 	if err != nil {
 		return fmt.Errorf("failed to get connector: %w", err)
 	}
+	if connector == nil {
+		return fmt.Errorf("failed to get connector: %s: %w", c.id, client.ErrNotFound)
+	}
 
 	fmt.Println(renderConnector(*connector))
 	return nil
@@ -124,6 +119,58 @@ func (c *connectorCommand) startConnectorWithClient(appCtx *AppContext) error {
 	return nil
 }
 
+// pullAuth returns the authentication for pulling the image, if a username or password is given.
+func (c *connectorCommand) pullAuth() *model.ConnectorStartOptionsPullAuth {
+	if !c.pullUsernameSetByUser && !c.pullPasswordSetByUser {
+		return nil
+	}
+
+	auth := &model.ConnectorStartOptionsPullAuth{Enabled: true}
+	if c.pullUsernameSetByUser {
+		auth.Username = &c.pullUsername
+	}
+	if c.pullPasswordSetByUser {
+		auth.Password = &c.pullPassword
+	}
+	return auth
+}
+
+func (c *connectorCommand) scaleConnectorWithClient(appCtx *AppContext) error {
+	if c.replicas < 0 {
+		return fmt.Errorf("invalid replicas: %d", c.replicas)
+	}
+
+	timeout, err := time.ParseDuration(c.startTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	envVars := make(model.ConnectorStartOptionsEnvVars)
+	for k, v := range c.envVars {
+		envVars[k] = v
+	}
+
+	startOpts := &model.ConnectorStartOptions{
+		Pull:          !c.noPull,
+		Timeout:       c.startTimeout,
+		EnvVars:       envVars,
+		PlacementTags: c.placementTags,
+		PullAuth:      c.pullAuth(),
+	}
+
+	instances, err := appCtx.Client.ScaleConnector(c.id, c.replicas, startOpts, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to scale connector: %w", err)
+	}
+
+	fmt.Printf("Connector %s scaled to %d instances\n", c.id, len(instances))
+	for _, i := range instances {
+		fmt.Printf("  %s\n", i.Id)
+	}
+
+	return nil
+}
+
 func (c *connectorCommand) stopConnectorWithClient(appCtx *AppContext) error {
 	instances, err := appCtx.Client.StopConnector(c.id, c.opts.Timeout)
 	if err != nil {
@@ -140,6 +187,9 @@ func (c *connectorCommand) copyConnectorWithClient(appCtx *AppContext) error {
 	if err != nil {
 		return fmt.Errorf("failed to get source connector: %w", err)
 	}
+	if connector == nil {
+		return fmt.Errorf("failed to get source connector: %s: %w", c.id, client.ErrNotFound)
+	}
 
 	// Create the copy
 	copied, err := appCtx.Client.CreateConnector(c.targetId, connector.Description, connector.RuntimeId, connector.Steps, c.opts.Timeout)
@@ -255,6 +255,55 @@ var _ = Describe("ConnectorCommand", func() {
 		})
 	})
 
+	Describe("scaleConnector", func() {
+		BeforeEach(func() {
+			cmd.id = "test-connector"
+			cmd.replicas = 3
+			cmd.startTimeout = "1m"
+		})
+
+		It("should scale a connector to the requested replicas", func() {
+			mockCl.instances = []model.Instance{
+				{Id: "instance-1", ConnectorId: "test-connector"},
+				{Id: "instance-2", ConnectorId: "test-connector"},
+				{Id: "instance-3", ConnectorId: "test-connector"},
+			}
+
+			err := cmd.scaleConnectorWithClient(appCtx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockCl.scaleCalled).To(BeTrue())
+			Expect(mockCl.scaleReplicas).To(Equal(3))
+			Expect(mockCl.startOptions.Pull).To(BeTrue())
+		})
+
+		It("should pass the pull authentication", func() {
+			cmd.pullUsername, cmd.pullUsernameSetByUser = "robot", true
+			cmd.pullPassword, cmd.pullPasswordSetByUser = "secret", true
+
+			Expect(cmd.scaleConnectorWithClient(appCtx)).To(Succeed())
+			Expect(mockCl.startOptions.PullAuth).ToNot(BeNil())
+			Expect(mockCl.startOptions.PullAuth.Enabled).To(BeTrue())
+			Expect(*mockCl.startOptions.PullAuth.Username).To(Equal("robot"))
+			Expect(*mockCl.startOptions.PullAuth.Password).To(Equal("secret"))
+		})
+
+		It("should reject negative replicas", func() {
+			cmd.replicas = -1
+
+			err := cmd.scaleConnectorWithClient(appCtx)
+			Expect(err).To(HaveOccurred())
+			Expect(mockCl.scaleCalled).To(BeFalse())
+		})
+
+		It("should handle scale errors", func() {
+			mockCl.connectorError = fmt.Errorf("failed to scale")
+
+			err := cmd.scaleConnectorWithClient(appCtx)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to scale"))
+		})
+	})
+
 	Describe("copyConnector", func() {
 		BeforeEach(func() {
 			cmd.id = "source-connector"
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/connecttest"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("ConnectorCommand against a connect service", func() {
+	var (
+		srv    *connecttest.Server
+		appCtx *AppContext
+		cmd    *connectorCommand
+	)
+
+	BeforeEach(func() {
+		var err error
+		srv, err = connecttest.NewServer(connecttest.WithConnectors(model.Connector{
+			ConnectorId: "existing",
+			Description: "An existing connector",
+			RuntimeId:   "wombat",
+			Steps: model.Steps{
+				Source:   &model.SourceStep{Type: "generate", Config: model.SourceStepConfig{"mapping": "root = {}"}},
+				Producer: &model.ProducerStep{Core: &model.ProducerStepCore{Subject: "test"}},
+			},
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(srv.Close)
+
+		nc, err := srv.Connect()
+		Expect(err).ToNot(HaveOccurred())
+
+		cl, err := client.NewClient(nc, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		appCtx = &AppContext{Nc: nc, Client: cl, DefaultTimeout: time.Second}
+		DeferCleanup(appCtx.Close)
+
+		cmd = &connectorCommand{
+			opts:         &Options{Timeout: time.Second},
+			envVars:      map[string]string{},
+			startTimeout: "1m",
+		}
+	})
+
+	It("should list connectors", func() {
+		Expect(cmd.listConnectorsWithClient(appCtx)).To(Succeed())
+	})
+
+	It("should copy, start, scale and stop a connector", func() {
+		cmd.id = "existing"
+		cmd.targetId = "copy"
+		Expect(cmd.copyConnectorWithClient(appCtx)).To(Succeed())
+		Expect(srv.Connector("copy").Description).To(Equal("An existing connector"))
+
+		cmd.id = "copy"
+		cmd.replicas = 1
+		Expect(cmd.startConnectorWithClient(appCtx)).To(Succeed())
+		Expect(srv.Instances("copy")).To(HaveLen(1))
+
+		cmd.replicas = 3
+		Expect(cmd.scaleConnectorWithClient(appCtx)).To(Succeed())
+		Expect(srv.Instances("copy")).To(HaveLen(3))
+
+		Expect(cmd.stopConnectorWithClient(appCtx)).To(Succeed())
+		Expect(srv.Instances("copy")).To(BeEmpty())
+
+		Expect(cmd.removeConnectorWithClient(appCtx)).To(Succeed())
+		Expect(srv.Connector("copy")).To(BeNil())
+	})
+
+	It("should report missing connectors", func() {
+		cmd.id = "missing"
+		err := cmd.getConnectorWithClient(appCtx)
+		Expect(errors.Is(err, client.ErrNotFound)).To(BeTrue())
+	})
+
+	It("should surface service failures", func() {
+		srv.InjectFailure(connecttest.OpStartConnector, connecttest.Failure{Code: 503, Description: "no capacity"})
+
+		cmd.id = "existing"
+		cmd.replicas = 1
+		err := cmd.startConnectorWithClient(appCtx)
+		Expect(errors.Is(err, client.ErrUnavailable)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("no capacity"))
+	})
+})
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/synadia-io/connect/runtime"
+)
+
+// jsonlMessage is a message as one line of a JSONL file. String payloads are used as is, other JSON values as their
+// JSON encoding. Header values are a string or an array of strings.
+type jsonlMessage struct {
+	Subject string          `json:"subject,omitempty"`
+	Headers map[string]any  `json:"headers,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// readMessages reads a JSONL file of messages. Lines which are not objects with a payload are the payload
+// themselves; empty lines are skipped.
+func readMessages(r io.Reader) ([]*runtime.Message, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var result []*runtime.Message
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		msg, err := parseMessage([]byte(text))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		result = append(result, msg)
+	}
+	return result, scanner.Err()
+}
+
+func parseMessage(line []byte) (*runtime.Message, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil || raw["payload"] == nil {
+		return runtime.NewMessage("", line), nil
+	}
+
+	var jm jsonlMessage
+	if err := json.Unmarshal(line, &jm); err != nil {
+		return nil, err
+	}
+
+	msg := runtime.NewMessage(jm.Subject, decodePayload(jm.Payload))
+	for k, v := range jm.Headers {
+		switch value := v.(type) {
+		case string:
+			msg.Headers.Add(k, value)
+		case []any:
+			for _, item := range value {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("header %s: values must be strings", k)
+				}
+				msg.Headers.Add(k, s)
+			}
+		default:
+			return nil, fmt.Errorf("header %s: value must be a string or an array of strings", k)
+		}
+	}
+	return msg, nil
+}
+
+func decodePayload(payload json.RawMessage) []byte {
+	var s string
+	if err := json.Unmarshal(payload, &s); err == nil {
+		return []byte(s)
+	}
+	return payload
+}
+
+// encodeMessage returns the message as a JSONL line, without the newline. Payloads which are JSON are embedded,
+// others are written as a string.
+func encodeMessage(msg *runtime.Message) ([]byte, error) {
+	jm := jsonlMessage{Subject: msg.Subject}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, msg.Data); err == nil {
+		jm.Payload = compact.Bytes()
+	} else {
+		b, err := json.Marshal(string(msg.Data))
+		if err != nil {
+			return nil, err
+		}
+		jm.Payload = b
+	}
+
+	if len(msg.Headers) > 0 {
+		jm.Headers = map[string]any{}
+		keys := make([]string, 0, len(msg.Headers))
+		for k := range msg.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if values := msg.Headers[k]; len(values) == 1 {
+				jm.Headers[k] = values[0]
+			} else {
+				jm.Headers[k] = values
+			}
+		}
+	}
+
+	return json.Marshal(jm)
+}
+
+// writeMessage writes the message as a JSONL line.
+func writeMessage(w io.Writer, msg *runtime.Message) error {
+	b, err := encodeMessage(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/runtime"
+)
+
+var _ = Describe("JSONL messages", func() {
+	It("should read envelopes and bare payloads", func() {
+		msgs, err := readMessages(strings.NewReader(`{"subject": "orders", "headers": {"A": "1", "B": ["2", "3"]}, "payload": {"id": 1}}
+{"payload": "plain text"}
+
+{"id": 2}
+not json
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msgs).To(HaveLen(4))
+
+		Expect(msgs[0].Subject).To(Equal("orders"))
+		Expect(msgs[0].Headers.Get("A")).To(Equal("1"))
+		Expect(msgs[0].Headers.Values("B")).To(Equal([]string{"2", "3"}))
+		Expect(string(msgs[0].Data)).To(Equal(`{"id": 1}`))
+
+		Expect(string(msgs[1].Data)).To(Equal("plain text"))
+		Expect(string(msgs[2].Data)).To(Equal(`{"id": 2}`))
+		Expect(string(msgs[3].Data)).To(Equal("not json"))
+	})
+
+	It("should report the line of invalid headers", func() {
+		_, err := readMessages(strings.NewReader("{\"payload\": 1}\n{\"payload\": 1, \"headers\": {\"A\": 1}}\n"))
+		Expect(err).To(MatchError(ContainSubstring("line 2")))
+	})
+
+	It("should write messages which read back the same", func() {
+		msg := runtime.NewMessage("orders", []byte("{\n  \"id\": 1\n}"))
+		msg.Headers.Set("A", "1")
+
+		var buf bytes.Buffer
+		Expect(writeMessage(&buf, msg)).To(Succeed())
+		Expect(writeMessage(&buf, runtime.NewMessage("", []byte("plain")))).To(Succeed())
+		Expect(buf.String()).To(Equal(`{"subject":"orders","headers":{"A":"1"},"payload":{"id":1}}` + "\n" + `{"payload":"plain"}` + "\n"))
+
+		msgs, err := readMessages(&buf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msgs).To(HaveLen(2))
+		Expect(msgs[0].Subject).To(Equal("orders"))
+		Expect(string(msgs[0].Data)).To(Equal(`{"id":1}`))
+		Expect(string(msgs[1].Data)).To(Equal("plain"))
+	})
+})
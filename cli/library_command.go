@@ -104,38 +104,11 @@ func (c *libraryCommand) search(pc *fisk.ParseContext) error {
 	fisk.FatalIfError(err, "failed to load options")
 	defer appCtx.Close()
 
-	w := table.NewWriter()
-	w.AppendHeader(table.Row{"Name", "Kind", "Runtime", "Status"})
-	w.SetStyle(table.StyleRounded)
-
-	filter := &model.ComponentSearchFilter{}
-
-	if c.runtime != "" {
-		filter.RuntimeId = &c.runtime
-	}
-
-	if c.status != "" {
-		st := model.ComponentStatus(c.status)
-		filter.Status = &st
-	}
-
-	if c.kind != "" {
-		k := model.ComponentKind(c.kind)
-		filter.Kind = &k
-	}
-
-	components, err := appCtx.Client.SearchComponents(filter, c.opts.Timeout)
-	if err != nil {
-		color.Red("Could not list components: %s", err)
+	if err := c.searchWithClient(appCtx); err != nil {
+		color.Red("%s", err)
 		os.Exit(1)
 	}
 
-	for _, component := range components {
-		w.AppendRow(table.Row{component.Name, component.Kind, component.RuntimeId, component.Status})
-	}
-
-	result := w.Render()
-	fmt.Println(result)
 	return nil
 }
 
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
@@ -66,21 +68,30 @@ func (c *libraryCommand) searchWithClient(appCtx *AppContext) error {
 		filter.Kind = &k
 	}
 
-	components, err := appCtx.Client.SearchComponents(filter, c.opts.Timeout)
-	if err != nil {
-		return fmt.Errorf("could not list components: %w", err)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
 
-	w := table.NewWriter()
-	w.AppendHeader(table.Row{"Name", "Kind", "Runtime", "Status"})
-	w.SetStyle(table.StyleRounded)
+	tbl := newStreamTable(os.Stdout, "",
+		streamColumn{Name: "Name", Width: 30},
+		streamColumn{Name: "Kind", Width: 11},
+		streamColumn{Name: "Runtime", Width: 15},
+		streamColumn{Name: "Status", Width: 12},
+	)
+
+	for component, err := range appCtx.Client.IterComponents(ctx, filter) {
+		if err != nil {
+			tbl.Close()
+			return fmt.Errorf("could not list components: %w", err)
+		}
 
-	for _, component := range components {
-		w.AppendRow(table.Row{component.Name, component.Kind, component.RuntimeId, component.Status})
+		tbl.AppendRow(component.Name, string(component.Kind), component.RuntimeId, string(component.Status))
+	}
+	tbl.Close()
+
+	if tbl.Rows() == 0 {
+		fmt.Println("No components found")
 	}
 
-	result := w.Render()
-	fmt.Println(result)
 	return nil
 }
 
@@ -0,0 +1,397 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/synadia-io/connect/model"
+)
+
+// The severities of lint findings. Errors fail the lint, warnings only with --strict.
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+)
+
+// The rules connectors are linted with
+const (
+	ruleSelfLoop            = "self-loop"
+	ruleFeedbackLoop        = "feedback-loop"
+	ruleDuplicateDurable    = "duplicate-durable"
+	ruleKvKeyCollision      = "kv-key-collision"
+	ruleMissingQueueGroup   = "missing-queue-group"
+	ruleHardcodedCredential = "hardcoded-credential"
+)
+
+// lintTarget is a connector to lint, with the number of replicas it runs with.
+type lintTarget struct {
+	Connector model.Connector
+	Replicas  int
+}
+
+// lintFinding is a problem found in one or more connectors.
+type lintFinding struct {
+	Rule       string   `json:"rule"`
+	Severity   string   `json:"severity"`
+	Connectors []string `json:"connectors"`
+	// Location is the step of the connector the finding is about, when it is about a single connector
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+// lintReport is the result of linting the connectors, in a form CI pipelines can consume.
+type lintReport struct {
+	Connectors []string      `json:"connectors"`
+	Findings   []lintFinding `json:"findings"`
+	Errors     int           `json:"errors"`
+	Warnings   int           `json:"warnings"`
+}
+
+// failed reports whether the lint failed, which it does on errors, and on warnings when strict.
+func (r lintReport) failed(strict bool) bool {
+	return r.Errors > 0 || (strict && r.Warnings > 0)
+}
+
+// lintSecretFieldPattern matches the names of source and sink configuration fields holding credentials.
+var lintSecretFieldPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|access_?key|private_?key|credentials?)$`)
+
+// lintEnvReferencePattern matches values taken from the environment as a whole.
+var lintEnvReferencePattern = regexp.MustCompile(`^\$\{[A-Za-z_][A-Za-z0-9_]*\}$`)
+
+// lintConnectors checks the connectors on their own and against each other for subjects they consume their own
+// messages from, shared durables and key-value keys, replicated core consumers without a queue group and credentials
+// written into their configuration.
+func lintConnectors(targets []lintTarget) lintReport {
+	targets = slices.Clone(targets)
+	slices.SortFunc(targets, func(a, b lintTarget) int { return strings.Compare(a.Connector.ConnectorId, b.Connector.ConnectorId) })
+
+	r := lintReport{Connectors: []string{}, Findings: []lintFinding{}}
+	var connectors []model.Connector
+	for _, t := range targets {
+		r.Connectors = append(r.Connectors, t.Connector.ConnectorId)
+		connectors = append(connectors, t.Connector)
+	}
+
+	r.Findings = append(r.Findings, lintLoops(buildTopology(connectors))...)
+	r.Findings = append(r.Findings, lintDurables(targets)...)
+	r.Findings = append(r.Findings, lintKvKeys(targets)...)
+	for _, t := range targets {
+		r.Findings = append(r.Findings, lintQueueGroup(t)...)
+		r.Findings = append(r.Findings, lintCredentials(t)...)
+	}
+
+	for _, f := range r.Findings {
+		if f.Severity == lintError {
+			r.Errors++
+		} else {
+			r.Warnings++
+		}
+	}
+	return r
+}
+
+// lintLoops reports the connectors consuming the messages they produce, directly or through other connectors.
+func lintLoops(t topology) []lintFinding {
+	var findings []lintFinding
+	for _, f := range t.Flows {
+		if f.From != f.To {
+			continue
+		}
+		findings = append(findings, lintFinding{
+			Rule:       ruleSelfLoop,
+			Severity:   lintError,
+			Connectors: []string{f.From},
+			Message:    fmt.Sprintf("produces to %s which its consumer reads from %s", f.Produced, f.Consumed),
+		})
+	}
+	for _, c := range t.Cycles {
+		if len(c) < 2 {
+			continue
+		}
+		findings = append(findings, lintFinding{
+			Rule:       ruleFeedbackLoop,
+			Severity:   lintError,
+			Connectors: c,
+			Message:    fmt.Sprintf("messages flow in a loop between %s", cycleString(c)),
+		})
+	}
+	return findings
+}
+
+// lintDurables reports stream consumers of different connectors sharing a durable. Consumers without a stream are
+// bound to the stream of their subject, so they collide with any consumer of the same durable on overlapping
+// subjects.
+func lintDurables(targets []lintTarget) []lintFinding {
+	type durable struct {
+		connector string
+		stream    string
+		subject   string
+	}
+
+	byName := map[string][]durable{}
+	for _, t := range targets {
+		c := t.Connector.Steps.Consumer
+		if c == nil || c.Stream == nil || c.Stream.Durable == nil || *c.Stream.Durable == "" {
+			continue
+		}
+		name := *c.Stream.Durable
+		byName[name] = append(byName[name], durable{t.Connector.ConnectorId, ptrValue(c.Stream.Stream), c.Stream.Subject})
+	}
+
+	var findings []lintFinding
+	for _, name := range sortedKeys(byName) {
+		durables := byName[name]
+		for i := range durables {
+			for _, other := range durables[i+1:] {
+				a := durables[i]
+				if a.stream != "" && other.stream != "" && a.stream != other.stream {
+					continue
+				}
+				if (a.stream == "" || other.stream == "") && !subjectsOverlap(a.subject, other.subject) {
+					continue
+				}
+				findings = append(findings, lintFinding{
+					Rule:       ruleDuplicateDurable,
+					Severity:   lintError,
+					Connectors: []string{a.connector, other.connector},
+					Message:    fmt.Sprintf("both consume with durable %q, splitting the messages between them", name),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// lintKvKeys reports connectors producing to overlapping keys of the same key-value bucket, overwriting each
+// other's values.
+func lintKvKeys(targets []lintTarget) []lintFinding {
+	type writer struct {
+		connector string
+		channel   topologyChannel
+	}
+
+	var writers []writer
+	for _, t := range targets {
+		for _, ch := range producedChannels(t.Connector.Steps) {
+			if ch.Kind == channelKv {
+				writers = append(writers, writer{t.Connector.ConnectorId, ch})
+			}
+		}
+	}
+
+	var findings []lintFinding
+	for i, a := range writers {
+		for _, b := range writers[i+1:] {
+			if a.connector == b.connector || !a.channel.overlaps(b.channel) {
+				continue
+			}
+			findings = append(findings, lintFinding{
+				Rule:       ruleKvKeyCollision,
+				Severity:   lintWarning,
+				Connectors: []string{a.connector, b.connector},
+				Message:    fmt.Sprintf("both write to %s and %s, overwriting each other's values", a.channel, b.channel),
+			})
+		}
+	}
+	return findings
+}
+
+// lintQueueGroup reports core consumers without a queue group in connectors running more than one replica, since
+// every replica then receives every message.
+func lintQueueGroup(t lintTarget) []lintFinding {
+	c := t.Connector.Steps.Consumer
+	if t.Replicas < 2 || c == nil || c.Core == nil || (c.Core.Queue != nil && *c.Core.Queue != "") {
+		return nil
+	}
+
+	return []lintFinding{{
+		Rule:       ruleMissingQueueGroup,
+		Severity:   lintError,
+		Connectors: []string{t.Connector.ConnectorId},
+		Location:   "consumer",
+		Message:    fmt.Sprintf("each of the %d replicas receives every message on %s, set a queue group to share them", t.Replicas, c.Core.Subject),
+	}}
+}
+
+// lintCredentials reports credentials written into the NATS configurations and the source and sink configurations
+// of a connector, rather than referenced from the environment with ${VAR}.
+func lintCredentials(t lintTarget) []lintFinding {
+	var findings []lintFinding
+	report := func(location string, field string) {
+		findings = append(findings, lintFinding{
+			Rule:       ruleHardcodedCredential,
+			Severity:   lintError,
+			Connectors: []string{t.Connector.ConnectorId},
+			Location:   location,
+			Message:    fmt.Sprintf("%s is hardcoded, reference it from the environment with ${VAR} or use a secret", field),
+		})
+	}
+
+	visitStepConfigs(t.Connector.Steps, func(location string, nats *model.NatsConfig, config map[string]interface{}) {
+		if nats != nil {
+			if u, err := url.Parse(nats.Url); err == nil && u.User != nil && !isEnvUserInfo(u.User) {
+				report(location, "nats url user info")
+			}
+			for field, value := range map[string]*string{"nats password": nats.Password, "nats token": nats.Token, "nats jwt": nats.Jwt, "nats seed": nats.Seed} {
+				if value != nil && *value != "" && !isEnvReference(*value) {
+					report(location, field)
+				}
+			}
+		}
+		for _, field := range secretConfigFields(config, "") {
+			report(location, "config "+field)
+		}
+	})
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Location != findings[j].Location {
+			return findings[i].Location < findings[j].Location
+		}
+		return findings[i].Message < findings[j].Message
+	})
+	return findings
+}
+
+// secretConfigFields returns the paths of the fields of the configuration named like credentials holding literal
+// values, also within lists.
+func secretConfigFields(config map[string]interface{}, prefix string) []string {
+	var fields []string
+	for _, k := range sortedKeys(config) {
+		fields = append(fields, secretConfigValues(k, config[k], prefix+k)...)
+	}
+	return fields
+}
+
+// secretConfigValues returns the paths of the literal credentials within the value of the field named key.
+func secretConfigValues(key string, value interface{}, path string) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return secretConfigFields(v, path+".")
+	case []interface{}:
+		var fields []string
+		for i, item := range v {
+			fields = append(fields, secretConfigValues(key, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return fields
+	case string:
+		if v != "" && !isEnvReference(v) && lintSecretFieldPattern.MatchString(key) {
+			return []string{path}
+		}
+	}
+	return nil
+}
+
+// isEnvReference reports whether the whole value is taken from the environment with ${VAR}.
+func isEnvReference(value string) bool {
+	return lintEnvReferencePattern.MatchString(value)
+}
+
+// isEnvUserInfo reports whether the user and password of a url are taken from the environment.
+func isEnvUserInfo(u *url.Userinfo) bool {
+	password, ok := u.Password()
+	return isEnvReference(u.Username()) && (!ok || isEnvReference(password))
+}
+
+// visitStepConfigs calls fn with the location, NATS configuration and source or sink configuration of every step of
+// the connector which has one.
+func visitStepConfigs(steps model.Steps, fn func(location string, nats *model.NatsConfig, config map[string]interface{})) {
+	var visitTransformer func(location string, t *model.TransformerStep)
+	visitTransformer = func(location string, t *model.TransformerStep) {
+		if t == nil {
+			return
+		}
+		if t.Service != nil {
+			fn(location+".service", &t.Service.Nats, nil)
+		}
+		if t.Composite != nil {
+			for i := range t.Composite.Sequential {
+				visitTransformer(fmt.Sprintf("%s.composite.sequential[%d]", location, i), &t.Composite.Sequential[i])
+			}
+		}
+	}
+	visitOutput := func(location string, o *model.OutputStep) {
+		visitTransformer(location+".transformer", o.Transformer)
+		if o.Producer != nil {
+			fn(location+".producer", &o.Producer.Nats, nil)
+		}
+		if o.Sink != nil {
+			fn(location+".sink", nil, o.Sink.Config)
+		}
+	}
+
+	if steps.Source != nil {
+		fn("source", nil, steps.Source.Config)
+	}
+	if steps.Consumer != nil {
+		fn("consumer", &steps.Consumer.Nats, nil)
+	}
+	visitTransformer("transformer", steps.Transformer)
+	if steps.Producer != nil {
+		fn("producer", &steps.Producer.Nats, nil)
+	}
+	if steps.Sink != nil {
+		fn("sink", nil, steps.Sink.Config)
+	}
+	if steps.Errors != nil && steps.Errors.DeadLetter != nil {
+		fn("errors.dead_letter", &steps.Errors.DeadLetter.Nats, nil)
+	}
+	if steps.Broker != nil {
+		for i := range steps.Broker.Outputs {
+			visitOutput(fmt.Sprintf("broker.outputs[%d]", i), &steps.Broker.Outputs[i])
+		}
+	}
+	if steps.Router != nil {
+		for i := range steps.Router.Routes {
+			visitOutput(fmt.Sprintf("router.routes[%d].output", i), &steps.Router.Routes[i].Output)
+		}
+		if steps.Router.Default != nil {
+			visitOutput("router.default", steps.Router.Default)
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func renderLintJSON(w io.Writer, r lintReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// renderLintText prints the findings as a table, followed by the number of errors and warnings.
+func renderLintText(w io.Writer, r lintReport) error {
+	if len(r.Findings) == 0 {
+		_, err := fmt.Fprintf(w, "%s\n", color.GreenString("No problems found in %d connectors", len(r.Connectors)))
+		return err
+	}
+
+	tw := table.NewWriter()
+	tw.SetTitle("Lint")
+	tw.AppendHeader(table.Row{"Severity", "Rule", "Connectors", "Location", "Message"})
+	tw.SetStyle(table.StyleRounded)
+	for _, f := range r.Findings {
+		severity := color.YellowString(f.Severity)
+		if f.Severity == lintError {
+			severity = color.RedString(f.Severity)
+		}
+		tw.AppendRow(table.Row{severity, f.Rule, strings.Join(f.Connectors, ", "), f.Location, f.Message})
+	}
+
+	_, err := fmt.Fprintf(w, "%s\n\n%d errors and %d warnings in %d connectors\n", tw.Render(), r.Errors, r.Warnings, len(r.Connectors))
+	return err
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/choria-io/fisk"
+	"github.com/synadia-io/connect/convert"
+	"github.com/synadia-io/connect/model"
+)
+
+type lintCommand struct {
+	opts *Options
+
+	files    []string
+	account  bool
+	replicas int
+	format   string
+	strict   bool
+}
+
+func ConfigureLintCommand(parentCmd commandHost, opts *Options) {
+	c := &lintCommand{
+		opts: opts,
+	}
+
+	lintCmd := parentCmd.Command("lint", "Check connectors for feedback loops, conflicting subjects and hardcoded credentials").Action(c.lint)
+	lintCmd.Flag("file", "Lint the connector in this ConnectFile, can be repeated").Short('f').StringsVar(&c.files)
+	lintCmd.Flag("account", "Lint the connectors of the account, together with those in files").UnNegatableBoolVar(&c.account)
+	lintCmd.Flag("replicas", "The number of replicas the connectors in files run with").Default("1").IntVar(&c.replicas)
+	lintCmd.Flag("format", "The format to report the findings in").Default("text").EnumVar(&c.format, "text", "json")
+	lintCmd.Flag("strict", "Fail on warnings as well as errors").UnNegatableBoolVar(&c.strict)
+}
+
+func (c *lintCommand) lint(pc *fisk.ParseContext) error {
+	if len(c.files) == 0 && !c.account {
+		return fmt.Errorf("at least one --file or --account is required")
+	}
+
+	var appCtx *AppContext
+	if c.account {
+		var err error
+		appCtx, err = LoadOptions(c.opts)
+		fisk.FatalIfError(err, "failed to load options")
+		defer appCtx.Close()
+	}
+
+	fisk.FatalIfError(c.lintWithClient(appCtx, os.Stdout), "lint failed")
+
+	return nil
+}
+
+// lintWithClient lints the connectors of the files, and those of the account when appCtx is set. A connector in a
+// file replaces the connector of the account with the same id, so changes can be linted before they are deployed.
+func (c *lintCommand) lintWithClient(appCtx *AppContext, w io.Writer) error {
+	var targets []lintTarget
+	if appCtx != nil {
+		summaries, err := appCtx.Client.ListConnectors(c.opts.Timeout)
+		if err != nil {
+			return fmt.Errorf("failed to list connectors: %w", err)
+		}
+
+		for _, s := range summaries {
+			connector, err := appCtx.Client.GetConnector(s.ConnectorId, c.opts.Timeout)
+			if err != nil {
+				return fmt.Errorf("failed to get connector %s: %w", s.ConnectorId, err)
+			}
+			if connector != nil {
+				targets = append(targets, lintTarget{Connector: *connector, Replicas: s.Instances.Running + s.Instances.Pending})
+			}
+		}
+	}
+
+	for _, file := range c.files {
+		csp, _, err := fromFile(nil, file)
+		if err != nil {
+			return err
+		}
+
+		connector := model.Connector{
+			ConnectorId: connectorIdFromFile(file),
+			Description: csp.Description,
+			RuntimeId:   csp.RuntimeId,
+			Steps:       convert.ConvertStepsFromSpec(csp.Steps),
+		}
+		targets = slices.DeleteFunc(targets, func(t lintTarget) bool { return t.Connector.ConnectorId == connector.ConnectorId })
+		targets = append(targets, lintTarget{Connector: connector, Replicas: c.replicas})
+	}
+
+	r := lintConnectors(targets)
+
+	var err error
+	if c.format == "json" {
+		err = renderLintJSON(w, r)
+	} else {
+		err = renderLintText(w, r)
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.failed(c.strict) {
+		return fmt.Errorf("lint found %d errors and %d warnings", r.Errors, r.Warnings)
+	}
+	return nil
+}
+
+// connectorIdFromFile names the connector of a ConnectFile after the file, e.g. orders for orders.connector.yml.
+func connectorIdFromFile(file string) string {
+	name := filepath.Base(file)
+	for _, ext := range []string{".yml", ".yaml", ".connector"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// streamColumn describes a column of a streamTable. As rows are printed before all of them are known, every
+// column has a fixed width; longer cells are wrapped.
+type streamColumn struct {
+	Name  string
+	Width int
+	Align text.Align
+}
+
+// streamTable prints a table row by row, so rows can be shown while they are still being received. It uses the
+// same box style as the other tables of the cli.
+type streamTable struct {
+	w       io.Writer
+	title   string
+	columns []streamColumn
+	box     table.BoxStyle
+	rows    int
+}
+
+func newStreamTable(w io.Writer, title string, columns ...streamColumn) *streamTable {
+	return &streamTable{
+		w:       w,
+		title:   title,
+		columns: columns,
+		box:     table.StyleRounded.Box,
+	}
+}
+
+// Rows returns the number of rows printed so far.
+func (t *streamTable) Rows() int {
+	return t.rows
+}
+
+// AppendRow prints the given row. The title and header are printed before the first row.
+func (t *streamTable) AppendRow(cells ...string) {
+	if t.rows == 0 {
+		t.printHeader()
+	}
+	t.printRow(cells, false)
+	t.rows++
+}
+
+// Close prints the bottom border of the table if any rows have been printed.
+func (t *streamTable) Close() {
+	if t.rows == 0 {
+		return
+	}
+	t.printBorder(t.box.BottomLeft, t.box.BottomSeparator, t.box.BottomRight)
+}
+
+func (t *streamTable) printHeader() {
+	if t.title != "" {
+		width := len(t.columns) - 1
+		for _, col := range t.columns {
+			width += col.Width + 2
+		}
+
+		fmt.Fprintln(t.w, t.box.TopLeft+strings.Repeat(t.box.MiddleHorizontal, width)+t.box.TopRight)
+		fmt.Fprintln(t.w, t.box.Left+t.box.PaddingLeft+text.AlignLeft.Apply(t.title, width-2)+t.box.PaddingRight+t.box.Right)
+		t.printBorder(t.box.LeftSeparator, t.box.TopSeparator, t.box.RightSeparator)
+	} else {
+		t.printBorder(t.box.TopLeft, t.box.TopSeparator, t.box.TopRight)
+	}
+
+	names := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		names[i] = col.Name
+	}
+	t.printRow(names, true)
+	t.printBorder(t.box.LeftSeparator, t.box.MiddleSeparator, t.box.RightSeparator)
+}
+
+func (t *streamTable) printBorder(left, separator, right string) {
+	parts := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		parts[i] = strings.Repeat(t.box.MiddleHorizontal, col.Width+2)
+	}
+	fmt.Fprintln(t.w, left+strings.Join(parts, separator)+right)
+}
+
+func (t *streamTable) printRow(cells []string, header bool) {
+	lines := make([][]string, len(t.columns))
+	height := 1
+	for i, col := range t.columns {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		if header {
+			cell = text.FormatUpper.Apply(cell)
+		}
+		if text.StringWidthWithoutEscSequences(cell) > col.Width {
+			cell = text.WrapSoft(cell, col.Width)
+		}
+		lines[i] = strings.Split(cell, "\n")
+		height = max(height, len(lines[i]))
+	}
+
+	for l := 0; l < height; l++ {
+		parts := make([]string, len(t.columns))
+		for i, col := range t.columns {
+			line := ""
+			if l < len(lines[i]) {
+				line = lines[i][l]
+			}
+			align := col.Align
+			if align == text.AlignDefault {
+				align = text.AlignLeft
+			}
+			parts[i] = t.box.PaddingLeft + align.Apply(line, col.Width) + t.box.PaddingRight
+		}
+		fmt.Fprintln(t.w, t.box.Left+strings.Join(parts, t.box.MiddleVertical)+t.box.Right)
+	}
+}
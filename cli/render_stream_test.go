@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("streamTable", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	It("should print nothing without rows", func() {
+		tbl := newStreamTable(buf, "Title", streamColumn{Name: "Name", Width: 10})
+		tbl.Close()
+
+		Expect(buf.String()).To(BeEmpty())
+		Expect(tbl.Rows()).To(Equal(0))
+	})
+
+	It("should print the header with the first row", func() {
+		tbl := newStreamTable(buf, "Title",
+			streamColumn{Name: "Name", Width: 6},
+			streamColumn{Name: "Count", Width: 5, Align: text.AlignRight},
+		)
+
+		tbl.AppendRow("a", "1")
+		Expect(buf.String()).To(Equal(strings.Join([]string{
+			"╭────────────────╮",
+			"│ Title          │",
+			"├────────┬───────┤",
+			"│ NAME   │ COUNT │",
+			"├────────┼───────┤",
+			"│ a      │     1 │",
+			"",
+		}, "\n")))
+
+		tbl.AppendRow("b", "2")
+		tbl.Close()
+		Expect(buf.String()).To(HaveSuffix(strings.Join([]string{
+			"│ a      │     1 │",
+			"│ b      │     2 │",
+			"╰────────┴───────╯",
+			"",
+		}, "\n")))
+		Expect(tbl.Rows()).To(Equal(2))
+	})
+
+	It("should wrap cells exceeding the column width", func() {
+		tbl := newStreamTable(buf, "", streamColumn{Name: "Description", Width: 11})
+		tbl.AppendRow("hello wonderful world")
+		tbl.Close()
+
+		Expect(buf.String()).To(Equal(strings.Join([]string{
+			"╭─────────────╮",
+			"│ DESCRIPTION │",
+			"├─────────────┤",
+			"│ hello       │",
+			"│ wonderful   │",
+			"│ world       │",
+			"╰─────────────╯",
+			"",
+		}, "\n")))
+	})
+})
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/fatih/color"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/convert"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+type replayCommand struct {
+	opts *Options
+
+	connector  string
+	file       string
+	fromStream string
+	fromFile   string
+	startSeq   uint64
+	startTime  string
+	filter     []string
+	count      int
+	toSubject  string
+}
+
+func ConfigureReplayCommand(parentCmd commandHost, opts *Options) {
+	c := &replayCommand{
+		opts: opts,
+	}
+
+	replayCmd := parentCmd.Command("replay", "Replay messages into the consumer of a connector").Action(c.replay)
+	replayCmd.Arg("connector", "The id of the connector, or the name of a standalone connector with --standalone").StringVar(&c.connector)
+	replayCmd.Flag("file", "Read the connector from a ConnectFile or standalone connector file").Short('f').StringVar(&c.file)
+	replayCmd.Flag("from-stream", "The stream to read the messages from").StringVar(&c.fromStream)
+	replayCmd.Flag("from-file", "JSONL file with the messages, e.g. captured with --dry-run").StringVar(&c.fromFile)
+	replayCmd.Flag("start-seq", "The stream sequence to start reading from").Uint64Var(&c.startSeq)
+	replayCmd.Flag("start-time", "The time to start reading the stream from, as RFC3339 or a duration ago like 1h").StringVar(&c.startTime)
+	replayCmd.Flag("filter", "Only read stream messages on these subjects").StringsVar(&c.filter)
+	replayCmd.Flag("count", "The maximum number of messages to replay, 0 for all").IntVar(&c.count)
+	replayCmd.Flag("to-subject", "Publish to this subject instead of the consumer subject of the connector").StringVar(&c.toSubject)
+}
+
+func (c *replayCommand) replay(pc *fisk.ParseContext) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	if c.opts.Standalone || c.file != "" {
+		return c.replayStandalone()
+	}
+
+	appCtx, err := LoadOptions(c.opts)
+	fisk.FatalIfError(err, "failed to load options")
+	defer appCtx.Close()
+
+	return c.replayWithClient(appCtx)
+}
+
+func (c *replayCommand) validate() error {
+	if c.connector == "" && c.file == "" {
+		return fmt.Errorf("a connector or --file is required")
+	}
+	if (c.fromStream == "") == (c.fromFile == "") {
+		return fmt.Errorf("exactly one of --from-stream or --from-file is required")
+	}
+	if c.fromFile != "" && (c.startSeq > 0 || c.startTime != "" || len(c.filter) > 0) {
+		return fmt.Errorf("--start-seq, --start-time and --filter can only be used with --from-stream")
+	}
+	if c.startSeq > 0 && c.startTime != "" {
+		return fmt.Errorf("--start-seq and --start-time are mutually exclusive")
+	}
+	return nil
+}
+
+// replayStandalone replays into a connector defined in a file, connecting with the NATS configuration of its
+// consumer unless servers are given.
+func (c *replayCommand) replayStandalone() error {
+	file := c.file
+	if file == "" {
+		file = fmt.Sprintf("%s.connector.yml", c.connector)
+	}
+
+	csp, _, err := fromFile(nil, file)
+	if err != nil {
+		return err
+	}
+	steps := convert.ConvertStepsFromSpec(csp.Steps)
+	if steps.Consumer == nil {
+		return fmt.Errorf("connector %q has no consumer to replay messages into", file)
+	}
+
+	var nc *nats.Conn
+	if c.opts.Servers != "" || c.opts.ContextName != "" {
+		nc, err = loadNats(c.opts)
+	} else {
+		nc, err = runtime.ConnectStep(steps.Consumer.Nats)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	defer nc.Close()
+
+	return c.replaySteps(nc, steps)
+}
+
+func (c *replayCommand) replaySteps(nc *nats.Conn, steps model.Steps) error {
+	if steps.Consumer == nil {
+		return fmt.Errorf("connector %q has no consumer to replay messages into", c.connector)
+	}
+
+	target, err := newReplayTarget(steps.Consumer, c.toSubject)
+	if err != nil {
+		return err
+	}
+
+	timeout := c.opts.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	js, err := jetstream.New(nc, jetstream.WithDefaultTimeout(timeout))
+	if err != nil {
+		return fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	ctx := context.Background()
+
+	var msgs []*runtime.Message
+	if c.fromFile != "" {
+		if msgs, err = readMessagesFile(c.fromFile); err != nil {
+			return err
+		}
+		if c.count > 0 && len(msgs) > c.count {
+			msgs = msgs[:c.count]
+		}
+	} else {
+		pos := streamPosition{StartSeq: c.startSeq, Filter: c.filter, Count: c.count}
+		if c.startTime != "" {
+			if pos.StartTime, err = parseStartTime(c.startTime, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if msgs, err = readStream(ctx, js, c.fromStream, pos, timeout); err != nil {
+			return err
+		}
+	}
+
+	n, err := replayMessages(ctx, nc, js, target, msgs)
+	if err != nil {
+		return fmt.Errorf("replayed %d of %d messages: %w", n, len(msgs), err)
+	}
+
+	fmt.Printf("Replayed %s messages to %s\n", color.GreenString("%d", n), color.GreenString(target.Subject))
+	return nil
+}
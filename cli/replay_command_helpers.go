@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// These are testable helper functions that can be called with a provided AppContext
+
+func (c *replayCommand) replayWithClient(appCtx *AppContext) error {
+	conn, err := appCtx.Client.GetConnector(c.connector, appCtx.DefaultTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to get connector %s: %w", c.connector, err)
+	}
+	if conn == nil {
+		return fmt.Errorf("connector %s not found", c.connector)
+	}
+
+	return c.replaySteps(appCtx.Nc, conn.Steps)
+}
+
+// replayTarget is where replayed messages are published to.
+type replayTarget struct {
+	// Subject may contain wildcards when taken from the consumer, in which case messages keep their original
+	// subject as long as it matches.
+	Subject string
+	// JetStream is set for stream consumers, whose messages are published with acknowledgement.
+	JetStream bool
+}
+
+// newReplayTarget picks the subject the consumer of a connector reads from, unless overridden.
+func newReplayTarget(consumer *model.ConsumerStep, override string) (replayTarget, error) {
+	jetStream := consumer.Stream != nil
+
+	switch {
+	case override != "":
+		if hasWildcard(override) {
+			return replayTarget{}, fmt.Errorf("cannot publish to wildcard subject %q", override)
+		}
+		return replayTarget{Subject: override, JetStream: jetStream}, nil
+	case consumer.Stream != nil:
+		return replayTarget{Subject: consumer.Stream.Subject, JetStream: true}, nil
+	case consumer.Core != nil:
+		return replayTarget{Subject: consumer.Core.Subject}, nil
+	case consumer.Kv != nil:
+		return replayTarget{}, fmt.Errorf("cannot replay into the key-value consumer of bucket %q, use --to-subject", consumer.Kv.Bucket)
+	default:
+		return replayTarget{}, fmt.Errorf("the consumer has no core, stream or kv configuration")
+	}
+}
+
+// subjectFor returns the subject to publish a message with the given original subject to.
+func (t replayTarget) subjectFor(original string) (string, error) {
+	if !hasWildcard(t.Subject) {
+		return t.Subject, nil
+	}
+	if original != "" && subjectMatches(t.Subject, original) {
+		return original, nil
+	}
+	return "", fmt.Errorf("subject %q of the message does not match %q, use --to-subject", original, t.Subject)
+}
+
+// streamPosition selects the stream messages to replay.
+type streamPosition struct {
+	StartSeq  uint64
+	StartTime time.Time
+	Filter    []string
+	Count     int
+}
+
+// readStream reads the messages of the stream which are stored at the time of the call, starting at the position.
+func readStream(ctx context.Context, js jetstream.JetStream, stream string, pos streamPosition, timeout time.Duration) ([]*runtime.Message, error) {
+	cfg := jetstream.ConsumerConfig{
+		DeliverPolicy:     jetstream.DeliverAllPolicy,
+		AckPolicy:         jetstream.AckNonePolicy,
+		FilterSubjects:    pos.Filter,
+		InactiveThreshold: time.Minute,
+		MemoryStorage:     true,
+	}
+	switch {
+	case pos.StartSeq > 0:
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = pos.StartSeq
+	case !pos.StartTime.IsZero():
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &pos.StartTime
+	}
+
+	cons, err := js.CreateConsumer(ctx, stream, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream %s: %w", stream, err)
+	}
+	defer func() { _ = js.DeleteConsumer(context.Background(), stream, cons.CachedInfo().Name) }()
+
+	pending := cons.CachedInfo().NumPending
+
+	var msgs []*runtime.Message
+	for pending > 0 && (pos.Count <= 0 || len(msgs) < pos.Count) {
+		m, err := cons.Next(jetstream.FetchMaxWait(timeout))
+		if err != nil {
+			return msgs, fmt.Errorf("failed to read stream %s: %w", stream, err)
+		}
+
+		meta, err := m.Metadata()
+		if err != nil {
+			return msgs, fmt.Errorf("failed to read stream %s: %w", stream, err)
+		}
+		pending = meta.NumPending
+
+		msgs = append(msgs, &runtime.Message{
+			Subject: m.Subject(),
+			Headers: replayHeaders(m.Headers()),
+			Data:    m.Data(),
+		})
+	}
+
+	return msgs, nil
+}
+
+// replayHeaders drops the Nats- headers, like Nats-Msg-Id, which would get a replayed message deduplicated or
+// rejected.
+func replayHeaders(h nats.Header) nats.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	result := nats.Header{}
+	for k, v := range h {
+		if !strings.HasPrefix(strings.ToLower(k), "nats-") {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// replayMessages publishes the messages to the target in order and returns how many were published.
+func replayMessages(ctx context.Context, nc *nats.Conn, js jetstream.JetStream, target replayTarget, msgs []*runtime.Message) (int, error) {
+	for i, m := range msgs {
+		subject, err := target.subjectFor(m.Subject)
+		if err != nil {
+			return i, err
+		}
+
+		msg := &nats.Msg{Subject: subject, Header: replayHeaders(m.Headers), Data: m.Data}
+		if target.JetStream {
+			if _, err := js.PublishMsg(ctx, msg); err != nil {
+				return i, fmt.Errorf("failed to publish to %s: %w", subject, err)
+			}
+			continue
+		}
+
+		if err := nc.PublishMsg(msg); err != nil {
+			return i, fmt.Errorf("failed to publish to %s: %w", subject, err)
+		}
+	}
+
+	if err := nc.Flush(); err != nil {
+		return len(msgs), fmt.Errorf("failed to flush: %w", err)
+	}
+	return len(msgs), nil
+}
+
+// parseStartTime parses an RFC3339 timestamp, or a duration relative to now.
+func parseStartTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid start time %q: expected RFC3339 or a duration", s)
+}
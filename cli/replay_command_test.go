@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/connecttest"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("ReplayCommand", func() {
+	Describe("validate", func() {
+		It("should require a connector or file", func() {
+			cmd := &replayCommand{fromStream: "ORDERS"}
+			Expect(cmd.validate()).To(MatchError(ContainSubstring("a connector or --file is required")))
+		})
+
+		It("should require exactly one source", func() {
+			cmd := &replayCommand{connector: "test"}
+			Expect(cmd.validate()).To(MatchError(ContainSubstring("exactly one of")))
+
+			cmd = &replayCommand{connector: "test", fromStream: "ORDERS", fromFile: "in.jsonl"}
+			Expect(cmd.validate()).To(MatchError(ContainSubstring("exactly one of")))
+		})
+
+		It("should reject stream positions for files", func() {
+			cmd := &replayCommand{connector: "test", fromFile: "in.jsonl", startSeq: 3}
+			Expect(cmd.validate()).To(MatchError(ContainSubstring("can only be used with --from-stream")))
+		})
+
+		It("should reject both a start sequence and time", func() {
+			cmd := &replayCommand{connector: "test", fromStream: "ORDERS", startSeq: 3, startTime: "1h"}
+			Expect(cmd.validate()).To(MatchError(ContainSubstring("mutually exclusive")))
+		})
+	})
+
+	Describe("newReplayTarget", func() {
+		It("should use the subject of a core consumer", func() {
+			t, err := newReplayTarget(&model.ConsumerStep{Core: &model.ConsumerStepCore{Subject: "orders"}}, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(replayTarget{Subject: "orders"}))
+		})
+
+		It("should publish to streams with acknowledgement", func() {
+			t, err := newReplayTarget(&model.ConsumerStep{Stream: &model.ConsumerStepStream{Subject: "orders.>"}}, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(replayTarget{Subject: "orders.>", JetStream: true}))
+		})
+
+		It("should prefer the override", func() {
+			t, err := newReplayTarget(&model.ConsumerStep{Core: &model.ConsumerStepCore{Subject: "orders"}}, "debug")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.Subject).To(Equal("debug"))
+
+			_, err = newReplayTarget(&model.ConsumerStep{Core: &model.ConsumerStepCore{Subject: "orders"}}, "debug.*")
+			Expect(err).To(MatchError(ContainSubstring("wildcard")))
+		})
+
+		It("should reject kv consumers", func() {
+			_, err := newReplayTarget(&model.ConsumerStep{Kv: &model.ConsumerStepKv{Bucket: "config", Key: ">"}}, "")
+			Expect(err).To(MatchError(ContainSubstring("--to-subject")))
+		})
+
+		It("should keep original subjects matching a wildcard", func() {
+			t := replayTarget{Subject: "orders.>"}
+
+			subject, err := t.subjectFor("orders.eu")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(subject).To(Equal("orders.eu"))
+
+			_, err = t.subjectFor("invoices.eu")
+			Expect(err).To(MatchError(ContainSubstring("does not match")))
+		})
+	})
+
+	Describe("parseStartTime", func() {
+		now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		It("should parse timestamps and durations", func() {
+			t, err := parseStartTime("2025-01-01T00:00:00Z", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+			t, err = parseStartTime("1h", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(now.Add(-time.Hour)))
+
+			_, err = parseStartTime("yesterday", now)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("against a connect service", func() {
+		var (
+			srv    *connecttest.Server
+			appCtx *AppContext
+			js     jetstream.JetStream
+			cmd    *replayCommand
+		)
+
+		BeforeEach(func() {
+			var err error
+			srv, err = connecttest.NewServer(
+				connecttest.WithJetStream(GinkgoT().TempDir()),
+				connecttest.WithConnectors(model.Connector{
+					ConnectorId: "outlet",
+					RuntimeId:   "wombat",
+					Steps: model.Steps{
+						Consumer: &model.ConsumerStep{Core: &model.ConsumerStepCore{Subject: "outlet.in"}},
+						Sink:     &model.SinkStep{Type: "http_client", Config: model.SinkStepConfig{"url": "http://localhost"}},
+					},
+				}),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(srv.Close)
+
+			nc, err := srv.Connect()
+			Expect(err).ToNot(HaveOccurred())
+
+			cl, err := client.NewClient(nc, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			appCtx = &AppContext{Nc: nc, Client: cl, DefaultTimeout: time.Second}
+			DeferCleanup(appCtx.Close)
+
+			js, err = jetstream.New(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			cmd = &replayCommand{opts: &Options{Timeout: time.Second}, connector: "outlet"}
+		})
+
+		subscribe := func(subject string) *nats.Subscription {
+			sub, err := appCtx.Nc.SubscribeSync(subject)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(sub.Unsubscribe)
+			Expect(appCtx.Nc.Flush()).To(Succeed())
+			return sub
+		}
+
+		It("should replay stream messages from a sequence", func() {
+			ctx := context.Background()
+			_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, body := range []string{"one", "two", "three"} {
+				msg := nats.NewMsg("orders.new")
+				msg.Data = []byte(body)
+				msg.Header.Set("Nats-Msg-Id", body)
+				msg.Header.Set("Trace", body)
+				_, err := js.PublishMsg(ctx, msg)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			sub := subscribe("outlet.in")
+
+			cmd.fromStream = "ORDERS"
+			cmd.startSeq = 2
+			Expect(cmd.replayWithClient(appCtx)).To(Succeed())
+
+			for _, body := range []string{"two", "three"} {
+				msg, err := sub.NextMsg(time.Second)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(msg.Data)).To(Equal(body))
+				Expect(msg.Header.Get("Trace")).To(Equal(body))
+				Expect(msg.Header.Get("Nats-Msg-Id")).To(BeEmpty())
+			}
+			_, err = sub.NextMsg(100 * time.Millisecond)
+			Expect(err).To(MatchError(nats.ErrTimeout))
+		})
+
+		It("should replay captured messages to another subject", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "captured.jsonl")
+			Expect(os.WriteFile(file, []byte(`{"subject":"orders.new","payload":{"id":1}}
+{"subject":"orders.new","payload":{"id":2}}
+`), 0644)).To(Succeed())
+
+			sub := subscribe("debug")
+
+			cmd.fromFile = file
+			cmd.toSubject = "debug"
+			cmd.count = 1
+			Expect(cmd.replayWithClient(appCtx)).To(Succeed())
+
+			msg, err := sub.NextMsg(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(msg.Data)).To(Equal(`{"id":1}`))
+			_, err = sub.NextMsg(100 * time.Millisecond)
+			Expect(err).To(MatchError(nats.ErrTimeout))
+		})
+
+		It("should fail for unknown connectors", func() {
+			cmd.connector = "unknown"
+			cmd.fromStream = "ORDERS"
+			Expect(cmd.replayWithClient(appCtx)).ToNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type schemaCommand struct {
+	opts *Options
+
+	bucket  string
+	name    string
+	file    string
+	format  string
+	message string
+	output  string
+}
+
+func ConfigureSchemaCommand(parentCmd commandHost, opts *Options) {
+	c := &schemaCommand{
+		opts: opts,
+	}
+
+	schemaCmd := parentCmd.Command("schema", "Manage the message schemas of the object store").Alias("schemas")
+	schemaCmd.Flag("bucket", "The object store bucket holding the schemas").Default("schemas").StringVar(&c.bucket)
+
+	pushCmd := schemaCmd.Command("push", "Check a schema and store it, creating the bucket if needed").Action(c.push)
+	pushCmd.Arg("name", "The name to store the schema as").Required().StringVar(&c.name)
+	pushCmd.Arg("file", "The file with the schema").Required().StringVar(&c.file)
+	pushCmd.Flag("format", "The format of the schema, guessed from the file extension by default").EnumVar(&c.format, "json_schema", "avro", "protobuf")
+	pushCmd.Flag("message", "The message protobuf payloads are encoded as, to check the schema with").StringVar(&c.message)
+
+	schemaCmd.Command("ls", "List the stored schemas").Alias("list").Action(c.list)
+
+	getCmd := schemaCmd.Command("get", "Print or save a stored schema").Action(c.get)
+	getCmd.Arg("name", "The name of the schema").Required().StringVar(&c.name)
+	getCmd.Flag("output", "Write the schema to this file instead of printing it").Short('o').StringVar(&c.output)
+}
+
+func (c *schemaCommand) push(pc *fisk.ParseContext) error {
+	return c.withJetStream(c.pushSchema)
+}
+
+func (c *schemaCommand) list(pc *fisk.ParseContext) error {
+	return c.withJetStream(c.listSchemas)
+}
+
+func (c *schemaCommand) get(pc *fisk.ParseContext) error {
+	return c.withJetStream(c.getSchema)
+}
+
+func (c *schemaCommand) withJetStream(fn func(ctx context.Context, js jetstream.JetStream) error) error {
+	nc, err := loadNats(c.opts)
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	defer nc.Close()
+
+	js, err := jetstream.New(nc, jetstream.WithDefaultTimeout(c.opts.Timeout))
+	if err != nil {
+		return fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	return fn(context.Background(), js)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/validation"
+)
+
+// The metadata of the stored schemas
+const (
+	schemaFormatMetadata  = "format"
+	schemaMessageMetadata = "message"
+)
+
+// schemaFormat returns the given format, or the format matching the extension of the file.
+func schemaFormat(file string, format string) (string, error) {
+	if format != "" {
+		return format, nil
+	}
+
+	switch filepath.Ext(file) {
+	case ".json":
+		return string(model.MessageSchemaFormatJsonSchema), nil
+	case ".avsc":
+		return string(model.MessageSchemaFormatAvro), nil
+	case ".proto":
+		return string(model.MessageSchemaFormatProtobuf), nil
+	default:
+		return "", fmt.Errorf("cannot tell the format of %s, use --format", file)
+	}
+}
+
+// pushSchema checks the schema in the file and stores it, with its format and message as metadata.
+func (c *schemaCommand) pushSchema(ctx context.Context, js jetstream.JetStream) error {
+	format, err := schemaFormat(c.file, c.format)
+	if err != nil {
+		return err
+	}
+	if format == string(model.MessageSchemaFormatProtobuf) && c.message == "" {
+		return fmt.Errorf("--message is required to check protobuf schemas")
+	}
+
+	definition, err := os.ReadFile(c.file)
+	if err != nil {
+		return fmt.Errorf("failed to read schema file: %w", err)
+	}
+	if _, err := validation.CompileMessageSchema(format, definition, c.message); err != nil {
+		return fmt.Errorf("invalid %s schema: %w", format, err)
+	}
+
+	store, err := js.ObjectStore(ctx, c.bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		store, err = js.CreateObjectStore(ctx, jetstream.ObjectStoreConfig{Bucket: c.bucket, Description: "Message schemas"})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open object store %s: %w", c.bucket, err)
+	}
+
+	metadata := map[string]string{schemaFormatMetadata: format}
+	if c.message != "" {
+		metadata[schemaMessageMetadata] = c.message
+	}
+	if _, err := store.Put(ctx, jetstream.ObjectMeta{Name: c.name, Metadata: metadata}, bytes.NewReader(definition)); err != nil {
+		return fmt.Errorf("failed to store schema %s: %w", c.name, err)
+	}
+
+	fmt.Printf("Stored %s schema %s in %s\n", format, color.GreenString(c.name), color.GreenString(c.bucket))
+	return nil
+}
+
+func (c *schemaCommand) listSchemas(ctx context.Context, js jetstream.JetStream) error {
+	store, err := js.ObjectStore(ctx, c.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to open object store %s: %w", c.bucket, err)
+	}
+
+	objects, err := store.List(ctx)
+	if errors.Is(err, jetstream.ErrNoObjectsFound) {
+		fmt.Println("No schemas found")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list schemas: %w", err)
+	}
+
+	w := table.NewWriter()
+	w.AppendHeader(table.Row{"Name", "Format", "Message", "Size", "Modified"})
+	w.SetStyle(table.StyleRounded)
+
+	for _, object := range objects {
+		w.AppendRow(table.Row{
+			object.Name,
+			object.Metadata[schemaFormatMetadata],
+			object.Metadata[schemaMessageMetadata],
+			object.Size,
+			object.ModTime.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	fmt.Println(w.Render())
+	return nil
+}
+
+func (c *schemaCommand) getSchema(ctx context.Context, js jetstream.JetStream) error {
+	store, err := js.ObjectStore(ctx, c.bucket)
+	if err != nil {
+		return fmt.Errorf("failed to open object store %s: %w", c.bucket, err)
+	}
+
+	definition, err := store.GetBytes(ctx, c.name)
+	if err != nil {
+		return fmt.Errorf("failed to read schema %s: %w", c.name, err)
+	}
+
+	if c.output == "" {
+		fmt.Println(string(definition))
+		return nil
+	}
+
+	if err := os.WriteFile(c.output, definition, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	fmt.Printf("Saved schema %s to %s\n", color.GreenString(c.name), color.GreenString(c.output))
+	return nil
+}
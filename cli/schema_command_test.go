@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/nats-io/nats.go/jetstream"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/connecttest"
+)
+
+var _ = Describe("SchemaCommand", func() {
+	Describe("schemaFormat", func() {
+		It("should guess the format from the extension", func() {
+			Expect(schemaFormat("order.json", "")).To(Equal("json_schema"))
+			Expect(schemaFormat("order.avsc", "")).To(Equal("avro"))
+			Expect(schemaFormat("order.proto", "")).To(Equal("protobuf"))
+			Expect(schemaFormat("order.txt", "avro")).To(Equal("avro"))
+
+			_, err := schemaFormat("order.txt", "")
+			Expect(err).To(MatchError(ContainSubstring("use --format")))
+		})
+	})
+
+	Describe("against a server", func() {
+		var (
+			js  jetstream.JetStream
+			ctx context.Context
+			dir string
+			cmd *schemaCommand
+		)
+
+		BeforeEach(func() {
+			srv, err := connecttest.NewServer(connecttest.WithJetStream(GinkgoT().TempDir()))
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(srv.Close)
+
+			nc, err := srv.Connect()
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(nc.Close)
+
+			js, err = jetstream.New(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx = context.Background()
+			dir = GinkgoT().TempDir()
+			cmd = &schemaCommand{opts: &Options{}, bucket: "schemas"}
+		})
+
+		write := func(name string, content string) string {
+			file := filepath.Join(dir, name)
+			Expect(os.WriteFile(file, []byte(content), 0644)).To(Succeed())
+			return file
+		}
+
+		It("should push, list and get schemas", func() {
+			cmd.name = "order"
+			cmd.file = write("order.proto", `syntax = "proto3"; message Order { string id = 1; }`)
+			cmd.message = "Order"
+			Expect(cmd.pushSchema(ctx, js)).To(Succeed())
+
+			store, err := js.ObjectStore(ctx, "schemas")
+			Expect(err).ToNot(HaveOccurred())
+			info, err := store.GetInfo(ctx, "order")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Metadata).To(Equal(map[string]string{"format": "protobuf", "message": "Order"}))
+
+			Expect(cmd.listSchemas(ctx, js)).To(Succeed())
+
+			cmd.output = filepath.Join(dir, "fetched.proto")
+			Expect(cmd.getSchema(ctx, js)).To(Succeed())
+			Expect(os.ReadFile(cmd.output)).To(Equal([]byte(`syntax = "proto3"; message Order { string id = 1; }`)))
+		})
+
+		It("should not push invalid schemas", func() {
+			cmd.name = "order"
+			cmd.file = write("order.json", `{"type": "text"}`)
+			Expect(cmd.pushSchema(ctx, js)).To(MatchError(ContainSubstring("invalid json_schema schema")))
+
+			_, err := js.ObjectStore(ctx, "schemas")
+			Expect(err).To(MatchError(jetstream.ErrBucketNotFound))
+		})
+
+		It("should require the message of protobuf schemas", func() {
+			cmd.name = "order"
+			cmd.file = write("order.proto", `message Order {}`)
+			Expect(cmd.pushSchema(ctx, js)).To(MatchError(ContainSubstring("--message is required")))
+		})
+
+		It("should fail for missing schemas", func() {
+			_, err := js.CreateObjectStore(ctx, jetstream.ObjectStoreConfig{Bucket: "schemas"})
+			Expect(err).ToNot(HaveOccurred())
+
+			cmd.name = "missing"
+			Expect(cmd.getSchema(ctx, js)).To(MatchError(ContainSubstring("failed to read schema missing")))
+		})
+	})
+})
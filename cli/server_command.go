@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/agent"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/standalone"
+)
+
+type serverCommand struct {
+	opts *Options
+
+	account         string
+	scheduler       string
+	bucket          string
+	replicas        int
+	libraryFile     string
+	dockerHosts     []string
+	workloadServers string
+	workloadCreds   string
+}
+
+func ConfigureServerCommand(parentCmd commandHost, opts *Options) {
+	c := &serverCommand{
+		opts: opts,
+	}
+
+	serverCmd := parentCmd.Command("server", "Run a self-hosted Connect control plane").Action(c.serve)
+	serverCmd.Flag("account", "Account to serve connectors for (defaults to the account of the connection)").StringVar(&c.account)
+	serverCmd.Flag("scheduler", "How instances are run: as containers on docker daemons or by agents").Default("container").EnumVar(&c.scheduler, "container", "agent")
+	serverCmd.Flag("bucket", "Key-value bucket to store the connectors in").Default("connect_connectors").StringVar(&c.bucket)
+	serverCmd.Flag("replicas", "Number of replicas of the key-value bucket").Default("1").IntVar(&c.replicas)
+	serverCmd.Flag("library", "JSON file with the runtimes and components to serve (defaults to the standalone runtimes)").PlaceHolder("FILE").StringVar(&c.libraryFile)
+	serverCmd.Flag("docker-host", "Docker daemon to run instances on, e.g. ssh://user@host (can be repeated, defaults to the local daemon)").PlaceHolder("HOST").StringsVar(&c.dockerHosts)
+	serverCmd.Flag("workload-server", "NATS url the connector instances connect to (defaults to the connected server)").PlaceHolder("URL").StringVar(&c.workloadServers)
+	serverCmd.Flag("workload-creds", "Credentials the connector instances connect with").PlaceHolder("FILE").StringVar(&c.workloadCreds)
+}
+
+func (c *serverCommand) serve(pc *fisk.ParseContext) error {
+	appCtx, err := LoadOptions(c.opts)
+	fisk.FatalIfError(err, "failed to load options")
+	defer appCtx.Close()
+
+	cfg, err := c.serviceConfig(appCtx)
+	fisk.FatalIfError(err, "failed to configure control plane")
+
+	svc, err := controlplane.New(appCtx.Nc, cfg)
+	fisk.FatalIfError(err, "failed to create control plane")
+
+	fisk.FatalIfError(svc.Start(), "failed to start control plane")
+	defer svc.Stop()
+
+	fmt.Printf("Serving connectors of account %s from bucket %s. Press Ctrl+C to stop.\n", cfg.Account, c.bucket)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	<-sigs
+
+	return nil
+}
+
+// serviceConfig sets up the store, scheduler and library of the control plane.
+func (c *serverCommand) serviceConfig(appCtx *AppContext) (controlplane.Config, error) {
+	account := c.account
+	if account == "" {
+		account = appCtx.Client.Account()
+	}
+
+	lib, err := c.library()
+	if err != nil {
+		return controlplane.Config{}, err
+	}
+
+	js, err := jetstream.New(appCtx.Nc)
+	if err != nil {
+		return controlplane.Config{}, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+
+	store, err := controlplane.CreateKVStore(ctx, js, c.bucket, c.replicas)
+	if err != nil {
+		return controlplane.Config{}, err
+	}
+
+	conn, err := c.workloadConnection(appCtx.Nc)
+	if err != nil {
+		return controlplane.Config{}, err
+	}
+
+	scheduler, err := c.newScheduler(appCtx.Nc, account, lib, conn)
+	if err != nil {
+		return controlplane.Config{}, err
+	}
+
+	return controlplane.Config{
+		Account:   account,
+		Store:     store,
+		Scheduler: scheduler,
+		Library:   lib,
+	}, nil
+}
+
+func (c *serverCommand) newScheduler(nc *nats.Conn, account string, lib controlplane.Library, conn controlplane.WorkloadConnection) (controlplane.Scheduler, error) {
+	if c.scheduler == "agent" {
+		return agent.NewScheduler(nc, account, lib.ResolveImage, conn)
+	}
+
+	hosts := c.dockerHosts
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+
+	backends := make([]controlplane.ContainerBackend, 0, len(hosts))
+	for _, host := range hosts {
+		backends = append(backends, docker.NewRunnerForHost(host))
+	}
+
+	return controlplane.NewContainerScheduler(account, lib.ResolveImage, conn, backends...)
+}
+
+func (c *serverCommand) library() (controlplane.Library, error) {
+	if c.libraryFile != "" {
+		return controlplane.LoadLibrary(c.libraryFile)
+	}
+
+	runtimes, err := standalone.NewRuntimeManager().LoadRuntimes()
+	if err != nil {
+		return controlplane.Library{}, fmt.Errorf("failed to load runtimes: %w", err)
+	}
+
+	return standaloneLibrary(runtimes), nil
+}
+
+// standaloneLibrary serves the runtimes configured for standalone mode. No components are known for them.
+func standaloneLibrary(runtimes []standalone.Runtime) controlplane.Library {
+	lib := controlplane.Library{Runtimes: []model.Runtime{}, Components: []model.Component{}}
+	for _, rt := range runtimes {
+		r := model.Runtime{
+			Id:             rt.ID,
+			Label:          rt.Name,
+			Image:          rt.Registry,
+			DefaultVersion: "latest",
+			Author:         model.RuntimeAuthor{Name: rt.Author},
+		}
+		if rt.Description != "" {
+			description := rt.Description
+			r.Description = &description
+		}
+
+		lib.Runtimes = append(lib.Runtimes, r)
+	}
+	return lib
+}
+
+// workloadConnection determines the connection details handed to the connector instances.
+func (c *serverCommand) workloadConnection(nc *nats.Conn) (controlplane.WorkloadConnection, error) {
+	conn := controlplane.WorkloadConnection{Servers: c.workloadServers}
+	if conn.Servers == "" {
+		conn.Servers = nc.ConnectedUrl()
+	}
+
+	if c.workloadCreds == "" {
+		return conn, nil
+	}
+
+	userJwt, seed, err := readCreds(c.workloadCreds)
+	if err != nil {
+		return conn, err
+	}
+	conn.JWT = userJwt
+	conn.Seed = seed
+
+	return conn, nil
+}
+
+// readCreds returns the user JWT and seed held by a credentials file.
+func readCreds(file string) (string, string, error) {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	userJwt, err := jwt.ParseDecoratedJWT(contents)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse credentials jwt: %w", err)
+	}
+
+	kp, err := jwt.ParseDecoratedNKey(contents)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse credentials seed: %w", err)
+	}
+
+	seed, err := kp.Seed()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read credentials seed: %w", err)
+	}
+
+	return userJwt, string(seed), nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nkeys"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/connecttest"
+	"github.com/synadia-io/connect/standalone"
+)
+
+var _ = Describe("ServerCommand", func() {
+	Describe("standaloneLibrary", func() {
+		It("should serve the standalone runtimes", func() {
+			lib := standaloneLibrary([]standalone.Runtime{{
+				ID:          "wombat",
+				Name:        "Wombat",
+				Description: "The wombat runtime",
+				Registry:    "registry.synadia.io/connect-runtime-wombat",
+				Author:      "Synadia",
+			}})
+
+			Expect(lib.Runtimes).To(HaveLen(1))
+			Expect(lib.Runtimes[0].Label).To(Equal("Wombat"))
+			Expect(*lib.Runtimes[0].Description).To(Equal("The wombat runtime"))
+			Expect(lib.Runtimes[0].Author.Name).To(Equal("Synadia"))
+			Expect(lib.Components).To(BeEmpty())
+
+			image, err := lib.ResolveImage("wombat:v1.0.3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(image).To(Equal("registry.synadia.io/connect-runtime-wombat:v1.0.3"))
+
+			image, err = lib.ResolveImage("wombat")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(image).To(Equal("registry.synadia.io/connect-runtime-wombat:latest"))
+		})
+	})
+
+	Describe("workloadConnection", func() {
+		var srv *connecttest.Server
+
+		BeforeEach(func() {
+			var err error
+			srv, err = connecttest.NewServer()
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(srv.Close)
+		})
+
+		It("should default to the connected server", func() {
+			nc, err := srv.Connect()
+			Expect(err).ToNot(HaveOccurred())
+			defer nc.Close()
+
+			conn, err := (&serverCommand{}).workloadConnection(nc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conn.Servers).To(Equal(srv.ClientURL()))
+			Expect(conn.JWT).To(BeEmpty())
+		})
+
+		It("should read the workload credentials", func() {
+			nc, err := srv.Connect()
+			Expect(err).ToNot(HaveOccurred())
+			defer nc.Close()
+
+			account, err := nkeys.CreateAccount()
+			Expect(err).ToNot(HaveOccurred())
+			user, err := nkeys.CreateUser()
+			Expect(err).ToNot(HaveOccurred())
+			userPub, err := user.PublicKey()
+			Expect(err).ToNot(HaveOccurred())
+			userSeed, err := user.Seed()
+			Expect(err).ToNot(HaveOccurred())
+
+			userJwt, err := jwt.NewUserClaims(userPub).Encode(account)
+			Expect(err).ToNot(HaveOccurred())
+			creds, err := jwt.FormatUserConfig(userJwt, userSeed)
+			Expect(err).ToNot(HaveOccurred())
+
+			file := filepath.Join(GinkgoT().TempDir(), "workload.creds")
+			Expect(os.WriteFile(file, creds, 0600)).To(Succeed())
+
+			conn, err := (&serverCommand{workloadServers: "nats://nats:4222", workloadCreds: file}).workloadConnection(nc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conn.Servers).To(Equal("nats://nats:4222"))
+			Expect(conn.JWT).To(Equal(userJwt))
+			Expect(conn.Seed).To(Equal(string(userSeed)))
+		})
+
+		It("should fail for missing credentials", func() {
+			nc, err := srv.Connect()
+			Expect(err).ToNot(HaveOccurred())
+			defer nc.Close()
+
+			_, err = (&serverCommand{workloadCreds: "/does/not/exist"}).workloadConnection(nc)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/choria-io/fisk"
 	"github.com/fatih/color"
@@ -31,6 +32,10 @@ type standaloneCommand struct {
 	envFileSetByUser bool
 	follow           bool
 	remove           bool
+	dryRun           bool
+	captureFile      string
+	maxMessages      int
+	duration         time.Duration
 
 	// Template flags
 	templateName string
@@ -65,6 +70,10 @@ func ConfigureStandaloneCommand(parentCmd commandHost, opts *Options) {
 	runCmd.Flag("env-file", "Read environment variables from file").Default(".env").IsSetByUser(&c.envFileSetByUser).StringVar(&c.envFile)
 	runCmd.Flag("follow", "Follow logs after starting").Short('f').BoolVar(&c.follow)
 	runCmd.Flag("rm", "Remove container when it exits").BoolVar(&c.remove)
+	runCmd.Flag("dry-run", "Capture the messages the connector would emit instead of writing them to the sink or producer").BoolVar(&c.dryRun)
+	runCmd.Flag("capture", "JSONL file to write the captured messages to, - for stdout (with --dry-run)").Default("-").StringVar(&c.captureFile)
+	runCmd.Flag("max-messages", "Stop after capturing this many messages, 0 for no limit (with --dry-run)").Default("10").IntVar(&c.maxMessages)
+	runCmd.Flag("duration", "Stop after this time, 0 for no limit (with --dry-run)").Default("0s").DurationVar(&c.duration)
 
 	// Stop command
 	stopCmd := standaloneCmd.Command("stop", "Stop a running connector").Action(c.stopConnector)
@@ -207,6 +216,10 @@ func (c *standaloneCommand) runConnector(pc *fisk.ParseContext) error {
 		return fmt.Errorf("docker is not available: %w", err)
 	}
 
+	if c.dryRun {
+		return c.dryRunConnector(runner, containerName, image, steps)
+	}
+
 	// Run the connector
 	runOpts := &docker.RunOptions{
 		ConnectorID: containerName,
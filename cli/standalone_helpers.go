@@ -1,13 +1,23 @@
 package cli
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/mitchellh/mapstructure"
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/model"
 	"github.com/synadia-io/connect/spec"
 	"github.com/synadia-io/connect/spec/builders"
+	"github.com/synadia-io/connect/standalone"
 	"gopkg.in/yaml.v3"
 )
 
@@ -153,3 +163,91 @@ var standaloneTemplates = []connectorTemplate{
 			Build(),
 	},
 }
+
+// dryRunConnector runs the connector with its sink or producer replaced by a capture sink, writing the captured
+// messages until the message or time limit is reached.
+func (c *standaloneCommand) dryRunConnector(runner *docker.Runner, containerName string, image string, steps model.Steps) error {
+	dryRunSteps, err := standalone.DryRunSteps(steps)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if c.captureFile != "-" {
+		f, err := os.Create(c.captureFile)
+		if err != nil {
+			return fmt.Errorf("failed to create capture file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if c.duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.duration)
+		defer cancel()
+	}
+
+	name := containerName + "_dry_run"
+	_, _ = fmt.Fprintf(os.Stderr, "Capturing the messages of '%s' with image '%s'. Press Ctrl+C to stop.\n", c.connectorName, image)
+
+	pr, pw := io.Pipe()
+	runErr := make(chan error, 1)
+	go func() {
+		err := runner.Attach(ctx, &docker.RunOptions{
+			ConnectorID: name,
+			Image:       image,
+			Steps:       dryRunSteps,
+			EnvVars:     c.envVars,
+			DockerOpts:  c.dockerOpts,
+		}, strings.NewReader(""), pw)
+		_ = pw.CloseWithError(err)
+		runErr <- err
+	}()
+
+	count, captureErr := captureMessages(pr, out, c.maxMessages)
+	// interrupted or timed out, checked before the context is cancelled below
+	stopped := ctx.Err() != nil
+
+	// the container keeps running when only the docker client is stopped
+	cancel()
+	_ = pr.Close()
+	_ = runner.ForceRemove(context.Background(), name)
+	err = <-runErr
+
+	_, _ = fmt.Fprintf(os.Stderr, "Captured %d messages\n", count)
+
+	if captureErr != nil && !errors.Is(captureErr, io.ErrClosedPipe) {
+		return captureErr
+	}
+	if err != nil && !stopped && (c.maxMessages <= 0 || count < c.maxMessages) {
+		return err
+	}
+	return nil
+}
+
+// captureMessages copies the captured messages to the writer, one per line, until limit messages were copied or the
+// reader is exhausted. Lines which are not captured messages, such as log lines, are skipped.
+func captureMessages(r io.Reader, w io.Writer, limit int) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	count := 0
+	for scanner.Scan() {
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &envelope); err != nil || envelope["payload"] == nil {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\n", scanner.Bytes()); err != nil {
+			return count, err
+		}
+
+		count++
+		if limit > 0 && count >= limit {
+			return count, nil
+		}
+	}
+	return count, scanner.Err()
+}
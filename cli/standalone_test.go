@@ -1,12 +1,17 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/convert"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/standalone"
 )
 
 var _ = Describe("StandaloneCommand", func() {
@@ -145,6 +150,53 @@ var _ = Describe("StandaloneCommand", func() {
 		})
 	})
 
+	Describe("loadConnectorSpec", func() {
+		load := func(errors string) model.Steps {
+			err := os.WriteFile("errors.connector.yml", []byte(`type: connector
+spec:
+  description: retrying connector
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: nats://localhost:4222
+      core:
+        subject: orders
+    sink:
+      type: stdout
+    errors:
+`+errors), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			connector, err := cmd.loadConnectorSpec("errors.connector.yml")
+			Expect(err).ToNot(HaveOccurred())
+			return convert.ConvertStepsFromSpec(connector.Steps)
+		}
+
+		It("should retry the default number of times when max_retries is not set", func() {
+			steps := load("      backoff: 1s\n")
+			Expect(steps.Errors.MaxRetries).To(HaveValue(Equal(3)))
+
+			config, err := standalone.NewWombatConverter().ConvertSteps(steps)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring("max_retries: 3"))
+		})
+
+		It("should keep max_retries 0 through the requests", func() {
+			steps := load("      max_retries: 0\n")
+
+			b, err := json.Marshal(steps)
+			Expect(err).ToNot(HaveOccurred())
+			var received model.Steps
+			Expect(json.Unmarshal(b, &received)).To(Succeed())
+			Expect(received.Errors.MaxRetries).To(HaveValue(Equal(0)))
+
+			config, err := standalone.NewWombatConverter().ConvertSteps(received)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).ToNot(ContainSubstring("retry:"))
+		})
+	})
+
 	Describe("removeConnector", func() {
 		BeforeEach(func() {
 			cmd.connectorName = "test-connector"
@@ -274,4 +326,27 @@ var _ = Describe("StandaloneCommand", func() {
 			Expect(appCtx.DefaultTimeout).To(Equal(5 * time.Second))
 		})
 	})
+
+	Describe("captureMessages", func() {
+		It("should copy captured messages and skip log lines", func() {
+			input := `level=info msg="starting"
+{"subject":"a","headers":{},"payload":1}
+{"not":"captured"}
+{"subject":"b","headers":{},"payload":"two"}
+{"subject":"c","headers":{},"payload":3}
+`
+			var out strings.Builder
+			count, err := captureMessages(strings.NewReader(input), &out, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(2))
+			Expect(out.String()).To(Equal("{\"subject\":\"a\",\"headers\":{},\"payload\":1}\n{\"subject\":\"b\",\"headers\":{},\"payload\":\"two\"}\n"))
+		})
+
+		It("should copy everything without a limit", func() {
+			var out strings.Builder
+			count, err := captureMessages(strings.NewReader("{\"payload\":1}\n{\"payload\":2}\n"), &out, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(2))
+		})
+	})
 })
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+	"iter"
 	"time"
 
 	"github.com/synadia-io/connect/model"
@@ -15,11 +17,13 @@ type mockClient struct {
 	connectorError  error
 	instances       []model.Instance
 	startOptions    *model.ConnectorStartOptions
+	scaleReplicas   int
 	createCalled    bool
 	deleteCalled    bool
 	patchCalled     bool
 	startCalled     bool
 	stopCalled      bool
+	scaleCalled     bool
 
 	// LibraryClient methods
 	runtimes   []model.RuntimeSummary
@@ -115,6 +119,16 @@ func (m *mockClient) StopConnector(id string, timeout time.Duration) ([]model.In
 	return m.instances, nil
 }
 
+func (m *mockClient) ScaleConnector(id string, replicas int, startOpts *model.ConnectorStartOptions, timeout time.Duration) ([]model.Instance, error) {
+	m.scaleCalled = true
+	m.scaleReplicas = replicas
+	m.startOptions = startOpts
+	if m.connectorError != nil {
+		return nil, m.connectorError
+	}
+	return m.instances, nil
+}
+
 // LibraryClient interface
 func (m *mockClient) ListRuntimes(timeout time.Duration) ([]model.RuntimeSummary, error) {
 	return m.runtimes, nil
@@ -132,6 +146,94 @@ func (m *mockClient) GetComponent(runtimeId string, kind model.ComponentKind, id
 	return m.component, nil
 }
 
+// ContextConnectorClient interface
+func (m *mockClient) ListConnectorsWithContext(ctx context.Context) ([]model.ConnectorSummary, error) {
+	return m.ListConnectors(0)
+}
+
+func (m *mockClient) IterConnectors(ctx context.Context) iter.Seq2[model.ConnectorSummary, error] {
+	return func(yield func(model.ConnectorSummary, error) bool) {
+		connectors, err := m.ListConnectors(0)
+		if err != nil {
+			yield(model.ConnectorSummary{}, err)
+			return
+		}
+		for _, c := range connectors {
+			if !yield(c, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m *mockClient) GetConnectorWithContext(ctx context.Context, id string) (*model.Connector, error) {
+	return m.GetConnector(id, 0)
+}
+
+func (m *mockClient) GetConnectorStatusWithContext(ctx context.Context, id string) (*model.ConnectorStatus, error) {
+	return m.GetConnectorStatus(id, 0)
+}
+
+func (m *mockClient) CreateConnectorWithContext(ctx context.Context, id, description, runtimeId string, steps model.Steps) (*model.Connector, error) {
+	return m.CreateConnector(id, description, runtimeId, steps, 0)
+}
+
+func (m *mockClient) PatchConnectorWithContext(ctx context.Context, id string, patch string) (*model.Connector, error) {
+	return m.PatchConnector(id, patch, 0)
+}
+
+func (m *mockClient) DeleteConnectorWithContext(ctx context.Context, id string) error {
+	return m.DeleteConnector(id, 0)
+}
+
+func (m *mockClient) ListConnectorInstancesWithContext(ctx context.Context, id string) ([]model.Instance, error) {
+	return m.ListConnectorInstances(id, 0)
+}
+
+func (m *mockClient) StartConnectorWithContext(ctx context.Context, id string, startOpts *model.ConnectorStartOptions) ([]model.Instance, error) {
+	return m.StartConnector(id, startOpts, 0)
+}
+
+func (m *mockClient) StopConnectorWithContext(ctx context.Context, id string) ([]model.Instance, error) {
+	return m.StopConnector(id, 0)
+}
+
+func (m *mockClient) ScaleConnectorWithContext(ctx context.Context, id string, replicas int, startOpts *model.ConnectorStartOptions) ([]model.Instance, error) {
+	return m.ScaleConnector(id, replicas, startOpts, 0)
+}
+
+// ContextLibraryClient interface
+func (m *mockClient) ListRuntimesWithContext(ctx context.Context) ([]model.RuntimeSummary, error) {
+	return m.ListRuntimes(0)
+}
+
+func (m *mockClient) GetRuntimeWithContext(ctx context.Context, id string) (*model.Runtime, error) {
+	return m.GetRuntime(id, 0)
+}
+
+func (m *mockClient) SearchComponentsWithContext(ctx context.Context, filter *model.ComponentSearchFilter) ([]model.ComponentSummary, error) {
+	return m.SearchComponents(filter, 0)
+}
+
+func (m *mockClient) IterComponents(ctx context.Context, filter *model.ComponentSearchFilter) iter.Seq2[model.ComponentSummary, error] {
+	return func(yield func(model.ComponentSummary, error) bool) {
+		components, err := m.SearchComponents(filter, 0)
+		if err != nil {
+			yield(model.ComponentSummary{}, err)
+			return
+		}
+		for _, c := range components {
+			if !yield(c, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m *mockClient) GetComponentWithContext(ctx context.Context, runtimeId string, kind model.ComponentKind, id string) (*model.Component, error) {
+	return m.GetComponent(runtimeId, kind, id, 0)
+}
+
 // Helper to create a mock AppContext
 func newMockAppContext() (*AppContext, *mockClient) {
 	mockCl := newMockClient()
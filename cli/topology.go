@@ -0,0 +1,474 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/synadia-io/connect/model"
+)
+
+// The kinds of topology channels
+const (
+	channelSubject = "subject"
+	channelKv      = "kv"
+)
+
+// topologyChannel is a subject or key of a key-value bucket connectors produce to or consume from.
+type topologyChannel struct {
+	Kind string `json:"kind"`
+	// Name is the subject, or the bucket of a key-value channel
+	Name string `json:"name"`
+	// Key is the key of a key-value channel, which may contain wildcards
+	Key string `json:"key,omitempty"`
+	// Stream is the stream a consumer reads the subject from, when it is named
+	Stream string `json:"stream,omitempty"`
+}
+
+// String returns the subject of the channel, or the bucket and key of a key-value channel.
+func (ch topologyChannel) String() string {
+	if ch.Kind == channelKv {
+		return fmt.Sprintf("kv:%s/%s", ch.Name, ch.Key)
+	}
+	if ch.Stream != "" {
+		return fmt.Sprintf("%s (%s)", ch.Name, ch.Stream)
+	}
+	return ch.Name
+}
+
+// templatePattern matches the ${! ... } interpolations of subjects and keys.
+var templatePattern = regexp.MustCompile(`\$\{!.*?\}`)
+
+// subject returns the subject or key of the channel, with the tokens resolved from the message as * wildcards.
+func (ch topologyChannel) subject() string {
+	s := ch.Name
+	if ch.Kind == channelKv {
+		s = ch.Key
+	}
+
+	tokens := strings.Split(templatePattern.ReplaceAllString(s, "*"), ".")
+	for i, token := range tokens {
+		if token != "*" && strings.Contains(token, "*") {
+			tokens[i] = "*"
+		}
+	}
+	return strings.Join(tokens, ".")
+}
+
+// overlaps reports whether messages produced to the channel can be consumed from the other channel.
+func (ch topologyChannel) overlaps(other topologyChannel) bool {
+	if ch.Kind != other.Kind {
+		return false
+	}
+	if ch.Kind == channelKv && ch.Name != other.Name {
+		return false
+	}
+	return subjectsOverlap(ch.subject(), other.subject())
+}
+
+// topologyNode is a connector with the channels it produces to and consumes from.
+type topologyNode struct {
+	Connector string            `json:"connector"`
+	Produces  []topologyChannel `json:"produces,omitempty"`
+	Consumes  []topologyChannel `json:"consumes,omitempty"`
+}
+
+// topologyFlow is a connector consuming the messages another connector produces.
+type topologyFlow struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Produced topologyChannel `json:"produced"`
+	Consumed topologyChannel `json:"consumed"`
+}
+
+// topology is the graph of the connectors connected by the channels they produce to and consume from, with the
+// problems found in it.
+type topology struct {
+	Connectors []topologyNode `json:"connectors"`
+	Flows      []topologyFlow `json:"flows"`
+	// Unconsumed are the channels produced to which no connector consumes from
+	Unconsumed []topologyChannel `json:"unconsumed"`
+	// Orphans are the connectors producing messages none of which are consumed by another connector
+	Orphans []string `json:"orphans"`
+	// Cycles are the groups of connectors whose messages flow back to themselves
+	Cycles [][]string `json:"cycles"`
+}
+
+// buildTopology connects the producers of the connectors to the consumers whose subjects or keys overlap, taking
+// wildcards and subjects templated from the message into account.
+func buildTopology(connectors []model.Connector) topology {
+	t := topology{Flows: []topologyFlow{}, Unconsumed: []topologyChannel{}, Orphans: []string{}, Cycles: [][]string{}}
+
+	connectors = slices.Clone(connectors)
+	slices.SortFunc(connectors, func(a, b model.Connector) int { return strings.Compare(a.ConnectorId, b.ConnectorId) })
+	for _, c := range connectors {
+		t.Connectors = append(t.Connectors, topologyNode{
+			Connector: c.ConnectorId,
+			Produces:  producedChannels(c.Steps),
+			Consumes:  consumedChannels(c.Steps),
+		})
+	}
+
+	for _, from := range t.Connectors {
+		produces := false
+		for _, produced := range from.Produces {
+			consumed := false
+			for _, to := range t.Connectors {
+				for _, ch := range to.Consumes {
+					if produced.overlaps(ch) {
+						t.Flows = append(t.Flows, topologyFlow{From: from.Connector, To: to.Connector, Produced: produced, Consumed: ch})
+						consumed = true
+						produces = produces || to.Connector != from.Connector
+					}
+				}
+			}
+			if !consumed && !slices.Contains(t.Unconsumed, produced) {
+				t.Unconsumed = append(t.Unconsumed, produced)
+			}
+		}
+		if len(from.Produces) > 0 && !produces {
+			t.Orphans = append(t.Orphans, from.Connector)
+		}
+	}
+
+	t.Cycles = topologyCycles(t.Connectors, t.Flows)
+	return t
+}
+
+func producedChannels(steps model.Steps) []topologyChannel {
+	var channels []topologyChannel
+	add := func(p *model.ProducerStep) {
+		if p == nil {
+			return
+		}
+		var ch topologyChannel
+		switch {
+		case p.Core != nil:
+			ch = topologyChannel{Kind: channelSubject, Name: p.Core.Subject}
+		case p.Stream != nil:
+			ch = topologyChannel{Kind: channelSubject, Name: p.Stream.Subject}
+		case p.Kv != nil:
+			ch = topologyChannel{Kind: channelKv, Name: p.Kv.Bucket, Key: p.Kv.Key}
+		default:
+			return
+		}
+		if !slices.Contains(channels, ch) {
+			channels = append(channels, ch)
+		}
+	}
+
+	add(steps.Producer)
+	if steps.Broker != nil {
+		for _, o := range steps.Broker.Outputs {
+			add(o.Producer)
+		}
+	}
+	if steps.Router != nil {
+		for _, r := range steps.Router.Routes {
+			add(r.Output.Producer)
+		}
+		if steps.Router.Default != nil {
+			add(steps.Router.Default.Producer)
+		}
+	}
+	if steps.Errors != nil {
+		add(steps.Errors.DeadLetter)
+	}
+	return channels
+}
+
+func consumedChannels(steps model.Steps) []topologyChannel {
+	c := steps.Consumer
+	switch {
+	case c == nil:
+		return nil
+	case c.Core != nil:
+		return []topologyChannel{{Kind: channelSubject, Name: c.Core.Subject}}
+	case c.Stream != nil:
+		channels := []topologyChannel{{Kind: channelSubject, Name: c.Stream.Subject, Stream: ptrValue(c.Stream.Stream)}}
+		for _, s := range c.Stream.FilterSubjects {
+			channels = append(channels, topologyChannel{Kind: channelSubject, Name: s, Stream: ptrValue(c.Stream.Stream)})
+		}
+		return channels
+	case c.Kv != nil:
+		key := c.Kv.Key
+		if key == "" {
+			key = ">"
+		}
+		return []topologyChannel{{Kind: channelKv, Name: c.Kv.Bucket, Key: key}}
+	}
+	return nil
+}
+
+// cycleString describes a group of connectors found by topologyCycles. The members of a group are sorted rather than
+// in the order the messages flow, so only a connector consuming its own messages is shown with an arrow.
+func cycleString(c []string) string {
+	if len(c) == 1 {
+		return c[0] + " -> " + c[0]
+	}
+	return strings.Join(c, ", ")
+}
+
+func ptrValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// topologyCycles finds the strongly connected groups of connectors, and connectors consuming their own messages,
+// with Tarjan's algorithm.
+func topologyCycles(nodes []topologyNode, flows []topologyFlow) [][]string {
+	next := map[string][]string{}
+	for _, f := range flows {
+		if !slices.Contains(next[f.From], f.To) {
+			next[f.From] = append(next[f.From], f.To)
+		}
+	}
+
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	cycles := [][]string{}
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		low[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, to := range next[id] {
+			if _, ok := index[to]; !ok {
+				visit(to)
+				low[id] = min(low[id], low[to])
+			} else if onStack[to] {
+				low[id] = min(low[id], index[to])
+			}
+		}
+
+		if low[id] != index[id] {
+			return
+		}
+		var group []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			group = append(group, top)
+			if top == id {
+				break
+			}
+		}
+		if len(group) > 1 || slices.Contains(next[id], id) {
+			slices.Sort(group)
+			cycles = append(cycles, group)
+		}
+	}
+
+	for _, n := range nodes {
+		if _, ok := index[n.Connector]; !ok {
+			visit(n.Connector)
+		}
+	}
+
+	slices.SortFunc(cycles, func(a, b []string) int { return strings.Compare(a[0], b[0]) })
+	return cycles
+}
+
+// channels returns every channel of the topology once, in the order they are produced to and consumed from.
+func (t topology) channels() []topologyChannel {
+	var channels []topologyChannel
+	for _, n := range t.Connectors {
+		for _, ch := range append(slices.Clone(n.Produces), n.Consumes...) {
+			if !slices.Contains(channels, ch) {
+				channels = append(channels, ch)
+			}
+		}
+	}
+	return channels
+}
+
+func (t topology) inCycle(connector string) bool {
+	return slices.ContainsFunc(t.Cycles, func(c []string) bool { return slices.Contains(c, connector) })
+}
+
+func renderTopologyJSON(w io.Writer, t topology) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+// renderTopologyDot renders the topology as a Graphviz digraph, with connectors as boxes and channels as ellipses.
+// Produced channels are connected to the overlapping channels consumers read with dashed edges. Unconsumed
+// channels and orphan connectors are red, connectors in a cycle orange.
+func renderTopologyDot(w io.Writer, t topology) error {
+	var b strings.Builder
+	b.WriteString("digraph topology {\n")
+	b.WriteString("  rankdir=LR;\n")
+
+	for _, n := range t.Connectors {
+		attrs := fmt.Sprintf("shape=box, label=%q", n.Connector)
+		switch {
+		case slices.Contains(t.Orphans, n.Connector):
+			attrs += ", color=red"
+		case t.inCycle(n.Connector):
+			attrs += ", color=orange"
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", "connector:"+n.Connector, attrs)
+	}
+	for _, ch := range t.channels() {
+		attrs := fmt.Sprintf("shape=ellipse, label=%q", ch.String())
+		if slices.Contains(t.Unconsumed, ch) {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", "channel:"+ch.String(), attrs)
+	}
+
+	for _, e := range t.edges() {
+		attrs := ""
+		if e.match {
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(&b, "  %q -> %q%s;\n", e.from, e.to, attrs)
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderTopologyMermaid renders the topology as a Mermaid flowchart, like renderTopologyDot.
+func renderTopologyMermaid(w io.Writer, t topology) error {
+	ids := map[string]string{}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range t.Connectors {
+		id := fmt.Sprintf("c%d", i)
+		ids["connector:"+n.Connector] = id
+		fmt.Fprintf(&b, "  %s[%q]\n", id, n.Connector)
+	}
+	for i, ch := range t.channels() {
+		id := fmt.Sprintf("s%d", i)
+		ids["channel:"+ch.String()] = id
+		fmt.Fprintf(&b, "  %s([%q])\n", id, ch.String())
+	}
+
+	for _, e := range t.edges() {
+		arrow := "-->"
+		if e.match {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[e.from], arrow, ids[e.to])
+	}
+
+	var problems, cycles []string
+	for _, n := range t.Connectors {
+		switch {
+		case slices.Contains(t.Orphans, n.Connector):
+			problems = append(problems, ids["connector:"+n.Connector])
+		case t.inCycle(n.Connector):
+			cycles = append(cycles, ids["connector:"+n.Connector])
+		}
+	}
+	for _, ch := range t.Unconsumed {
+		problems = append(problems, ids["channel:"+ch.String()])
+	}
+	if len(problems) > 0 {
+		b.WriteString("  classDef problem stroke:#d33,stroke-width:2px\n")
+		fmt.Fprintf(&b, "  class %s problem\n", strings.Join(problems, ","))
+	}
+	if len(cycles) > 0 {
+		b.WriteString("  classDef cycle stroke:#f90,stroke-width:2px\n")
+		fmt.Fprintf(&b, "  class %s cycle\n", strings.Join(cycles, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type topologyEdge struct {
+	from  string
+	to    string
+	match bool
+}
+
+// edges returns the edges from the connectors to the channels they produce to, from the channels to the connectors
+// consuming from them, and between the produced and consumed channels which are not the same.
+func (t topology) edges() []topologyEdge {
+	var edges []topologyEdge
+	add := func(e topologyEdge) {
+		if !slices.Contains(edges, e) {
+			edges = append(edges, e)
+		}
+	}
+
+	for _, n := range t.Connectors {
+		for _, ch := range n.Produces {
+			add(topologyEdge{from: "connector:" + n.Connector, to: "channel:" + ch.String()})
+		}
+	}
+	for _, f := range t.Flows {
+		if f.Produced != f.Consumed {
+			add(topologyEdge{from: "channel:" + f.Produced.String(), to: "channel:" + f.Consumed.String(), match: true})
+		}
+	}
+	for _, n := range t.Connectors {
+		for _, ch := range n.Consumes {
+			add(topologyEdge{from: "channel:" + ch.String(), to: "connector:" + n.Connector})
+		}
+	}
+	return edges
+}
+
+// renderTopologyText prints the flows between the connectors as a table, followed by the problems found.
+func renderTopologyText(w io.Writer, t topology) error {
+	tw := table.NewWriter()
+	tw.SetTitle("Topology")
+	tw.AppendHeader(table.Row{"Producer", "Subject", "Consumer", "Consumed As"})
+	tw.SetStyle(table.StyleRounded)
+	for _, f := range t.Flows {
+		consumedAs := ""
+		if f.Consumed != f.Produced {
+			consumedAs = f.Consumed.String()
+		}
+		tw.AppendRow(table.Row{f.From, f.Produced.String(), f.To, consumedAs})
+	}
+	if _, err := fmt.Fprintln(w, tw.Render()); err != nil {
+		return err
+	}
+
+	section := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		_, _ = fmt.Fprintf(w, "\n%s\n", color.YellowString(title))
+		for _, item := range items {
+			_, _ = fmt.Fprintf(w, "  %s %s\n", color.RedString("!"), item)
+		}
+	}
+
+	var unconsumed, cycles []string
+	for _, ch := range t.Unconsumed {
+		unconsumed = append(unconsumed, ch.String())
+	}
+	for _, c := range t.Cycles {
+		cycles = append(cycles, cycleString(c))
+	}
+
+	section("Orphan producers, whose messages no connector consumes:", t.Orphans)
+	section("Unconsumed subjects:", unconsumed)
+	section("Cycles:", cycles)
+
+	if len(t.Orphans) == 0 && len(unconsumed) == 0 && len(cycles) == 0 {
+		_, _ = fmt.Fprintf(w, "\n%s\n", color.GreenString("No orphan producers, unconsumed subjects or cycles found"))
+	}
+	return nil
+}
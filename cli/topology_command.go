@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/choria-io/fisk"
+	"github.com/synadia-io/connect/model"
+)
+
+type topologyCommand struct {
+	opts *Options
+
+	format string
+}
+
+func ConfigureTopologyCommand(parentCmd commandHost, opts *Options) {
+	c := &topologyCommand{
+		opts: opts,
+	}
+
+	topologyCmd := parentCmd.Command("topology", "Show how messages flow between the connectors").Action(c.topology)
+	topologyCmd.Flag("format", "The format to show the topology in").Default("text").EnumVar(&c.format, "text", "dot", "mermaid", "json")
+}
+
+func (c *topologyCommand) topology(pc *fisk.ParseContext) error {
+	appCtx, err := LoadOptions(c.opts)
+	fisk.FatalIfError(err, "failed to load options")
+	defer appCtx.Close()
+
+	fisk.FatalIfError(c.topologyWithClient(appCtx, os.Stdout), "failed to show the topology")
+
+	return nil
+}
+
+func (c *topologyCommand) topologyWithClient(appCtx *AppContext, w io.Writer) error {
+	summaries, err := appCtx.Client.ListConnectors(c.opts.Timeout)
+	if err != nil {
+		return fmt.Errorf("failed to list connectors: %w", err)
+	}
+
+	var connectors []model.Connector
+	for _, s := range summaries {
+		connector, err := appCtx.Client.GetConnector(s.ConnectorId, c.opts.Timeout)
+		if err != nil {
+			return fmt.Errorf("failed to get connector %s: %w", s.ConnectorId, err)
+		}
+		if connector != nil {
+			connectors = append(connectors, *connector)
+		}
+	}
+
+	t := buildTopology(connectors)
+	switch c.format {
+	case "dot":
+		return renderTopologyDot(w, t)
+	case "mermaid":
+		return renderTopologyMermaid(w, t)
+	case "json":
+		return renderTopologyJSON(w, t)
+	default:
+		return renderTopologyText(w, t)
+	}
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/connecttest"
+	"github.com/synadia-io/connect/model"
+)
+
+func topologyConnector(id string, consumer *model.ConsumerStep, producer *model.ProducerStep) model.Connector {
+	return model.Connector{
+		ConnectorId: id,
+		RuntimeId:   "wombat",
+		Steps:       model.Steps{Consumer: consumer, Producer: producer},
+	}
+}
+
+func coreConsumer(subject string) *model.ConsumerStep {
+	return &model.ConsumerStep{Core: &model.ConsumerStepCore{Subject: subject}}
+}
+
+func coreProducer(subject string) *model.ProducerStep {
+	return &model.ProducerStep{Core: &model.ProducerStepCore{Subject: subject}}
+}
+
+var _ = Describe("Topology", func() {
+	Describe("buildTopology", func() {
+		It("should connect producers to consumers with overlapping subjects", func() {
+			t := buildTopology([]model.Connector{
+				topologyConnector("ingest", nil, coreProducer("orders.new.eu")),
+				topologyConnector("enrich", coreConsumer("orders.*.eu"), &model.ProducerStep{Stream: &model.ProducerStepStream{Subject: "enriched.orders"}}),
+				topologyConnector("archive", &model.ConsumerStep{Stream: &model.ConsumerStepStream{Subject: "enriched.>"}}, nil),
+			})
+
+			Expect(t.Flows).To(HaveLen(2))
+			Expect(t.Flows[0].From).To(Equal("enrich"))
+			Expect(t.Flows[0].To).To(Equal("archive"))
+			Expect(t.Flows[1].From).To(Equal("ingest"))
+			Expect(t.Flows[1].To).To(Equal("enrich"))
+			Expect(t.Flows[1].Consumed.Name).To(Equal("orders.*.eu"))
+			Expect(t.Orphans).To(BeEmpty())
+			Expect(t.Unconsumed).To(BeEmpty())
+			Expect(t.Cycles).To(BeEmpty())
+		})
+
+		It("should treat subjects templated from the message as wildcards", func() {
+			t := buildTopology([]model.Connector{
+				topologyConnector("split", nil, coreProducer(`orders.${! meta("region") }`)),
+				topologyConnector("eu", coreConsumer("orders.eu"), nil),
+				topologyConnector("other", coreConsumer("payments.eu"), nil),
+			})
+
+			Expect(t.Flows).To(HaveLen(1))
+			Expect(t.Flows[0].To).To(Equal("eu"))
+		})
+
+		It("should connect key-value buckets by key", func() {
+			t := buildTopology([]model.Connector{
+				topologyConnector("writer", nil, &model.ProducerStep{Kv: &model.ProducerStepKv{Bucket: "config", Key: "app.name"}}),
+				topologyConnector("all", &model.ConsumerStep{Kv: &model.ConsumerStepKv{Bucket: "config"}}, nil),
+				topologyConnector("other", &model.ConsumerStep{Kv: &model.ConsumerStepKv{Bucket: "cache", Key: "app.name"}}, nil),
+			})
+
+			Expect(t.Flows).To(HaveLen(1))
+			Expect(t.Flows[0].To).To(Equal("all"))
+			Expect(t.Flows[0].Consumed.Key).To(Equal(">"))
+		})
+
+		It("should report orphan producers and unconsumed subjects", func() {
+			t := buildTopology([]model.Connector{
+				topologyConnector("lonely", nil, coreProducer("nobody.listens")),
+				topologyConnector("router", coreConsumer("in"), nil),
+			})
+			t2 := buildTopology([]model.Connector{{
+				ConnectorId: "router",
+				Steps: model.Steps{
+					Consumer: coreConsumer("in"),
+					Router: &model.RouterStep{
+						Routes:  []model.RouteStep{{Condition: "true", Output: model.OutputStep{Producer: coreProducer("in")}}},
+						Default: &model.OutputStep{Producer: coreProducer("rejected")},
+					},
+				},
+			}})
+
+			Expect(t.Orphans).To(Equal([]string{"lonely"}))
+			Expect(t.Unconsumed).To(ConsistOf(topologyChannel{Kind: channelSubject, Name: "nobody.listens"}))
+
+			Expect(t2.Unconsumed).To(ConsistOf(topologyChannel{Kind: channelSubject, Name: "rejected"}))
+			Expect(t2.Orphans).To(Equal([]string{"router"}))
+			Expect(t2.Cycles).To(Equal([][]string{{"router"}}))
+		})
+
+		It("should find cycles between connectors", func() {
+			t := buildTopology([]model.Connector{
+				topologyConnector("a", coreConsumer("a"), coreProducer("b")),
+				topologyConnector("b", coreConsumer("b"), coreProducer("c")),
+				topologyConnector("c", coreConsumer("c"), coreProducer("a")),
+				topologyConnector("d", coreConsumer("c"), nil),
+			})
+
+			Expect(t.Cycles).To(Equal([][]string{{"a", "b", "c"}}))
+		})
+	})
+
+	Describe("rendering", func() {
+		var t topology
+
+		BeforeEach(func() {
+			color.NoColor = true
+			t = buildTopology([]model.Connector{
+				topologyConnector("ingest", nil, coreProducer("orders.new")),
+				topologyConnector("enrich", coreConsumer("orders.*"), coreProducer("enriched")),
+			})
+		})
+
+		It("should render DOT", func() {
+			var out bytes.Buffer
+			Expect(renderTopologyDot(&out, t)).To(Succeed())
+			Expect(out.String()).To(HavePrefix("digraph topology {\n"))
+			Expect(out.String()).To(ContainSubstring(`"connector:ingest" -> "channel:orders.new";`))
+			Expect(out.String()).To(ContainSubstring(`"channel:orders.new" -> "channel:orders.*" [style=dashed];`))
+			Expect(out.String()).To(ContainSubstring(`"channel:orders.*" -> "connector:enrich";`))
+			Expect(out.String()).To(ContainSubstring(`"channel:enriched" [shape=ellipse, label="enriched", color=red];`))
+		})
+
+		It("should render Mermaid", func() {
+			var out bytes.Buffer
+			Expect(renderTopologyMermaid(&out, t)).To(Succeed())
+			Expect(out.String()).To(HavePrefix("flowchart LR\n"))
+			Expect(out.String()).To(ContainSubstring(`c0["enrich"]`))
+			Expect(out.String()).To(ContainSubstring("c1 --> s2\n"))
+			Expect(out.String()).To(ContainSubstring("s2 -.-> s1\n"))
+			Expect(out.String()).To(ContainSubstring("class c0,s0 problem\n"))
+		})
+
+		It("should render JSON", func() {
+			var out bytes.Buffer
+			Expect(renderTopologyJSON(&out, t)).To(Succeed())
+
+			var parsed topology
+			Expect(json.Unmarshal(out.Bytes(), &parsed)).To(Succeed())
+			Expect(parsed).To(Equal(t))
+		})
+
+		It("should highlight problems in the terminal view", func() {
+			var out bytes.Buffer
+			Expect(renderTopologyText(&out, t)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("Orphan producers, whose messages no connector consumes:\n  ! enrich\n"))
+			Expect(out.String()).To(ContainSubstring("Unconsumed subjects:\n  ! enriched\n"))
+			Expect(out.String()).ToNot(ContainSubstring("Cycles:"))
+		})
+
+		It("should list the connectors of a cycle without implying an order", func() {
+			t = buildTopology([]model.Connector{
+				topologyConnector("a", coreConsumer("a"), coreProducer("c")),
+				topologyConnector("b", coreConsumer("b"), coreProducer("a")),
+				topologyConnector("c", coreConsumer("c"), coreProducer("b")),
+				topologyConnector("echo", coreConsumer("echo"), coreProducer("echo")),
+			})
+
+			var out bytes.Buffer
+			Expect(renderTopologyText(&out, t)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("Cycles:\n  ! a, b, c\n  ! echo -> echo\n"))
+		})
+	})
+
+	It("should build the topology of the connectors of a connect service", func() {
+		srv, err := connecttest.NewServer(connecttest.WithConnectors(
+			topologyConnector("ingest", nil, coreProducer("orders.new")),
+			topologyConnector("enrich", coreConsumer("orders.*"), nil),
+		))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(srv.Close)
+
+		nc, err := srv.Connect()
+		Expect(err).ToNot(HaveOccurred())
+
+		cl, err := client.NewClient(nc, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		appCtx := &AppContext{Nc: nc, Client: cl, DefaultTimeout: time.Second}
+		DeferCleanup(appCtx.Close)
+
+		cmd := &topologyCommand{opts: &Options{Timeout: time.Second}, format: "json"}
+
+		var out bytes.Buffer
+		Expect(cmd.topologyWithClient(appCtx, &out)).To(Succeed())
+
+		var parsed topology
+		Expect(json.Unmarshal(out.Bytes(), &parsed)).To(Succeed())
+		Expect(parsed.Flows).To(HaveLen(1))
+		Expect(parsed.Flows[0].From).To(Equal("ingest"))
+		Expect(parsed.Flows[0].To).To(Equal("enrich"))
+	})
+})
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/choria-io/fisk"
+	"github.com/fatih/color"
+	"github.com/synadia-io/connect/convert"
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/runtime"
+	"github.com/synadia-io/connect/standalone"
+)
+
+type transformCommand struct {
+	opts *Options
+
+	file      string
+	input     string
+	expect    string
+	batch     bool
+	container bool
+	image     string
+}
+
+func ConfigureTransformCommand(parentCmd commandHost, opts *Options) {
+	c := &transformCommand{
+		opts: opts,
+	}
+
+	transformCmd := parentCmd.Command("transform", "Work with the transformer of a connector")
+
+	testCmd := transformCmd.Command("test", "Run the transformer of a connector on sample messages").Action(c.test)
+	testCmd.Flag("file", "The ConnectFile with the transformer").Short('f').Default("./ConnectFile").StringVar(&c.file)
+	testCmd.Flag("input", "JSONL file with the input messages").Required().StringVar(&c.input)
+	testCmd.Flag("expect", "JSONL file with the expected output messages").StringVar(&c.expect)
+	testCmd.Flag("batch", "Transform all input messages as a single batch, e.g. to combine them").BoolVar(&c.batch)
+	testCmd.Flag("container", "Run the transformer inside the runtime container, which transformers with mappings always do").BoolVar(&c.container)
+	testCmd.Flag("image", "Override the runtime image used with --container").StringVar(&c.image)
+}
+
+func (c *transformCommand) test(pc *fisk.ParseContext) error {
+	csp, _, err := fromFile(nil, c.file)
+	if err != nil {
+		return err
+	}
+
+	steps := convert.ConvertStepsFromSpec(csp.Steps)
+	if steps.Transformer == nil {
+		return fmt.Errorf("ConnectFile %q has no transformer", c.file)
+	}
+
+	inputs, err := readMessagesFile(c.input)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// mappings are evaluated by the runtime
+	container := c.container || hasMapping(*steps.Transformer)
+
+	var results []transformResult
+	if container {
+		image := c.image
+		if image == "" {
+			if image, err = standalone.NewRuntimeManager().ResolveRuntimeImage(csp.RuntimeId); err != nil {
+				return fmt.Errorf("failed to resolve runtime %q: %w", csp.RuntimeId, err)
+			}
+		}
+		if !c.container {
+			_, _ = fmt.Fprintf(os.Stderr, "Running the transformer in %s, as its mappings are evaluated by the runtime\n", image)
+		}
+		results, err = transformInContainer(ctx, docker.NewRunner(), image, *steps.Transformer, inputs, c.batch)
+	} else {
+		results, err = transformLocally(ctx, *steps.Transformer, inputs, c.batch)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	var outputs []*runtime.Message
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			_, _ = fmt.Fprintf(os.Stderr, "%s %s\n", color.RedString("input %s:", r.label()), r.Err)
+			continue
+		}
+		for _, out := range r.Outputs {
+			if err := writeMessage(os.Stdout, out); err != nil {
+				return err
+			}
+		}
+		outputs = append(outputs, r.Outputs...)
+	}
+
+	if c.expect != "" {
+		expected, err := readMessagesFile(c.expect)
+		if err != nil {
+			return err
+		}
+
+		if diffs := compareOutputs(expected, outputs); len(diffs) > 0 {
+			for _, d := range diffs {
+				_, _ = fmt.Fprintln(os.Stderr, d)
+			}
+			return fmt.Errorf("%d of the outputs do not match %s", len(diffs), c.expect)
+		}
+		color.Green("✓ %d outputs match %s", len(outputs), c.expect)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("the transformer failed on %d of %d inputs", failed, len(inputs))
+	}
+	return nil
+}
+
+func readMessagesFile(file string) ([]*runtime.Message, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", file, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	msgs, err := readMessages(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", file, err)
+	}
+	return msgs, nil
+}
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+	"github.com/synadia-io/connect/runtime/transform"
+)
+
+// transformResult holds the outputs of an input, or of all inputs when they were transformed together.
+type transformResult struct {
+	// Input is the index of the input, or -1 for all inputs
+	Input   int
+	Outputs []*runtime.Message
+	Err     error
+}
+
+func (r transformResult) label() string {
+	if r.Input < 0 {
+		return "all"
+	}
+	return fmt.Sprintf("%d", r.Input+1)
+}
+
+// transformLocally runs the transformer on each input, or on all inputs as a single batch. Mappings need an
+// evaluator among the options.
+func transformLocally(ctx context.Context, step model.TransformerStep, inputs []*runtime.Message, batch bool, opts ...transform.Option) ([]transformResult, error) {
+	t, err := transform.New(step, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = t.Close() }()
+
+	if batch {
+		outputs, err := t.Transform(ctx, inputs)
+		return []transformResult{{Input: -1, Outputs: outputs, Err: err}}, nil
+	}
+
+	results := make([]transformResult, 0, len(inputs))
+	for i, input := range inputs {
+		outputs, err := transform.Apply(ctx, t, input)
+		results = append(results, transformResult{Input: i, Outputs: outputs, Err: err})
+	}
+	return results, nil
+}
+
+func hasMapping(step model.TransformerStep) bool {
+	if step.Mapping != nil {
+		return true
+	}
+	if step.Composite != nil {
+		for _, s := range step.Composite.Sequential {
+			if hasMapping(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containerEnvelope is a message passed through the container as a line of JSON, with the index of its input. The
+// payload is base64 encoded, so any payload fits on a line.
+type containerEnvelope struct {
+	Input   *int              `json:"input"`
+	Subject string            `json:"subject,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Payload []byte            `json:"payload"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// containerInputMetadata carries the index of the input through the transformer in the container.
+const containerInputMetadata = "connect_transform_input"
+
+// unwrapEnvelope turns the envelope into the message the transformer sees, with the headers as metadata and the
+// subject as nats_subject like the nats input sets it.
+var unwrapEnvelope = strings.Join([]string{
+	`let envelope = this`,
+	`meta = $envelope.headers | {}`,
+	`meta nats_subject = $envelope.subject | ""`,
+	fmt.Sprintf(`meta %s = $envelope.input.string()`, containerInputMetadata),
+	`root = $envelope.payload.decode("base64")`,
+}, "\n")
+
+// wrapEnvelope turns the outputs of the transformer, or its errors, back into envelopes.
+var wrapEnvelope = strings.Join([]string{
+	fmt.Sprintf(`root.input = @%s.number().catch(null)`, containerInputMetadata),
+	`root.subject = @nats_subject`,
+	fmt.Sprintf(`root.headers = @.filter(kv -> kv.key != "%s" && kv.key != "nats_subject")`, containerInputMetadata),
+	`root.payload = content().encode("base64").string()`,
+	`root.error = if errored() { error() }`,
+}, "\n")
+
+// containerSteps wraps the transformer between stdin and stdout, so the runtime reads the envelopes of the inputs and
+// writes those of the outputs, one per line. Batches end with an empty line.
+func containerSteps(step model.TransformerStep, batch bool) model.Steps {
+	codec := "lines"
+	if batch {
+		codec = "lines/multipart"
+	}
+
+	return model.Steps{
+		Source: &model.SourceStep{Type: "stdin", Config: model.SourceStepConfig{"codec": codec}},
+		Transformer: &model.TransformerStep{Composite: &model.CompositeTransformerStep{Sequential: []model.TransformerStep{
+			{Mapping: &model.MappingTransformerStep{Sourcecode: unwrapEnvelope}},
+			step,
+			{Mapping: &model.MappingTransformerStep{Sourcecode: wrapEnvelope}},
+		}}},
+		Sink: &model.SinkStep{Type: "stdout", Config: model.SinkStepConfig{"codec": "lines"}},
+	}
+}
+
+// containerInput encodes the inputs as envelopes, one per line.
+func containerInput(inputs []*runtime.Message, batch bool) ([]byte, error) {
+	var b bytes.Buffer
+	for i, input := range inputs {
+		env := containerEnvelope{Input: &i, Subject: input.Subject, Payload: input.Data}
+		if len(input.Headers) > 0 {
+			env.Headers = map[string]string{}
+			for k := range input.Headers {
+				env.Headers[k] = input.Headers.Get(k)
+			}
+		}
+
+		line, err := json.Marshal(env)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	if batch {
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+// containerResults decodes the envelopes written by the container into the results of the inputs, or into a single
+// result for a batch. Outputs which lost the index of their input, because the transformer dropped its metadata, are
+// reported for all inputs.
+func containerResults(output []byte, inputs []*runtime.Message, batch bool) ([]transformResult, error) {
+	results := make([]transformResult, len(inputs))
+	for i := range results {
+		results[i].Input = i
+	}
+	if batch {
+		results = []transformResult{{Input: -1}}
+	}
+	var unknown *transformResult
+
+	for n, line := range bytes.Split(output, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var env containerEnvelope
+		if err := json.Unmarshal(line, &env); err != nil {
+			return nil, fmt.Errorf("invalid output line %d of the container: %w", n+1, err)
+		}
+
+		var r *transformResult
+		switch {
+		case batch:
+			r = &results[0]
+		case env.Input != nil && *env.Input >= 0 && *env.Input < len(inputs):
+			r = &results[*env.Input]
+		default:
+			if unknown == nil {
+				unknown = &transformResult{Input: -1}
+			}
+			r = unknown
+		}
+
+		if env.Error != "" {
+			r.Err = errors.Join(r.Err, errors.New(env.Error))
+			continue
+		}
+
+		msg := runtime.NewMessage(env.Subject, env.Payload)
+		for k, v := range env.Headers {
+			msg.Headers.Set(k, v)
+		}
+		r.Outputs = append(r.Outputs, msg)
+	}
+
+	if unknown != nil {
+		results = append(results, *unknown)
+	}
+	return results, nil
+}
+
+// transformInContainer runs the transformer in the runtime image, on each input or on all inputs as a single batch.
+// Headers hold a single value in the runtime, so only the first value of the headers of the inputs is passed.
+func transformInContainer(ctx context.Context, runner *docker.Runner, image string, step model.TransformerStep, inputs []*runtime.Message, batch bool) ([]transformResult, error) {
+	stdin, err := containerInput(inputs, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	err = runner.Attach(ctx, &docker.RunOptions{Image: image, Steps: containerSteps(step, batch)}, bytes.NewReader(stdin), &stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the transformer in %s: %w", image, err)
+	}
+
+	return containerResults(stdout.Bytes(), inputs, batch)
+}
+
+// compareOutputs compares the outputs with the expected messages in order. Payloads which are JSON are compared
+// as JSON; subjects and headers are only compared when the expected message has them.
+func compareOutputs(expected, actual []*runtime.Message) []string {
+	var diffs []string
+	for i := 0; i < max(len(expected), len(actual)); i++ {
+		switch {
+		case i >= len(actual):
+			diffs = append(diffs, fmt.Sprintf("output %d:\n- %s\n+ (missing)", i+1, describeMessage(expected[i])))
+		case i >= len(expected):
+			diffs = append(diffs, fmt.Sprintf("output %d:\n- (none)\n+ %s", i+1, describeMessage(actual[i])))
+		case !matchesMessage(expected[i], actual[i]):
+			diffs = append(diffs, fmt.Sprintf("output %d:\n- %s\n+ %s", i+1, describeMessage(expected[i]), describeMessage(actual[i])))
+		}
+	}
+	return diffs
+}
+
+func matchesMessage(expected, actual *runtime.Message) bool {
+	if expected.Subject != "" && expected.Subject != actual.Subject {
+		return false
+	}
+	for k, values := range expected.Headers {
+		if !reflect.DeepEqual(values, actual.Headers.Values(k)) {
+			return false
+		}
+	}
+	return samePayload(expected.Data, actual.Data)
+}
+
+func samePayload(a, b []byte) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) == nil && json.Unmarshal(b, &vb) == nil {
+		return reflect.DeepEqual(va, vb)
+	}
+	return bytes.Equal(a, b)
+}
+
+func describeMessage(msg *runtime.Message) string {
+	b, err := encodeMessage(msg)
+	if err != nil {
+		return string(msg.Data)
+	}
+	return string(b)
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+	"github.com/synadia-io/connect/runtime/transform"
+)
+
+func payloads(msgs []*runtime.Message) []string {
+	result := make([]string, len(msgs))
+	for i, msg := range msgs {
+		result[i] = string(msg.Data)
+	}
+	return result
+}
+
+func messages(payloads ...string) []*runtime.Message {
+	result := make([]*runtime.Message, len(payloads))
+	for i, p := range payloads {
+		result[i] = runtime.NewMessage("in", []byte(p))
+	}
+	return result
+}
+
+var _ = Describe("TransformCommand", func() {
+	Describe("transformLocally", func() {
+		It("should transform each input on its own", func() {
+			step := model.TransformerStep{Explode: &model.ExplodeTransformerStep{Format: "json_array"}}
+
+			results, err := transformLocally(context.Background(), step, messages(`[1,2]`, `{}`, `[3]`), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(3))
+
+			Expect(results[0].Outputs).To(HaveLen(2))
+			Expect(results[1].Err).To(HaveOccurred())
+			Expect(results[1].label()).To(Equal("2"))
+			Expect(string(results[2].Outputs[0].Data)).To(Equal("3"))
+		})
+
+		It("should transform all inputs as a batch", func() {
+			step := model.TransformerStep{Combine: &model.CombineTransformerStep{Format: "lines"}}
+
+			results, err := transformLocally(context.Background(), step, messages("a", "b"), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].label()).To(Equal("all"))
+			Expect(string(results[0].Outputs[0].Data)).To(Equal("a\nb"))
+		})
+
+		It("should evaluate mappings with the evaluator of the options", func() {
+			step := model.TransformerStep{Composite: &model.CompositeTransformerStep{Sequential: []model.TransformerStep{
+				{Mapping: &model.MappingTransformerStep{Sourcecode: "upper"}},
+			}}}
+			upper := transform.EvaluatorFunc(func(string) (transform.MappingFunc, error) {
+				return func(_ context.Context, msg *runtime.Message) (*runtime.Message, error) {
+					return runtime.NewMessage(msg.Subject, []byte(strings.ToUpper(string(msg.Data)))), nil
+				}, nil
+			})
+
+			results, err := transformLocally(context.Background(), step, messages("a"), false, transform.WithEvaluator(upper))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(results[0].Outputs[0].Data)).To(Equal("A"))
+			Expect(hasMapping(step)).To(BeTrue())
+		})
+	})
+
+	Describe("containerSteps", func() {
+		It("should wrap the transformer between stdin and stdout", func() {
+			step := model.TransformerStep{Mapping: &model.MappingTransformerStep{Sourcecode: "root = this"}}
+
+			steps := containerSteps(step, false)
+			Expect(steps.Source.Type).To(Equal("stdin"))
+			Expect(steps.Source.Config["codec"]).To(Equal("lines"))
+			Expect(steps.Sink.Type).To(Equal("stdout"))
+			Expect(steps.Consumer).To(BeNil())
+			Expect(steps.Producer).To(BeNil())
+
+			sequence := steps.Transformer.Composite.Sequential
+			Expect(sequence).To(HaveLen(3))
+			Expect(sequence[0].Mapping.Sourcecode).To(ContainSubstring(`decode("base64")`))
+			Expect(sequence[1]).To(Equal(step))
+			Expect(sequence[2].Mapping.Sourcecode).To(ContainSubstring(`encode("base64")`))
+
+			Expect(containerSteps(step, true).Source.Config["codec"]).To(Equal("lines/multipart"))
+		})
+	})
+
+	Describe("container envelopes", func() {
+		It("should pass the subjects, headers and payloads of the inputs", func() {
+			inputs := messages("a\nb", "c")
+			inputs[0].Headers.Set("Trace", "1")
+
+			stdin, err := containerInput(inputs, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stdin)).To(Equal(`{"input":0,"subject":"in","headers":{"Trace":"1"},"payload":"YQpi"}` + "\n" +
+				`{"input":1,"subject":"in","payload":"Yw=="}` + "\n"))
+
+			stdin, err = containerInput(inputs, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(stdin)).To(HaveSuffix("}\n\n"))
+		})
+
+		It("should keep the results of each input", func() {
+			stdout := `{"input":1,"subject":"out","headers":{"Trace":"1"},"payload":"WA=="}
+{"input":0,"subject":"in","headers":{},"payload":"YQ==","error":"mapping failed"}
+{"input":1,"subject":"out","payload":"WQ=="}
+{"input":null,"subject":"","payload":"Wg=="}
+`
+			results, err := containerResults([]byte(stdout), messages("a", "b", "c"), false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(4))
+
+			Expect(results[0].Err).To(MatchError("mapping failed"))
+			Expect(payloads(results[1].Outputs)).To(Equal([]string{"X", "Y"}))
+			Expect(results[1].Outputs[0].Subject).To(Equal("out"))
+			Expect(results[1].Outputs[0].Headers.Get("Trace")).To(Equal("1"))
+			Expect(results[2].Outputs).To(BeEmpty())
+			Expect(results[3].label()).To(Equal("all"))
+			Expect(payloads(results[3].Outputs)).To(Equal([]string{"Z"}))
+		})
+
+		It("should collect the results of a batch", func() {
+			results, err := containerResults([]byte(`{"input":0,"payload":"YWI="}`+"\n"), messages("a", "b"), true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].label()).To(Equal("all"))
+			Expect(payloads(results[0].Outputs)).To(Equal([]string{"ab"}))
+		})
+	})
+
+	Describe("compareOutputs", func() {
+		It("should compare json payloads as json", func() {
+			Expect(compareOutputs(messages(`{"a": 1, "b": 2}`), messages(`{"b":2,"a":1}`))).To(BeEmpty())
+		})
+
+		It("should only compare subjects and headers which are expected", func() {
+			expected := messages("a")
+			expected[0].Subject = ""
+			expected[0].Headers.Set("Trace", "1")
+
+			actual := messages("a")
+			actual[0].Headers.Set("Trace", "1")
+			actual[0].Headers.Set("Other", "x")
+			Expect(compareOutputs(expected, actual)).To(BeEmpty())
+
+			actual[0].Headers.Set("Trace", "2")
+			Expect(compareOutputs(expected, actual)).To(HaveLen(1))
+		})
+
+		It("should report differences, missing and extra outputs", func() {
+			diffs := compareOutputs(messages("a", "b"), messages("a", "c", "d"))
+			Expect(diffs).To(HaveLen(2))
+			Expect(diffs[0]).To(Equal("output 2:\n- {\"subject\":\"in\",\"payload\":\"b\"}\n+ {\"subject\":\"in\",\"payload\":\"c\"}"))
+			Expect(diffs[1]).To(ContainSubstring("- (none)"))
+
+			diffs = compareOutputs(messages("a", "b"), messages("a"))
+			Expect(diffs).To(HaveLen(1))
+			Expect(diffs[0]).To(ContainSubstring("+ (missing)"))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/google/shlex"
 )
@@ -49,3 +50,52 @@ func editFile(f string) error {
 
 	return nil
 }
+
+// hasWildcard reports whether the subject contains a * or > token.
+func hasWildcard(subject string) bool {
+	for _, token := range strings.Split(subject, ".") {
+		if token == "*" || token == ">" {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectMatches reports whether the subject matches the pattern, which may contain * and > wildcards.
+func subjectMatches(pattern string, subject string) bool {
+	pt := strings.Split(pattern, ".")
+	st := strings.Split(subject, ".")
+
+	for i, token := range pt {
+		if token == ">" {
+			return len(st) > i
+		}
+		if i >= len(st) || (token != "*" && token != st[i]) {
+			return false
+		}
+	}
+	return len(pt) == len(st)
+}
+
+// subjectsOverlap reports whether a message can be published to a subject matching both subjects, either of which
+// may contain * and > wildcards.
+func subjectsOverlap(a string, b string) bool {
+	if !hasWildcard(a) {
+		return subjectMatches(b, a)
+	}
+	if !hasWildcard(b) {
+		return subjectMatches(a, b)
+	}
+
+	at := strings.Split(a, ".")
+	bt := strings.Split(b, ".")
+	for i := 0; i < len(at) && i < len(bt); i++ {
+		if at[i] == ">" || bt[i] == ">" {
+			return true
+		}
+		if at[i] != "*" && bt[i] != "*" && at[i] != bt[i] {
+			return false
+		}
+	}
+	return len(at) == len(bt)
+}
@@ -91,4 +91,45 @@ var _ = Describe("Util", func() {
 		// Note: Testing successful execution would require mocking exec.Command
 		// which is beyond the scope of simple unit tests
 	})
+
+	Describe("subjectMatches", func() {
+		It("should match literal subjects", func() {
+			Expect(subjectMatches("orders.new", "orders.new")).To(BeTrue())
+			Expect(subjectMatches("orders.new", "orders.old")).To(BeFalse())
+			Expect(subjectMatches("orders", "orders.new")).To(BeFalse())
+		})
+
+		It("should match * against a single token", func() {
+			Expect(subjectMatches("orders.*", "orders.new")).To(BeTrue())
+			Expect(subjectMatches("orders.*", "orders.new.eu")).To(BeFalse())
+			Expect(subjectMatches("*.new", "orders.new")).To(BeTrue())
+		})
+
+		It("should match > against one or more tokens", func() {
+			Expect(subjectMatches("orders.>", "orders.new.eu")).To(BeTrue())
+			Expect(subjectMatches("orders.>", "orders")).To(BeFalse())
+			Expect(subjectMatches(">", "orders")).To(BeTrue())
+		})
+
+		It("should detect wildcards", func() {
+			Expect(hasWildcard("orders.*")).To(BeTrue())
+			Expect(hasWildcard("orders.>")).To(BeTrue())
+			Expect(hasWildcard("orders.new*")).To(BeFalse())
+		})
+	})
+
+	Describe("subjectsOverlap", func() {
+		It("should match literal subjects against patterns either way", func() {
+			Expect(subjectsOverlap("orders.new", "orders.*")).To(BeTrue())
+			Expect(subjectsOverlap("orders.>", "orders.new.eu")).To(BeTrue())
+			Expect(subjectsOverlap("orders.new", "orders.old")).To(BeFalse())
+		})
+
+		It("should overlap patterns matching a common subject", func() {
+			Expect(subjectsOverlap("orders.*.eu", "orders.new.*")).To(BeTrue())
+			Expect(subjectsOverlap("orders.>", "*.new")).To(BeTrue())
+			Expect(subjectsOverlap("orders.*", "payments.*")).To(BeFalse())
+			Expect(subjectsOverlap("orders.*", "orders.*.eu")).To(BeFalse())
+		})
+	})
 })
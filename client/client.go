@@ -1,6 +1,8 @@
 package client
 
 import (
+	"context"
+	"iter"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -13,6 +15,9 @@ type Client interface {
 	ConnectorClient
 	LibraryClient
 
+	ContextConnectorClient
+	ContextLibraryClient
+
 	Close()
 }
 
@@ -27,6 +32,7 @@ type ConnectorClient interface {
 	ListConnectorInstances(id string, timeout time.Duration) ([]model.Instance, error)
 	StartConnector(id string, startOpts *model.ConnectorStartOptions, timeout time.Duration) ([]model.Instance, error)
 	StopConnector(id string, timeout time.Duration) ([]model.Instance, error)
+	ScaleConnector(id string, replicas int, startOpts *model.ConnectorStartOptions, timeout time.Duration) ([]model.Instance, error)
 }
 
 type LibraryClient interface {
@@ -37,26 +43,82 @@ type LibraryClient interface {
 	GetComponent(runtimeId string, kind model.ComponentKind, id string, timeout time.Duration) (*model.Component, error)
 }
 
-func NewClient(nc *nats.Conn, trace bool) (Client, error) {
+// ContextConnectorClient provides the connector operations bound to a context. The request is abandoned as
+// soon as the context is cancelled or its deadline expires.
+type ContextConnectorClient interface {
+	ListConnectorsWithContext(ctx context.Context) ([]model.ConnectorSummary, error)
+	// IterConnectors yields the connectors as they are received from the service. Iteration stops after an
+	// error has been yielded.
+	IterConnectors(ctx context.Context) iter.Seq2[model.ConnectorSummary, error]
+	GetConnectorWithContext(ctx context.Context, id string) (*model.Connector, error)
+	GetConnectorStatusWithContext(ctx context.Context, id string) (*model.ConnectorStatus, error)
+	CreateConnectorWithContext(ctx context.Context, id, description, runtimeId string, steps model.Steps) (*model.Connector, error)
+	PatchConnectorWithContext(ctx context.Context, id string, patch string) (*model.Connector, error)
+	DeleteConnectorWithContext(ctx context.Context, id string) error
+
+	ListConnectorInstancesWithContext(ctx context.Context, id string) ([]model.Instance, error)
+	StartConnectorWithContext(ctx context.Context, id string, startOpts *model.ConnectorStartOptions) ([]model.Instance, error)
+	StopConnectorWithContext(ctx context.Context, id string) ([]model.Instance, error)
+	ScaleConnectorWithContext(ctx context.Context, id string, replicas int, startOpts *model.ConnectorStartOptions) ([]model.Instance, error)
+}
+
+// ContextLibraryClient provides the library operations bound to a context.
+type ContextLibraryClient interface {
+	ListRuntimesWithContext(ctx context.Context) ([]model.RuntimeSummary, error)
+	GetRuntimeWithContext(ctx context.Context, id string) (*model.Runtime, error)
+
+	SearchComponentsWithContext(ctx context.Context, filter *model.ComponentSearchFilter) ([]model.ComponentSummary, error)
+	// IterComponents yields the components matching the filter as they are received from the service.
+	// Iteration stops after an error has been yielded.
+	IterComponents(ctx context.Context, filter *model.ComponentSearchFilter) iter.Seq2[model.ComponentSummary, error]
+	GetComponentWithContext(ctx context.Context, runtimeId string, kind model.ComponentKind, id string) (*model.Component, error)
+}
+
+// ClientOpt configures optional behavior of a client.
+type ClientOpt func(opts *retryPolicies)
+
+// WithIdempotentRetry sets the retry policy for requests which only read state, like listing or getting
+// connectors. These requests are retried with DefaultRetryPolicy unless configured otherwise; passing nil
+// disables retries.
+func WithIdempotentRetry(policy *RetryPolicy) ClientOpt {
+	return func(opts *retryPolicies) {
+		opts.idempotent = policy
+	}
+}
+
+// WithMutatingRetry sets the retry policy for requests which change state, like creating or starting
+// connectors. These requests are not retried unless a policy is given.
+func WithMutatingRetry(policy *RetryPolicy) ClientOpt {
+	return func(opts *retryPolicies) {
+		opts.mutating = policy
+	}
+}
+
+func NewClient(nc *nats.Conn, trace bool, opts ...ClientOpt) (Client, error) {
 	t, err := NewTransport(nc, trace)
 	if err != nil {
 		return nil, err
 	}
 
-	return &client{
-		t:               t,
-		connectorClient: connectorClient{t: t},
-		libraryClient:   libraryClient{t: t},
-	}, nil
+	return newClient(t, opts...), nil
 }
 
-func NewClientForAccount(nc *nats.Conn, account string, trace bool) Client {
-	t := NewTransportForAccount(nc, account, trace)
+func NewClientForAccount(nc *nats.Conn, account string, trace bool, opts ...ClientOpt) Client {
+	return newClient(NewTransportForAccount(nc, account, trace), opts...)
+}
+
+func newClient(t *Transport, opts ...ClientOpt) *client {
+	policies := retryPolicies{
+		idempotent: DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(&policies)
+	}
 
 	return &client{
 		t:               t,
-		connectorClient: connectorClient{t: t},
-		libraryClient:   libraryClient{t: t},
+		connectorClient: connectorClient{t: t, retryPolicies: policies},
+		libraryClient:   libraryClient{t: t, retryPolicies: policies},
 	}
 }
 
@@ -73,3 +135,22 @@ func (c *client) Account() string {
 func (c *client) Close() {
 	c.t.Close()
 }
+
+// withTimeout derives a context from the given timeout for the timeout based client methods.
+func withTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// retryPolicies holds the retry policies of a client by kind of request.
+type retryPolicies struct {
+	idempotent *RetryPolicy
+	mutating   *RetryPolicy
+}
+
+func (p retryPolicies) idempotentRetry() Opt {
+	return WithRetry(p.idempotent)
+}
+
+func (p retryPolicies) mutatingRetry() Opt {
+	return WithRetry(p.mutating)
+}
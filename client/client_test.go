@@ -43,6 +43,14 @@ var _ = Describe("Client", func() {
 			var _ LibraryClient = client
 		})
 
+		It("should implement ContextConnectorClient interface", func() {
+			var _ ContextConnectorClient = client
+		})
+
+		It("should implement ContextLibraryClient interface", func() {
+			var _ ContextLibraryClient = client
+		})
+
 		It("should return account name", func() {
 			Expect(client.Account()).To(Equal("test-account"))
 		})
@@ -1,16 +1,21 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/synadia-io/connect/model"
 )
 
 type connectorClient struct {
 	t *Transport
+	retryPolicies
 }
 
 func (c *connectorClient) subject(suffix string) string {
@@ -18,19 +23,21 @@ func (c *connectorClient) subject(suffix string) string {
 }
 
 func (c *connectorClient) ListConnectors(timeout time.Duration) ([]model.ConnectorSummary, error) {
-	req := model.ConnectorListRequest{}
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
 
-	var resp model.ConnectorListResponse
-	gotResponse, err := c.t.RequestJson(c.subject("LIST"), req, &resp, WithTimeout(timeout))
-	if err != nil {
-		return nil, fmt.Errorf("unable to list connectors: %v", err)
-	}
+	return c.ListConnectorsWithContext(ctx)
+}
 
-	if !gotResponse {
-		return []model.ConnectorSummary{}, nil
+func (c *connectorClient) ListConnectorsWithContext(ctx context.Context) ([]model.ConnectorSummary, error) {
+	result := []model.ConnectorSummary{}
+	for connector, err := range c.IterConnectors(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, connector)
 	}
 
-	result := resp.Connectors
 	slices.SortFunc(result, func(a, b model.ConnectorSummary) int {
 		return strings.Compare(a.ConnectorId, b.ConnectorId)
 	})
@@ -38,15 +45,30 @@ func (c *connectorClient) ListConnectors(timeout time.Duration) ([]model.Connect
 	return result, nil
 }
 
+func (c *connectorClient) IterConnectors(ctx context.Context) iter.Seq2[model.ConnectorSummary, error] {
+	req := model.ConnectorListRequest{}
+
+	return iterList(ctx, c.t, c.subject("LIST"), req, func(page model.ConnectorListResponse) []model.ConnectorSummary {
+		return page.Connectors
+	}, "unable to list connectors", c.idempotentRetry())
+}
+
 func (c *connectorClient) GetConnector(name string, timeout time.Duration) (*model.Connector, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.GetConnectorWithContext(ctx, name)
+}
+
+func (c *connectorClient) GetConnectorWithContext(ctx context.Context, name string) (*model.Connector, error) {
 	req := model.ConnectorGetRequest{
 		Id: name,
 	}
 
 	var resp model.ConnectorGetResponse
-	gotResponse, err := c.t.RequestJson(c.subject("GET"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject("GET"), req, &resp, c.idempotentRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to get connector: %v", err)
+		return nil, fmt.Errorf("unable to get connector: %w", err)
 	}
 
 	if !gotResponse {
@@ -57,14 +79,21 @@ func (c *connectorClient) GetConnector(name string, timeout time.Duration) (*mod
 }
 
 func (c *connectorClient) GetConnectorStatus(name string, timeout time.Duration) (*model.ConnectorStatus, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.GetConnectorStatusWithContext(ctx, name)
+}
+
+func (c *connectorClient) GetConnectorStatusWithContext(ctx context.Context, name string) (*model.ConnectorStatus, error) {
 	req := model.ConnectorStatusRequest{
 		ConnectorId: name,
 	}
 
 	var resp model.ConnectorStatusResponse
-	gotResponse, err := c.t.RequestJson(c.subject("STATUS"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject("STATUS"), req, &resp, c.idempotentRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to get connector status: %v", err)
+		return nil, fmt.Errorf("unable to get connector status: %w", err)
 	}
 
 	if !gotResponse {
@@ -75,6 +104,13 @@ func (c *connectorClient) GetConnectorStatus(name string, timeout time.Duration)
 }
 
 func (c *connectorClient) CreateConnector(id, description, runtimeId string, steps model.Steps, timeout time.Duration) (*model.Connector, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.CreateConnectorWithContext(ctx, id, description, runtimeId, steps)
+}
+
+func (c *connectorClient) CreateConnectorWithContext(ctx context.Context, id, description, runtimeId string, steps model.Steps) (*model.Connector, error) {
 	req := model.ConnectorCreateRequest{
 		Id:          id,
 		Description: description,
@@ -83,9 +119,9 @@ func (c *connectorClient) CreateConnector(id, description, runtimeId string, ste
 	}
 
 	var resp model.ConnectorCreateResponse
-	gotResponse, err := c.t.RequestJson(c.subject("CREATE"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject("CREATE"), req, &resp, c.mutatingRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to create connector: %v", err)
+		return nil, fmt.Errorf("unable to create connector: %w", err)
 	}
 
 	if !gotResponse {
@@ -96,15 +132,22 @@ func (c *connectorClient) CreateConnector(id, description, runtimeId string, ste
 }
 
 func (c *connectorClient) PatchConnector(id string, patch string, timeout time.Duration) (*model.Connector, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.PatchConnectorWithContext(ctx, id, patch)
+}
+
+func (c *connectorClient) PatchConnectorWithContext(ctx context.Context, id string, patch string) (*model.Connector, error) {
 	req := model.ConnectorPatchRequest{
 		ConnectorId: id,
 		Patch:       patch,
 	}
 
 	var resp model.ConnectorPatchResponse
-	gotResponse, err := c.t.RequestJson(c.subject("PATCH"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject("PATCH"), req, &resp, c.mutatingRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to patch connector: %v", err)
+		return nil, fmt.Errorf("unable to patch connector: %w", err)
 	}
 
 	if !gotResponse {
@@ -115,28 +158,42 @@ func (c *connectorClient) PatchConnector(id string, patch string, timeout time.D
 }
 
 func (c *connectorClient) DeleteConnector(id string, timeout time.Duration) error {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.DeleteConnectorWithContext(ctx, id)
+}
+
+func (c *connectorClient) DeleteConnectorWithContext(ctx context.Context, id string) error {
 	req := model.ConnectorDeleteRequest{
 		Id: id,
 	}
 
 	var resp model.ConnectorDeleteResponse
-	_, err := c.t.RequestJson(c.subject("DELETE"), req, &resp, WithTimeout(timeout))
+	_, err := c.t.RequestJsonWithContext(ctx, c.subject("DELETE"), req, &resp, c.mutatingRetry())
 	if err != nil {
-		return fmt.Errorf("unable to delete connector: %v", err)
+		return fmt.Errorf("unable to delete connector: %w", err)
 	}
 
 	return nil
 }
 
 func (c *connectorClient) ListConnectorInstances(id string, timeout time.Duration) ([]model.Instance, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.ListConnectorInstancesWithContext(ctx, id)
+}
+
+func (c *connectorClient) ListConnectorInstancesWithContext(ctx context.Context, id string) ([]model.Instance, error) {
 	req := model.ConnectorInstancesRequest{
 		ConnectorId: &id,
 	}
 
 	var resp model.ConnectorInstancesResponse
-	gotResponse, err := c.t.RequestJson(c.subject("INSTANCES"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject("INSTANCES"), req, &resp, c.idempotentRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to list connector instances: %v", err)
+		return nil, fmt.Errorf("unable to list connector instances: %w", err)
 	}
 
 	if !gotResponse {
@@ -147,15 +204,22 @@ func (c *connectorClient) ListConnectorInstances(id string, timeout time.Duratio
 }
 
 func (c *connectorClient) StartConnector(id string, startOpts *model.ConnectorStartOptions, timeout time.Duration) ([]model.Instance, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.StartConnectorWithContext(ctx, id, startOpts)
+}
+
+func (c *connectorClient) StartConnectorWithContext(ctx context.Context, id string, startOpts *model.ConnectorStartOptions) ([]model.Instance, error) {
 	req := model.ConnectorStartRequest{
 		ConnectorId: id,
 		Options:     startOpts,
 	}
 
 	var resp model.ConnectorStartResponse
-	hasResponded, err := c.t.RequestJson(c.subject("START"), req, &resp, WithTimeout(timeout))
+	hasResponded, err := c.t.RequestJsonWithContext(ctx, c.subject("START"), req, &resp, c.mutatingRetry())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unable to start connector: %w", err)
 	}
 
 	if !hasResponded {
@@ -166,14 +230,21 @@ func (c *connectorClient) StartConnector(id string, startOpts *model.ConnectorSt
 }
 
 func (c *connectorClient) StopConnector(id string, timeout time.Duration) ([]model.Instance, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.StopConnectorWithContext(ctx, id)
+}
+
+func (c *connectorClient) StopConnectorWithContext(ctx context.Context, id string) ([]model.Instance, error) {
 	req := model.ConnectorStopRequest{
 		ConnectorId: id,
 	}
 
 	var resp model.ConnectorStopResponse
-	hasResponded, err := c.t.RequestJson(c.subject("STOP"), req, &resp, WithTimeout(timeout))
+	hasResponded, err := c.t.RequestJsonWithContext(ctx, c.subject("STOP"), req, &resp, c.mutatingRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to stop connector: %v", err)
+		return nil, fmt.Errorf("unable to stop connector: %w", err)
 	}
 
 	if !hasResponded {
@@ -182,3 +253,70 @@ func (c *connectorClient) StopConnector(id string, timeout time.Duration) ([]mod
 
 	return resp.Instances, nil
 }
+
+func (c *connectorClient) ScaleConnector(id string, replicas int, startOpts *model.ConnectorStartOptions, timeout time.Duration) ([]model.Instance, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.ScaleConnectorWithContext(ctx, id, replicas, startOpts)
+}
+
+// ScaleConnectorWithContext changes the number of running instances of a connector to the given number of
+// replicas. The SCALE endpoint is used when the service provides it. Otherwise the missing instances are started,
+// and scaling down is only possible to zero, as the service can only stop all instances of a connector.
+func (c *connectorClient) ScaleConnectorWithContext(ctx context.Context, id string, replicas int, startOpts *model.ConnectorStartOptions) ([]model.Instance, error) {
+	if replicas < 0 {
+		return nil, fmt.Errorf("unable to scale connector: replicas must not be negative")
+	}
+
+	req := model.ConnectorScaleRequest{
+		ConnectorId: id,
+		Replicas:    replicas,
+		Options:     startOpts,
+	}
+
+	var resp model.ConnectorScaleResponse
+	hasResponded, err := c.t.RequestJsonWithContext(ctx, c.subject("SCALE"), req, &resp, c.mutatingRetry())
+	if err != nil {
+		if errors.Is(err, nats.ErrNoResponders) {
+			return c.scaleByDelta(ctx, id, replicas, startOpts)
+		}
+
+		return nil, fmt.Errorf("unable to scale connector: %w", err)
+	}
+
+	if !hasResponded {
+		return nil, nil
+	}
+
+	return resp.Instances, nil
+}
+
+func (c *connectorClient) scaleByDelta(ctx context.Context, id string, replicas int, startOpts *model.ConnectorStartOptions) ([]model.Instance, error) {
+	instances, err := c.ListConnectorInstancesWithContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current := len(instances)
+	switch {
+	case replicas > current:
+		opts := model.ConnectorStartOptions{}
+		if startOpts != nil {
+			opts = *startOpts
+		}
+		opts.Replicas = replicas - current
+
+		if _, err := c.StartConnectorWithContext(ctx, id, &opts); err != nil {
+			return nil, fmt.Errorf("unable to scale connector: %w", err)
+		}
+	case replicas == 0 && current > 0:
+		if _, err := c.StopConnectorWithContext(ctx, id); err != nil {
+			return nil, fmt.Errorf("unable to scale connector: %w", err)
+		}
+	case replicas < current:
+		return nil, fmt.Errorf("unable to scale connector from %d to %d instances: the service has no SCALE endpoint and can only stop all instances, scale to 0 or stop the connector instead", current, replicas)
+	}
+
+	return c.ListConnectorInstancesWithContext(ctx, id)
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("ConnectorClient", func() {
+	var (
+		srv *server.Server
+		nc  *nats.Conn
+		cl  Client
+	)
+
+	BeforeEach(func() {
+		var err error
+		srv, err = server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+		Expect(err).ToNot(HaveOccurred())
+		go srv.Start()
+		Expect(srv.ReadyForConnections(5 * time.Second)).To(BeTrue())
+
+		nc, err = nats.Connect(srv.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		cl = NewClientForAccount(nc, "test-account", false)
+	})
+
+	AfterEach(func() {
+		nc.Close()
+		srv.Shutdown()
+	})
+
+	// respondWithPages answers list requests with one message per page, flagging all but the last one with the
+	// has-more header.
+	respondWithPages := func(pages ...[]string) {
+		_, err := nc.Subscribe("$CONSVC.test-account.CONNECTORS.LIST", func(msg *nats.Msg) {
+			for i, page := range pages {
+				resp := model.ConnectorListResponse{Connectors: []model.ConnectorSummary{}}
+				for _, id := range page {
+					resp.Connectors = append(resp.Connectors, model.ConnectorSummary{ConnectorId: id, RuntimeId: "wombat"})
+				}
+
+				b, err := json.Marshal(resp)
+				Expect(err).ToNot(HaveOccurred())
+
+				reply := nats.NewMsg(msg.Reply)
+				reply.Data = b
+				if i < len(pages)-1 {
+					reply.Header.Set(HasMoreHeader, "true")
+				}
+				Expect(nc.PublishMsg(reply)).To(Succeed())
+			}
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Describe("ListConnectors", func() {
+		It("should collect and sort the connectors of all pages", func() {
+			respondWithPages([]string{"c", "a"}, []string{"d"}, []string{"b"})
+
+			connectors, err := cl.ListConnectors(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			var ids []string
+			for _, c := range connectors {
+				ids = append(ids, c.ConnectorId)
+			}
+			Expect(ids).To(Equal([]string{"a", "b", "c", "d"}))
+		})
+
+		It("should return the error of the service", func() {
+			_, err := nc.Subscribe("$CONSVC.test-account.CONNECTORS.LIST", func(msg *nats.Msg) {
+				reply := nats.NewMsg(msg.Reply)
+				reply.Header.Set(ServiceErrorHeader, "not allowed")
+				reply.Header.Set(ServiceErrorCodeHeader, "403")
+				_ = msg.RespondMsg(reply)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = cl.ListConnectors(time.Second)
+			Expect(errors.Is(err, ErrForbidden)).To(BeTrue())
+		})
+	})
+
+	Describe("IterConnectors", func() {
+		It("should yield the connectors in the order they are received", func() {
+			respondWithPages([]string{"c", "a"}, []string{"b"})
+
+			var ids []string
+			for c, err := range cl.IterConnectors(ctxWithTimeout(time.Second)) {
+				Expect(err).ToNot(HaveOccurred())
+				ids = append(ids, c.ConnectorId)
+			}
+			Expect(ids).To(Equal([]string{"c", "a", "b"}))
+		})
+
+		It("should stop when the caller stops iterating", func() {
+			respondWithPages([]string{"a", "b"}, []string{"c"})
+
+			var ids []string
+			for c, err := range cl.IterConnectors(ctxWithTimeout(time.Second)) {
+				Expect(err).ToNot(HaveOccurred())
+				ids = append(ids, c.ConnectorId)
+				break
+			}
+			Expect(ids).To(Equal([]string{"a"}))
+		})
+
+		It("should yield an error when no page arrives in time", func() {
+			_, err := nc.Subscribe("$CONSVC.test-account.CONNECTORS.LIST", func(msg *nats.Msg) {})
+			Expect(err).ToNot(HaveOccurred())
+
+			var errs []error
+			for _, err := range cl.IterConnectors(ctxWithTimeout(200 * time.Millisecond)) {
+				errs = append(errs, err)
+			}
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0]).To(HaveOccurred())
+		})
+	})
+
+	Describe("ScaleConnector", func() {
+		// serveWithoutScale answers instance, start and stop requests like a service without the SCALE endpoint.
+		serveWithoutScale := func(running int) *[]model.ConnectorStartOptions {
+			var starts []model.ConnectorStartOptions
+			instances := func() []model.Instance {
+				result := make([]model.Instance, running)
+				for i := range result {
+					result[i] = model.Instance{ConnectorId: "test", Id: fmt.Sprintf("%d", i)}
+				}
+				return result
+			}
+			respond := func(msg *nats.Msg, resp any) {
+				b, err := json.Marshal(resp)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(msg.Respond(b)).To(Succeed())
+			}
+
+			_, err := nc.Subscribe("$CONSVC.test-account.CONNECTORS.INSTANCES", func(msg *nats.Msg) {
+				respond(msg, model.ConnectorInstancesResponse{Instances: instances()})
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = nc.Subscribe("$CONSVC.test-account.CONNECTORS.STOP", func(msg *nats.Msg) {
+				stopped := instances()
+				running = 0
+				respond(msg, model.ConnectorStopResponse{Instances: stopped})
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = nc.Subscribe("$CONSVC.test-account.CONNECTORS.START", func(msg *nats.Msg) {
+				var req model.ConnectorStartRequest
+				Expect(json.Unmarshal(msg.Data, &req)).To(Succeed())
+				starts = append(starts, *req.Options)
+				running += req.Options.Replicas
+				respond(msg, model.ConnectorStartResponse{Instances: instances()})
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.Flush()).To(Succeed())
+
+			return &starts
+		}
+
+		It("should start the missing instances without the SCALE endpoint", func() {
+			starts := serveWithoutScale(1)
+
+			instances, err := cl.ScaleConnector("test", 3, &model.ConnectorStartOptions{Pull: true}, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(3))
+			Expect(*starts).To(HaveLen(1))
+			Expect((*starts)[0].Replicas).To(Equal(2))
+			Expect((*starts)[0].Pull).To(BeTrue())
+		})
+
+		It("should refuse to scale down to some instances without the SCALE endpoint", func() {
+			starts := serveWithoutScale(3)
+
+			_, err := cl.ScaleConnector("test", 1, nil, time.Second)
+			Expect(err).To(MatchError(ContainSubstring("no SCALE endpoint")))
+			Expect(*starts).To(BeEmpty())
+
+			instances, err := cl.ListConnectorInstances("test", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(3))
+		})
+
+		It("should stop all instances to scale down to zero without the SCALE endpoint", func() {
+			starts := serveWithoutScale(3)
+
+			instances, err := cl.ScaleConnector("test", 0, nil, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(BeEmpty())
+			Expect(*starts).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	ServiceErrorHeader     = "Nats-Service-Error"
+	ServiceErrorCodeHeader = "Nats-Service-Error-Code"
+)
+
+var (
+	ErrBadRequest    = errors.New("bad request")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrUnavailable   = errors.New("service unavailable")
+)
+
+// codeErrors maps the service error codes to the sentinel errors they match.
+var codeErrors = map[int]error{
+	400: ErrBadRequest,
+	401: ErrUnauthorized,
+	403: ErrForbidden,
+	404: ErrNotFound,
+	409: ErrAlreadyExists,
+	503: ErrUnavailable,
+}
+
+// ServiceError is returned when the service responded with an error. Use errors.Is with one of the sentinel
+// errors to check for a specific kind of failure, or errors.As to get hold of the code and description.
+type ServiceError struct {
+	Code        int
+	Description string
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Description, e.Code)
+}
+
+// Is reports whether the service error matches the given sentinel error.
+func (e *ServiceError) Is(target error) bool {
+	sentinel, ok := codeErrors[e.Code]
+	return ok && sentinel == target
+}
+
+// serviceErrorFromMsg returns the service error carried in the headers of the message, or nil if the message
+// does not carry one.
+func serviceErrorFromMsg(msg *nats.Msg) error {
+	description := msg.Header.Get(ServiceErrorHeader)
+	if description == "" {
+		return nil
+	}
+
+	code, _ := strconv.Atoi(msg.Header.Get(ServiceErrorCodeHeader))
+	return &ServiceError{
+		Code:        code,
+		Description: description,
+	}
+}
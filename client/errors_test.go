@@ -0,0 +1,70 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServiceError", func() {
+	It("should include the description and code in the message", func() {
+		err := &ServiceError{Code: 404, Description: "connector not found"}
+		Expect(err.Error()).To(Equal("connector not found (404)"))
+	})
+
+	DescribeTable("should match the sentinel error for its code",
+		func(code int, sentinel error) {
+			var err error = &ServiceError{Code: code, Description: "failed"}
+			Expect(errors.Is(err, sentinel)).To(BeTrue())
+		},
+		Entry("bad request", 400, ErrBadRequest),
+		Entry("unauthorized", 401, ErrUnauthorized),
+		Entry("forbidden", 403, ErrForbidden),
+		Entry("not found", 404, ErrNotFound),
+		Entry("already exists", 409, ErrAlreadyExists),
+		Entry("unavailable", 503, ErrUnavailable),
+	)
+
+	It("should not match the sentinel error of another code", func() {
+		var err error = &ServiceError{Code: 409, Description: "connector exists"}
+		Expect(errors.Is(err, ErrNotFound)).To(BeFalse())
+	})
+
+	It("should be found through wrapped errors", func() {
+		err := fmt.Errorf("unable to get connector: %w", &ServiceError{Code: 404, Description: "connector not found"})
+		Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+
+		var se *ServiceError
+		Expect(errors.As(err, &se)).To(BeTrue())
+		Expect(se.Code).To(Equal(404))
+		Expect(se.Description).To(Equal("connector not found"))
+	})
+
+	Describe("serviceErrorFromMsg", func() {
+		It("should return nil when the message carries no error", func() {
+			msg := nats.NewMsg("test")
+			Expect(serviceErrorFromMsg(msg)).To(BeNil())
+		})
+
+		It("should parse the error headers", func() {
+			msg := nats.NewMsg("test")
+			msg.Header.Set(ServiceErrorHeader, "connector exists")
+			msg.Header.Set(ServiceErrorCodeHeader, "409")
+
+			err := serviceErrorFromMsg(msg)
+			Expect(err).To(Equal(&ServiceError{Code: 409, Description: "connector exists"}))
+			Expect(errors.Is(err, ErrAlreadyExists)).To(BeTrue())
+		})
+
+		It("should tolerate a missing or invalid code", func() {
+			msg := nats.NewMsg("test")
+			msg.Header.Set(ServiceErrorHeader, "boom")
+			msg.Header.Set(ServiceErrorCodeHeader, "abc")
+
+			Expect(serviceErrorFromMsg(msg)).To(Equal(&ServiceError{Code: 0, Description: "boom"}))
+		})
+	})
+})
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/synadia-io/connect/model"
@@ -16,6 +18,7 @@ const (
 
 type libraryClient struct {
 	t *Transport
+	retryPolicies
 }
 
 func (c *libraryClient) subject(kind libraryEntityKind, suffix string) string {
@@ -23,11 +26,18 @@ func (c *libraryClient) subject(kind libraryEntityKind, suffix string) string {
 }
 
 func (c *libraryClient) ListRuntimes(timeout time.Duration) ([]model.RuntimeSummary, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.ListRuntimesWithContext(ctx)
+}
+
+func (c *libraryClient) ListRuntimesWithContext(ctx context.Context) ([]model.RuntimeSummary, error) {
 	req := model.RuntimeListRequest{}
 	var resp model.RuntimeListResponse
-	gotResponse, err := c.t.RequestJson(c.subject(runtimes, "LIST"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject(runtimes, "LIST"), req, &resp, c.idempotentRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to list runtimes: %v", err)
+		return nil, fmt.Errorf("unable to list runtimes: %w", err)
 	}
 
 	if !gotResponse {
@@ -38,13 +48,20 @@ func (c *libraryClient) ListRuntimes(timeout time.Duration) ([]model.RuntimeSumm
 }
 
 func (c *libraryClient) GetRuntime(id string, timeout time.Duration) (*model.Runtime, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.GetRuntimeWithContext(ctx, id)
+}
+
+func (c *libraryClient) GetRuntimeWithContext(ctx context.Context, id string) (*model.Runtime, error) {
 	req := model.RuntimeGetRequest{
 		Name: id,
 	}
 	var resp model.RuntimeGetResponse
-	gotResponse, err := c.t.RequestJson(c.subject(runtimes, "GET"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject(runtimes, "GET"), req, &resp, c.idempotentRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to get runtime: %v", err)
+		return nil, fmt.Errorf("unable to get runtime: %w", err)
 	}
 
 	if !gotResponse {
@@ -55,24 +72,42 @@ func (c *libraryClient) GetRuntime(id string, timeout time.Duration) (*model.Run
 }
 
 func (c *libraryClient) SearchComponents(filter *model.ComponentSearchFilter, timeout time.Duration) ([]model.ComponentSummary, error) {
-	req := model.ComponentSearchRequest{
-		Filter: filter,
-	}
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
 
-	var resp model.ComponentSearchResponse
-	gotResponse, err := c.t.RequestJson(c.subject(components, "LIST"), req, &resp, WithTimeout(timeout))
-	if err != nil {
-		return nil, fmt.Errorf("unable to search components: %v", err)
+	return c.SearchComponentsWithContext(ctx, filter)
+}
+
+func (c *libraryClient) SearchComponentsWithContext(ctx context.Context, filter *model.ComponentSearchFilter) ([]model.ComponentSummary, error) {
+	result := []model.ComponentSummary{}
+	for component, err := range c.IterComponents(ctx, filter) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, component)
 	}
 
-	if !gotResponse {
-		return []model.ComponentSummary{}, nil
+	return result, nil
+}
+
+func (c *libraryClient) IterComponents(ctx context.Context, filter *model.ComponentSearchFilter) iter.Seq2[model.ComponentSummary, error] {
+	req := model.ComponentSearchRequest{
+		Filter: filter,
 	}
 
-	return resp.Components, nil
+	return iterList(ctx, c.t, c.subject(components, "LIST"), req, func(page model.ComponentSearchResponse) []model.ComponentSummary {
+		return page.Components
+	}, "unable to search components", c.idempotentRetry())
 }
 
 func (c *libraryClient) GetComponent(runtimeId string, kind model.ComponentKind, id string, timeout time.Duration) (*model.Component, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	return c.GetComponentWithContext(ctx, runtimeId, kind, id)
+}
+
+func (c *libraryClient) GetComponentWithContext(ctx context.Context, runtimeId string, kind model.ComponentKind, id string) (*model.Component, error) {
 	req := model.ComponentGetRequest{
 		RuntimeId: runtimeId,
 		Kind:      kind,
@@ -80,9 +115,9 @@ func (c *libraryClient) GetComponent(runtimeId string, kind model.ComponentKind,
 	}
 
 	var resp model.ComponentGetResponse
-	gotResponse, err := c.t.RequestJson(c.subject(components, "GET"), req, &resp, WithTimeout(timeout))
+	gotResponse, err := c.t.RequestJsonWithContext(ctx, c.subject(components, "GET"), req, &resp, c.idempotentRetry())
 	if err != nil {
-		return nil, fmt.Errorf("unable to get component: %v", err)
+		return nil, fmt.Errorf("unable to get component: %w", err)
 	}
 
 	if !gotResponse {
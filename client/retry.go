@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// RetryPolicy describes how often and how fast a failed request is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. A value of 1 or less disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the time to wait before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the time to wait between two attempts.
+	MaxBackoff time.Duration
+
+	// Multiplier is applied to the backoff after every attempt.
+	Multiplier float64
+
+	// Jitter randomizes the backoff by up to the given fraction, e.g. 0.2 for +/- 20%.
+	Jitter float64
+
+	// AttemptTimeout bounds a single attempt. When zero, each attempt may take the remaining time until the
+	// deadline of the context, or the request timeout if the context has no deadline. Requests timing out are then
+	// only retried without a deadline, so a slow service is not sent the same request again.
+	AttemptTimeout time.Duration
+
+	// Retryable decides whether a failed attempt is retried. IsRetryable is used when nil.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy returns the policy used for idempotent requests unless configured otherwise.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// IsRetryable reports whether the error is transient: there were no responders for the request, the request
+// timed out or the service reported itself as unavailable.
+func IsRetryable(err error) bool {
+	return errors.Is(err, nats.ErrNoResponders) ||
+		errors.Is(err, nats.ErrTimeout) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrUnavailable)
+}
+
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// backoff returns the time to wait after the given (1-based) attempt failed.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= max(p.Multiplier, 1)
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, float64(p.MaxBackoff))
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// attemptContext derives the context for a single attempt. An attempt gets the whole time left until the deadline
+// of the parent context, or the request timeout without a deadline, unless the policy bounds its attempts.
+func (p *RetryPolicy) attemptContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if p != nil && p.AttemptTimeout > 0 {
+		return context.WithTimeout(ctx, p.AttemptTimeout)
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// retry calls fn until it succeeds, fails with an error that is not retryable, the attempts are exhausted or
+// the context is done. Each call receives the context for that attempt.
+func (p *RetryPolicy) retry(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	attempts := p.attempts()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := p.attemptContext(ctx, timeout)
+		err = fn(attemptCtx)
+		cancel()
+
+		if err == nil || attempt >= attempts || ctx.Err() != nil || !p.retryable(err) {
+			return err
+		}
+
+		wait := p.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	Describe("backoff", func() {
+		It("should grow exponentially up to the maximum", func() {
+			p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+			Expect(p.backoff(1)).To(Equal(100 * time.Millisecond))
+			Expect(p.backoff(2)).To(Equal(200 * time.Millisecond))
+			Expect(p.backoff(3)).To(Equal(400 * time.Millisecond))
+			Expect(p.backoff(5)).To(Equal(time.Second))
+		})
+
+		It("should stay within the jitter bounds", func() {
+			p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.5}
+			for i := 0; i < 100; i++ {
+				Expect(p.backoff(1)).To(BeNumerically(">=", 50*time.Millisecond))
+				Expect(p.backoff(1)).To(BeNumerically("<=", 150*time.Millisecond))
+			}
+		})
+	})
+
+	Describe("IsRetryable", func() {
+		It("should retry transient errors", func() {
+			Expect(IsRetryable(nats.ErrNoResponders)).To(BeTrue())
+			Expect(IsRetryable(nats.ErrTimeout)).To(BeTrue())
+			Expect(IsRetryable(context.DeadlineExceeded)).To(BeTrue())
+			Expect(IsRetryable(&ServiceError{Code: 503, Description: "unavailable"})).To(BeTrue())
+		})
+
+		It("should not retry other errors", func() {
+			Expect(IsRetryable(&ServiceError{Code: 404, Description: "not found"})).To(BeFalse())
+			Expect(IsRetryable(context.Canceled)).To(BeFalse())
+			Expect(IsRetryable(errors.New("boom"))).To(BeFalse())
+		})
+	})
+
+	Describe("with a server", func() {
+		var (
+			srv       *server.Server
+			nc        *nats.Conn
+			transport *Transport
+			policy    *RetryPolicy
+		)
+
+		BeforeEach(func() {
+			var err error
+			srv, err = server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+			Expect(err).ToNot(HaveOccurred())
+			go srv.Start()
+			Expect(srv.ReadyForConnections(5 * time.Second)).To(BeTrue())
+
+			nc, err = nats.Connect(srv.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			transport = NewTransportForAccount(nc, "test-account", false)
+			policy = &RetryPolicy{MaxAttempts: 5, InitialBackoff: 50 * time.Millisecond, Multiplier: 1}
+		})
+
+		AfterEach(func() {
+			nc.Close()
+			srv.Shutdown()
+		})
+
+		respondAfter := func(subject string, delay time.Duration, payload string) {
+			time.AfterFunc(delay, func() {
+				_, _ = nc.Subscribe(subject, func(msg *nats.Msg) {
+					_ = msg.Respond([]byte(payload))
+				})
+				_ = nc.Flush()
+			})
+		}
+
+		It("should retry until a responder is available", func() {
+			respondAfter("test.rollover", 120*time.Millisecond, `"ok"`)
+
+			resp, err := transport.Request("test.rollover", nil, WithRetry(policy))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(resp)).To(Equal(`"ok"`))
+		})
+
+		It("should not retry without a policy", func() {
+			_, err := transport.Request("test.rollover", nil)
+			Expect(errors.Is(err, nats.ErrNoResponders)).To(BeTrue())
+		})
+
+		It("should give up after the maximum number of attempts", func() {
+			var calls atomic.Int32
+			_, err := nc.Subscribe("test.unavailable", func(msg *nats.Msg) {
+				calls.Add(1)
+				resp := nats.NewMsg(msg.Reply)
+				resp.Header.Set(ServiceErrorHeader, "unavailable")
+				resp.Header.Set(ServiceErrorCodeHeader, "503")
+				_ = msg.RespondMsg(resp)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			policy.MaxAttempts = 3
+			_, err = transport.Request("test.unavailable", nil, WithRetry(policy))
+			Expect(errors.Is(err, ErrUnavailable)).To(BeTrue())
+			Expect(calls.Load()).To(Equal(int32(3)))
+		})
+
+		It("should not retry errors that are not retryable", func() {
+			var calls atomic.Int32
+			_, err := nc.Subscribe("test.missing", func(msg *nats.Msg) {
+				calls.Add(1)
+				resp := nats.NewMsg(msg.Reply)
+				resp.Header.Set(ServiceErrorHeader, "not found")
+				resp.Header.Set(ServiceErrorCodeHeader, "404")
+				_ = msg.RespondMsg(resp)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = transport.Request("test.missing", nil, WithRetry(policy))
+			Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+			Expect(calls.Load()).To(Equal(int32(1)))
+		})
+
+		It("should give slow replies the whole deadline of the context", func() {
+			var calls atomic.Int32
+			_, err := nc.Subscribe("test.slow", func(msg *nats.Msg) {
+				calls.Add(1)
+				time.Sleep(500 * time.Millisecond)
+				_ = msg.Respond([]byte(`"ok"`))
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			policy.MaxAttempts = 3
+			resp, err := transport.RequestWithContext(ctx, "test.slow", nil, WithRetry(policy))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(resp)).To(Equal(`"ok"`))
+			Expect(calls.Load()).To(Equal(int32(1)))
+		})
+
+		It("should retry attempts timing out within the deadline of the context", func() {
+			var calls atomic.Int32
+			_, err := nc.Subscribe("test.slow", func(msg *nats.Msg) {
+				if calls.Add(1) == 1 {
+					return
+				}
+				_ = msg.Respond([]byte(`"ok"`))
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			policy.MaxAttempts = 3
+			policy.AttemptTimeout = 500 * time.Millisecond
+			resp, err := transport.RequestWithContext(ctx, "test.slow", nil, WithRetry(policy))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(resp)).To(Equal(`"ok"`))
+			Expect(calls.Load()).To(Equal(int32(2)))
+		})
+
+		It("should retry list requests until a responder is available", func() {
+			respondAfter("test.list", 120*time.Millisecond, `"ok"`)
+
+			var responses []string
+			err := transport.RequestList("test.list", nil, func(resp []byte, hasMore bool) error {
+				responses = append(responses, string(resp))
+				return nil
+			}, WithRetry(policy))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(responses).To(Equal([]string{`"ok"`}))
+		})
+
+		It("should not retry list requests once a response was handled", func() {
+			var calls atomic.Int32
+			_, err := nc.Subscribe("test.partial", func(msg *nats.Msg) {
+				calls.Add(1)
+				resp := nats.NewMsg(msg.Reply)
+				resp.Header.Set(HasMoreHeader, "true")
+				resp.Data = []byte(`"first"`)
+				_ = msg.RespondMsg(resp)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			var responses []string
+			err = transport.RequestList("test.partial", nil, func(resp []byte, hasMore bool) error {
+				responses = append(responses, string(resp))
+				return nil
+			}, WithTimeout(100*time.Millisecond), WithRetry(policy))
+			Expect(err).To(HaveOccurred())
+			Expect(responses).To(Equal([]string{`"first"`}))
+			Expect(calls.Load()).To(Equal(int32(1)))
+		})
+
+		It("should retry idempotent client calls by default", func() {
+			respondAfter("$CONSVC.test-account.CONNECTORS.STATUS", 120*time.Millisecond, `{"status":{"running":1,"stopped":0}}`)
+
+			cl := NewClientForAccount(nc, "test-account", false)
+			_, err := cl.GetConnectorStatus("test", 2*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should not retry mutating client calls by default", func() {
+			respondAfter("$CONSVC.test-account.CONNECTORS.DELETE", 120*time.Millisecond, `{"existed":true}`)
+
+			cl := NewClientForAccount(nc, "test-account", false)
+			err := cl.DeleteConnector("test", 2*time.Second)
+			Expect(errors.Is(err, nats.ErrNoResponders)).To(BeTrue())
+		})
+
+		It("should retry mutating client calls when opted in", func() {
+			respondAfter("$CONSVC.test-account.CONNECTORS.DELETE", 120*time.Millisecond, `{"existed":true}`)
+
+			cl := NewClientForAccount(nc, "test-account", false, WithMutatingRetry(policy))
+			err := cl.DeleteConnector("test", 2*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
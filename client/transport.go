@@ -2,8 +2,11 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/nats-io/nats-server/v2/server"
@@ -16,6 +19,9 @@ type ResponseHandler func(resp []byte, hasMore bool) error
 
 type RequestOpts struct {
 	Timeout time.Duration
+
+	// Retry is the policy used to retry failed requests. Requests are not retried when nil.
+	Retry *RetryPolicy
 }
 
 func DefaultRequestOpts() *RequestOpts {
@@ -32,10 +38,17 @@ func WithTimeout(timeout time.Duration) Opt {
 	}
 }
 
+// WithRetry retries failed requests according to the given policy. Passing nil disables retries.
+func WithRetry(policy *RetryPolicy) Opt {
+	return func(opts *RequestOpts) {
+		opts.Retry = policy
+	}
+}
+
 func NewTransport(nc *nats.Conn, trace bool) (*Transport, error) {
 	account, err := getUserInfo(nc)
 	if err != nil {
-		return nil, fmt.Errorf("could not get user info: %s", err)
+		return nil, fmt.Errorf("could not get user info: %w", err)
 	}
 
 	return NewTransportForAccount(nc, account.Account, trace), nil
@@ -60,6 +73,13 @@ func (t *Transport) Account() string {
 }
 
 func (t *Transport) Request(subject string, payload any, opts ...Opt) ([]byte, error) {
+	return t.RequestWithContext(context.Background(), subject, payload, opts...)
+}
+
+// RequestWithContext sends the payload to the given subject and waits for the response until the context is
+// done. The timeout from the options is only applied when the context has no deadline. Failed requests are
+// retried if a retry policy is given in the options.
+func (t *Transport) RequestWithContext(ctx context.Context, subject string, payload any, opts ...Opt) ([]byte, error) {
 	options := DefaultRequestOpts()
 	for _, opt := range opts {
 		opt(options)
@@ -68,29 +88,36 @@ func (t *Transport) Request(subject string, payload any, opts ...Opt) ([]byte, e
 	// -- encode the Request
 	req, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("unable to marshal Request: %v", err)
+		return nil, fmt.Errorf("unable to marshal Request: %w", err)
 	}
 
 	if t.trace {
 		fmt.Println(">>> ", subject, " [", string(req), "]")
 	}
 
-	resp, err := t.nc.Request(subject, req, options.Timeout)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get response: %v", err)
-	}
+	var resp *nats.Msg
+	err = options.Retry.retry(ctx, options.Timeout, func(ctx context.Context) error {
+		msg, err := t.nc.RequestWithContext(ctx, subject, req)
+		if err != nil {
+			return fmt.Errorf("unable to get response: %w", err)
+		}
 
-	serviceErr := resp.Header.Get("Nats-Service-Error")
-	serviceErrCode := resp.Header.Get("Nats-Service-Error-Code")
-	if serviceErr != "" {
-		return nil, fmt.Errorf("%s (%s)", serviceErr, serviceErrCode)
+		resp = msg
+		return serviceErrorFromMsg(msg)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return resp.Data, nil
 }
 
 func (t *Transport) RequestJson(subject string, payload any, target any, opts ...Opt) (bool, error) {
-	b, err := t.Request(subject, payload, opts...)
+	return t.RequestJsonWithContext(context.Background(), subject, payload, target, opts...)
+}
+
+func (t *Transport) RequestJsonWithContext(ctx context.Context, subject string, payload any, target any, opts ...Opt) (bool, error) {
+	b, err := t.RequestWithContext(ctx, subject, payload, opts...)
 	if err != nil {
 		return false, err
 	}
@@ -100,18 +127,54 @@ func (t *Transport) RequestJson(subject string, payload any, target any, opts ..
 	}
 
 	if err := json.Unmarshal(b, target); err != nil {
-		return false, fmt.Errorf("could not parse response: %s", err)
+		return false, fmt.Errorf("could not parse response: %w", err)
 	}
 
 	return true, nil
 }
 
 func (t *Transport) RequestList(subject string, payload any, h ResponseHandler, opts ...Opt) error {
+	return t.RequestListWithContext(context.Background(), subject, payload, h, opts...)
+}
+
+// RequestListWithContext sends the payload to the given subject and passes every response message to the
+// handler until a message without the has-more header is received. When the context has no deadline, the
+// timeout from the options is applied to each individual response message. A failed request is only retried
+// if no response has been passed to the handler yet.
+func (t *Transport) RequestListWithContext(ctx context.Context, subject string, payload any, h ResponseHandler, opts ...Opt) error {
 	options := DefaultRequestOpts()
 	for _, opt := range opts {
 		opt(options)
 	}
 
+	// -- encode the Request
+	req, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("unable to marshal Request: %w", err)
+	}
+
+	handled := false
+	policy := options.Retry
+	if policy != nil {
+		retryable := policy.retryable
+		p := *policy
+		p.Retryable = func(err error) bool {
+			return !handled && retryable(err)
+		}
+		policy = &p
+	}
+
+	return policy.retry(ctx, options.Timeout, func(attemptCtx context.Context) error {
+		return t.requestList(ctx, attemptCtx, subject, req, options.Timeout, func(resp []byte, hasMore bool) error {
+			handled = true
+			return h(resp, hasMore)
+		})
+	})
+}
+
+// requestList performs a single list request. The attempt context bounds the wait for the first response, the
+// remaining responses are awaited within the context of the whole request.
+func (t *Transport) requestList(ctx context.Context, attemptCtx context.Context, subject string, req []byte, timeout time.Duration, h ResponseHandler) error {
 	inb := nats.NewInbox()
 	if t.trace {
 		fmt.Println("??? ", inb)
@@ -121,34 +184,35 @@ func (t *Transport) RequestList(subject string, payload any, h ResponseHandler,
 	if err != nil {
 		return err
 	}
-
-	// -- encode the Request
-	req, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("unable to marshal Request: %v", err)
-	}
+	defer func() { _ = sub.Unsubscribe() }()
 
 	if t.trace {
 		fmt.Println(">>> ", subject, " [", string(req), "]")
 	}
 
 	if err := t.nc.PublishRequest(subject, inb, req); err != nil {
-		return fmt.Errorf("unable to publish Request: %v", err)
+		return fmt.Errorf("unable to publish Request: %w", err)
 	}
 	if err := t.nc.Flush(); err != nil {
-		return fmt.Errorf("unable to flush: %v", err)
+		return fmt.Errorf("unable to flush: %w", err)
 	}
 
+	_, hasDeadline := ctx.Deadline()
+	first := true
 	for {
-		msg, err := sub.NextMsg(options.Timeout)
+		var msg *nats.Msg
+		if first {
+			msg, err = sub.NextMsgWithContext(attemptCtx)
+		} else {
+			msg, err = t.nextMsg(ctx, sub, hasDeadline, timeout)
+		}
 		if err != nil {
-			return fmt.Errorf("unable to get response: %v", err)
+			return fmt.Errorf("unable to get response: %w", err)
 		}
+		first = false
 
-		serviceErr := msg.Header.Get("Nats-Service-Error")
-		serviceErrCode := msg.Header.Get("Nats-Service-Error-Code")
-		if serviceErr != "" {
-			return fmt.Errorf("%s (%s)", serviceErr, serviceErrCode)
+		if err := serviceErrorFromMsg(msg); err != nil {
+			return err
 		}
 
 		hasMore := msg.Header.Get(HasMoreHeader) == "true"
@@ -168,6 +232,50 @@ func (t *Transport) RequestList(subject string, payload any, h ResponseHandler,
 	return nil
 }
 
+// errStopIteration is returned by a response handler to stop an iteration early.
+var errStopIteration = errors.New("iteration stopped")
+
+// iterList sends a list request and yields the items of every response page as they are received. The items
+// of a page are extracted by the given function, errors are prefixed with the given message.
+func iterList[P any, T any](ctx context.Context, t *Transport, subject string, payload any, items func(page P) []T, errMsg string, opts ...Opt) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := t.RequestListWithContext(ctx, subject, payload, func(resp []byte, hasMore bool) error {
+			if len(resp) == 0 {
+				return nil
+			}
+
+			var page P
+			if err := json.Unmarshal(resp, &page); err != nil {
+				return fmt.Errorf("could not parse response: %w", err)
+			}
+
+			for _, item := range items(page) {
+				if !yield(item, nil) {
+					return errStopIteration
+				}
+			}
+
+			return nil
+		}, opts...)
+
+		if err != nil && !errors.Is(err, errStopIteration) {
+			var zero T
+			yield(zero, fmt.Errorf("%s: %w", errMsg, err))
+		}
+	}
+}
+
+func (t *Transport) nextMsg(ctx context.Context, sub *nats.Subscription, hasDeadline bool, timeout time.Duration) (*nats.Msg, error) {
+	if hasDeadline {
+		return sub.NextMsgWithContext(ctx)
+	}
+
+	msgCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return sub.NextMsgWithContext(msgCtx)
+}
+
 func (t *Transport) Close() {
 	t.nc.Close()
 }
@@ -175,7 +283,7 @@ func (t *Transport) Close() {
 func getUserInfo(nc *nats.Conn) (*server.UserInfo, error) {
 	resp, err := nc.Request("$SYS.REQ.USER.INFO", nil, time.Second)
 	if err != nil {
-		return nil, fmt.Errorf("could not get user info: %s", err)
+		return nil, fmt.Errorf("could not get user info: %w", err)
 	}
 
 	var res = struct {
@@ -185,7 +293,7 @@ func getUserInfo(nc *nats.Conn) (*server.UserInfo, error) {
 	}{}
 
 	if err = json.Unmarshal(resp.Data, &res); err != nil {
-		return nil, fmt.Errorf("could not parse user info: %s", err)
+		return nil, fmt.Errorf("could not parse user info: %w", err)
 	}
 
 	if res.Error != nil {
@@ -1,8 +1,11 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"time"
 
+	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -57,6 +60,80 @@ var _ = Describe("Transport", func() {
 		})
 	})
 
-	// Integration tests would require a real NATS server
-	// These are unit tests focusing on the Transport structure and methods
+	Describe("RequestWithContext", func() {
+		var srv *server.Server
+
+		BeforeEach(func() {
+			var err error
+			srv, err = server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+			Expect(err).ToNot(HaveOccurred())
+			go srv.Start()
+			Expect(srv.ReadyForConnections(5 * time.Second)).To(BeTrue())
+
+			nc, err = nats.Connect(srv.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			transport = NewTransportForAccount(nc, "test-account", false)
+
+			// a responder that never answers
+			_, err = nc.Subscribe("test.silent", func(msg *nats.Msg) {})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			nc.Close()
+			srv.Shutdown()
+		})
+
+		It("should stop waiting when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			_, err := transport.RequestWithContext(ctx, "test.silent", nil, WithTimeout(10*time.Second))
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("should prefer the context deadline over the request timeout", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := transport.RequestWithContext(ctx, "test.silent", nil, WithTimeout(10*time.Second))
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+
+		It("should apply the request timeout when the context has no deadline", func() {
+			_, err := transport.RequestWithContext(context.Background(), "test.silent", nil, WithTimeout(50*time.Millisecond))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return a service error the caller can inspect", func() {
+			_, err := nc.Subscribe("$CONSVC.test-account.CONNECTORS.GET", func(msg *nats.Msg) {
+				resp := nats.NewMsg(msg.Reply)
+				resp.Header.Set(ServiceErrorHeader, "connector not found")
+				resp.Header.Set(ServiceErrorCodeHeader, "404")
+				_ = msg.RespondMsg(resp)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			cl := &connectorClient{t: transport}
+			_, err = cl.GetConnectorWithContext(context.Background(), "missing")
+			Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+
+			var se *ServiceError
+			Expect(errors.As(err, &se)).To(BeTrue())
+			Expect(se.Code).To(Equal(404))
+		})
+
+		It("should stop waiting for list responses when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			err := transport.RequestListWithContext(ctx, "test.silent", nil, func(resp []byte, hasMore bool) error {
+				return nil
+			}, WithTimeout(10*time.Second))
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		})
+	})
 })
@@ -29,6 +29,13 @@ func main() {
 	cli.ConfigureConnectorCommand(ncli, opts)
 	cli.ConfigureLibraryCommand(ncli, opts)
 	cli.ConfigureLogsCommand(ncli, opts)
+	cli.ConfigureTransformCommand(ncli, opts)
+	cli.ConfigureReplayCommand(ncli, opts)
+	cli.ConfigureSchemaCommand(ncli, opts)
+	cli.ConfigureTopologyCommand(ncli, opts)
+	cli.ConfigureLintCommand(ncli, opts)
+	cli.ConfigureAgentCommand(ncli, opts)
+	cli.ConfigureServerCommand(ncli, opts)
 	cli.ConfigureStandaloneCommand(ncli, opts)
 
 	ncli.MustParseWithUsage(os.Args[1:])
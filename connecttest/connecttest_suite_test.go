@@ -0,0 +1,13 @@
+package connecttest_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestConnecttest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Connecttest Suite")
+}
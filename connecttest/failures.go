@@ -0,0 +1,101 @@
+package connecttest
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/controlplane"
+)
+
+// Operation identifies a request by the last two tokens of its subject.
+type Operation = controlplane.Operation
+
+const (
+	OpCreateConnector = controlplane.OpCreateConnector
+	OpGetConnector    = controlplane.OpGetConnector
+	OpListConnectors  = controlplane.OpListConnectors
+	OpPatchConnector  = controlplane.OpPatchConnector
+	OpDeleteConnector = controlplane.OpDeleteConnector
+	OpStartConnector  = controlplane.OpStartConnector
+	OpStopConnector   = controlplane.OpStopConnector
+	OpScaleConnector  = controlplane.OpScaleConnector
+	OpListInstances   = controlplane.OpListInstances
+	OpConnectorStatus = controlplane.OpConnectorStatus
+
+	OpListRuntimes     = controlplane.OpListRuntimes
+	OpGetRuntime       = controlplane.OpGetRuntime
+	OpSearchComponents = controlplane.OpSearchComponents
+	OpGetComponent     = controlplane.OpGetComponent
+)
+
+// Failure describes how a request is failed instead of being answered.
+type Failure struct {
+	// Code and Description are sent as service error. Ignored when Drop is set.
+	Code        int
+	Description string
+
+	// Drop leaves the request unanswered, so the client runs into its timeout.
+	Drop bool
+
+	// Delay postpones the failure. When neither an error nor Drop is set, the request is answered normally
+	// after the delay.
+	Delay time.Duration
+
+	// Times limits the number of requests the failure applies to. Zero applies it to all requests until the
+	// failures are cleared.
+	Times int
+}
+
+// InjectFailure makes requests for the given operation fail. Failures of an operation are applied in the order
+// they were injected.
+func (s *Server) InjectFailure(op Operation, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[op] = append(s.failures[op], &f)
+}
+
+// ClearFailures removes all injected failures.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = map[Operation][]*Failure{}
+}
+
+// intercept counts the request and applies the next failure for the operation, if any. It reports whether the
+// request has been handled.
+func (s *Server) intercept(op Operation, msg *nats.Msg) bool {
+	s.mu.Lock()
+	s.requests[op]++
+
+	var f *Failure
+	if pending := s.failures[op]; len(pending) > 0 {
+		f = pending[0]
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures[op] = pending[1:]
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if f == nil {
+		return false
+	}
+
+	if f.Delay > 0 {
+		time.Sleep(f.Delay)
+	}
+
+	switch {
+	case f.Drop:
+		return true
+	case f.Code != 0 || f.Description != "":
+		controlplane.RespondError(msg, f.Code, f.Description)
+		return true
+	default:
+		return false
+	}
+}
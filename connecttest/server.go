@@ -0,0 +1,212 @@
+// Package connecttest provides an in-memory Connect control plane for testing code that uses the client
+// package, without the need for the hosted service.
+//
+// The Server runs an embedded NATS server and answers the connector requests on
+// $CONSVC.<account>.CONNECTORS.* as well as the library requests on $CONLIB.*. Connectors and their instances
+// are only kept in memory; no workloads are actually started. The requests are answered by the same
+// controlplane.Service the self-hosted `connect server` runs.
+package connecttest
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/model"
+)
+
+// DefaultAccount is the account of clients connecting to the embedded NATS server without credentials.
+const DefaultAccount = "$G"
+
+//go:embed fixtures/library.json
+var defaultLibrary []byte
+
+// Library holds the runtimes and components served by the library endpoints.
+type Library = controlplane.Library
+
+// DefaultLibrary returns the library served unless configured otherwise. It contains the wombat runtime with a
+// couple of sources and sinks.
+func DefaultLibrary() Library {
+	var lib Library
+	if err := json.Unmarshal(defaultLibrary, &lib); err != nil {
+		panic(fmt.Sprintf("invalid default library fixture: %s", err))
+	}
+	return lib
+}
+
+// LoadLibrary reads a library fixture from the given JSON file. The file holds an object with a "runtimes" and a
+// "components" array, like the model.Runtime and model.Component types.
+func LoadLibrary(file string) (Library, error) {
+	return controlplane.LoadLibrary(file)
+}
+
+type Option func(s *Server)
+
+// WithAccount sets the account the control plane answers requests for. Defaults to DefaultAccount.
+func WithAccount(account string) Option {
+	return func(s *Server) {
+		s.cfg.Account = account
+	}
+}
+
+// WithLibrary sets the runtimes and components served by the library endpoints.
+func WithLibrary(lib Library) Option {
+	return func(s *Server) {
+		s.cfg.Library = lib
+	}
+}
+
+// WithPageSize splits list responses into pages of the given number of items. By default, a single page is
+// sent.
+func WithPageSize(size int) Option {
+	return func(s *Server) {
+		s.cfg.PageSize = size
+	}
+}
+
+// WithJetStream enables JetStream on the embedded NATS server, storing its data in the given directory.
+func WithJetStream(storeDir string) Option {
+	return func(s *Server) {
+		s.storeDir = storeDir
+	}
+}
+
+// WithConnectors seeds the control plane with the given connectors.
+func WithConnectors(connectors ...model.Connector) Option {
+	return func(s *Server) {
+		for _, c := range connectors {
+			_ = s.cfg.Store.Create(context.Background(), c)
+		}
+	}
+}
+
+// Server is an in-memory Connect control plane running on an embedded NATS server.
+type Server struct {
+	cfg controlplane.Config
+	svc *controlplane.Service
+
+	ns       *server.Server
+	nc       *nats.Conn
+	storeDir string
+
+	mu       sync.Mutex
+	failures map[Operation][]*Failure
+	requests map[Operation]int
+}
+
+// NewServer starts an embedded NATS server on a random local port and starts answering Connect requests.
+func NewServer(opts ...Option) (*Server, error) {
+	s := &Server{
+		cfg: controlplane.Config{
+			Account:   DefaultAccount,
+			Library:   DefaultLibrary(),
+			Store:     controlplane.NewMemoryStore(),
+			Scheduler: controlplane.NewMemoryScheduler(),
+		},
+		failures: map[Operation][]*Failure{},
+		requests: map[Operation]int{},
+	}
+	s.cfg.Intercept = s.intercept
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: s.storeDir != "",
+		StoreDir:  s.storeDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create nats server: %w", err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(10 * time.Second) {
+		ns.Shutdown()
+		return nil, fmt.Errorf("nats server did not start in time")
+	}
+	s.ns = ns
+
+	nc, err := nats.Connect(ns.ClientURL(), nats.Name("connecttest"))
+	if err != nil {
+		ns.Shutdown()
+		return nil, fmt.Errorf("could not connect to nats server: %w", err)
+	}
+	s.nc = nc
+
+	svc, err := controlplane.New(nc, s.cfg)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := svc.Start(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	s.svc = svc
+
+	return s, nil
+}
+
+// Account returns the account the control plane answers requests for.
+func (s *Server) Account() string {
+	return s.cfg.Account
+}
+
+// ClientURL returns the url clients use to connect to the embedded NATS server.
+func (s *Server) ClientURL() string {
+	return s.ns.ClientURL()
+}
+
+// Connect opens a new connection to the embedded NATS server.
+func (s *Server) Connect(opts ...nats.Option) (*nats.Conn, error) {
+	return nats.Connect(s.ClientURL(), opts...)
+}
+
+// Close stops answering requests and shuts the embedded NATS server down.
+func (s *Server) Close() {
+	if s.svc != nil {
+		s.svc.Stop()
+	}
+	if s.nc != nil {
+		s.nc.Close()
+	}
+	if s.ns != nil {
+		s.ns.Shutdown()
+		s.ns.WaitForShutdown()
+	}
+}
+
+// Connector returns the stored connector with the given id, or nil if there is none.
+func (s *Server) Connector(id string) *model.Connector {
+	c, err := s.cfg.Store.Get(context.Background(), id)
+	if err != nil {
+		return nil
+	}
+	return c
+}
+
+// Instances returns the running instances of the connector with the given id.
+func (s *Server) Instances(id string) []model.Instance {
+	instances, err := s.cfg.Scheduler.Instances(context.Background(), id)
+	if err != nil || len(instances) == 0 {
+		return nil
+	}
+	return instances
+}
+
+// Requests returns the number of requests received for the given operation, including failed ones.
+func (s *Server) Requests(op Operation) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[op]
+}
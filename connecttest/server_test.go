@@ -0,0 +1,260 @@
+package connecttest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/connecttest"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		srv *connecttest.Server
+		nc  *nats.Conn
+		cl  client.Client
+	)
+
+	steps := model.Steps{
+		Source: &model.SourceStep{Type: "generate", Config: model.SourceStepConfig{"mapping": "root = {}"}},
+		Producer: &model.ProducerStep{
+			Core: &model.ProducerStepCore{Subject: "test"},
+		},
+	}
+
+	start := func(opts ...connecttest.Option) {
+		var err error
+		srv, err = connecttest.NewServer(opts...)
+		Expect(err).ToNot(HaveOccurred())
+
+		nc, err = srv.Connect()
+		Expect(err).ToNot(HaveOccurred())
+
+		cl, err = client.NewClient(nc, false)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	AfterEach(func() {
+		nc.Close()
+		srv.Close()
+	})
+
+	Describe("connectors", func() {
+		BeforeEach(func() {
+			start()
+		})
+
+		It("should answer for the account of the client", func() {
+			Expect(cl.Account()).To(Equal(srv.Account()))
+		})
+
+		It("should manage the lifecycle of a connector", func() {
+			created, err := cl.CreateConnector("test", "a test connector", "wombat", steps, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.ConnectorId).To(Equal("test"))
+
+			connector, err := cl.GetConnector("test", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connector.Description).To(Equal("a test connector"))
+			Expect(connector.Steps.Producer.Core.Subject).To(Equal("test"))
+
+			patched, err := cl.PatchConnector("test", `{"description": "patched"}`, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(patched.Description).To(Equal("patched"))
+			Expect(srv.Connector("test").Description).To(Equal("patched"))
+
+			instances, err := cl.StartConnector("test", &model.ConnectorStartOptions{Replicas: 2}, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(2))
+			Expect(srv.Instances("test")).To(HaveLen(2))
+
+			status, err := cl.GetConnectorStatus("test", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Running).To(Equal(2))
+
+			// running connectors can't be deleted
+			var serviceErr *client.ServiceError
+			Expect(errors.As(cl.DeleteConnector("test", time.Second), &serviceErr)).To(BeTrue())
+			Expect(serviceErr.Code).To(Equal(409))
+
+			instances, err = cl.StopConnector("test", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(2))
+
+			status, err = cl.GetConnectorStatus("test", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Running).To(Equal(0))
+			Expect(status.Stopped).To(Equal(2))
+
+			Expect(cl.DeleteConnector("test", time.Second)).To(Succeed())
+			Expect(srv.Connector("test")).To(BeNil())
+		})
+
+		It("should reject duplicate connectors", func() {
+			_, err := cl.CreateConnector("test", "", "wombat", steps, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = cl.CreateConnector("test", "", "wombat", steps, time.Second)
+			Expect(errors.Is(err, client.ErrAlreadyExists)).To(BeTrue())
+		})
+
+		It("should report missing connectors", func() {
+			_, err := cl.GetConnector("missing", time.Second)
+			Expect(errors.Is(err, client.ErrNotFound)).To(BeTrue())
+
+			_, err = cl.StartConnector("missing", nil, time.Second)
+			Expect(errors.Is(err, client.ErrNotFound)).To(BeTrue())
+		})
+
+		It("should scale connectors", func() {
+			_, err := cl.CreateConnector("test", "", "wombat", steps, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			instances, err := cl.ScaleConnector("test", 3, nil, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(3))
+
+			instances, err = cl.ScaleConnector("test", 1, nil, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(1))
+
+			instances, err = cl.ListConnectorInstances("test", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(HaveLen(1))
+		})
+	})
+
+	Describe("seeding and paging", func() {
+		It("should list seeded connectors in pages", func() {
+			start(
+				connecttest.WithPageSize(2),
+				connecttest.WithConnectors(
+					model.Connector{ConnectorId: "c", RuntimeId: "wombat", Steps: steps},
+					model.Connector{ConnectorId: "a", RuntimeId: "wombat", Steps: steps},
+					model.Connector{ConnectorId: "b", RuntimeId: "wombat", Steps: steps},
+				),
+			)
+
+			connectors, err := cl.ListConnectors(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connectors).To(HaveLen(3))
+			Expect(connectors[0].ConnectorId).To(Equal("a"))
+			Expect(connectors[2].ConnectorId).To(Equal("c"))
+		})
+
+		It("should list an empty account", func() {
+			start()
+
+			connectors, err := cl.ListConnectors(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(connectors).To(BeEmpty())
+		})
+	})
+
+	Describe("library", func() {
+		It("should serve the default library", func() {
+			start()
+
+			runtimes, err := cl.ListRuntimes(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runtimes).To(HaveLen(1))
+			Expect(runtimes[0].Id).To(Equal("wombat"))
+
+			rt, err := cl.GetRuntime("wombat", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rt.Image).ToNot(BeEmpty())
+
+			kind := model.ComponentKindSink
+			components, err := cl.SearchComponents(&model.ComponentSearchFilter{Kind: &kind}, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(components).ToNot(BeEmpty())
+			for _, c := range components {
+				Expect(c.Kind).To(Equal(model.ComponentKindSink))
+			}
+
+			component, err := cl.GetComponent("wombat", model.ComponentKindSource, "generate", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(component.Fields).ToNot(BeEmpty())
+
+			_, err = cl.GetComponent("wombat", model.ComponentKindSource, "missing", time.Second)
+			Expect(errors.Is(err, client.ErrNotFound)).To(BeTrue())
+		})
+
+		It("should serve a library loaded from a fixture", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "library.json")
+			Expect(os.WriteFile(file, []byte(`{
+				"runtimes": [{"id": "custom", "label": "Custom", "author": {"name": "me"}, "image": "custom", "default_version": "1"}],
+				"components": []
+			}`), 0644)).To(Succeed())
+
+			lib, err := connecttest.LoadLibrary(file)
+			Expect(err).ToNot(HaveOccurred())
+			start(connecttest.WithLibrary(lib))
+
+			runtimes, err := cl.ListRuntimes(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runtimes).To(HaveLen(1))
+			Expect(runtimes[0].Id).To(Equal("custom"))
+		})
+	})
+
+	Describe("failure injection", func() {
+		BeforeEach(func() {
+			start()
+		})
+
+		It("should fail requests with a service error", func() {
+			srv.InjectFailure(connecttest.OpCreateConnector, connecttest.Failure{Code: 403, Description: "not allowed"})
+
+			_, err := cl.CreateConnector("test", "", "wombat", steps, time.Second)
+			Expect(errors.Is(err, client.ErrForbidden)).To(BeTrue())
+			Expect(srv.Connector("test")).To(BeNil())
+
+			srv.ClearFailures()
+			_, err = cl.CreateConnector("test", "", "wombat", steps, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail a limited number of requests", func() {
+			srv.InjectFailure(connecttest.OpConnectorStatus, connecttest.Failure{Code: 503, Description: "unavailable", Times: 2})
+			_, err := cl.CreateConnector("test", "", "wombat", steps, time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			// status requests are retried by the client
+			_, err = cl.GetConnectorStatus("test", 5*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(srv.Requests(connecttest.OpConnectorStatus)).To(Equal(3))
+		})
+
+		It("should drop requests", func() {
+			srv.InjectFailure(connecttest.OpGetRuntime, connecttest.Failure{Drop: true})
+
+			ctl := client.NewClientForAccount(nc, srv.Account(), false, client.WithIdempotentRetry(nil))
+			_, err := ctl.GetRuntime("wombat", 100*time.Millisecond)
+			Expect(errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+
+		It("should delay requests without holding up the others", func() {
+			srv.InjectFailure(connecttest.OpGetRuntime, connecttest.Failure{Delay: 500 * time.Millisecond})
+
+			delayed := make(chan error, 1)
+			go func() {
+				_, err := cl.GetRuntime("wombat", 5*time.Second)
+				delayed <- err
+			}()
+			Eventually(func() int { return srv.Requests(connecttest.OpGetRuntime) }).Should(Equal(1))
+
+			_, err := cl.ListRuntimes(200 * time.Millisecond)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(delayed).ToNot(Receive())
+			Eventually(delayed, 2*time.Second).Should(Receive(BeNil()))
+		})
+	})
+})
@@ -0,0 +1,478 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/model"
+)
+
+// defaultRequestTimeout bounds the store and scheduler calls made while handling a request, unless the start
+// options carry a timeout.
+const defaultRequestTimeout = time.Minute
+
+func (s *Service) handleConnectors(op Operation, msg *nats.Msg) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	switch op {
+	case OpCreateConnector:
+		s.createConnector(ctx, msg)
+	case OpGetConnector:
+		s.getConnector(ctx, msg)
+	case OpListConnectors:
+		s.listConnectors(ctx, msg)
+	case OpPatchConnector:
+		s.patchConnector(ctx, msg)
+	case OpDeleteConnector:
+		s.deleteConnector(ctx, msg)
+	case OpStartConnector:
+		s.startConnector(msg)
+	case OpStopConnector:
+		s.stopConnector(ctx, msg)
+	case OpScaleConnector:
+		s.scaleConnector(msg)
+	case OpListInstances:
+		s.listInstances(ctx, msg)
+	case OpConnectorStatus:
+		s.connectorStatus(ctx, msg)
+	default:
+		RespondError(msg, 404, fmt.Sprintf("unknown operation %s", op))
+	}
+}
+
+// respondStoreError answers with the service error matching the store or scheduler error.
+func (s *Service) respondStoreError(msg *nats.Msg, err error) {
+	switch {
+	case errors.Is(err, ErrConnectorNotFound):
+		RespondError(msg, 404, err.Error())
+	case errors.Is(err, ErrConnectorExists):
+		RespondError(msg, 409, err.Error())
+	default:
+		RespondError(msg, 500, err.Error())
+	}
+}
+
+func (s *Service) createConnector(ctx context.Context, msg *nats.Msg) {
+	var req model.ConnectorCreateRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	if req.Id == "" {
+		RespondError(msg, 400, "connector id is required")
+		return
+	}
+
+	if req.RuntimeId == "" {
+		RespondError(msg, 400, "runtime id is required")
+		return
+	}
+
+	c := model.Connector{
+		ConnectorId: req.Id,
+		Description: req.Description,
+		RuntimeId:   req.RuntimeId,
+		Steps:       req.Steps,
+	}
+	if err := s.cfg.Store.Create(ctx, c); err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	s.respond(msg, model.ConnectorCreateResponse{Connector: c})
+}
+
+func (s *Service) getConnector(ctx context.Context, msg *nats.Msg) {
+	var req model.ConnectorGetRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	c, err := s.cfg.Store.Get(ctx, req.Id)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	s.respond(msg, model.ConnectorGetResponse{Connector: c})
+}
+
+func (s *Service) listConnectors(ctx context.Context, msg *nats.Msg) {
+	connectors, err := s.cfg.Store.List(ctx)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	instances, err := s.cfg.Scheduler.Instances(ctx, "")
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	running := map[string]int{}
+	for _, inst := range instances {
+		running[inst.ConnectorId]++
+	}
+
+	exited, err := s.exited(ctx, "")
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	stopped := map[string]int{}
+	for _, inst := range exited {
+		stopped[inst.ConnectorId]++
+	}
+
+	s.stoppedMu.Lock()
+	summaries := make([]model.ConnectorSummary, 0, len(connectors))
+	for _, c := range connectors {
+		summaries = append(summaries, model.ConnectorSummary{
+			ConnectorId: c.ConnectorId,
+			Description: c.Description,
+			RuntimeId:   c.RuntimeId,
+			Instances: model.ConnectorSummaryInstances{
+				Running: running[c.ConnectorId],
+				Stopped: s.stopped[c.ConnectorId] + stopped[c.ConnectorId],
+			},
+		})
+	}
+	s.stoppedMu.Unlock()
+
+	respondPages(s, msg, summaries, func(items []model.ConnectorSummary) any {
+		return model.ConnectorListResponse{Connectors: items}
+	})
+}
+
+func (s *Service) patchConnector(ctx context.Context, msg *nats.Msg) {
+	var req model.ConnectorPatchRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.cfg.Store.Get(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	original, err := json.Marshal(existing)
+	if err != nil {
+		RespondError(msg, 500, fmt.Sprintf("could not marshal connector: %s", err))
+		return
+	}
+
+	patched, err := jsonpatch.MergePatch(original, []byte(req.Patch))
+	if err != nil {
+		RespondError(msg, 400, fmt.Sprintf("invalid patch: %s", err))
+		return
+	}
+
+	var c model.Connector
+	if err := json.Unmarshal(patched, &c); err != nil {
+		RespondError(msg, 400, fmt.Sprintf("invalid patch: %s", err))
+		return
+	}
+	c.ConnectorId = req.ConnectorId
+
+	if err := s.cfg.Store.Update(ctx, c); err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	s.respond(msg, model.ConnectorPatchResponse{Connector: c})
+}
+
+func (s *Service) deleteConnector(ctx context.Context, msg *nats.Msg) {
+	var req model.ConnectorDeleteRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instances, err := s.cfg.Scheduler.Instances(ctx, req.Id)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	if len(instances) > 0 {
+		RespondError(msg, 409, fmt.Sprintf("connector %s is running", req.Id))
+		return
+	}
+
+	existed, err := s.cfg.Store.Delete(ctx, req.Id)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+	s.stoppedMu.Lock()
+	delete(s.stopped, req.Id)
+	s.stoppedMu.Unlock()
+
+	s.respond(msg, model.ConnectorDeleteResponse{Existed: existed})
+}
+
+func (s *Service) startConnector(msg *nats.Msg) {
+	var req model.ConnectorStartRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	var opts model.ConnectorStartOptions
+	if req.Options != nil {
+		opts = *req.Options
+	}
+
+	ctx, cancel := startContext(opts)
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.cfg.Store.Get(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	started, err := s.startInstances(ctx, StartRequest{
+		Namespace: s.cfg.Account,
+		Connector: *c,
+		Options:   opts,
+		Replicas:  max(opts.Replicas, 1),
+	})
+	if err != nil {
+		RespondError(msg, 500, fmt.Sprintf("could not start connector %s: %s", req.ConnectorId, err))
+		return
+	}
+
+	s.respond(msg, model.ConnectorStartResponse{Instances: started})
+}
+
+func (s *Service) stopConnector(ctx context.Context, msg *nats.Msg) {
+	var req model.ConnectorStopRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.cfg.Store.Get(ctx, req.ConnectorId); err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	instances, err := s.cfg.Scheduler.Instances(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	stopped, err := s.stop(ctx, req.ConnectorId, instances, len(instances))
+	if err != nil {
+		RespondError(msg, 500, fmt.Sprintf("could not stop connector %s: %s", req.ConnectorId, err))
+		return
+	}
+
+	// clean up the instances which exited on their own; they are already counted as stopped
+	exited, err := s.exited(ctx, req.ConnectorId)
+	if err == nil && len(exited) > 0 {
+		err = s.cfg.Scheduler.Stop(ctx, exited)
+		if err == nil {
+			s.countStopped(req.ConnectorId, len(exited))
+		}
+	}
+	if err != nil {
+		RespondError(msg, 500, fmt.Sprintf("could not stop connector %s: %s", req.ConnectorId, err))
+		return
+	}
+
+	s.respond(msg, model.ConnectorStopResponse{Instances: stopped})
+}
+
+func (s *Service) scaleConnector(msg *nats.Msg) {
+	var req model.ConnectorScaleRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	if req.Replicas < 0 {
+		RespondError(msg, 400, "replicas must not be negative")
+		return
+	}
+
+	var opts model.ConnectorStartOptions
+	if req.Options != nil {
+		opts = *req.Options
+	}
+
+	ctx, cancel := startContext(opts)
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.cfg.Store.Get(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	instances, err := s.cfg.Scheduler.Instances(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	if current := len(instances); req.Replicas > current {
+		_, err = s.startInstances(ctx, StartRequest{
+			Namespace: s.cfg.Account,
+			Connector: *c,
+			Options:   opts,
+			Replicas:  req.Replicas - current,
+		})
+	} else {
+		_, err = s.stop(ctx, req.ConnectorId, instances, current-req.Replicas)
+	}
+	if err != nil {
+		RespondError(msg, 500, fmt.Sprintf("could not scale connector %s: %s", req.ConnectorId, err))
+		return
+	}
+
+	instances, err = s.cfg.Scheduler.Instances(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	s.respond(msg, model.ConnectorScaleResponse{Instances: instances})
+}
+
+func (s *Service) listInstances(ctx context.Context, msg *nats.Msg) {
+	var req model.ConnectorInstancesRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	id := ""
+	if req.ConnectorId != nil {
+		id = *req.ConnectorId
+	}
+
+	instances, err := s.cfg.Scheduler.Instances(ctx, id)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	s.respond(msg, model.ConnectorInstancesResponse{Instances: instances})
+}
+
+func (s *Service) connectorStatus(ctx context.Context, msg *nats.Msg) {
+	var req model.ConnectorStatusRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	if _, err := s.cfg.Store.Get(ctx, req.ConnectorId); err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	instances, err := s.cfg.Scheduler.Instances(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	exited, err := s.exited(ctx, req.ConnectorId)
+	if err != nil {
+		s.respondStoreError(msg, err)
+		return
+	}
+
+	s.stoppedMu.Lock()
+	stopped := s.stopped[req.ConnectorId] + len(exited)
+	s.stoppedMu.Unlock()
+
+	s.respond(msg, model.ConnectorStatusResponse{
+		Status: model.ConnectorStatus{
+			Running: len(instances),
+			Stopped: stopped,
+		},
+	})
+}
+
+// stop stops the given number of most recently started instances and returns them. The caller holds mu.
+func (s *Service) stop(ctx context.Context, connectorId string, instances []model.Instance, count int) ([]model.Instance, error) {
+	count = min(count, len(instances))
+	stopped := append([]model.Instance{}, instances[len(instances)-count:]...)
+	if len(stopped) == 0 {
+		return stopped, nil
+	}
+
+	if err := s.cfg.Scheduler.Stop(ctx, stopped); err != nil {
+		return nil, err
+	}
+
+	s.countStopped(connectorId, count)
+	return stopped, nil
+}
+
+func (s *Service) countStopped(connectorId string, count int) {
+	s.stoppedMu.Lock()
+	defer s.stoppedMu.Unlock()
+
+	s.stopped[connectorId] += count
+}
+
+// startInstances starts instances through the scheduler. The instances which did start are stopped again when it
+// fails, rather than leaving them running without the client knowing about them.
+func (s *Service) startInstances(ctx context.Context, req StartRequest) ([]model.Instance, error) {
+	started, err := s.cfg.Scheduler.Start(ctx, req)
+	if err == nil || len(started) == 0 {
+		return started, err
+	}
+
+	// the request context may be what made the start fail
+	stopCtx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	if serr := s.cfg.Scheduler.Stop(stopCtx, started); serr != nil {
+		return nil, fmt.Errorf("%w, and the %d instances which did start could not be stopped: %s", err, len(started), serr)
+	}
+	return nil, err
+}
+
+// exited returns the instances which exited on their own, if the scheduler knows about them.
+func (s *Service) exited(ctx context.Context, connectorId string) ([]model.Instance, error) {
+	reporter, ok := s.cfg.Scheduler.(ExitedReporter)
+	if !ok {
+		return nil, nil
+	}
+	return reporter.Exited(ctx, connectorId)
+}
+
+// startContext bounds starting instances by the timeout of the start options, if any.
+func startContext(opts model.ConnectorStartOptions) (context.Context, context.CancelFunc) {
+	timeout := defaultRequestTimeout
+	if d, err := time.ParseDuration(opts.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
@@ -0,0 +1,224 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/nats-io/nuid"
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// Labels set on the containers started by the ContainerScheduler.
+const (
+	ConnectorLabel = "io.synadia.connect.connector"
+	InstanceLabel  = "io.synadia.connect.instance"
+	NamespaceLabel = "io.synadia.connect.namespace"
+)
+
+// ContainerBackend runs containers on a single docker daemon. It is implemented by docker.Runner.
+type ContainerBackend interface {
+	Start(ctx context.Context, opts *docker.RunOptions) error
+	ListContainers(ctx context.Context, labels map[string]string) ([]docker.ContainerStatus, error)
+	ForceRemove(ctx context.Context, name string) error
+	Pull(ctx context.Context, image string) error
+}
+
+// ImageResolver returns the image to run for a runtime reference like "wombat" or "wombat:v1.0.3".
+type ImageResolver func(runtimeRef string) (string, error)
+
+// WorkloadConnection holds the NATS connection details handed to the connector instances.
+type WorkloadConnection struct {
+	// Servers is the NATS url the instances connect to.
+	Servers string
+	// JWT and Seed are the user credentials of the instances, if any.
+	JWT  string
+	Seed string
+}
+
+// Env returns the environment variables the runtime reads the connection details from.
+func (w WorkloadConnection) Env() map[string]string {
+	env := map[string]string{}
+	if w.Servers != "" {
+		env[runtime.NatsUrlVar] = w.Servers
+	}
+	if w.JWT != "" {
+		env[runtime.NatsJwtVar] = base64.StdEncoding.EncodeToString([]byte(w.JWT))
+	}
+	if w.Seed != "" {
+		env[runtime.NatsSeedVar] = w.Seed
+	}
+	return env
+}
+
+// ContainerScheduler runs each connector instance as a container, spreading the instances over one or more
+// docker daemons. The instances are tracked through the labels of their containers, so they survive a restart
+// of the control plane. Only the containers of its own namespace are listed and removed, so the control planes
+// of several accounts can share a docker daemon. Placement tags are ignored, the instances go to the backends in
+// turn.
+type ContainerScheduler struct {
+	namespace string
+	backends  []ContainerBackend
+	resolve   ImageResolver
+	conn      WorkloadConnection
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewContainerScheduler creates a scheduler running the instances of the namespace on the given backends, in turn.
+func NewContainerScheduler(namespace string, resolve ImageResolver, conn WorkloadConnection, backends ...ContainerBackend) (*ContainerScheduler, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("at least one container backend is required")
+	}
+
+	return &ContainerScheduler{
+		namespace: namespace,
+		backends:  backends,
+		resolve:   resolve,
+		conn:      conn,
+	}, nil
+}
+
+func (c *ContainerScheduler) Start(ctx context.Context, req StartRequest) ([]model.Instance, error) {
+	image, err := c.resolve(req.Connector.RuntimeId)
+	if err != nil {
+		return nil, err
+	}
+
+	pulled := map[ContainerBackend]bool{}
+	started := make([]model.Instance, 0, req.Replicas)
+	for range req.Replicas {
+		backend := c.nextBackend()
+
+		if req.Options.Pull && !pulled[backend] {
+			if err := backend.Pull(ctx, image); err != nil {
+				return started, fmt.Errorf("could not pull %s: %w", image, err)
+			}
+			pulled[backend] = true
+		}
+
+		inst := model.Instance{
+			ConnectorId: req.Connector.ConnectorId,
+			Id:          nuid.Next(),
+		}
+
+		env := c.conn.Env()
+		for k, v := range req.Options.EnvVars {
+			env[k] = v
+		}
+		env[runtime.NamespaceEnvVar] = req.Namespace
+		env[runtime.GroupEnvVar] = inst.ConnectorId
+		env[runtime.InstanceEnvVar] = inst.Id
+
+		err := backend.Start(ctx, &docker.RunOptions{
+			ConnectorID: containerName(inst),
+			Image:       image,
+			Steps:       req.Connector.Steps,
+			EnvVars:     env,
+			RuntimeID:   req.Connector.RuntimeId,
+			Labels: map[string]string{
+				ConnectorLabel: inst.ConnectorId,
+				InstanceLabel:  inst.Id,
+				NamespaceLabel: c.namespace,
+			},
+		})
+		if err != nil {
+			return started, fmt.Errorf("could not start instance of %s: %w", inst.ConnectorId, err)
+		}
+
+		started = append(started, inst)
+	}
+
+	return started, nil
+}
+
+func (c *ContainerScheduler) Stop(ctx context.Context, instances []model.Instance) error {
+	var errs []error
+	for _, backend := range c.backends {
+		containers, err := c.containers(ctx, backend, "")
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, ct := range containers {
+			if containsInstance(instances, ct.Labels[InstanceLabel]) {
+				if err := backend.ForceRemove(ctx, ct.Name); err != nil {
+					errs = append(errs, fmt.Errorf("could not remove %s: %w", ct.Name, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *ContainerScheduler) Instances(ctx context.Context, connectorId string) ([]model.Instance, error) {
+	return c.instances(ctx, connectorId, true)
+}
+
+// Exited returns the instances whose containers exited on their own, in the order they were started. They are
+// removed once their connector is stopped.
+func (c *ContainerScheduler) Exited(ctx context.Context, connectorId string) ([]model.Instance, error) {
+	return c.instances(ctx, connectorId, false)
+}
+
+// instances returns the instances of the connector whose containers are running, or are not, in the order they
+// were started.
+func (c *ContainerScheduler) instances(ctx context.Context, connectorId string, running bool) ([]model.Instance, error) {
+	instances := []model.Instance{}
+	for _, backend := range c.backends {
+		containers, err := c.containers(ctx, backend, connectorId)
+		if err != nil {
+			return nil, err
+		}
+
+		// docker lists the most recently created containers first
+		slices.Reverse(containers)
+		for _, ct := range containers {
+			if ct.IsContainerRunning() != running {
+				continue
+			}
+
+			instances = append(instances, model.Instance{
+				ConnectorId: ct.Labels[ConnectorLabel],
+				Id:          ct.Labels[InstanceLabel],
+			})
+		}
+	}
+	return instances, nil
+}
+
+// containers lists the instance containers of the connector in the namespace, or of all its connectors if the id
+// is empty. Only containers started by a control plane carry the instance label.
+func (c *ContainerScheduler) containers(ctx context.Context, backend ContainerBackend, connectorId string) ([]docker.ContainerStatus, error) {
+	labels := map[string]string{InstanceLabel: "", NamespaceLabel: c.namespace}
+	if connectorId != "" {
+		labels[ConnectorLabel] = connectorId
+	}
+
+	containers, err := backend.ListContainers(ctx, labels)
+	if err != nil {
+		return nil, fmt.Errorf("could not list containers: %w", err)
+	}
+	return containers, nil
+}
+
+func (c *ContainerScheduler) nextBackend() ContainerBackend {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	backend := c.backends[c.next%len(c.backends)]
+	c.next++
+	return backend
+}
+
+// containerName returns the name of the container running the instance.
+func containerName(inst model.Instance) string {
+	return fmt.Sprintf("connect_%s_%s", inst.ConnectorId, inst.Id)
+}
@@ -0,0 +1,247 @@
+package controlplane_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/docker"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// fakeBackend keeps containers in memory, newest first like docker lists them.
+type fakeBackend struct {
+	mu         sync.Mutex
+	containers []*docker.RunOptions
+	exited     map[string]bool
+	pulled     []string
+
+	// limit makes starting fail once the given number of containers exist
+	limit int
+	// hold makes starting wait until it is closed, after signalling held
+	hold chan struct{}
+	held chan struct{}
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{exited: map[string]bool{}}
+}
+
+func (f *fakeBackend) Start(_ context.Context, opts *docker.RunOptions) error {
+	if f.hold != nil {
+		f.held <- struct{}{}
+		<-f.hold
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.limit > 0 && len(f.containers) >= f.limit {
+		return fmt.Errorf("no room for %s", opts.ConnectorID)
+	}
+	f.containers = append([]*docker.RunOptions{opts}, f.containers...)
+	return nil
+}
+
+func (f *fakeBackend) ListContainers(_ context.Context, labels map[string]string) ([]docker.ContainerStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []docker.ContainerStatus
+	for _, c := range f.containers {
+		matches := true
+		for k, v := range labels {
+			if actual, ok := c.Labels[k]; !ok || (v != "" && actual != v) {
+				matches = false
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		status := "Up 1 second"
+		if f.exited[c.ConnectorID] {
+			status = "Exited (1) 1 second ago"
+		}
+		result = append(result, docker.ContainerStatus{Name: c.ConnectorID, Status: status, Exists: true, Labels: c.Labels})
+	}
+	return result, nil
+}
+
+func (f *fakeBackend) ForceRemove(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, c := range f.containers {
+		if c.ConnectorID == name {
+			f.containers = append(f.containers[:i], f.containers[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no such container: %s", name)
+}
+
+func (f *fakeBackend) Pull(_ context.Context, image string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pulled = append(f.pulled, image)
+	return nil
+}
+
+func (f *fakeBackend) started() []*docker.RunOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*docker.RunOptions{}, f.containers...)
+}
+
+var _ = Describe("ContainerScheduler", func() {
+	var (
+		local, remote *fakeBackend
+		scheduler     *controlplane.ContainerScheduler
+		ctx           context.Context
+	)
+
+	connector := model.Connector{
+		ConnectorId: "test",
+		RuntimeId:   "wombat:v1.0.3",
+		Steps: model.Steps{
+			Producer: &model.ProducerStep{Core: &model.ProducerStepCore{Subject: "test"}},
+		},
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		local, remote = newFakeBackend(), newFakeBackend()
+
+		lib := controlplane.Library{Runtimes: []model.Runtime{{Id: "wombat", Image: "registry.synadia.io/connect-runtime-wombat", DefaultVersion: "latest"}}}
+		conn := controlplane.WorkloadConnection{Servers: "nats://nats:4222", JWT: "the-jwt", Seed: "the-seed"}
+
+		var err error
+		scheduler, err = controlplane.NewContainerScheduler("ACCOUNT", lib.ResolveImage, conn, local, remote)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should require a backend", func() {
+		_, err := controlplane.NewContainerScheduler("ACCOUNT", nil, controlplane.WorkloadConnection{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should start labelled containers with the workload environment", func() {
+		instances, err := scheduler.Start(ctx, controlplane.StartRequest{
+			Namespace: "ACCOUNT",
+			Connector: connector,
+			Options:   model.ConnectorStartOptions{EnvVars: model.ConnectorStartOptionsEnvVars{"KEY": "value"}},
+			Replicas:  1,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(HaveLen(1))
+
+		started := local.started()
+		Expect(started).To(HaveLen(1))
+
+		opts := started[0]
+		Expect(opts.Image).To(Equal("registry.synadia.io/connect-runtime-wombat:v1.0.3"))
+		Expect(opts.ConnectorID).To(Equal("connect_test_" + instances[0].Id))
+		Expect(opts.Steps.Producer.Core.Subject).To(Equal("test"))
+		Expect(opts.Labels).To(HaveKeyWithValue(controlplane.InstanceLabel, instances[0].Id))
+		Expect(opts.Labels).To(HaveKeyWithValue(controlplane.ConnectorLabel, "test"))
+		Expect(opts.Labels).To(HaveKeyWithValue(controlplane.NamespaceLabel, "ACCOUNT"))
+		Expect(opts.EnvVars).To(HaveKeyWithValue("KEY", "value"))
+		Expect(opts.EnvVars).To(HaveKeyWithValue(runtime.NamespaceEnvVar, "ACCOUNT"))
+		Expect(opts.EnvVars).To(HaveKeyWithValue(runtime.GroupEnvVar, "test"))
+		Expect(opts.EnvVars).To(HaveKeyWithValue(runtime.InstanceEnvVar, instances[0].Id))
+		Expect(opts.EnvVars).To(HaveKeyWithValue(runtime.NatsUrlVar, "nats://nats:4222"))
+		Expect(opts.EnvVars).To(HaveKeyWithValue(runtime.NatsJwtVar, base64.StdEncoding.EncodeToString([]byte("the-jwt"))))
+		Expect(opts.EnvVars).To(HaveKeyWithValue(runtime.NatsSeedVar, "the-seed"))
+	})
+
+	It("should spread the instances over the backends", func() {
+		_, err := scheduler.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 3, Options: model.ConnectorStartOptions{Pull: true}})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(local.started()).To(HaveLen(2))
+		Expect(remote.started()).To(HaveLen(1))
+		Expect(local.pulled).To(HaveLen(1))
+		Expect(remote.pulled).To(HaveLen(1))
+	})
+
+	It("should only report running instances in the order they were started", func() {
+		first, err := scheduler.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 1})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = scheduler.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 1})
+		Expect(err).ToNot(HaveOccurred())
+		third, err := scheduler.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 1})
+		Expect(err).ToNot(HaveOccurred())
+
+		remote.exited["connect_test_"+remote.started()[0].Labels[controlplane.InstanceLabel]] = true
+
+		instances, err := scheduler.Instances(ctx, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(Equal([]model.Instance{first[0], third[0]}))
+
+		instances, err = scheduler.Instances(ctx, "other")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(BeEmpty())
+	})
+
+	It("should report the instances which exited", func() {
+		instances, err := scheduler.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 2})
+		Expect(err).ToNot(HaveOccurred())
+
+		remote.exited["connect_test_"+instances[1].Id] = true
+
+		exited, err := scheduler.Exited(ctx, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exited).To(Equal(instances[1:]))
+
+		Expect(scheduler.Stop(ctx, exited)).To(Succeed())
+		Expect(remote.started()).To(BeEmpty())
+	})
+
+	It("should remove the containers of stopped instances", func() {
+		instances, err := scheduler.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 2})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(scheduler.Stop(ctx, instances[1:])).To(Succeed())
+		Expect(local.started()).To(HaveLen(1))
+		Expect(remote.started()).To(BeEmpty())
+
+		running, err := scheduler.Instances(ctx, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(running).To(Equal(instances[:1]))
+	})
+
+	It("should leave the containers of other namespaces alone", func() {
+		lib := controlplane.Library{Runtimes: []model.Runtime{{Id: "wombat", Image: "registry.synadia.io/connect-runtime-wombat", DefaultVersion: "latest"}}}
+		other, err := controlplane.NewContainerScheduler("OTHER", lib.ResolveImage, controlplane.WorkloadConnection{}, local, remote)
+		Expect(err).ToNot(HaveOccurred())
+
+		instances, err := scheduler.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 1})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = other.Start(ctx, controlplane.StartRequest{Connector: connector, Replicas: 1})
+		Expect(err).ToNot(HaveOccurred())
+
+		running, err := scheduler.Instances(ctx, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(running).To(Equal(instances))
+
+		Expect(other.Stop(ctx, instances)).To(Succeed())
+		Expect(local.started()).To(HaveLen(2))
+
+		running, err = scheduler.Instances(ctx, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(running).To(Equal(instances))
+	})
+
+	It("should fail for unknown runtimes", func() {
+		_, err := scheduler.Start(ctx, controlplane.StartRequest{Connector: model.Connector{ConnectorId: "x", RuntimeId: "unknown"}, Replicas: 1})
+		Expect(err).To(MatchError(ContainSubstring("runtime unknown not found")))
+	})
+})
@@ -0,0 +1,239 @@
+// Package controlplane implements the Connect control plane service on top of a NATS connection.
+//
+// The Service answers the connector requests on $CONSVC.<account>.CONNECTORS.* and the library requests on
+// $CONLIB.* with the same model types the client package uses. Where connectors are stored and how their
+// instances are run is up to the Store and the Scheduler it is configured with, which allows the same service
+// to back both the self-hosted `connect server` and the in-memory connecttest package.
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Operation identifies a request by the last two tokens of its subject.
+type Operation string
+
+const (
+	OpCreateConnector Operation = "CONNECTORS.CREATE"
+	OpGetConnector    Operation = "CONNECTORS.GET"
+	OpListConnectors  Operation = "CONNECTORS.LIST"
+	OpPatchConnector  Operation = "CONNECTORS.PATCH"
+	OpDeleteConnector Operation = "CONNECTORS.DELETE"
+	OpStartConnector  Operation = "CONNECTORS.START"
+	OpStopConnector   Operation = "CONNECTORS.STOP"
+	OpScaleConnector  Operation = "CONNECTORS.SCALE"
+	OpListInstances   Operation = "CONNECTORS.INSTANCES"
+	OpConnectorStatus Operation = "CONNECTORS.STATUS"
+
+	OpListRuntimes     Operation = "RUNTIMES.LIST"
+	OpGetRuntime       Operation = "RUNTIMES.GET"
+	OpSearchComponents Operation = "COMPONENTS.LIST"
+	OpGetComponent     Operation = "COMPONENTS.GET"
+)
+
+// QueueGroup is the queue group the service subscribes with, so that requests are answered by one of the services
+// running for the account.
+const QueueGroup = "connect"
+
+// Config configures a Service.
+type Config struct {
+	// Account is the account the service answers connector requests for.
+	Account string
+
+	// Store keeps the connectors. Defaults to an in-memory store.
+	Store Store
+
+	// Scheduler runs the connector instances. Defaults to an in-memory scheduler which does not run anything.
+	Scheduler Scheduler
+
+	// Library holds the runtimes and components served by the library endpoints.
+	Library Library
+
+	// PageSize splits list responses into pages of the given number of items. By default, a single page is
+	// sent.
+	PageSize int
+
+	// Intercept is called for every request before it is handled. The request is not handled any further when
+	// it reports true.
+	Intercept func(op Operation, msg *nats.Msg) bool
+}
+
+// Service answers Connect control plane requests.
+type Service struct {
+	cfg  Config
+	nc   *nats.Conn
+	subs []*nats.Subscription
+
+	// handling tracks the requests being handled. handlingMu guards adding to it against Stop waiting for it,
+	// with closing set once Stop started.
+	handlingMu sync.Mutex
+	handling   sync.WaitGroup
+	closing    bool
+
+	// mu serializes the requests changing connectors or their instances
+	mu sync.Mutex
+
+	// stoppedMu guards the number of stopped instances, which is read without waiting for mu
+	stoppedMu sync.Mutex
+	stopped   map[string]int
+}
+
+// New creates a service answering requests on the given connection once started.
+func New(nc *nats.Conn, cfg Config) (*Service, error) {
+	if cfg.Account == "" {
+		return nil, fmt.Errorf("account is required")
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Scheduler == nil {
+		cfg.Scheduler = NewMemoryScheduler()
+	}
+
+	return &Service{
+		cfg:     cfg,
+		nc:      nc,
+		stopped: map[string]int{},
+	}, nil
+}
+
+// Account returns the account the service answers connector requests for.
+func (s *Service) Account() string {
+	return s.cfg.Account
+}
+
+// Store returns the store holding the connectors.
+func (s *Service) Store() Store {
+	return s.cfg.Store
+}
+
+// Scheduler returns the scheduler running the connector instances.
+func (s *Service) Scheduler() Scheduler {
+	return s.cfg.Scheduler
+}
+
+// Start subscribes to the control plane subjects.
+func (s *Service) Start() error {
+	if err := s.subscribe(fmt.Sprintf("$CONSVC.%s.CONNECTORS.*", s.cfg.Account), s.handleConnectors); err != nil {
+		s.Stop()
+		return err
+	}
+	if err := s.subscribe("$CONLIB.*.*", s.handleLibrary); err != nil {
+		s.Stop()
+		return err
+	}
+
+	if err := s.nc.Flush(); err != nil {
+		s.Stop()
+		return fmt.Errorf("could not flush subscriptions: %w", err)
+	}
+
+	return nil
+}
+
+// Stop stops answering requests and waits for the requests being handled. Running instances are left alone.
+func (s *Service) Stop() {
+	s.handlingMu.Lock()
+	s.closing = true
+	s.handlingMu.Unlock()
+
+	for _, sub := range s.subs {
+		_ = sub.Unsubscribe()
+	}
+	s.subs = nil
+	s.handling.Wait()
+}
+
+// subscribe handles every request on its own goroutine, so that slow requests like starting a connector do not
+// hold up the others.
+func (s *Service) subscribe(subject string, h func(op Operation, msg *nats.Msg)) error {
+	sub, err := s.nc.QueueSubscribe(subject, QueueGroup, func(msg *nats.Msg) {
+		tokens := strings.Split(msg.Subject, ".")
+		op := Operation(strings.Join(tokens[len(tokens)-2:], "."))
+
+		s.handlingMu.Lock()
+		if s.closing {
+			s.handlingMu.Unlock()
+			return
+		}
+		s.handling.Add(1)
+		s.handlingMu.Unlock()
+
+		go func() {
+			defer s.handling.Done()
+
+			if s.cfg.Intercept != nil && s.cfg.Intercept(op, msg) {
+				return
+			}
+			h(op, msg)
+		}()
+	})
+	if err != nil {
+		return fmt.Errorf("could not subscribe to %s: %w", subject, err)
+	}
+
+	s.subs = append(s.subs, sub)
+	return nil
+}
+
+// decode parses the request, answering with a bad request error if that fails.
+func (s *Service) decode(msg *nats.Msg, req any) bool {
+	if err := json.Unmarshal(msg.Data, req); err != nil {
+		RespondError(msg, 400, fmt.Sprintf("invalid request: %s", err))
+		return false
+	}
+	return true
+}
+
+func (s *Service) respond(msg *nats.Msg, resp any) {
+	b, err := json.Marshal(resp)
+	if err != nil {
+		RespondError(msg, 500, fmt.Sprintf("could not marshal response: %s", err))
+		return
+	}
+
+	_ = msg.Respond(b)
+}
+
+// respondPages sends the items in pages of the configured size, flagging all but the last page with the
+// has-more header.
+func respondPages[T any](s *Service, msg *nats.Msg, items []T, page func(items []T) any) {
+	size := s.cfg.PageSize
+	if size <= 0 || size > len(items) {
+		size = max(len(items), 1)
+	}
+
+	for start := 0; ; start += size {
+		end := min(start+size, len(items))
+
+		b, err := json.Marshal(page(items[start:end]))
+		if err != nil {
+			RespondError(msg, 500, fmt.Sprintf("could not marshal response: %s", err))
+			return
+		}
+
+		resp := nats.NewMsg(msg.Reply)
+		resp.Data = b
+		if end < len(items) {
+			resp.Header.Set("Nats-Has-More", "true")
+		}
+		_ = s.nc.PublishMsg(resp)
+
+		if end >= len(items) {
+			return
+		}
+	}
+}
+
+// RespondError answers the request with a service error carrying the given code and description.
+func RespondError(msg *nats.Msg, code int, description string) {
+	resp := nats.NewMsg(msg.Reply)
+	resp.Header.Set("Nats-Service-Error", description)
+	resp.Header.Set("Nats-Service-Error-Code", fmt.Sprintf("%d", code))
+	_ = msg.RespondMsg(resp)
+}
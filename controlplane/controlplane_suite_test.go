@@ -0,0 +1,37 @@
+package controlplane_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestControlplane(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controlplane Suite")
+}
+
+// startJetStreamServer runs an embedded NATS server with JetStream enabled until the spec ends.
+func startJetStreamServer() *server.Server {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+		StoreDir:  GinkgoT().TempDir(),
+	})
+	Expect(err).ToNot(HaveOccurred())
+
+	go ns.Start()
+	Expect(ns.ReadyForConnections(10 * time.Second)).To(BeTrue())
+
+	DeferCleanup(func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+	})
+	return ns
+}
@@ -0,0 +1,162 @@
+package controlplane
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/model"
+)
+
+// Library holds the runtimes and components served by the library endpoints.
+type Library struct {
+	Runtimes   []model.Runtime   `json:"runtimes"`
+	Components []model.Component `json:"components"`
+}
+
+// LoadLibrary reads a library from the given JSON file. The file holds an object with a "runtimes" and a
+// "components" array, like the model.Runtime and model.Component types.
+func LoadLibrary(file string) (Library, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return Library{}, fmt.Errorf("could not read library: %w", err)
+	}
+
+	var lib Library
+	if err := json.Unmarshal(b, &lib); err != nil {
+		return Library{}, fmt.Errorf("could not parse library: %w", err)
+	}
+	return lib, nil
+}
+
+// Runtime returns the runtime with the given id, or nil if there is none. A version suffix like in
+// "wombat:v1.0.3" is ignored.
+func (l Library) Runtime(id string) *model.Runtime {
+	id, _, _ = strings.Cut(id, ":")
+	for _, rt := range l.Runtimes {
+		if rt.Id == id {
+			return &rt
+		}
+	}
+	return nil
+}
+
+// ResolveImage returns the image to run for the given runtime reference. The version defaults to the default
+// version of the runtime.
+func (l Library) ResolveImage(runtimeRef string) (string, error) {
+	id, version, _ := strings.Cut(runtimeRef, ":")
+
+	rt := l.Runtime(id)
+	if rt == nil {
+		return "", fmt.Errorf("runtime %s not found", id)
+	}
+
+	if version == "" {
+		version = rt.DefaultVersion
+	}
+	if version == "" {
+		version = "latest"
+	}
+
+	return fmt.Sprintf("%s:%s", rt.Image, version), nil
+}
+
+func (s *Service) handleLibrary(op Operation, msg *nats.Msg) {
+	switch op {
+	case OpListRuntimes:
+		s.listRuntimes(msg)
+	case OpGetRuntime:
+		s.getRuntime(msg)
+	case OpSearchComponents:
+		s.searchComponents(msg)
+	case OpGetComponent:
+		s.getComponent(msg)
+	default:
+		RespondError(msg, 404, fmt.Sprintf("unknown operation %s", op))
+	}
+}
+
+func (s *Service) listRuntimes(msg *nats.Msg) {
+	summaries := make([]model.RuntimeSummary, 0, len(s.cfg.Library.Runtimes))
+	for _, rt := range s.cfg.Library.Runtimes {
+		summaries = append(summaries, model.RuntimeSummary{
+			Author:         rt.Author.Name,
+			DefaultVersion: rt.DefaultVersion,
+			Description:    rt.Description,
+			Id:             rt.Id,
+			Label:          rt.Label,
+		})
+	}
+
+	s.respond(msg, model.RuntimeListResponse{Runtimes: summaries})
+}
+
+func (s *Service) getRuntime(msg *nats.Msg) {
+	var req model.RuntimeGetRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	for _, rt := range s.cfg.Library.Runtimes {
+		if rt.Id == req.Name {
+			s.respond(msg, model.RuntimeGetResponse{Runtime: &rt})
+			return
+		}
+	}
+
+	RespondError(msg, 404, fmt.Sprintf("runtime %s not found", req.Name))
+}
+
+func (s *Service) searchComponents(msg *nats.Msg) {
+	var req model.ComponentSearchRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	summaries := []model.ComponentSummary{}
+	for _, c := range s.cfg.Library.Components {
+		if f := req.Filter; f != nil {
+			if f.RuntimeId != nil && *f.RuntimeId != c.RuntimeId {
+				continue
+			}
+			if f.Kind != nil && *f.Kind != c.Kind {
+				continue
+			}
+			if f.Status != nil && *f.Status != c.Status {
+				continue
+			}
+		}
+
+		summaries = append(summaries, model.ComponentSummary{
+			Description: c.Description,
+			Icon:        c.Icon,
+			Kind:        c.Kind,
+			Label:       c.Label,
+			Name:        c.Name,
+			RuntimeId:   c.RuntimeId,
+			Status:      c.Status,
+		})
+	}
+
+	respondPages(s, msg, summaries, func(items []model.ComponentSummary) any {
+		return model.ComponentSearchResponse{Components: items}
+	})
+}
+
+func (s *Service) getComponent(msg *nats.Msg) {
+	var req model.ComponentGetRequest
+	if !s.decode(msg, &req) {
+		return
+	}
+
+	for _, c := range s.cfg.Library.Components {
+		if c.RuntimeId == req.RuntimeId && c.Kind == req.Kind && c.Name == req.Name {
+			s.respond(msg, model.ComponentGetResponse{Component: &c})
+			return
+		}
+	}
+
+	RespondError(msg, 404, fmt.Sprintf("component %s %s of runtime %s not found", req.Kind, req.Name, req.RuntimeId))
+}
@@ -0,0 +1,101 @@
+package controlplane
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nats-io/nuid"
+	"github.com/synadia-io/connect/model"
+)
+
+// StartRequest describes the instances a Scheduler is asked to start.
+type StartRequest struct {
+	// Namespace is the account the instances run for.
+	Namespace string
+	// Connector is the connector to run.
+	Connector model.Connector
+	// Options are the start options given by the client.
+	Options model.ConnectorStartOptions
+	// Replicas is the number of instances to start.
+	Replicas int
+}
+
+// Scheduler runs connector instances.
+type Scheduler interface {
+	// Start starts the requested number of instances and returns them. When it fails, it returns the instances
+	// which did start along with the error.
+	Start(ctx context.Context, req StartRequest) ([]model.Instance, error)
+	// Stop stops the given instances.
+	Stop(ctx context.Context, instances []model.Instance) error
+	// Instances returns the running instances of the connector in the order they were started, or those of all
+	// connectors if the id is empty.
+	Instances(ctx context.Context, connectorId string) ([]model.Instance, error)
+}
+
+// ExitedReporter is implemented by schedulers which know about instances that exited on their own. Exited
+// instances count as stopped and are cleaned up when their connector is stopped.
+type ExitedReporter interface {
+	Exited(ctx context.Context, connectorId string) ([]model.Instance, error)
+}
+
+// NewMemoryScheduler creates a scheduler which only keeps track of instances without running anything.
+func NewMemoryScheduler() Scheduler {
+	return &memoryScheduler{}
+}
+
+type memoryScheduler struct {
+	mu        sync.Mutex
+	instances []model.Instance
+}
+
+func (m *memoryScheduler) Start(_ context.Context, req StartRequest) ([]model.Instance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	started := make([]model.Instance, req.Replicas)
+	for i := range started {
+		started[i] = model.Instance{
+			ConnectorId: req.Connector.ConnectorId,
+			Id:          nuid.Next(),
+		}
+	}
+
+	m.instances = append(m.instances, started...)
+	return started, nil
+}
+
+func (m *memoryScheduler) Stop(_ context.Context, instances []model.Instance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	remaining := m.instances[:0]
+	for _, inst := range m.instances {
+		if !containsInstance(instances, inst.Id) {
+			remaining = append(remaining, inst)
+		}
+	}
+	m.instances = remaining
+	return nil
+}
+
+func (m *memoryScheduler) Instances(_ context.Context, connectorId string) ([]model.Instance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instances := []model.Instance{}
+	for _, inst := range m.instances {
+		if connectorId == "" || inst.ConnectorId == connectorId {
+			instances = append(instances, inst)
+		}
+	}
+	return instances, nil
+}
+
+func containsInstance(instances []model.Instance, id string) bool {
+	for _, inst := range instances {
+		if inst.Id == id {
+			return true
+		}
+	}
+	return false
+}
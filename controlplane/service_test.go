@@ -0,0 +1,204 @@
+package controlplane_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/client"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("Service", func() {
+	var (
+		nc        *nats.Conn
+		store     controlplane.Store
+		backend   *fakeBackend
+		scheduler controlplane.Scheduler
+		cl        client.Client
+	)
+
+	lib := controlplane.Library{Runtimes: []model.Runtime{{
+		Id:             "wombat",
+		Label:          "Wombat",
+		Image:          "registry.synadia.io/connect-runtime-wombat",
+		DefaultVersion: "latest",
+		Author:         model.RuntimeAuthor{Name: "Synadia"},
+	}}}
+
+	steps := model.Steps{
+		Producer: &model.ProducerStep{Core: &model.ProducerStepCore{Subject: "test"}},
+	}
+
+	startService := func() {
+		svc, err := controlplane.New(nc, controlplane.Config{
+			Account:   "$G",
+			Store:     store,
+			Scheduler: scheduler,
+			Library:   lib,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(svc.Start()).To(Succeed())
+		DeferCleanup(svc.Stop)
+	}
+
+	BeforeEach(func() {
+		ns := startJetStreamServer()
+
+		var err error
+		nc, err = nats.Connect(ns.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		js, err := jetstream.New(nc)
+		Expect(err).ToNot(HaveOccurred())
+
+		store, err = controlplane.CreateKVStore(context.Background(), js, "connectors", 1)
+		Expect(err).ToNot(HaveOccurred())
+
+		backend = newFakeBackend()
+		scheduler, err = controlplane.NewContainerScheduler("$G", lib.ResolveImage, controlplane.WorkloadConnection{Servers: ns.ClientURL()}, backend)
+		Expect(err).ToNot(HaveOccurred())
+
+		startService()
+
+		cl, err = client.NewClient(nc, false)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should require an account", func() {
+		_, err := controlplane.New(nc, controlplane.Config{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should run connectors through the scheduler", func() {
+		_, err := cl.CreateConnector("test", "a test connector", "wombat", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		instances, err := cl.StartConnector("test", &model.ConnectorStartOptions{Replicas: 2}, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(HaveLen(2))
+		Expect(backend.started()).To(HaveLen(2))
+
+		list, err := cl.ListConnectors(time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Instances.Running).To(Equal(2))
+
+		scaled, err := cl.ScaleConnector("test", 1, nil, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scaled).To(Equal(instances[:1]))
+
+		err = cl.DeleteConnector("test", time.Second)
+		var serr *client.ServiceError
+		Expect(errors.As(err, &serr)).To(BeTrue())
+		Expect(serr.Code).To(Equal(409))
+
+		stopped, err := cl.StopConnector("test", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stopped).To(Equal(instances[:1]))
+		Expect(backend.started()).To(BeEmpty())
+
+		status, err := cl.GetConnectorStatus("test", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Running).To(Equal(0))
+		Expect(status.Stopped).To(Equal(2))
+
+		Expect(cl.DeleteConnector("test", time.Second)).To(Succeed())
+	})
+
+	It("should stop the instances which started when starting fails", func() {
+		_, err := cl.CreateConnector("test", "a test connector", "wombat", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		backend.limit = 1
+		_, err = cl.StartConnector("test", &model.ConnectorStartOptions{Replicas: 2}, time.Second)
+		Expect(err).To(MatchError(ContainSubstring("no room")))
+		Expect(backend.started()).To(BeEmpty())
+
+		_, err = cl.ScaleConnector("test", 2, nil, time.Second)
+		Expect(err).To(MatchError(ContainSubstring("no room")))
+		Expect(backend.started()).To(BeEmpty())
+	})
+
+	It("should answer other requests while a connector starts", func() {
+		_, err := cl.CreateConnector("test", "a test connector", "wombat", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		backend.hold = make(chan struct{})
+		backend.held = make(chan struct{}, 1)
+		started := make(chan error, 1)
+		go func() {
+			_, err := cl.StartConnector("test", nil, 5*time.Second)
+			started <- err
+		}()
+		Eventually(backend.held).Should(Receive())
+
+		list, err := cl.ListConnectors(time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(started).ToNot(Receive())
+
+		close(backend.hold)
+		Eventually(started).Should(Receive(BeNil()))
+		Expect(backend.started()).To(HaveLen(1))
+	})
+
+	It("should count exited containers as stopped and remove them on stop", func() {
+		_, err := cl.CreateConnector("test", "a test connector", "wombat", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		instances, err := cl.StartConnector("test", &model.ConnectorStartOptions{Replicas: 2}, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		backend.mu.Lock()
+		backend.exited["connect_test_"+instances[0].Id] = true
+		backend.mu.Unlock()
+
+		status, err := cl.GetConnectorStatus("test", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Running).To(Equal(1))
+		Expect(status.Stopped).To(Equal(1))
+
+		_, err = cl.StopConnector("test", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backend.started()).To(BeEmpty())
+
+		status, err = cl.GetConnectorStatus("test", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Stopped).To(Equal(2))
+	})
+
+	It("should keep the connectors in the key-value bucket", func() {
+		_, err := cl.CreateConnector("test", "a test connector", "wombat", steps, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = cl.PatchConnector("test", `{"description": "patched"}`, time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		c, err := store.Get(context.Background(), "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Description).To(Equal("patched"))
+		Expect(c.Steps.Producer.Core.Subject).To(Equal("test"))
+	})
+
+	It("should report missing connectors as not found", func() {
+		_, err := cl.StartConnector("missing", nil, time.Second)
+		Expect(err).To(MatchError(client.ErrNotFound))
+	})
+
+	It("should serve the library", func() {
+		runtimes, err := cl.ListRuntimes(time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runtimes).To(HaveLen(1))
+		Expect(runtimes[0].Author).To(Equal("Synadia"))
+
+		rt, err := cl.GetRuntime("wombat", time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rt.Image).To(Equal("registry.synadia.io/connect-runtime-wombat"))
+	})
+})
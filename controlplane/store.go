@@ -0,0 +1,221 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/model"
+)
+
+var (
+	// ErrConnectorNotFound is returned by a Store when the requested connector does not exist.
+	ErrConnectorNotFound = errors.New("connector not found")
+	// ErrConnectorExists is returned by a Store when a connector is created with an id already in use.
+	ErrConnectorExists = errors.New("connector already exists")
+)
+
+// Store keeps the connector definitions of a control plane.
+type Store interface {
+	// Get returns the connector with the given id, or ErrConnectorNotFound.
+	Get(ctx context.Context, id string) (*model.Connector, error)
+	// List returns all connectors, sorted by id.
+	List(ctx context.Context) ([]model.Connector, error)
+	// Create adds a new connector, or returns ErrConnectorExists.
+	Create(ctx context.Context, connector model.Connector) error
+	// Update replaces an existing connector, or returns ErrConnectorNotFound.
+	Update(ctx context.Context, connector model.Connector) error
+	// Delete removes the connector with the given id and reports whether it existed.
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+// NewMemoryStore creates a store which only keeps the connectors in memory.
+func NewMemoryStore() Store {
+	return &memoryStore{connectors: map[string]model.Connector{}}
+}
+
+type memoryStore struct {
+	mu         sync.Mutex
+	connectors map[string]model.Connector
+}
+
+func (m *memoryStore) Get(_ context.Context, id string) (*model.Connector, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.connectors[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, ErrConnectorNotFound)
+	}
+	return &c, nil
+}
+
+func (m *memoryStore) List(_ context.Context) ([]model.Connector, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	connectors := make([]model.Connector, 0, len(m.connectors))
+	for _, c := range m.connectors {
+		connectors = append(connectors, c)
+	}
+
+	sortConnectors(connectors)
+	return connectors, nil
+}
+
+func (m *memoryStore) Create(_ context.Context, connector model.Connector) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.connectors[connector.ConnectorId]; ok {
+		return fmt.Errorf("%s: %w", connector.ConnectorId, ErrConnectorExists)
+	}
+
+	m.connectors[connector.ConnectorId] = connector
+	return nil
+}
+
+func (m *memoryStore) Update(_ context.Context, connector model.Connector) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.connectors[connector.ConnectorId]; !ok {
+		return fmt.Errorf("%s: %w", connector.ConnectorId, ErrConnectorNotFound)
+	}
+
+	m.connectors[connector.ConnectorId] = connector
+	return nil
+}
+
+func (m *memoryStore) Delete(_ context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.connectors[id]
+	delete(m.connectors, id)
+	return ok, nil
+}
+
+// NewKVStore creates a store which persists the connectors as JSON in the given JetStream key-value bucket,
+// keyed by connector id.
+func NewKVStore(kv jetstream.KeyValue) Store {
+	return &kvStore{kv: kv}
+}
+
+// CreateKVStore creates or updates the key-value bucket with the given name and returns a store using it.
+func CreateKVStore(ctx context.Context, js jetstream.JetStream, bucket string, replicas int) (Store, error) {
+	kv, err := js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "Synadia Connect connectors",
+		Replicas:    max(replicas, 1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create key-value bucket %s: %w", bucket, err)
+	}
+
+	return NewKVStore(kv), nil
+}
+
+type kvStore struct {
+	kv jetstream.KeyValue
+}
+
+func (k *kvStore) Get(ctx context.Context, id string) (*model.Connector, error) {
+	entry, err := k.kv.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, fmt.Errorf("%s: %w", id, ErrConnectorNotFound)
+		}
+		return nil, fmt.Errorf("could not get connector %s: %w", id, err)
+	}
+
+	var c model.Connector
+	if err := json.Unmarshal(entry.Value(), &c); err != nil {
+		return nil, fmt.Errorf("could not decode connector %s: %w", id, err)
+	}
+	return &c, nil
+}
+
+func (k *kvStore) List(ctx context.Context) ([]model.Connector, error) {
+	keys, err := k.kv.ListKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list connectors: %w", err)
+	}
+	defer func() { _ = keys.Stop() }()
+
+	connectors := []model.Connector{}
+	for key := range keys.Keys() {
+		c, err := k.Get(ctx, key)
+		if errors.Is(err, ErrConnectorNotFound) {
+			// deleted while listing
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, *c)
+	}
+
+	sortConnectors(connectors)
+	return connectors, nil
+}
+
+func (k *kvStore) Create(ctx context.Context, connector model.Connector) error {
+	b, err := json.Marshal(connector)
+	if err != nil {
+		return fmt.Errorf("could not encode connector %s: %w", connector.ConnectorId, err)
+	}
+
+	if _, err := k.kv.Create(ctx, connector.ConnectorId, b); err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return fmt.Errorf("%s: %w", connector.ConnectorId, ErrConnectorExists)
+		}
+		return fmt.Errorf("could not create connector %s: %w", connector.ConnectorId, err)
+	}
+	return nil
+}
+
+func (k *kvStore) Update(ctx context.Context, connector model.Connector) error {
+	entry, err := k.kv.Get(ctx, connector.ConnectorId)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return fmt.Errorf("%s: %w", connector.ConnectorId, ErrConnectorNotFound)
+		}
+		return fmt.Errorf("could not get connector %s: %w", connector.ConnectorId, err)
+	}
+
+	b, err := json.Marshal(connector)
+	if err != nil {
+		return fmt.Errorf("could not encode connector %s: %w", connector.ConnectorId, err)
+	}
+
+	if _, err := k.kv.Update(ctx, connector.ConnectorId, b, entry.Revision()); err != nil {
+		return fmt.Errorf("could not update connector %s: %w", connector.ConnectorId, err)
+	}
+	return nil
+}
+
+func (k *kvStore) Delete(ctx context.Context, id string) (bool, error) {
+	if _, err := k.kv.Get(ctx, id); err != nil {
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not get connector %s: %w", id, err)
+	}
+
+	if err := k.kv.Delete(ctx, id); err != nil {
+		return false, fmt.Errorf("could not delete connector %s: %w", id, err)
+	}
+	return true, nil
+}
+
+func sortConnectors(connectors []model.Connector) {
+	slices.SortFunc(connectors, func(a, b model.Connector) int {
+		return strings.Compare(a.ConnectorId, b.ConnectorId)
+	})
+}
@@ -0,0 +1,131 @@
+package controlplane_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/controlplane"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("Store", func() {
+	connector := func(id string) model.Connector {
+		return model.Connector{
+			ConnectorId: id,
+			Description: "connector " + id,
+			RuntimeId:   "wombat",
+			Steps: model.Steps{
+				Producer: &model.ProducerStep{Core: &model.ProducerStepCore{Subject: id}},
+			},
+		}
+	}
+
+	behaveLikeAStore := func(newStore func() controlplane.Store) {
+		var (
+			store controlplane.Store
+			ctx   context.Context
+		)
+
+		BeforeEach(func() {
+			store = newStore()
+
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+			DeferCleanup(cancel)
+		})
+
+		It("should create and get a connector", func() {
+			Expect(store.Create(ctx, connector("test"))).To(Succeed())
+
+			c, err := store.Get(ctx, "test")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Description).To(Equal("connector test"))
+			Expect(c.Steps.Producer.Core.Subject).To(Equal("test"))
+		})
+
+		It("should refuse to create a connector twice", func() {
+			Expect(store.Create(ctx, connector("test"))).To(Succeed())
+			Expect(store.Create(ctx, connector("test"))).To(MatchError(controlplane.ErrConnectorExists))
+		})
+
+		It("should report missing connectors", func() {
+			_, err := store.Get(ctx, "missing")
+			Expect(err).To(MatchError(controlplane.ErrConnectorNotFound))
+
+			Expect(store.Update(ctx, connector("missing"))).To(MatchError(controlplane.ErrConnectorNotFound))
+
+			existed, err := store.Delete(ctx, "missing")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(existed).To(BeFalse())
+		})
+
+		It("should update a connector", func() {
+			Expect(store.Create(ctx, connector("test"))).To(Succeed())
+
+			updated := connector("test")
+			updated.Description = "updated"
+			Expect(store.Update(ctx, updated)).To(Succeed())
+
+			c, err := store.Get(ctx, "test")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Description).To(Equal("updated"))
+		})
+
+		It("should list the connectors sorted by id", func() {
+			list, err := store.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list).To(BeEmpty())
+
+			for _, id := range []string{"c", "a", "b"} {
+				Expect(store.Create(ctx, connector(id))).To(Succeed())
+			}
+
+			list, err = store.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list).To(HaveLen(3))
+			Expect([]string{list[0].ConnectorId, list[1].ConnectorId, list[2].ConnectorId}).To(Equal([]string{"a", "b", "c"}))
+		})
+
+		It("should delete a connector", func() {
+			Expect(store.Create(ctx, connector("test"))).To(Succeed())
+
+			existed, err := store.Delete(ctx, "test")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(existed).To(BeTrue())
+
+			_, err = store.Get(ctx, "test")
+			Expect(err).To(MatchError(controlplane.ErrConnectorNotFound))
+
+			list, err := store.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(list).To(BeEmpty())
+
+			Expect(store.Create(ctx, connector("test"))).To(Succeed())
+		})
+	}
+
+	Describe("memory", func() {
+		behaveLikeAStore(controlplane.NewMemoryStore)
+	})
+
+	Describe("key-value", func() {
+		behaveLikeAStore(func() controlplane.Store {
+			ns := startJetStreamServer()
+
+			nc, err := nats.Connect(ns.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(nc.Close)
+
+			js, err := jetstream.New(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			store, err := controlplane.CreateKVStore(context.Background(), js, "connectors", 1)
+			Expect(err).ToNot(HaveOccurred())
+			return store
+		})
+	})
+})
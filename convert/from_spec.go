@@ -2,6 +2,7 @@ package convert
 
 import (
 	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
 	"github.com/synadia-io/connect/spec"
 )
 
@@ -9,15 +10,9 @@ func ConvertStepsFromSpec(sp spec.StepsSpec) model.Steps {
 	result := model.Steps{}
 
 	if sp.Consumer != nil {
-		ncfg := sp.Consumer.Nats
-
 		result.Consumer = &model.ConsumerStep{
-			Nats: model.NatsConfig{
-				Url:         ncfg.Url,
-				AuthEnabled: ncfg.AuthEnabled,
-				Jwt:         ncfg.Jwt,
-				Seed:        ncfg.Seed,
-			},
+			Nats:   ConvertNatsConfigFromSpec(sp.Consumer.Nats),
+			Schema: ConvertMessageSchemaFromSpec(sp.Consumer.Schema),
 		}
 
 		if sp.Consumer.Core != nil {
@@ -29,7 +24,18 @@ func ConvertStepsFromSpec(sp spec.StepsSpec) model.Steps {
 
 		if sp.Consumer.Stream != nil {
 			result.Consumer.Stream = &model.ConsumerStepStream{
-				Subject: sp.Consumer.Stream.Subject,
+				Subject:        sp.Consumer.Stream.Subject,
+				Stream:         sp.Consumer.Stream.Stream,
+				Durable:        sp.Consumer.Stream.Durable,
+				Bind:           sp.Consumer.Stream.Bind,
+				FilterSubjects: sp.Consumer.Stream.FilterSubjects,
+				DeliverPolicy:  model.ConsumerStepStreamDeliverPolicy(sp.Consumer.Stream.DeliverPolicy),
+				OptStartSeq:    sp.Consumer.Stream.OptStartSeq,
+				OptStartTime:   sp.Consumer.Stream.OptStartTime,
+				AckPolicy:      model.ConsumerStepStreamAckPolicy(sp.Consumer.Stream.AckPolicy),
+				AckWait:        sp.Consumer.Stream.AckWait,
+				MaxDeliver:     sp.Consumer.Stream.MaxDeliver,
+				MaxAckPending:  sp.Consumer.Stream.MaxAckPending,
 			}
 		}
 
@@ -39,39 +45,33 @@ func ConvertStepsFromSpec(sp spec.StepsSpec) model.Steps {
 				Key:    sp.Consumer.Kv.Key,
 			}
 		}
+
+		if sp.Consumer.Headers != nil {
+			result.Consumer.Headers = &model.ConsumerStepHeaders{
+				Propagate: sp.Consumer.Headers.Propagate,
+				Static:    model.ConsumerStepHeadersStatic(sp.Consumer.Headers.Static),
+			}
+		}
 	}
 
 	if sp.Producer != nil {
-		ncfg := sp.Producer.Nats
-
-		result.Producer = &model.ProducerStep{
-			Nats: model.NatsConfig{
-				Url:         ncfg.Url,
-				AuthEnabled: ncfg.AuthEnabled,
-				Jwt:         ncfg.Jwt,
-				Seed:        ncfg.Seed,
-			},
-			Threads: sp.Producer.Threads,
-		}
+		p := ConvertProducerFromSpec(*sp.Producer)
+		result.Producer = &p
+	}
 
-		if sp.Producer.Core != nil {
-			result.Producer.Core = &model.ProducerStepCore{
-				Subject: sp.Producer.Core.Subject,
-			}
-		}
+	if sp.Broker != nil {
+		b := ConvertBrokerFromSpec(*sp.Broker)
+		result.Broker = &b
+	}
 
-		if sp.Producer.Stream != nil {
-			result.Producer.Stream = &model.ProducerStepStream{
-				Subject: sp.Producer.Stream.Subject,
-			}
-		}
+	if sp.Router != nil {
+		r := ConvertRouterFromSpec(*sp.Router)
+		result.Router = &r
+	}
 
-		if sp.Producer.Kv != nil {
-			result.Producer.Kv = &model.ProducerStepKv{
-				Bucket: sp.Producer.Kv.Bucket,
-				Key:    sp.Producer.Kv.Key,
-			}
-		}
+	if sp.Errors != nil {
+		e := ConvertErrorPolicyFromSpec(*sp.Errors)
+		result.Errors = &e
 	}
 
 	if sp.Source != nil {
@@ -82,10 +82,8 @@ func ConvertStepsFromSpec(sp spec.StepsSpec) model.Steps {
 	}
 
 	if sp.Sink != nil {
-		result.Sink = &model.SinkStep{
-			Type:   sp.Sink.Type,
-			Config: model.SinkStepConfig(sp.Sink.Config),
-		}
+		k := ConvertSinkFromSpec(*sp.Sink)
+		result.Sink = &k
 	}
 
 	if sp.Transformer != nil {
@@ -96,21 +94,208 @@ func ConvertStepsFromSpec(sp spec.StepsSpec) model.Steps {
 	return result
 }
 
+func ConvertProducerFromSpec(producer spec.ProducerStepSpec) model.ProducerStep {
+	result := model.ProducerStep{
+		Nats:      ConvertNatsConfigFromSpec(producer.Nats),
+		Threads:   producer.Threads,
+		RateLimit: ConvertRateLimitFromSpec(producer.RateLimit),
+		Batching:  ConvertBatchingFromSpec(producer.Batching),
+		Schema:    ConvertMessageSchemaFromSpec(producer.Schema),
+	}
+
+	if producer.Core != nil {
+		result.Core = &model.ProducerStepCore{
+			Subject: producer.Core.Subject,
+		}
+	}
+
+	if producer.Stream != nil {
+		result.Stream = &model.ProducerStepStream{
+			Subject: producer.Stream.Subject,
+		}
+	}
+
+	if producer.Kv != nil {
+		result.Kv = &model.ProducerStepKv{
+			Bucket: producer.Kv.Bucket,
+			Key:    producer.Kv.Key,
+		}
+	}
+
+	if producer.Headers != nil {
+		result.Headers = &model.ProducerStepHeaders{
+			Propagate: producer.Headers.Propagate,
+			Static:    model.ProducerStepHeadersStatic(producer.Headers.Static),
+			MsgId:     producer.Headers.MsgId,
+		}
+	}
+
+	return result
+}
+
+func ConvertErrorPolicyFromSpec(policy spec.ErrorPolicySpec) model.ErrorPolicy {
+	result := model.ErrorPolicy{
+		MaxRetries: maxRetries(policy.MaxRetries),
+		Backoff:    policy.Backoff,
+		MaxBackoff: policy.MaxBackoff,
+	}
+
+	if policy.DeadLetter != nil {
+		p := ConvertProducerFromSpec(*policy.DeadLetter)
+		result.DeadLetter = &p
+	}
+
+	return result
+}
+
+func ConvertBrokerFromSpec(broker spec.BrokerStepSpec) model.BrokerStep {
+	result := model.BrokerStep{
+		Pattern: model.BrokerStepPattern(broker.Pattern),
+	}
+
+	for _, output := range broker.Outputs {
+		result.Outputs = append(result.Outputs, ConvertOutputFromSpec(output))
+	}
+
+	return result
+}
+
+func ConvertRouterFromSpec(router spec.RouterStepSpec) model.RouterStep {
+	result := model.RouterStep{}
+
+	for _, route := range router.Routes {
+		result.Routes = append(result.Routes, model.RouteStep{
+			Condition: route.Condition,
+			Output:    ConvertOutputFromSpec(route.Output),
+		})
+	}
+
+	if router.Default != nil {
+		o := ConvertOutputFromSpec(*router.Default)
+		result.Default = &o
+	}
+
+	return result
+}
+
+func ConvertOutputFromSpec(output spec.OutputStepSpec) model.OutputStep {
+	result := model.OutputStep{}
+
+	if output.Transformer != nil {
+		t := ConvertTransformerFromSpec(*output.Transformer)
+		result.Transformer = &t
+	}
+
+	if output.Producer != nil {
+		p := ConvertProducerFromSpec(*output.Producer)
+		result.Producer = &p
+	}
+
+	if output.Sink != nil {
+		k := ConvertSinkFromSpec(*output.Sink)
+		result.Sink = &k
+	}
+
+	return result
+}
+
+func ConvertSinkFromSpec(sink spec.SinkStepSpec) model.SinkStep {
+	return model.SinkStep{
+		Type:      sink.Type,
+		Config:    model.SinkStepConfig(sink.Config),
+		RateLimit: ConvertRateLimitFromSpec(sink.RateLimit),
+		Batching:  ConvertBatchingFromSpec(sink.Batching),
+	}
+}
+
+func ConvertNatsConfigFromSpec(cfg spec.NatsConfigSpec) model.NatsConfig {
+	result := model.NatsConfig{
+		Url:         cfg.Url,
+		AuthEnabled: cfg.AuthEnabled,
+		Jwt:         cfg.Jwt,
+		Seed:        cfg.Seed,
+		Nkey:        cfg.Nkey,
+		User:        cfg.User,
+		Password:    cfg.Password,
+		Token:       cfg.Token,
+	}
+
+	if cfg.Tls != nil {
+		result.Tls = &model.NatsConfigTls{
+			CaFile:             cfg.Tls.CaFile,
+			CertFile:           cfg.Tls.CertFile,
+			KeyFile:            cfg.Tls.KeyFile,
+			InsecureSkipVerify: cfg.Tls.InsecureSkipVerify,
+		}
+	}
+
+	if cfg.Creds != nil {
+		result.Creds = &model.NatsConfigCreds{
+			File:   cfg.Creds.File,
+			Secret: cfg.Creds.Secret,
+		}
+	}
+
+	return result
+}
+
+func ConvertRateLimitFromSpec(limit *spec.RateLimitSpec) *model.RateLimit {
+	if limit == nil {
+		return nil
+	}
+
+	return &model.RateLimit{
+		Count:  limit.Count,
+		Period: limit.Period,
+		Burst:  limit.Burst,
+	}
+}
+
+func ConvertBatchingFromSpec(batching *spec.BatchingSpec) *model.Batching {
+	if batching == nil {
+		return nil
+	}
+
+	return &model.Batching{
+		Count:    batching.Count,
+		ByteSize: batching.ByteSize,
+		Period:   batching.Period,
+	}
+}
+
+func ConvertMessageSchemaFromSpec(schema *spec.MessageSchemaSpec) *model.MessageSchema {
+	if schema == nil {
+		return nil
+	}
+
+	result := &model.MessageSchema{
+		Format:    model.MessageSchemaFormat(schema.Format),
+		Inline:    schema.Inline,
+		File:      schema.File,
+		Message:   schema.Message,
+		OnFailure: model.MessageSchemaOnFailure(schema.OnFailure),
+	}
+
+	if schema.ObjectStore != nil {
+		result.ObjectStore = &model.MessageSchemaObjectStore{
+			Bucket: schema.ObjectStore.Bucket,
+			Name:   schema.ObjectStore.Name,
+		}
+	}
+
+	return result
+}
+
 func ConvertTransformerFromSpec(sp spec.TransformerStepSpec) model.TransformerStep {
 	result := model.TransformerStep{}
 
 	if sp.Service != nil {
-		ncfg := sp.Service.Nats
-
 		result.Service = &model.ServiceTransformerStep{
-			Endpoint: sp.Service.Endpoint,
-			Timeout:  sp.Service.Timeout,
-			Nats: model.NatsConfig{
-				Url:         ncfg.Url,
-				AuthEnabled: ncfg.AuthEnabled,
-				Jwt:         ncfg.Jwt,
-				Seed:        ncfg.Seed,
-			},
+			Endpoint:  sp.Service.Endpoint,
+			Timeout:   sp.Service.Timeout,
+			RateLimit: ConvertRateLimitFromSpec(sp.Service.RateLimit),
+			Batching:  ConvertBatchingFromSpec(sp.Service.Batching),
+			Nats:      ConvertNatsConfigFromSpec(sp.Service.Nats),
 		}
 	}
 
@@ -144,3 +329,12 @@ func ConvertTransformerFromSpec(sp spec.TransformerStepSpec) model.TransformerSt
 
 	return result
 }
+
+// maxRetries fills in runtime.DefaultMaxRetries when max_retries is not set, keeping an explicit 0.
+func maxRetries(v *int) *int {
+	if v == nil {
+		n := runtime.DefaultMaxRetries
+		return &n
+	}
+	return v
+}
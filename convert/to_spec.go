@@ -9,15 +9,9 @@ func ConvertStepsToSpec(steps model.Steps) spec.StepsSpec {
 	result := spec.StepsSpec{}
 
 	if steps.Consumer != nil {
-		ncfg := steps.Consumer.Nats
-
 		result.Consumer = &spec.ConsumerStepSpec{
-			Nats: spec.NatsConfigSpec{
-				Url:         ncfg.Url,
-				AuthEnabled: ncfg.AuthEnabled,
-				Jwt:         ncfg.Jwt,
-				Seed:        ncfg.Seed,
-			},
+			Nats:   ConvertNatsConfigToSpec(steps.Consumer.Nats),
+			Schema: ConvertMessageSchemaToSpec(steps.Consumer.Schema),
 		}
 
 		if steps.Consumer.Core != nil {
@@ -29,7 +23,18 @@ func ConvertStepsToSpec(steps model.Steps) spec.StepsSpec {
 
 		if steps.Consumer.Stream != nil {
 			result.Consumer.Stream = &spec.ConsumerStepSpecStream{
-				Subject: steps.Consumer.Stream.Subject,
+				Subject:        steps.Consumer.Stream.Subject,
+				Stream:         steps.Consumer.Stream.Stream,
+				Durable:        steps.Consumer.Stream.Durable,
+				Bind:           steps.Consumer.Stream.Bind,
+				FilterSubjects: steps.Consumer.Stream.FilterSubjects,
+				DeliverPolicy:  spec.ConsumerStepSpecStreamDeliverPolicy(steps.Consumer.Stream.DeliverPolicy),
+				OptStartSeq:    steps.Consumer.Stream.OptStartSeq,
+				OptStartTime:   steps.Consumer.Stream.OptStartTime,
+				AckPolicy:      spec.ConsumerStepSpecStreamAckPolicy(steps.Consumer.Stream.AckPolicy),
+				AckWait:        steps.Consumer.Stream.AckWait,
+				MaxDeliver:     steps.Consumer.Stream.MaxDeliver,
+				MaxAckPending:  steps.Consumer.Stream.MaxAckPending,
 			}
 		}
 
@@ -39,39 +44,33 @@ func ConvertStepsToSpec(steps model.Steps) spec.StepsSpec {
 				Key:    steps.Consumer.Kv.Key,
 			}
 		}
+
+		if steps.Consumer.Headers != nil {
+			result.Consumer.Headers = &spec.ConsumerStepSpecHeaders{
+				Propagate: steps.Consumer.Headers.Propagate,
+				Static:    spec.ConsumerStepSpecHeadersStatic(steps.Consumer.Headers.Static),
+			}
+		}
 	}
 
 	if steps.Producer != nil {
-		ncfg := steps.Producer.Nats
-
-		result.Producer = &spec.ProducerStepSpec{
-			Nats: spec.NatsConfigSpec{
-				Url:         ncfg.Url,
-				AuthEnabled: ncfg.AuthEnabled,
-				Jwt:         ncfg.Jwt,
-				Seed:        ncfg.Seed,
-			},
-			Threads: steps.Producer.Threads,
-		}
+		p := ConvertProducerToSpec(*steps.Producer)
+		result.Producer = &p
+	}
 
-		if steps.Producer.Core != nil {
-			result.Producer.Core = &spec.ProducerStepSpecCore{
-				Subject: steps.Producer.Core.Subject,
-			}
-		}
+	if steps.Broker != nil {
+		b := ConvertBrokerToSpec(*steps.Broker)
+		result.Broker = &b
+	}
 
-		if steps.Producer.Stream != nil {
-			result.Producer.Stream = &spec.ProducerStepSpecStream{
-				Subject: steps.Producer.Stream.Subject,
-			}
-		}
+	if steps.Router != nil {
+		r := ConvertRouterToSpec(*steps.Router)
+		result.Router = &r
+	}
 
-		if steps.Producer.Kv != nil {
-			result.Producer.Kv = &spec.ProducerStepSpecKv{
-				Bucket: steps.Producer.Kv.Bucket,
-				Key:    steps.Producer.Kv.Key,
-			}
-		}
+	if steps.Errors != nil {
+		e := ConvertErrorPolicyToSpec(*steps.Errors)
+		result.Errors = &e
 	}
 
 	if steps.Source != nil {
@@ -82,10 +81,8 @@ func ConvertStepsToSpec(steps model.Steps) spec.StepsSpec {
 	}
 
 	if steps.Sink != nil {
-		result.Sink = &spec.SinkStepSpec{
-			Type:   steps.Sink.Type,
-			Config: spec.SinkStepSpecConfig(steps.Sink.Config),
-		}
+		k := ConvertSinkToSpec(*steps.Sink)
+		result.Sink = &k
 	}
 
 	if steps.Transformer != nil {
@@ -96,6 +93,198 @@ func ConvertStepsToSpec(steps model.Steps) spec.StepsSpec {
 	return result
 }
 
+func ConvertProducerToSpec(producer model.ProducerStep) spec.ProducerStepSpec {
+	result := spec.ProducerStepSpec{
+		Nats:      ConvertNatsConfigToSpec(producer.Nats),
+		Threads:   producer.Threads,
+		RateLimit: ConvertRateLimitToSpec(producer.RateLimit),
+		Batching:  ConvertBatchingToSpec(producer.Batching),
+		Schema:    ConvertMessageSchemaToSpec(producer.Schema),
+	}
+
+	if producer.Core != nil {
+		result.Core = &spec.ProducerStepSpecCore{
+			Subject: producer.Core.Subject,
+		}
+	}
+
+	if producer.Stream != nil {
+		result.Stream = &spec.ProducerStepSpecStream{
+			Subject: producer.Stream.Subject,
+		}
+	}
+
+	if producer.Kv != nil {
+		result.Kv = &spec.ProducerStepSpecKv{
+			Bucket: producer.Kv.Bucket,
+			Key:    producer.Kv.Key,
+		}
+	}
+
+	if producer.Headers != nil {
+		result.Headers = &spec.ProducerStepSpecHeaders{
+			Propagate: producer.Headers.Propagate,
+			Static:    spec.ProducerStepSpecHeadersStatic(producer.Headers.Static),
+			MsgId:     producer.Headers.MsgId,
+		}
+	}
+
+	return result
+}
+
+func ConvertErrorPolicyToSpec(policy model.ErrorPolicy) spec.ErrorPolicySpec {
+	result := spec.ErrorPolicySpec{
+		MaxRetries: maxRetries(policy.MaxRetries),
+		Backoff:    policy.Backoff,
+		MaxBackoff: policy.MaxBackoff,
+	}
+
+	if policy.DeadLetter != nil {
+		p := ConvertProducerToSpec(*policy.DeadLetter)
+		result.DeadLetter = &p
+	}
+
+	return result
+}
+
+func ConvertBrokerToSpec(broker model.BrokerStep) spec.BrokerStepSpec {
+	result := spec.BrokerStepSpec{
+		Pattern: spec.BrokerStepSpecPattern(broker.Pattern),
+	}
+
+	for _, output := range broker.Outputs {
+		result.Outputs = append(result.Outputs, ConvertOutputToSpec(output))
+	}
+
+	return result
+}
+
+func ConvertRouterToSpec(router model.RouterStep) spec.RouterStepSpec {
+	result := spec.RouterStepSpec{}
+
+	for _, route := range router.Routes {
+		result.Routes = append(result.Routes, spec.RouteStepSpec{
+			Condition: route.Condition,
+			Output:    ConvertOutputToSpec(route.Output),
+		})
+	}
+
+	if router.Default != nil {
+		o := ConvertOutputToSpec(*router.Default)
+		result.Default = &o
+	}
+
+	return result
+}
+
+func ConvertOutputToSpec(output model.OutputStep) spec.OutputStepSpec {
+	result := spec.OutputStepSpec{}
+
+	if output.Transformer != nil {
+		t := ConvertTransformerToSpec(*output.Transformer)
+		result.Transformer = &t
+	}
+
+	if output.Producer != nil {
+		p := ConvertProducerToSpec(*output.Producer)
+		result.Producer = &p
+	}
+
+	if output.Sink != nil {
+		k := ConvertSinkToSpec(*output.Sink)
+		result.Sink = &k
+	}
+
+	return result
+}
+
+func ConvertSinkToSpec(sink model.SinkStep) spec.SinkStepSpec {
+	return spec.SinkStepSpec{
+		Type:      sink.Type,
+		Config:    spec.SinkStepSpecConfig(sink.Config),
+		RateLimit: ConvertRateLimitToSpec(sink.RateLimit),
+		Batching:  ConvertBatchingToSpec(sink.Batching),
+	}
+}
+
+func ConvertNatsConfigToSpec(cfg model.NatsConfig) spec.NatsConfigSpec {
+	result := spec.NatsConfigSpec{
+		Url:         cfg.Url,
+		AuthEnabled: cfg.AuthEnabled,
+		Jwt:         cfg.Jwt,
+		Seed:        cfg.Seed,
+		Nkey:        cfg.Nkey,
+		User:        cfg.User,
+		Password:    cfg.Password,
+		Token:       cfg.Token,
+	}
+
+	if cfg.Tls != nil {
+		result.Tls = &spec.NatsConfigSpecTls{
+			CaFile:             cfg.Tls.CaFile,
+			CertFile:           cfg.Tls.CertFile,
+			KeyFile:            cfg.Tls.KeyFile,
+			InsecureSkipVerify: cfg.Tls.InsecureSkipVerify,
+		}
+	}
+
+	if cfg.Creds != nil {
+		result.Creds = &spec.NatsConfigSpecCreds{
+			File:   cfg.Creds.File,
+			Secret: cfg.Creds.Secret,
+		}
+	}
+
+	return result
+}
+
+func ConvertRateLimitToSpec(limit *model.RateLimit) *spec.RateLimitSpec {
+	if limit == nil {
+		return nil
+	}
+
+	return &spec.RateLimitSpec{
+		Count:  limit.Count,
+		Period: limit.Period,
+		Burst:  limit.Burst,
+	}
+}
+
+func ConvertBatchingToSpec(batching *model.Batching) *spec.BatchingSpec {
+	if batching == nil {
+		return nil
+	}
+
+	return &spec.BatchingSpec{
+		Count:    batching.Count,
+		ByteSize: batching.ByteSize,
+		Period:   batching.Period,
+	}
+}
+
+func ConvertMessageSchemaToSpec(schema *model.MessageSchema) *spec.MessageSchemaSpec {
+	if schema == nil {
+		return nil
+	}
+
+	result := &spec.MessageSchemaSpec{
+		Format:    spec.MessageSchemaSpecFormat(schema.Format),
+		Inline:    schema.Inline,
+		File:      schema.File,
+		Message:   schema.Message,
+		OnFailure: spec.MessageSchemaSpecOnFailure(schema.OnFailure),
+	}
+
+	if schema.ObjectStore != nil {
+		result.ObjectStore = &spec.MessageSchemaSpecObjectStore{
+			Bucket: schema.ObjectStore.Bucket,
+			Name:   schema.ObjectStore.Name,
+		}
+	}
+
+	return result
+}
+
 func ConvertTransformerToSpec(transformer model.TransformerStep) spec.TransformerStepSpec {
 	result := spec.TransformerStepSpec{}
 
@@ -114,17 +303,12 @@ func ConvertTransformerToSpec(transformer model.TransformerStep) spec.Transforme
 	}
 
 	if transformer.Service != nil {
-		ncfg := transformer.Service.Nats
-
 		result.Service = &spec.TransformerStepSpecService{
-			Endpoint: transformer.Service.Endpoint,
-			Timeout:  transformer.Service.Timeout,
-			Nats: spec.NatsConfigSpec{
-				Url:         ncfg.Url,
-				AuthEnabled: ncfg.AuthEnabled,
-				Jwt:         ncfg.Jwt,
-				Seed:        ncfg.Seed,
-			},
+			Endpoint:  transformer.Service.Endpoint,
+			Timeout:   transformer.Service.Timeout,
+			RateLimit: ConvertRateLimitToSpec(transformer.Service.RateLimit),
+			Batching:  ConvertBatchingToSpec(transformer.Service.Batching),
+			Nats:      ConvertNatsConfigToSpec(transformer.Service.Nats),
 		}
 	}
 
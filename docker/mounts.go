@@ -0,0 +1,185 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/synadia-io/connect/model"
+	"gopkg.in/yaml.v3"
+)
+
+// MountDir is the directory of the container the files referenced by the NATS configurations and message schemas of
+// the steps are mounted in.
+const MountDir = "/etc/connect/files"
+
+// MountFiles returns the read-only volumes mounting the TLS, credentials and nkey files referenced by the NATS
+// configurations of the steps and the files of their message schemas into the container, and a copy of the steps
+// referring to the mounted files. The directory of Protobuf schemas is mounted, for the files they import. A file
+// referenced several times is mounted once.
+func MountFiles(steps model.Steps) (model.Steps, []string, error) {
+	data, err := yaml.Marshal(steps)
+	if err != nil {
+		return steps, nil, fmt.Errorf("failed to marshal steps: %w", err)
+	}
+	var mounted model.Steps
+	if err := yaml.Unmarshal(data, &mounted); err != nil {
+		return steps, nil, fmt.Errorf("failed to copy steps: %w", err)
+	}
+
+	targets := map[string]string{}
+	var volumes []string
+	mountPath := func(path string) (string, error) {
+		source, err := filepath.Abs(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to mount %s: %w", path, err)
+		}
+		if _, err := os.Stat(source); err != nil {
+			return "", fmt.Errorf("failed to mount %s: %w", path, err)
+		}
+
+		target, ok := targets[source]
+		if !ok {
+			target = fmt.Sprintf("%s/%d-%s", MountDir, len(targets), filepath.Base(source))
+			targets[source] = target
+			volumes = append(volumes, fmt.Sprintf("%s:%s:ro", source, target))
+		}
+		return target, nil
+	}
+	mount := func(path *string) error {
+		if path == nil {
+			return nil
+		}
+		target, err := mountPath(*path)
+		if err != nil {
+			return err
+		}
+		*path = target
+		return nil
+	}
+
+	for _, cfg := range natsConfigs(&mounted) {
+		var files []*string
+		if cfg.Tls != nil {
+			files = append(files, cfg.Tls.CaFile, cfg.Tls.CertFile, cfg.Tls.KeyFile)
+		}
+		if cfg.Creds != nil {
+			files = append(files, cfg.Creds.File)
+		}
+		files = append(files, cfg.Nkey)
+
+		for _, file := range files {
+			if err := mount(file); err != nil {
+				return steps, nil, err
+			}
+		}
+	}
+
+	for _, schema := range messageSchemas(&mounted) {
+		if schema.File == nil {
+			continue
+		}
+		if schema.Format != model.MessageSchemaFormatProtobuf {
+			if err := mount(schema.File); err != nil {
+				return steps, nil, err
+			}
+			continue
+		}
+
+		dir, err := mountPath(filepath.Dir(*schema.File))
+		if err != nil {
+			return steps, nil, err
+		}
+		file := dir + "/" + filepath.Base(*schema.File)
+		schema.File = &file
+	}
+
+	if len(volumes) == 0 {
+		return steps, nil, nil
+	}
+	return mounted, volumes, nil
+}
+
+// natsConfigs returns the NATS configurations of the consumer, the producers and the service transformers of the
+// steps.
+func natsConfigs(steps *model.Steps) []*model.NatsConfig {
+	var configs []*model.NatsConfig
+
+	var addTransformer func(t *model.TransformerStep)
+	addTransformer = func(t *model.TransformerStep) {
+		if t == nil {
+			return
+		}
+		if t.Service != nil {
+			configs = append(configs, &t.Service.Nats)
+		}
+		if t.Composite != nil {
+			for i := range t.Composite.Sequential {
+				addTransformer(&t.Composite.Sequential[i])
+			}
+		}
+	}
+	addOutput := func(o *model.OutputStep) {
+		if o.Producer != nil {
+			configs = append(configs, &o.Producer.Nats)
+		}
+		addTransformer(o.Transformer)
+	}
+
+	if steps.Consumer != nil {
+		configs = append(configs, &steps.Consumer.Nats)
+	}
+	addTransformer(steps.Transformer)
+	if steps.Producer != nil {
+		configs = append(configs, &steps.Producer.Nats)
+	}
+	if steps.Errors != nil && steps.Errors.DeadLetter != nil {
+		configs = append(configs, &steps.Errors.DeadLetter.Nats)
+	}
+	if steps.Broker != nil {
+		for i := range steps.Broker.Outputs {
+			addOutput(&steps.Broker.Outputs[i])
+		}
+	}
+	if steps.Router != nil {
+		for i := range steps.Router.Routes {
+			addOutput(&steps.Router.Routes[i].Output)
+		}
+		if steps.Router.Default != nil {
+			addOutput(steps.Router.Default)
+		}
+	}
+	return configs
+}
+
+// messageSchemas returns the message schemas of the consumer and the producers of the steps.
+func messageSchemas(steps *model.Steps) []*model.MessageSchema {
+	var schemas []*model.MessageSchema
+	addProducer := func(p *model.ProducerStep) {
+		if p != nil && p.Schema != nil {
+			schemas = append(schemas, p.Schema)
+		}
+	}
+
+	if steps.Consumer != nil && steps.Consumer.Schema != nil {
+		schemas = append(schemas, steps.Consumer.Schema)
+	}
+	addProducer(steps.Producer)
+	if steps.Errors != nil {
+		addProducer(steps.Errors.DeadLetter)
+	}
+	if steps.Broker != nil {
+		for i := range steps.Broker.Outputs {
+			addProducer(steps.Broker.Outputs[i].Producer)
+		}
+	}
+	if steps.Router != nil {
+		for i := range steps.Router.Routes {
+			addProducer(steps.Router.Routes[i].Output.Producer)
+		}
+		if steps.Router.Default != nil {
+			addProducer(steps.Router.Default.Producer)
+		}
+	}
+	return schemas
+}
@@ -2,12 +2,15 @@ package docker
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/synadia-io/connect/model"
@@ -17,12 +20,16 @@ import (
 type Runner struct {
 	// For now, we'll use simple docker command execution
 	// In the future, we can add the Docker client library
+
+	// host is the docker daemon the commands are sent to; empty for the local daemon
+	host string
 }
 
 type ContainerStatus struct {
 	Name   string
 	Status string
 	Exists bool
+	Labels map[string]string
 }
 
 type RunOptions struct {
@@ -33,14 +40,27 @@ type RunOptions struct {
 	DockerOpts  string
 	WorkDir     string
 	Follow      bool
+	Attach      bool // Optional: connect stdin and stdout to the container instead of detaching
 	Remove      bool
-	RuntimeID   string // Optional: runtime ID for converter selection
+	RuntimeID   string            // Optional: runtime ID for converter selection
+	Labels      map[string]string // Optional: labels to set on the container
 }
 
 func NewRunner() *Runner {
 	return &Runner{}
 }
 
+// NewRunnerForHost creates a runner which sends its commands to the given docker daemon, e.g.
+// ssh://user@host or tcp://host:2376. An empty host uses the local daemon.
+func NewRunnerForHost(host string) *Runner {
+	return &Runner{host: host}
+}
+
+// Host returns the docker daemon the runner sends its commands to; empty for the local daemon.
+func (r *Runner) Host() string {
+	return r.host
+}
+
 func (r *Runner) Run(ctx context.Context, opts *RunOptions) error {
 	if opts.Image == "" {
 		opts.Image = "synadia/connect:latest" // Default image
@@ -78,51 +98,98 @@ func (r *Runner) Run(ctx context.Context, opts *RunOptions) error {
 		}
 	}
 
-	// Encode the original steps as base64 for the runtime
-	stepsYAML, err := yaml.Marshal(opts.Steps)
+	args, err := runArgs(opts)
 	if err != nil {
-		return fmt.Errorf("failed to marshal steps: %w", err)
+		return err
 	}
-	encodedSteps := base64.StdEncoding.EncodeToString(stepsYAML)
 
-	// Build Docker command
-	args := []string{"run"}
+	fmt.Printf("Running: docker %s\n", strings.Join(args, " "))
 
-	if opts.Remove {
-		args = append(args, "--rm")
+	return r.executeDockerCommand(ctx, args, opts.Follow)
+}
+
+// Start runs a detached container without any interaction, replacing a container with the same name. It is
+// used to run connector instances on behalf of a control plane.
+func (r *Runner) Start(ctx context.Context, opts *RunOptions) error {
+	if opts.Image == "" {
+		return fmt.Errorf("image is required")
 	}
 
-	if opts.Follow {
-		args = append(args, "-it")
-	} else {
-		args = append(args, "-d")
+	detached := *opts
+	detached.Follow = false
+
+	args, err := runArgs(&detached)
+	if err != nil {
+		return err
 	}
 
-	// Add connector ID as name
-	if opts.ConnectorID != "" {
-		args = append(args, "--name", opts.ConnectorID)
+	if detached.ConnectorID != "" {
+		_ = r.ForceRemove(ctx, detached.ConnectorID)
 	}
 
-	// Add environment variables
-	for k, v := range opts.EnvVars {
-		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	_, err = r.output(ctx, args...)
+	return err
+}
+
+// Attach runs a container with its stdin and stdout connected to the given reader and writer, and removes it once
+// it exited. The error contains what the container wrote to stderr.
+func (r *Runner) Attach(ctx context.Context, opts *RunOptions, stdin io.Reader, stdout io.Writer) error {
+	if opts.Image == "" {
+		return fmt.Errorf("image is required")
 	}
 
-	// Add customer options
-	if opts.DockerOpts != "" {
-		dockerOpts := strings.Fields(opts.DockerOpts)
-		args = append(args, dockerOpts...)
+	attached := *opts
+	attached.Follow = false
+	attached.Attach = true
+	attached.Remove = true
+
+	args, err := runArgs(&attached)
+	if err != nil {
+		return err
 	}
 
-	// Add the image
-	args = append(args, opts.Image)
+	var stderr bytes.Buffer
+	cmd := r.command(ctx, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
 
-	// Add the base64-encoded steps as the first argument
-	args = append(args, encodedSteps)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
 
-	fmt.Printf("Running: docker %s\n", strings.Join(args, " "))
+// ListContainers returns the containers, running or not, which have all the given labels. A label with an
+// empty value only needs to be present.
+func (r *Runner) ListContainers(ctx context.Context, labels map[string]string) ([]ContainerStatus, error) {
+	args := []string{"ps", "-a", "--no-trunc", "--format", "{{.Names}}\t{{.Status}}\t{{.Labels}}"}
+	for k, v := range labels {
+		if v == "" {
+			args = append(args, "--filter", fmt.Sprintf("label=%s", k))
+		} else {
+			args = append(args, "--filter", fmt.Sprintf("label=%s=%s", k, v))
+		}
+	}
 
-	return r.executeDockerCommand(ctx, args, opts.Follow)
+	output, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseContainerList(string(output)), nil
+}
+
+// ForceRemove removes a container whether it is running or not, without printing anything.
+func (r *Runner) ForceRemove(ctx context.Context, name string) error {
+	_, err := r.output(ctx, "rm", "-f", name)
+	return err
+}
+
+// Pull pulls the image without printing the progress.
+func (r *Runner) Pull(ctx context.Context, image string) error {
+	_, err := r.output(ctx, "pull", "-q", image)
+	return err
 }
 
 func (r *Runner) Stop(ctx context.Context, connectorID string) error {
@@ -166,8 +233,131 @@ func (r *Runner) Remove(ctx context.Context, connectorID string) error {
 	return r.executeDockerCommand(ctx, args, false)
 }
 
+// runArgs builds the arguments of the docker run command for the options.
+func runArgs(opts *RunOptions) ([]string, error) {
+	// The steps refer to the files of the NATS configurations where they are mounted
+	steps, volumes, err := MountFiles(opts.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encode the steps as base64 for the runtime
+	stepsYAML, err := yaml.Marshal(steps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal steps: %w", err)
+	}
+	encodedSteps := base64.StdEncoding.EncodeToString(stepsYAML)
+
+	// Build Docker command
+	args := []string{"run"}
+
+	if opts.Remove {
+		args = append(args, "--rm")
+	}
+
+	switch {
+	case opts.Follow:
+		args = append(args, "-it")
+	case opts.Attach:
+		args = append(args, "-i")
+	default:
+		args = append(args, "-d")
+	}
+
+	// Add connector ID as name
+	if opts.ConnectorID != "" {
+		args = append(args, "--name", opts.ConnectorID)
+	}
+
+	// Add labels, sorted to keep the command stable
+	labels := make([]string, 0, len(opts.Labels))
+	for k, v := range opts.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		args = append(args, "--label", l)
+	}
+
+	// Add environment variables
+	for k, v := range opts.EnvVars {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	// Mount the files of the NATS configurations
+	for _, v := range volumes {
+		args = append(args, "-v", v)
+	}
+
+	// Add customer options
+	if opts.DockerOpts != "" {
+		dockerOpts := strings.Fields(opts.DockerOpts)
+		args = append(args, dockerOpts...)
+	}
+
+	// Add the image
+	args = append(args, opts.Image)
+
+	// Add the base64-encoded steps as the first argument
+	args = append(args, encodedSteps)
+
+	return args, nil
+}
+
+// parseContainerList parses the "name<tab>status<tab>labels" lines printed by ListContainers.
+func parseContainerList(output string) []ContainerStatus {
+	var containers []ContainerStatus
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+
+		status := ContainerStatus{
+			Name:   parts[0],
+			Status: parts[1],
+			Exists: true,
+			Labels: map[string]string{},
+		}
+		if len(parts) == 3 && parts[2] != "" {
+			for _, label := range strings.Split(parts[2], ",") {
+				k, v, _ := strings.Cut(label, "=")
+				status.Labels[k] = v
+			}
+		}
+
+		containers = append(containers, status)
+	}
+	return containers
+}
+
+// command creates the docker command, pointed at the configured daemon.
+func (r *Runner) command(ctx context.Context, args ...string) *exec.Cmd {
+	if r.host != "" {
+		args = append([]string{"-H", r.host}, args...)
+	}
+	return exec.CommandContext(ctx, "docker", args...)
+}
+
+// output runs the docker command and returns its output. The error output is included in the error.
+func (r *Runner) output(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := r.command(ctx, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("docker %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("docker %s: %w", args[0], err)
+	}
+	return output, nil
+}
+
 func (r *Runner) executeDockerCommand(ctx context.Context, args []string, interactive bool) error {
-	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd := r.command(ctx, args...)
 
 	if interactive {
 		// For interactive commands, connect to stdin/stdout/stderr
@@ -242,7 +432,7 @@ func (r *Runner) GetContainerStatus(ctx context.Context, connectorID string) (*C
 	containerName := connectorID
 
 	// Check if container exists and get its status
-	cmd := exec.CommandContext(ctx, "docker", "ps", "-a", "--filter", fmt.Sprintf("name=%s", containerName), "--format", "{{.Names}}\t{{.Status}}")
+	cmd := r.command(ctx, "ps", "-a", "--filter", fmt.Sprintf("name=%s", containerName), "--format", "{{.Names}}\t{{.Status}}")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to check container status: %w", err)
@@ -2,10 +2,16 @@ package docker
 
 import (
 	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/builders"
+	"github.com/synadia-io/connect/model"
+	"gopkg.in/yaml.v3"
 )
 
 var _ = Describe("Runner", func() {
@@ -164,4 +170,120 @@ var _ = Describe("Runner", func() {
 			Expect(shortCtx.Err()).To(MatchError(context.DeadlineExceeded))
 		})
 	})
+
+	Describe("runArgs", func() {
+		It("should add sorted labels before the image", func() {
+			args, err := runArgs(&RunOptions{
+				ConnectorID: "test-connector",
+				Image:       "registry.synadia.io/connect-runtime-wombat:latest",
+				Labels:      map[string]string{"b": "2", "a": "1"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(args[:6]).To(Equal([]string{"run", "-d", "--name", "test-connector", "--label", "a=1"}))
+			Expect(args[6:8]).To(Equal([]string{"--label", "b=2"}))
+			Expect(args[8]).To(Equal("registry.synadia.io/connect-runtime-wombat:latest"))
+		})
+
+		It("should keep stdin open for attached containers", func() {
+			args, err := runArgs(&RunOptions{Image: "runtime:latest", Attach: true, Remove: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(args[:4]).To(Equal([]string{"run", "--rm", "-i", "runtime:latest"}))
+		})
+
+		It("should mount the files of the NATS configurations", func() {
+			dir := GinkgoT().TempDir()
+			ca := filepath.Join(dir, "ca.pem")
+			creds := filepath.Join(dir, "user.creds")
+			Expect(os.WriteFile(ca, []byte("ca"), 0644)).To(Succeed())
+			Expect(os.WriteFile(creds, []byte("creds"), 0600)).To(Succeed())
+
+			nats := builders.NatsConfig().RootCAs(ca).CredsFile(creds).Build()
+			steps := model.Steps{
+				Consumer: &model.ConsumerStep{Nats: nats, Core: &model.ConsumerStepCore{Subject: "orders"}},
+				Producer: &model.ProducerStep{Nats: builders.NatsConfig().RootCAs(ca).Build(), Core: &model.ProducerStepCore{Subject: "copy"}},
+			}
+
+			args, err := runArgs(&RunOptions{Image: "runtime:latest", Steps: steps})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(args[:6]).To(Equal([]string{
+				"run", "-d",
+				"-v", ca + ":/etc/connect/files/0-ca.pem:ro",
+				"-v", creds + ":/etc/connect/files/1-user.creds:ro",
+			}))
+
+			data, err := base64.StdEncoding.DecodeString(args[len(args)-1])
+			Expect(err).ToNot(HaveOccurred())
+			var mounted model.Steps
+			Expect(yaml.Unmarshal(data, &mounted)).To(Succeed())
+			Expect(*mounted.Consumer.Nats.Tls.CaFile).To(Equal("/etc/connect/files/0-ca.pem"))
+			Expect(*mounted.Consumer.Nats.Creds.File).To(Equal("/etc/connect/files/1-user.creds"))
+			Expect(*mounted.Producer.Nats.Tls.CaFile).To(Equal("/etc/connect/files/0-ca.pem"))
+
+			// the steps of the caller are left as they are
+			Expect(*steps.Consumer.Nats.Tls.CaFile).To(Equal(ca))
+		})
+
+		It("should mount the files of the message schemas", func() {
+			dir := GinkgoT().TempDir()
+			order := filepath.Join(dir, "order.json")
+			proto := filepath.Join(dir, "protos", "order.proto")
+			Expect(os.WriteFile(order, []byte("{}"), 0644)).To(Succeed())
+			Expect(os.Mkdir(filepath.Dir(proto), 0755)).To(Succeed())
+			Expect(os.WriteFile(proto, []byte(`syntax = "proto3";`), 0644)).To(Succeed())
+
+			steps := model.Steps{
+				Consumer: &model.ConsumerStep{
+					Core:   &model.ConsumerStepCore{Subject: "orders"},
+					Schema: &model.MessageSchema{Format: model.MessageSchemaFormatJsonSchema, File: &order},
+				},
+				Producer: &model.ProducerStep{
+					Core:   &model.ProducerStepCore{Subject: "copy"},
+					Schema: &model.MessageSchema{Format: model.MessageSchemaFormatProtobuf, File: &proto},
+				},
+			}
+
+			args, err := runArgs(&RunOptions{Image: "runtime:latest", Steps: steps})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(args[:6]).To(Equal([]string{
+				"run", "-d",
+				"-v", order + ":/etc/connect/files/0-order.json:ro",
+				"-v", filepath.Dir(proto) + ":/etc/connect/files/1-protos:ro",
+			}))
+
+			data, err := base64.StdEncoding.DecodeString(args[len(args)-1])
+			Expect(err).ToNot(HaveOccurred())
+			var mounted model.Steps
+			Expect(yaml.Unmarshal(data, &mounted)).To(Succeed())
+			Expect(*mounted.Consumer.Schema.File).To(Equal("/etc/connect/files/0-order.json"))
+			Expect(*mounted.Producer.Schema.File).To(Equal("/etc/connect/files/1-protos/order.proto"))
+			Expect(*steps.Producer.Schema.File).To(Equal(proto))
+		})
+
+		It("should fail for missing files", func() {
+			steps := model.Steps{Consumer: &model.ConsumerStep{Nats: builders.NatsConfig().Nkey("missing.nk").Build()}}
+
+			_, err := runArgs(&RunOptions{Image: "runtime:latest", Steps: steps})
+			Expect(err).To(MatchError(ContainSubstring("failed to mount missing.nk")))
+		})
+	})
+
+	Describe("parseContainerList", func() {
+		It("should parse names, status and labels", func() {
+			containers := parseContainerList("one\tUp 5 minutes\ta=1,b=2\ntwo\tExited (0) 1 minute ago\t\n")
+			Expect(containers).To(HaveLen(2))
+
+			Expect(containers[0].Name).To(Equal("one"))
+			Expect(containers[0].IsContainerRunning()).To(BeTrue())
+			Expect(containers[0].Labels).To(Equal(map[string]string{"a": "1", "b": "2"}))
+
+			Expect(containers[1].Name).To(Equal("two"))
+			Expect(containers[1].IsContainerRunning()).To(BeFalse())
+			Expect(containers[1].Labels).To(BeEmpty())
+		})
+
+		It("should return nothing for empty output", func() {
+			Expect(parseContainerList("")).To(BeEmpty())
+		})
+	})
 })
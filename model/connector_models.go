@@ -8,6 +8,83 @@ import (
 	"reflect"
 )
 
+// Groups messages into batches which a step handles at once. A batch is complete
+// once any of the limits is reached
+type Batching struct {
+	// The total size of the payloads in a batch in bytes
+	ByteSize *int `json:"byte_size,omitempty" yaml:"byte_size,omitempty" mapstructure:"byte_size,omitempty"`
+
+	// The number of messages in a batch
+	Count *int `json:"count,omitempty" yaml:"count,omitempty" mapstructure:"count,omitempty"`
+
+	// The period after which an incomplete batch is handled
+	Period *string `json:"period,omitempty" yaml:"period,omitempty" mapstructure:"period,omitempty"`
+}
+
+// Several outputs the messages are delivered to, replacing the sink or producer
+type BrokerStep struct {
+	// The outputs the messages are delivered to
+	Outputs []OutputStep `json:"outputs" yaml:"outputs" mapstructure:"outputs"`
+
+	// How messages are delivered to the outputs. fan_out delivers every message to
+	// all outputs, round_robin to one output after the other and fallback to the
+	// first output which accepts it
+	Pattern BrokerStepPattern `json:"pattern,omitempty" yaml:"pattern,omitempty" mapstructure:"pattern,omitempty"`
+}
+
+type BrokerStepPattern string
+
+const BrokerStepPatternFallback BrokerStepPattern = "fallback"
+const BrokerStepPatternFanOut BrokerStepPattern = "fan_out"
+const BrokerStepPatternRoundRobin BrokerStepPattern = "round_robin"
+
+var enumValues_BrokerStepPattern = []interface{}{
+	"fan_out",
+	"round_robin",
+	"fallback",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BrokerStepPattern) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_BrokerStepPattern {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_BrokerStepPattern, v)
+	}
+	*j = BrokerStepPattern(v)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BrokerStep) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["outputs"]; raw != nil && !ok {
+		return fmt.Errorf("field outputs in BrokerStep: required")
+	}
+	type Plain BrokerStep
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["pattern"]; !ok || v == nil {
+		plain.Pattern = "fan_out"
+	}
+	*j = BrokerStep(plain)
+	return nil
+}
+
 // Combine all messages in the batch into a single message
 type CombineTransformerStep struct {
 	// The format of the payload to explode
@@ -331,12 +408,18 @@ type ConsumerStep struct {
 	// The configuration for reading from Core NATS subjects
 	Core *ConsumerStepCore `json:"core,omitempty" yaml:"core,omitempty" mapstructure:"core,omitempty"`
 
+	// The headers of the messages read from NATS
+	Headers *ConsumerStepHeaders `json:"headers,omitempty" yaml:"headers,omitempty" mapstructure:"headers,omitempty"`
+
 	// The configuration for reading from the NATS KV store
 	Kv *ConsumerStepKv `json:"kv,omitempty" yaml:"kv,omitempty" mapstructure:"kv,omitempty"`
 
 	// Nats corresponds to the JSON schema field "nats".
 	Nats NatsConfig `json:"nats" yaml:"nats" mapstructure:"nats"`
 
+	// Schema corresponds to the JSON schema field "schema".
+	Schema *MessageSchema `json:"schema,omitempty" yaml:"schema,omitempty" mapstructure:"schema,omitempty"`
+
 	// The configuration for reading from JetStream streams
 	Stream *ConsumerStepStream `json:"stream,omitempty" yaml:"stream,omitempty" mapstructure:"stream,omitempty"`
 }
@@ -368,6 +451,19 @@ func (j *ConsumerStepCore) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// The headers of the messages read from NATS
+type ConsumerStepHeaders struct {
+	// The headers which are kept, matched case-insensitively. A trailing * matches
+	// any suffix. Without it all headers are kept
+	Propagate []string `json:"propagate,omitempty" yaml:"propagate,omitempty" mapstructure:"propagate,omitempty"`
+
+	// Headers added to every message
+	Static ConsumerStepHeadersStatic `json:"static,omitempty" yaml:"static,omitempty" mapstructure:"static,omitempty"`
+}
+
+// Headers added to every message
+type ConsumerStepHeadersStatic map[string]string
+
 // The configuration for reading from the NATS KV store
 type ConsumerStepKv struct {
 	// The bucket to use when reading from the KV store
@@ -400,10 +496,115 @@ func (j *ConsumerStepKv) UnmarshalJSON(value []byte) error {
 
 // The configuration for reading from JetStream streams
 type ConsumerStepStream struct {
+	// How messages are acknowledged
+	AckPolicy ConsumerStepStreamAckPolicy `json:"ack_policy,omitempty" yaml:"ack_policy,omitempty" mapstructure:"ack_policy,omitempty"`
+
+	// How long the server waits for an acknowledgement before redelivering a
+	// message, e.g. 30s
+	AckWait *string `json:"ack_wait,omitempty" yaml:"ack_wait,omitempty" mapstructure:"ack_wait,omitempty"`
+
+	// Bind to the existing durable consumer instead of creating or updating it
+	Bind bool `json:"bind,omitempty" yaml:"bind,omitempty" mapstructure:"bind,omitempty"`
+
+	// Where in the stream to start delivering messages
+	DeliverPolicy ConsumerStepStreamDeliverPolicy `json:"deliver_policy,omitempty" yaml:"deliver_policy,omitempty" mapstructure:"deliver_policy,omitempty"`
+
+	// The name of the durable consumer, allowing the connector to resume where it
+	// left off after a restart
+	Durable *string `json:"durable,omitempty" yaml:"durable,omitempty" mapstructure:"durable,omitempty"`
+
+	// Additional subjects of the stream to consume from
+	FilterSubjects []string `json:"filter_subjects,omitempty" yaml:"filter_subjects,omitempty" mapstructure:"filter_subjects,omitempty"`
+
+	// The maximum number of messages delivered but not yet acknowledged
+	MaxAckPending *int `json:"max_ack_pending,omitempty" yaml:"max_ack_pending,omitempty" mapstructure:"max_ack_pending,omitempty"`
+
+	// The maximum number of times a message is delivered
+	MaxDeliver *int `json:"max_deliver,omitempty" yaml:"max_deliver,omitempty" mapstructure:"max_deliver,omitempty"`
+
+	// The stream sequence to start at when the deliver policy is by_start_sequence
+	OptStartSeq *int `json:"opt_start_seq,omitempty" yaml:"opt_start_seq,omitempty" mapstructure:"opt_start_seq,omitempty"`
+
+	// The RFC3339 time to start at when the deliver policy is by_start_time
+	OptStartTime *string `json:"opt_start_time,omitempty" yaml:"opt_start_time,omitempty" mapstructure:"opt_start_time,omitempty"`
+
+	// The name of the stream, looked up by subject if not set
+	Stream *string `json:"stream,omitempty" yaml:"stream,omitempty" mapstructure:"stream,omitempty"`
+
 	// The subject to consume from
 	Subject string `json:"subject" yaml:"subject" mapstructure:"subject"`
 }
 
+type ConsumerStepStreamAckPolicy string
+
+const ConsumerStepStreamAckPolicyAll ConsumerStepStreamAckPolicy = "all"
+const ConsumerStepStreamAckPolicyExplicit ConsumerStepStreamAckPolicy = "explicit"
+const ConsumerStepStreamAckPolicyNone ConsumerStepStreamAckPolicy = "none"
+
+var enumValues_ConsumerStepStreamAckPolicy = []interface{}{
+	"explicit",
+	"all",
+	"none",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ConsumerStepStreamAckPolicy) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_ConsumerStepStreamAckPolicy {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_ConsumerStepStreamAckPolicy, v)
+	}
+	*j = ConsumerStepStreamAckPolicy(v)
+	return nil
+}
+
+type ConsumerStepStreamDeliverPolicy string
+
+const ConsumerStepStreamDeliverPolicyAll ConsumerStepStreamDeliverPolicy = "all"
+const ConsumerStepStreamDeliverPolicyByStartSequence ConsumerStepStreamDeliverPolicy = "by_start_sequence"
+const ConsumerStepStreamDeliverPolicyByStartTime ConsumerStepStreamDeliverPolicy = "by_start_time"
+const ConsumerStepStreamDeliverPolicyLast ConsumerStepStreamDeliverPolicy = "last"
+const ConsumerStepStreamDeliverPolicyLastPerSubject ConsumerStepStreamDeliverPolicy = "last_per_subject"
+const ConsumerStepStreamDeliverPolicyNew ConsumerStepStreamDeliverPolicy = "new"
+
+var enumValues_ConsumerStepStreamDeliverPolicy = []interface{}{
+	"all",
+	"last",
+	"new",
+	"by_start_sequence",
+	"by_start_time",
+	"last_per_subject",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ConsumerStepStreamDeliverPolicy) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_ConsumerStepStreamDeliverPolicy {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_ConsumerStepStreamDeliverPolicy, v)
+	}
+	*j = ConsumerStepStreamDeliverPolicy(v)
+	return nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler.
 func (j *ConsumerStepStream) UnmarshalJSON(value []byte) error {
 	var raw map[string]interface{}
@@ -418,6 +619,15 @@ func (j *ConsumerStepStream) UnmarshalJSON(value []byte) error {
 	if err := json.Unmarshal(value, &plain); err != nil {
 		return err
 	}
+	if v, ok := raw["ack_policy"]; !ok || v == nil {
+		plain.AckPolicy = "explicit"
+	}
+	if v, ok := raw["bind"]; !ok || v == nil {
+		plain.Bind = false
+	}
+	if v, ok := raw["deliver_policy"]; !ok || v == nil {
+		plain.DeliverPolicy = "all"
+	}
 	*j = ConsumerStepStream(plain)
 	return nil
 }
@@ -440,6 +650,49 @@ func (j *ConsumerStep) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// How messages the sink or producer fails to deliver are retried and
+// dead-lettered
+type ErrorPolicy struct {
+	// The delay before the first retry, doubled for every next retry
+	Backoff string `json:"backoff,omitempty" yaml:"backoff,omitempty" mapstructure:"backoff,omitempty"`
+
+	// The producer receiving the messages which still fail after the last retry.
+	// Without it these messages are nacked
+	DeadLetter *ProducerStep `json:"dead_letter,omitempty" yaml:"dead_letter,omitempty" mapstructure:"dead_letter,omitempty"`
+
+	// The maximum delay between retries
+	MaxBackoff string `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty" mapstructure:"max_backoff,omitempty"`
+
+	// The number of times delivery of a message is retried before it is
+	// dead-lettered
+	MaxRetries *int `json:"max_retries,omitempty" yaml:"max_retries,omitempty" mapstructure:"max_retries,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ErrorPolicy) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	type Plain ErrorPolicy
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["backoff"]; !ok || v == nil {
+		plain.Backoff = "1s"
+	}
+	if v, ok := raw["max_backoff"]; !ok || v == nil {
+		plain.MaxBackoff = "30s"
+	}
+	if v, ok := raw["max_retries"]; !ok || v == nil {
+		maxRetries := 3
+		plain.MaxRetries = &maxRetries
+	}
+	*j = ErrorPolicy(plain)
+	return nil
+}
+
 // Explode a message with a json array payload into multiple messages
 type ExplodeTransformerStep struct {
 	// The delimiter to use for the payload in case format is csv
@@ -598,6 +851,142 @@ func (j *MappingTransformerStep) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// The schema every message of a step is validated against
+type MessageSchema struct {
+	// The path of the file holding the schema definition
+	File *string `json:"file,omitempty" yaml:"file,omitempty" mapstructure:"file,omitempty"`
+
+	// The format of the schema definition
+	Format MessageSchemaFormat `json:"format" yaml:"format" mapstructure:"format"`
+
+	// The schema definition
+	Inline *string `json:"inline,omitempty" yaml:"inline,omitempty" mapstructure:"inline,omitempty"`
+
+	// The fully qualified name of the protobuf message the payload is encoded as
+	Message *string `json:"message,omitempty" yaml:"message,omitempty" mapstructure:"message,omitempty"`
+
+	// The NATS object store object holding the schema definition
+	ObjectStore *MessageSchemaObjectStore `json:"object_store,omitempty" yaml:"object_store,omitempty" mapstructure:"object_store,omitempty"`
+
+	// What happens to messages which do not match the schema. dead_letter publishes
+	// them to the dead letter producer of the errors section, drop acknowledges and
+	// discards them
+	OnFailure MessageSchemaOnFailure `json:"on_failure,omitempty" yaml:"on_failure,omitempty" mapstructure:"on_failure,omitempty"`
+}
+
+type MessageSchemaFormat string
+
+const MessageSchemaFormatAvro MessageSchemaFormat = "avro"
+const MessageSchemaFormatJsonSchema MessageSchemaFormat = "json_schema"
+const MessageSchemaFormatProtobuf MessageSchemaFormat = "protobuf"
+
+var enumValues_MessageSchemaFormat = []interface{}{
+	"json_schema",
+	"avro",
+	"protobuf",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchemaFormat) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_MessageSchemaFormat {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_MessageSchemaFormat, v)
+	}
+	*j = MessageSchemaFormat(v)
+	return nil
+}
+
+// The NATS object store object holding the schema definition
+type MessageSchemaObjectStore struct {
+	// The object store bucket
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+
+	// The name of the object
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchemaObjectStore) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["bucket"]; raw != nil && !ok {
+		return fmt.Errorf("field bucket in MessageSchemaObjectStore: required")
+	}
+	if _, ok := raw["name"]; raw != nil && !ok {
+		return fmt.Errorf("field name in MessageSchemaObjectStore: required")
+	}
+	type Plain MessageSchemaObjectStore
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	*j = MessageSchemaObjectStore(plain)
+	return nil
+}
+
+type MessageSchemaOnFailure string
+
+const MessageSchemaOnFailureDeadLetter MessageSchemaOnFailure = "dead_letter"
+const MessageSchemaOnFailureDrop MessageSchemaOnFailure = "drop"
+
+var enumValues_MessageSchemaOnFailure = []interface{}{
+	"dead_letter",
+	"drop",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchemaOnFailure) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_MessageSchemaOnFailure {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_MessageSchemaOnFailure, v)
+	}
+	*j = MessageSchemaOnFailure(v)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchema) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["format"]; raw != nil && !ok {
+		return fmt.Errorf("field format in MessageSchema: required")
+	}
+	type Plain MessageSchema
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["on_failure"]; !ok || v == nil {
+		plain.OnFailure = "dead_letter"
+	}
+	*j = MessageSchema(plain)
+	return nil
+}
+
 // Information on how to collect metrics. If not set, no metrics will be collected
 type Metrics struct {
 	// The path to collect metrics from
@@ -629,15 +1018,34 @@ type NatsConfig struct {
 	// Whether authentication is enabled
 	AuthEnabled bool `json:"auth_enabled,omitempty" yaml:"auth_enabled,omitempty" mapstructure:"auth_enabled,omitempty"`
 
+	// The credentials of the user, with a JWT and seed. Exactly one of file or
+	// secret is required
+	Creds *NatsConfigCreds `json:"creds,omitempty" yaml:"creds,omitempty" mapstructure:"creds,omitempty"`
+
 	// The JWT token used during authentication. Only applicable if auth_enabled is
 	// true
 	Jwt *string `json:"jwt,omitempty" yaml:"jwt,omitempty" mapstructure:"jwt,omitempty"`
 
+	// The file with the nkey seed to authenticate with
+	Nkey *string `json:"nkey,omitempty" yaml:"nkey,omitempty" mapstructure:"nkey,omitempty"`
+
+	// The password of the user
+	Password *string `json:"password,omitempty" yaml:"password,omitempty" mapstructure:"password,omitempty"`
+
 	// The seed used during authentication. Only applicable if auth_enabled is true
 	Seed *string `json:"seed,omitempty" yaml:"seed,omitempty" mapstructure:"seed,omitempty"`
 
+	// The TLS settings of the connection
+	Tls *NatsConfigTls `json:"tls,omitempty" yaml:"tls,omitempty" mapstructure:"tls,omitempty"`
+
+	// The token to authenticate with
+	Token *string `json:"token,omitempty" yaml:"token,omitempty" mapstructure:"token,omitempty"`
+
 	// The url of the nats server to connect to
 	Url string `json:"url" yaml:"url" mapstructure:"url"`
+
+	// The user to authenticate as
+	User *string `json:"user,omitempty" yaml:"user,omitempty" mapstructure:"user,omitempty"`
 }
 
 // UnmarshalJSON implements json.Unmarshaler.
@@ -661,17 +1069,87 @@ func (j *NatsConfig) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// The credentials of the user, with a JWT and seed. Exactly one of file or secret
+// is required
+type NatsConfigCreds struct {
+	// The credentials file
+	File *string `json:"file,omitempty" yaml:"file,omitempty" mapstructure:"file,omitempty"`
+
+	// The name of the secret holding the content of the credentials file, provided
+	// to the connector as an environment variable of the same name
+	Secret *string `json:"secret,omitempty" yaml:"secret,omitempty" mapstructure:"secret,omitempty"`
+}
+
+// The TLS settings of the connection
+type NatsConfigTls struct {
+	// The file with the certificate authorities the server certificate is verified
+	// with
+	CaFile *string `json:"ca_file,omitempty" yaml:"ca_file,omitempty" mapstructure:"ca_file,omitempty"`
+
+	// The file with the client certificate, for mutual TLS
+	CertFile *string `json:"cert_file,omitempty" yaml:"cert_file,omitempty" mapstructure:"cert_file,omitempty"`
+
+	// Whether the server certificate is accepted without verifying it
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty" mapstructure:"insecure_skip_verify,omitempty"`
+
+	// The file with the key of the client certificate
+	KeyFile *string `json:"key_file,omitempty" yaml:"key_file,omitempty" mapstructure:"key_file,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *NatsConfigTls) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	type Plain NatsConfigTls
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["insecure_skip_verify"]; !ok || v == nil {
+		plain.InsecureSkipVerify = false
+	}
+	*j = NatsConfigTls(plain)
+	return nil
+}
+
+// An output of a broker, a sink or producer with an optional transformer for the
+// messages delivered to it
+type OutputStep struct {
+	// Producer corresponds to the JSON schema field "producer".
+	Producer *ProducerStep `json:"producer,omitempty" yaml:"producer,omitempty" mapstructure:"producer,omitempty"`
+
+	// Sink corresponds to the JSON schema field "sink".
+	Sink *SinkStep `json:"sink,omitempty" yaml:"sink,omitempty" mapstructure:"sink,omitempty"`
+
+	// The transformer applied only to the messages delivered to this output
+	Transformer *TransformerStep `json:"transformer,omitempty" yaml:"transformer,omitempty" mapstructure:"transformer,omitempty"`
+}
+
 // The producer writing messages to NATS
 type ProducerStep struct {
+	// Batching corresponds to the JSON schema field "batching".
+	Batching *Batching `json:"batching,omitempty" yaml:"batching,omitempty" mapstructure:"batching,omitempty"`
+
 	// The configuration for writing to Core NATS subjects
 	Core *ProducerStepCore `json:"core,omitempty" yaml:"core,omitempty" mapstructure:"core,omitempty"`
 
+	// The headers of the messages written to NATS
+	Headers *ProducerStepHeaders `json:"headers,omitempty" yaml:"headers,omitempty" mapstructure:"headers,omitempty"`
+
 	// The configuration for writing to the NATS KV store
 	Kv *ProducerStepKv `json:"kv,omitempty" yaml:"kv,omitempty" mapstructure:"kv,omitempty"`
 
 	// Nats corresponds to the JSON schema field "nats".
 	Nats NatsConfig `json:"nats" yaml:"nats" mapstructure:"nats"`
 
+	// RateLimit corresponds to the JSON schema field "rate_limit".
+	RateLimit *RateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" mapstructure:"rate_limit,omitempty"`
+
+	// Schema corresponds to the JSON schema field "schema".
+	Schema *MessageSchema `json:"schema,omitempty" yaml:"schema,omitempty" mapstructure:"schema,omitempty"`
+
 	// The configuration for writing to JetStream streams
 	Stream *ProducerStepStream `json:"stream,omitempty" yaml:"stream,omitempty" mapstructure:"stream,omitempty"`
 
@@ -681,7 +1159,8 @@ type ProducerStep struct {
 
 // The configuration for writing to Core NATS subjects
 type ProducerStepCore struct {
-	// The subject to send data to
+	// The subject to send data to. It can be templated from the message, e.g.
+	// orders.${! meta("region") }
 	Subject string `json:"subject" yaml:"subject" mapstructure:"subject"`
 }
 
@@ -703,6 +1182,23 @@ func (j *ProducerStepCore) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// The headers of the messages written to NATS
+type ProducerStepHeaders struct {
+	// The Nats-Msg-Id header JetStream deduplicates messages by, templated from the
+	// message, e.g. ${! json("id") }
+	MsgId *string `json:"msg_id,omitempty" yaml:"msg_id,omitempty" mapstructure:"msg_id,omitempty"`
+
+	// The headers which are kept, matched case-insensitively. A trailing * matches
+	// any suffix. Without it all headers are kept
+	Propagate []string `json:"propagate,omitempty" yaml:"propagate,omitempty" mapstructure:"propagate,omitempty"`
+
+	// Headers added to every message
+	Static ProducerStepHeadersStatic `json:"static,omitempty" yaml:"static,omitempty" mapstructure:"static,omitempty"`
+}
+
+// Headers added to every message
+type ProducerStepHeadersStatic map[string]string
+
 // The configuration for writing to the NATS KV store
 type ProducerStepKv struct {
 	// The bucket to use when writing to the KV store
@@ -735,7 +1231,8 @@ func (j *ProducerStepKv) UnmarshalJSON(value []byte) error {
 
 // The configuration for writing to JetStream streams
 type ProducerStepStream struct {
-	// The subject to send data to
+	// The subject to send data to. It can be templated from the message, e.g.
+	// orders.${! meta("region") }
 	Subject string `json:"subject" yaml:"subject" mapstructure:"subject"`
 }
 
@@ -778,14 +1275,114 @@ func (j *ProducerStep) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// Limits the number of messages a step handles per period
+type RateLimit struct {
+	// The number of messages which may be handled at once. Without it the count is
+	// the burst
+	Burst *int `json:"burst,omitempty" yaml:"burst,omitempty" mapstructure:"burst,omitempty"`
+
+	// The number of messages handled per period
+	Count int `json:"count" yaml:"count" mapstructure:"count"`
+
+	// The period the count applies to
+	Period string `json:"period,omitempty" yaml:"period,omitempty" mapstructure:"period,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *RateLimit) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["count"]; raw != nil && !ok {
+		return fmt.Errorf("field count in RateLimit: required")
+	}
+	type Plain RateLimit
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["period"]; !ok || v == nil {
+		plain.Period = "1s"
+	}
+	*j = RateLimit(plain)
+	return nil
+}
+
+// A route of a router
+type RouteStep struct {
+	// The bloblang query deciding whether a message takes the route, e.g. this.type
+	// == "order" or @kind == "refund"
+	Condition string `json:"condition" yaml:"condition" mapstructure:"condition"`
+
+	// Output corresponds to the JSON schema field "output".
+	Output OutputStep `json:"output" yaml:"output" mapstructure:"output"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *RouteStep) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["condition"]; raw != nil && !ok {
+		return fmt.Errorf("field condition in RouteStep: required")
+	}
+	if _, ok := raw["output"]; raw != nil && !ok {
+		return fmt.Errorf("field output in RouteStep: required")
+	}
+	type Plain RouteStep
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	*j = RouteStep(plain)
+	return nil
+}
+
+// Routes each message to the output of the first route whose condition matches
+// it, replacing the sink or producer
+type RouterStep struct {
+	// The output receiving the messages no route matches. Without it these messages
+	// are dropped
+	Default *OutputStep `json:"default,omitempty" yaml:"default,omitempty" mapstructure:"default,omitempty"`
+
+	// The routes, evaluated in order
+	Routes []RouteStep `json:"routes" yaml:"routes" mapstructure:"routes"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *RouterStep) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["routes"]; raw != nil && !ok {
+		return fmt.Errorf("field routes in RouterStep: required")
+	}
+	type Plain RouterStep
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	*j = RouterStep(plain)
+	return nil
+}
+
 // A service transformer sends each message to a nats service to be transformed
 type ServiceTransformerStep struct {
+	// Batching corresponds to the JSON schema field "batching".
+	Batching *Batching `json:"batching,omitempty" yaml:"batching,omitempty" mapstructure:"batching,omitempty"`
+
 	// The nats subject on which the service is receiving requests
 	Endpoint string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"`
 
 	// Nats corresponds to the JSON schema field "nats".
 	Nats NatsConfig `json:"nats" yaml:"nats" mapstructure:"nats"`
 
+	// RateLimit corresponds to the JSON schema field "rate_limit".
+	RateLimit *RateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" mapstructure:"rate_limit,omitempty"`
+
 	// The timeout for the service call
 	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty" mapstructure:"timeout,omitempty"`
 }
@@ -816,9 +1413,15 @@ func (j *ServiceTransformerStep) UnmarshalJSON(value []byte) error {
 
 // The external system that is the target for the messages
 type SinkStep struct {
+	// Batching corresponds to the JSON schema field "batching".
+	Batching *Batching `json:"batching,omitempty" yaml:"batching,omitempty" mapstructure:"batching,omitempty"`
+
 	// The configuration of the sink step
 	Config SinkStepConfig `json:"config" yaml:"config" mapstructure:"config"`
 
+	// RateLimit corresponds to the JSON schema field "rate_limit".
+	RateLimit *RateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" mapstructure:"rate_limit,omitempty"`
+
 	// The type of the sink step. This should be a sink that is included in the
 	// connector's runtime
 	Type string `json:"type" yaml:"type" mapstructure:"type"`
@@ -883,12 +1486,21 @@ func (j *SourceStep) UnmarshalJSON(value []byte) error {
 }
 
 type Steps struct {
+	// Broker corresponds to the JSON schema field "broker".
+	Broker *BrokerStep `json:"broker,omitempty" yaml:"broker,omitempty" mapstructure:"broker,omitempty"`
+
 	// Consumer corresponds to the JSON schema field "consumer".
 	Consumer *ConsumerStep `json:"consumer,omitempty" yaml:"consumer,omitempty" mapstructure:"consumer,omitempty"`
 
+	// Errors corresponds to the JSON schema field "errors".
+	Errors *ErrorPolicy `json:"errors,omitempty" yaml:"errors,omitempty" mapstructure:"errors,omitempty"`
+
 	// Producer corresponds to the JSON schema field "producer".
 	Producer *ProducerStep `json:"producer,omitempty" yaml:"producer,omitempty" mapstructure:"producer,omitempty"`
 
+	// Router corresponds to the JSON schema field "router".
+	Router *RouterStep `json:"router,omitempty" yaml:"router,omitempty" mapstructure:"router,omitempty"`
+
 	// Sink corresponds to the JSON schema field "sink".
 	Sink *SinkStep `json:"sink,omitempty" yaml:"sink,omitempty" mapstructure:"sink,omitempty"`
 
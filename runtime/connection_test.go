@@ -0,0 +1,219 @@
+package runtime_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func startServer(opts *server.Options) *server.Server {
+	opts.Host = "127.0.0.1"
+	opts.Port = -1
+	opts.NoLog = true
+	opts.NoSigs = true
+
+	ns, err := server.NewServer(opts)
+	Expect(err).ToNot(HaveOccurred())
+	ns.Start()
+	Expect(ns.ReadyForConnections(5 * time.Second)).To(BeTrue())
+	DeferCleanup(ns.Shutdown)
+	return ns
+}
+
+var emptySteps = base64.StdEncoding.EncodeToString([]byte("{}"))
+
+var _ = Describe("Runtime SDK", func() {
+	Describe("FromEnv", func() {
+		setenv := func(key, value string) {
+			old, had := os.LookupEnv(key)
+			Expect(os.Setenv(key, value)).To(Succeed())
+			DeferCleanup(func() {
+				if had {
+					_ = os.Setenv(key, old)
+				} else {
+					_ = os.Unsetenv(key)
+				}
+			})
+		}
+
+		It("should decode the jwt and parse the log level", func() {
+			setenv(runtime.NatsUrlVar, "nats://example:4222")
+			setenv(runtime.NatsJwtVar, base64.StdEncoding.EncodeToString([]byte("the-jwt")))
+			setenv(runtime.NatsSeedVar, "the-seed")
+			setenv(runtime.NamespaceEnvVar, "ACC")
+			setenv(runtime.GroupEnvVar, "my-connector")
+			setenv(runtime.InstanceEnvVar, "inst-1")
+			setenv(runtime.LogLevelEnvVar, "WARN")
+
+			rt, err := runtime.FromEnv()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rt.NatsUrl).To(Equal("nats://example:4222"))
+			Expect(rt.NatsJwt).To(Equal("the-jwt"))
+			Expect(rt.NatsSeed).To(Equal("the-seed"))
+			Expect(rt.Namespace).To(Equal("ACC"))
+			Expect(rt.Connector).To(Equal("my-connector"))
+			Expect(rt.Instance).To(Equal("inst-1"))
+			Expect(rt.LogLevel).To(Equal(slog.LevelWarn))
+			Expect(rt.Logger.Enabled(context.Background(), slog.LevelInfo)).To(BeFalse())
+			Expect(rt.Logger.Enabled(context.Background(), slog.LevelWarn)).To(BeTrue())
+		})
+
+		It("should check the metrics path", func() {
+			setenv(runtime.MetricsPortEnvVar, "9090")
+			setenv(runtime.MetricsPathEnvVar, "stats")
+
+			rt, err := runtime.FromEnv()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rt.Metrics.Path).To(HaveValue(Equal("/stats")))
+
+			for _, path := range []string{"/healthz", "readyz", "/my metrics", "/{id}"} {
+				setenv(runtime.MetricsPathEnvVar, path)
+				_, err = runtime.FromEnv()
+				Expect(err).To(MatchError(ContainSubstring("metrics path")), path)
+			}
+		})
+	})
+
+	Describe("Logger", func() {
+		It("should carry the workload attributes", func() {
+			var buf bytes.Buffer
+			rt := runtime.NewRuntime(
+				runtime.WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+				runtime.WithNamespace("ACC"),
+				runtime.WithGroup("my-connector"),
+				runtime.WithInstance("inst-1"),
+			)
+
+			rt.Logger.Info("hello")
+
+			var entry map[string]any
+			Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+			Expect(entry).To(HaveKeyWithValue("msg", "hello"))
+			Expect(entry).To(HaveKeyWithValue("namespace", "ACC"))
+			Expect(entry).To(HaveKeyWithValue("connector", "my-connector"))
+			Expect(entry).To(HaveKeyWithValue("instance", "inst-1"))
+		})
+	})
+
+	Describe("NatsConfig", func() {
+		It("should connect to the url of the runtime", func() {
+			ns := startServer(&server.Options{})
+			Expect(os.Getenv(runtime.NatsUrlVar)).To(BeEmpty())
+
+			rt := runtime.NewRuntime(runtime.WithNatsUrl(ns.ClientURL()), runtime.WithGroup("my-connector"), runtime.WithInstance("inst-1"))
+			DeferCleanup(rt.Close)
+
+			nc, err := rt.NatsConfig()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.ConnectedUrl()).To(Equal(ns.ClientURL()))
+			Expect(nc.Opts.Name).To(Equal("my-connector-inst-1"))
+			Expect(nc.Opts.MaxReconnect).To(Equal(-1))
+		})
+
+		It("should authenticate with the nkey seed", func() {
+			kp, err := nkeys.CreateUser()
+			Expect(err).ToNot(HaveOccurred())
+			pub, err := kp.PublicKey()
+			Expect(err).ToNot(HaveOccurred())
+			seed, err := kp.Seed()
+			Expect(err).ToNot(HaveOccurred())
+
+			ns := startServer(&server.Options{Nkeys: []*server.NkeyUser{{Nkey: pub}}})
+
+			rt := runtime.NewRuntime(runtime.WithNatsUrl(ns.ClientURL()), runtime.WithNatsSeed(string(seed)))
+			DeferCleanup(rt.Close)
+
+			_, err = rt.NatsConfig()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should reject connections without the nkey seed", func() {
+			kp, err := nkeys.CreateUser()
+			Expect(err).ToNot(HaveOccurred())
+			pub, err := kp.PublicKey()
+			Expect(err).ToNot(HaveOccurred())
+
+			ns := startServer(&server.Options{Nkeys: []*server.NkeyUser{{Nkey: pub}}})
+
+			rt := runtime.NewRuntime(runtime.WithNatsUrl(ns.ClientURL()))
+			_, err = rt.NatsConfig()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Close", func() {
+		It("should drain the connections", func() {
+			ns := startServer(&server.Options{})
+			rt := runtime.NewRuntime(runtime.WithNatsUrl(ns.ClientURL()))
+
+			nc, err := rt.NatsConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			received := make(chan struct{}, 10)
+			_, err = nc.Subscribe("work", func(*nats.Msg) {
+				time.Sleep(10 * time.Millisecond)
+				received <- struct{}{}
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.Flush()).To(Succeed())
+
+			pub, err := nats.Connect(ns.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			defer pub.Close()
+			for range 5 {
+				Expect(pub.Publish("work", nil)).To(Succeed())
+			}
+			Expect(pub.Flush()).To(Succeed())
+			Eventually(func() uint64 { return nc.Stats().InMsgs }).Should(BeEquivalentTo(5))
+
+			rt.Close()
+
+			Expect(nc.IsClosed()).To(BeTrue())
+			Expect(received).To(HaveLen(5))
+
+			_, err = rt.NatsConfig()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Launch", func() {
+		It("should drain the connections once the workload returns", func() {
+			ns := startServer(&server.Options{})
+			rt := runtime.NewRuntime(runtime.WithNatsUrl(ns.ClientURL()))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			var nc *nats.Conn
+			err := rt.Launch(ctx, func(ctx context.Context, rt *runtime.Runtime, steps model.Steps) error {
+				var err error
+				nc, err = rt.NatsConfig()
+				if err != nil {
+					return err
+				}
+
+				cancel()
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(5 * time.Second):
+					return context.DeadlineExceeded
+				}
+			}, emptySteps)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.IsClosed()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,448 @@
+package runtime
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nkeys"
+	"github.com/synadia-io/connect/model"
+)
+
+// KVOperationHeader holds the operation of a key-value update which deleted or purged the key.
+const KVOperationHeader = "KV-Operation"
+
+// Message is a message read by a consumer or written by a producer.
+type Message struct {
+	// Subject is the subject the message was received on, or the key for key-value updates
+	Subject string
+	Headers nats.Header
+	Data    []byte
+
+	ack func() error
+	nak func() error
+}
+
+// NewMessage creates a message without acknowledgement, e.g. to be written by a producer.
+func NewMessage(subject string, data []byte) *Message {
+	return &Message{Subject: subject, Headers: nats.Header{}, Data: data}
+}
+
+// Ack acknowledges the message. Messages of core subjects and key-value watches need no acknowledgement.
+func (m *Message) Ack() error {
+	if m.ack == nil {
+		return nil
+	}
+	return m.ack()
+}
+
+// Nak asks for the message to be redelivered, if its consumer supports redelivery.
+func (m *Message) Nak() error {
+	if m.nak == nil {
+		return nil
+	}
+	return m.nak()
+}
+
+// StepOpt configures the connection and consumer of OpenConsumer and OpenProducer.
+type StepOpt func(*stepOptions)
+
+type stepOptions struct {
+	durable      string
+	natsOptions  []nats.Option
+	errorHandler *ErrorHandler
+}
+
+// WithDurable sets the name of the durable consumer reading from a stream, overriding the durable of the step.
+// Without either the consumer is ephemeral.
+func WithDurable(name string) StepOpt {
+	return func(o *stepOptions) {
+		o.durable = name
+	}
+}
+
+// WithErrorHandler makes Producer.Run deliver messages through the handler, retrying and dead-lettering failed
+// writes. Consumers and producers dead-letter the messages which do not match their schema through it.
+func WithErrorHandler(h *ErrorHandler) StepOpt {
+	return func(o *stepOptions) {
+		o.errorHandler = h
+	}
+}
+
+// WithNatsOptions adds options to the connection of the step, e.g. those of Runtime.NatsOptions.
+func WithNatsOptions(opts ...nats.Option) StepOpt {
+	return func(o *stepOptions) {
+		o.natsOptions = append(o.natsOptions, opts...)
+	}
+}
+
+func newStepOptions(opts []StepOpt) *stepOptions {
+	o := &stepOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ConnectStep connects to the NATS server of a step, with its TLS settings and authenticating with its JWT and seed
+// if enabled, or its credentials, nkey, user or token.
+func ConnectStep(cfg model.NatsConfig, opts ...nats.Option) (*nats.Conn, error) {
+	stepOpts, err := natsConfigOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nc, err := nats.Connect(cfg.Url, append(stepOpts, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.Url, err)
+	}
+	return nc, nil
+}
+
+// natsConfigOptions returns the connection options for the TLS settings and authentication of the configuration.
+// Credentials held by a secret are read from the environment variable named after it.
+func natsConfigOptions(cfg model.NatsConfig) ([]nats.Option, error) {
+	var opts []nats.Option
+
+	if cfg.Tls != nil {
+		if cfg.Tls.InsecureSkipVerify {
+			opts = append(opts, nats.Secure(&tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: true}))
+		} else {
+			opts = append(opts, nats.Secure())
+		}
+		if cfg.Tls.CaFile != nil {
+			opts = append(opts, nats.RootCAs(*cfg.Tls.CaFile))
+		}
+		if cfg.Tls.CertFile != nil && cfg.Tls.KeyFile != nil {
+			opts = append(opts, nats.ClientCert(*cfg.Tls.CertFile, *cfg.Tls.KeyFile))
+		}
+	}
+
+	switch {
+	case cfg.AuthEnabled && cfg.Jwt != nil && cfg.Seed != nil:
+		opts = append(opts, nats.UserJWTAndSeed(*cfg.Jwt, *cfg.Seed))
+	case cfg.Creds != nil && cfg.Creds.File != nil:
+		opts = append(opts, nats.UserCredentials(*cfg.Creds.File))
+	case cfg.Creds != nil && cfg.Creds.Secret != nil:
+		opt, err := credsFromSecret(*cfg.Creds.Secret)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	case cfg.Nkey != nil:
+		opt, err := nats.NkeyOptionFromSeed(*cfg.Nkey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read nkey seed: %w", err)
+		}
+		opts = append(opts, opt)
+	case cfg.User != nil:
+		password := ""
+		if cfg.Password != nil {
+			password = *cfg.Password
+		}
+		opts = append(opts, nats.UserInfo(*cfg.User, password))
+	case cfg.Token != nil:
+		opts = append(opts, nats.Token(*cfg.Token))
+	}
+
+	return opts, nil
+}
+
+func credsFromSecret(name string) (nats.Option, error) {
+	contents := os.Getenv(name)
+	if contents == "" {
+		return nil, fmt.Errorf("the secret %s holding the credentials is not set", name)
+	}
+
+	jwt, err := nkeys.ParseDecoratedJWT([]byte(contents))
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials in secret %s: %w", name, err)
+	}
+	kp, err := nkeys.ParseDecoratedNKey([]byte(contents))
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials in secret %s: %w", name, err)
+	}
+	seed, err := kp.Seed()
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials in secret %s: %w", name, err)
+	}
+	return nats.UserJWTAndSeed(jwt, string(seed)), nil
+}
+
+// OpenConsumer reads the messages of the consumer step until the context is done. Core subjects are read with the
+// queue group of the step, streams through a consumer configured by the step and key-value buckets through a watch of
+// the key. Messages which do not match the schema of the step are dropped or dead-lettered through the error handler.
+// Only the headers the step propagates are kept, and its static headers are added. The channel is closed once the
+// connection drained after the context is done.
+func OpenConsumer(ctx context.Context, step *model.ConsumerStep, opts ...StepOpt) (<-chan *Message, error) {
+	if step == nil {
+		return nil, fmt.Errorf("no consumer step")
+	}
+	o := newStepOptions(opts)
+
+	closed := make(chan struct{})
+	nc, err := ConnectStep(step.Nats, append(o.natsOptions, nats.ClosedHandler(func(*nats.Conn) { close(closed) }))...)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := newSchemaEnforcer(ctx, nc, step.Schema, "consumer", o.errorHandler)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	msgs := make(chan *Message)
+	headers := consumerHeaders(step.Headers)
+	deliver := func(m *Message) bool {
+		if valid, err := schema.check(ctx, m); !valid {
+			// rejected messages are acknowledged unless dead-lettering failed, so they are redelivered
+			if err != nil {
+				_ = m.Nak()
+			} else {
+				_ = m.Ack()
+			}
+			return true
+		}
+
+		// consumer headers are never interpolated, so applying them cannot fail
+		m.Headers, _ = headers.apply(m)
+		select {
+		case msgs <- m:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var stop func()
+	switch {
+	case step.Core != nil:
+		stop, err = consumeCore(nc, step.Core, deliver)
+	case step.Stream != nil:
+		stop, err = consumeStream(ctx, nc, step.Stream, o.durable, deliver)
+	case step.Kv != nil:
+		stop, err = consumeKv(ctx, nc, step.Kv, deliver)
+	default:
+		err = fmt.Errorf("the consumer step has no core, stream or kv configuration")
+	}
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		stop()
+		if err := nc.Drain(); err != nil {
+			nc.Close()
+		}
+		<-closed
+		close(msgs)
+	}()
+
+	return msgs, nil
+}
+
+func consumeCore(nc *nats.Conn, core *model.ConsumerStepCore, deliver func(*Message) bool) (func(), error) {
+	handler := func(msg *nats.Msg) {
+		deliver(&Message{Subject: msg.Subject, Headers: msg.Header, Data: msg.Data})
+	}
+
+	var err error
+	if core.Queue != nil && *core.Queue != "" {
+		_, err = nc.QueueSubscribe(core.Subject, *core.Queue, handler)
+	} else {
+		_, err = nc.Subscribe(core.Subject, handler)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", core.Subject, err)
+	}
+
+	if err := nc.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", core.Subject, err)
+	}
+
+	// draining the connection drains the subscription
+	return func() {}, nil
+}
+
+func consumeStream(ctx context.Context, nc *nats.Conn, stream *model.ConsumerStepStream, durable string, deliver func(*Message) bool) (func(), error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	name := ""
+	if stream.Stream != nil {
+		name = *stream.Stream
+	} else if name, err = js.StreamNameBySubject(ctx, stream.Subject); err != nil {
+		return nil, fmt.Errorf("failed to find the stream of %s: %w", stream.Subject, err)
+	}
+
+	cfg, err := StreamConsumerConfig(stream, durable)
+	if err != nil {
+		return nil, err
+	}
+
+	var consumer jetstream.Consumer
+	if stream.Bind {
+		consumer, err = js.Consumer(ctx, name, cfg.Durable)
+	} else {
+		consumer, err = js.CreateOrUpdateConsumer(ctx, name, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer on stream %s: %w", name, err)
+	}
+
+	acks := consumer.CachedInfo().Config.AckPolicy != jetstream.AckNonePolicy
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		m := &Message{
+			Subject: msg.Subject(),
+			Headers: msg.Headers(),
+			Data:    msg.Data(),
+		}
+		if acks {
+			m.ack, m.nak = msg.Ack, msg.Nak
+		}
+		deliver(m)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume from stream %s: %w", name, err)
+	}
+
+	return cc.Stop, nil
+}
+
+// StreamConsumerConfig builds the JetStream consumer configuration of a stream consumer step. The durable name
+// overrides the one of the step when not empty. Settings which are not set keep the server defaults, except for the
+// ack policy which is explicit unless configured otherwise.
+func StreamConsumerConfig(stream *model.ConsumerStepStream, durable string) (jetstream.ConsumerConfig, error) {
+	cfg := jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: stream.Subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+	}
+	if len(stream.FilterSubjects) > 0 {
+		cfg.FilterSubject = ""
+		cfg.FilterSubjects = append([]string{stream.Subject}, stream.FilterSubjects...)
+	}
+	if cfg.Durable == "" && stream.Durable != nil {
+		cfg.Durable = *stream.Durable
+	}
+	if stream.Bind && cfg.Durable == "" {
+		return cfg, fmt.Errorf("binding to an existing consumer requires a durable name")
+	}
+
+	switch stream.DeliverPolicy {
+	case "", model.ConsumerStepStreamDeliverPolicyAll:
+	case model.ConsumerStepStreamDeliverPolicyLast:
+		cfg.DeliverPolicy = jetstream.DeliverLastPolicy
+	case model.ConsumerStepStreamDeliverPolicyNew:
+		cfg.DeliverPolicy = jetstream.DeliverNewPolicy
+	case model.ConsumerStepStreamDeliverPolicyLastPerSubject:
+		cfg.DeliverPolicy = jetstream.DeliverLastPerSubjectPolicy
+	case model.ConsumerStepStreamDeliverPolicyByStartSequence:
+		if stream.OptStartSeq == nil || *stream.OptStartSeq < 1 {
+			return cfg, fmt.Errorf("deliver policy %s requires opt_start_seq", stream.DeliverPolicy)
+		}
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = uint64(*stream.OptStartSeq)
+	case model.ConsumerStepStreamDeliverPolicyByStartTime:
+		if stream.OptStartTime == nil {
+			return cfg, fmt.Errorf("deliver policy %s requires opt_start_time", stream.DeliverPolicy)
+		}
+		t, err := time.Parse(time.RFC3339, *stream.OptStartTime)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid opt_start_time %q: %w", *stream.OptStartTime, err)
+		}
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &t
+	default:
+		return cfg, fmt.Errorf("unknown deliver policy %q", stream.DeliverPolicy)
+	}
+
+	switch stream.AckPolicy {
+	case "", model.ConsumerStepStreamAckPolicyExplicit:
+	case model.ConsumerStepStreamAckPolicyAll:
+		cfg.AckPolicy = jetstream.AckAllPolicy
+	case model.ConsumerStepStreamAckPolicyNone:
+		cfg.AckPolicy = jetstream.AckNonePolicy
+	default:
+		return cfg, fmt.Errorf("unknown ack policy %q", stream.AckPolicy)
+	}
+
+	if stream.AckWait != nil {
+		d, err := time.ParseDuration(*stream.AckWait)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid ack_wait %q: %w", *stream.AckWait, err)
+		}
+		cfg.AckWait = d
+	}
+	if stream.MaxDeliver != nil {
+		cfg.MaxDeliver = *stream.MaxDeliver
+	}
+	if stream.MaxAckPending != nil {
+		cfg.MaxAckPending = *stream.MaxAckPending
+	}
+
+	return cfg, nil
+}
+
+func consumeKv(ctx context.Context, nc *nats.Conn, kv *model.ConsumerStepKv, deliver func(*Message) bool) (func(), error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := js.KeyValue(ctx, kv.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bucket %s: %w", kv.Bucket, err)
+	}
+
+	key := kv.Key
+	if key == "" {
+		key = ">"
+	}
+
+	watcher, err := bucket.Watch(context.Background(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s in bucket %s: %w", key, kv.Bucket, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for entry := range watcher.Updates() {
+			// a nil entry marks the end of the initial values
+			if entry == nil {
+				continue
+			}
+
+			msg := &Message{Subject: entry.Key(), Headers: nats.Header{}, Data: entry.Value()}
+			switch entry.Operation() {
+			case jetstream.KeyValueDelete:
+				msg.Headers.Set(KVOperationHeader, "DEL")
+			case jetstream.KeyValuePurge:
+				msg.Headers.Set(KVOperationHeader, "PURGE")
+			}
+
+			if !deliver(msg) {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		_ = watcher.Stop()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}, nil
+}
@@ -0,0 +1,393 @@
+package runtime_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/builders"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func receive(msgs <-chan *runtime.Message) *runtime.Message {
+	var msg *runtime.Message
+	EventuallyWithOffset(1, msgs).Should(Receive(&msg))
+	return msg
+}
+
+var _ = Describe("Consumers and producers", func() {
+	var ns *server.Server
+	var nc *nats.Conn
+	var js jetstream.JetStream
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ns = startServer(&server.Options{JetStream: true, StoreDir: GinkgoT().TempDir()})
+
+		var err error
+		nc, err = nats.Connect(ns.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		js, err = jetstream.New(nc)
+		Expect(err).ToNot(HaveOccurred())
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
+	natsConfig := func() model.NatsConfig {
+		return model.NatsConfig{Url: ns.ClientURL()}
+	}
+
+	Describe("OpenConsumer", func() {
+		It("should share core messages within the queue group", func() {
+			queue := "workers"
+			step := &model.ConsumerStep{Nats: natsConfig(), Core: &model.ConsumerStepCore{Subject: "orders.>", Queue: &queue}}
+
+			first, err := runtime.OpenConsumer(ctx, step)
+			Expect(err).ToNot(HaveOccurred())
+			second, err := runtime.OpenConsumer(ctx, step)
+			Expect(err).ToNot(HaveOccurred())
+
+			for i := range 10 {
+				Expect(nc.Publish(fmt.Sprintf("orders.%d", i), []byte("order"))).To(Succeed())
+			}
+
+			received := 0
+			Eventually(func() int {
+				for {
+					select {
+					case msg := <-first:
+						Expect(msg.Ack()).To(Succeed())
+						received++
+					case msg := <-second:
+						Expect(msg.Ack()).To(Succeed())
+						received++
+					default:
+						return received
+					}
+				}
+			}).Should(Equal(10))
+			Consistently(first, 100*time.Millisecond).ShouldNot(Receive())
+			Consistently(second, 100*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should close the channel once the context is done", func() {
+			step := &model.ConsumerStep{Nats: natsConfig(), Core: &model.ConsumerStepCore{Subject: "orders"}}
+
+			cctx, cancel := context.WithCancel(ctx)
+			msgs, err := runtime.OpenConsumer(cctx, step)
+			Expect(err).ToNot(HaveOccurred())
+
+			cancel()
+			Eventually(msgs).Should(BeClosed())
+		})
+
+		It("should keep the propagated headers and add the static ones", func() {
+			step := &model.ConsumerStep{Nats: natsConfig(), Core: &model.ConsumerStepCore{Subject: "orders"}, Headers: &model.ConsumerStepHeaders{
+				Propagate: []string{"trace-id", "X-*"},
+				Static:    model.ConsumerStepHeadersStatic{"Source": "crm"},
+			}}
+
+			msgs, err := runtime.OpenConsumer(ctx, step)
+			Expect(err).ToNot(HaveOccurred())
+
+			in := nats.NewMsg("orders")
+			in.Header.Set("Trace-Id", "t1")
+			in.Header.Set("X-Tenant", "acme")
+			in.Header.Set("Authorization", "secret")
+			Expect(nc.PublishMsg(in)).To(Succeed())
+
+			msg := receive(msgs)
+			Expect(msg.Headers).To(Equal(nats.Header{"Trace-Id": {"t1"}, "X-Tenant": {"acme"}, "Source": {"crm"}}))
+		})
+
+		It("should read a stream through a durable consumer", func() {
+			_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+			Expect(err).ToNot(HaveOccurred())
+			for i := range 2 {
+				_, err := js.Publish(ctx, fmt.Sprintf("orders.%d", i), []byte("order"))
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			step := &model.ConsumerStep{Nats: natsConfig(), Stream: &model.ConsumerStepStream{Subject: "orders.>"}}
+			msgs, err := runtime.OpenConsumer(ctx, step, runtime.WithDurable("my-connector"))
+			Expect(err).ToNot(HaveOccurred())
+
+			msg := receive(msgs)
+			Expect(msg.Subject).To(Equal("orders.0"))
+			Expect(msg.Ack()).To(Succeed())
+
+			msg = receive(msgs)
+			Expect(msg.Subject).To(Equal("orders.1"))
+			Expect(msg.Nak()).To(Succeed())
+
+			msg = receive(msgs)
+			Expect(msg.Subject).To(Equal("orders.1"))
+			Expect(msg.Ack()).To(Succeed())
+
+			Eventually(func() uint64 {
+				info, err := js.Consumer(ctx, "ORDERS", "my-connector")
+				Expect(err).ToNot(HaveOccurred())
+				ci, err := info.Info(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				return ci.AckFloor.Consumer
+			}).Should(BeNumerically(">=", 2))
+		})
+
+		It("should configure the stream consumer from the step", func() {
+			_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+			Expect(err).ToNot(HaveOccurred())
+			for i := range 3 {
+				_, err := js.Publish(ctx, fmt.Sprintf("orders.%d", i), []byte("order"))
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			stream := builders.ConsumerStepStream("orders.1").
+				Stream("ORDERS").
+				Durable("resumable").
+				FilterSubjects("orders.2").
+				StartSequence(2).
+				AckWait(time.Minute).
+				MaxDeliver(3).
+				MaxAckPending(10).
+				Build()
+			msgs, err := runtime.OpenConsumer(ctx, &model.ConsumerStep{Nats: natsConfig(), Stream: &stream})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receive(msgs).Subject).To(Equal("orders.1"))
+			Expect(receive(msgs).Subject).To(Equal("orders.2"))
+
+			cons, err := js.Consumer(ctx, "ORDERS", "resumable")
+			Expect(err).ToNot(HaveOccurred())
+			cfg := cons.CachedInfo().Config
+			Expect(cfg.FilterSubjects).To(Equal([]string{"orders.1", "orders.2"}))
+			Expect(cfg.DeliverPolicy).To(Equal(jetstream.DeliverByStartSequencePolicy))
+			Expect(cfg.OptStartSeq).To(Equal(uint64(2)))
+			Expect(cfg.AckWait).To(Equal(time.Minute))
+			Expect(cfg.MaxDeliver).To(Equal(3))
+			Expect(cfg.MaxAckPending).To(Equal(10))
+		})
+
+		It("should bind to an existing consumer", func() {
+			_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = js.CreateConsumer(ctx, "ORDERS", jetstream.ConsumerConfig{Durable: "existing", FilterSubject: "orders.eu", AckPolicy: jetstream.AckExplicitPolicy})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = js.Publish(ctx, "orders.eu", []byte("order"))
+			Expect(err).ToNot(HaveOccurred())
+
+			stream := builders.ConsumerStepStream("orders.>").Stream("ORDERS").Bind("existing").Build()
+			msgs, err := runtime.OpenConsumer(ctx, &model.ConsumerStep{Nats: natsConfig(), Stream: &stream})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(receive(msgs).Subject).To(Equal("orders.eu"))
+
+			stream = builders.ConsumerStepStream("orders.>").Stream("ORDERS").Bind("missing").Build()
+			_, err = runtime.OpenConsumer(ctx, &model.ConsumerStep{Nats: natsConfig(), Stream: &stream})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail without a stream for the subject", func() {
+			step := &model.ConsumerStep{Nats: natsConfig(), Stream: &model.ConsumerStepStream{Subject: "missing"}}
+			_, err := runtime.OpenConsumer(ctx, step)
+			Expect(err).To(MatchError(ContainSubstring("failed to find the stream")))
+		})
+
+		It("should watch the keys of a bucket", func() {
+			kv, err := js.CreateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: "config"})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = kv.PutString(ctx, "app.name", "connect")
+			Expect(err).ToNot(HaveOccurred())
+
+			step := &model.ConsumerStep{Nats: natsConfig(), Kv: &model.ConsumerStepKv{Bucket: "config", Key: "app.>"}}
+			msgs, err := runtime.OpenConsumer(ctx, step)
+			Expect(err).ToNot(HaveOccurred())
+
+			msg := receive(msgs)
+			Expect(msg.Subject).To(Equal("app.name"))
+			Expect(string(msg.Data)).To(Equal("connect"))
+
+			_, err = kv.PutString(ctx, "other", "ignored")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kv.Delete(ctx, "app.name")).To(Succeed())
+
+			msg = receive(msgs)
+			Expect(msg.Subject).To(Equal("app.name"))
+			Expect(msg.Headers.Get(runtime.KVOperationHeader)).To(Equal("DEL"))
+		})
+	})
+
+	Describe("OpenProducer", func() {
+		It("should publish to a core subject", func() {
+			sub, err := nc.SubscribeSync("out")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.Flush()).To(Succeed())
+
+			p, err := runtime.OpenProducer(&model.ProducerStep{Nats: natsConfig(), Core: &model.ProducerStepCore{Subject: "out"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			msg := runtime.NewMessage("in", []byte("hello"))
+			msg.Headers.Set("Trace", "1")
+			Expect(p.Publish(ctx, msg)).To(Succeed())
+			Expect(p.Close()).To(Succeed())
+
+			got, err := sub.NextMsg(time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(got.Data)).To(Equal("hello"))
+			Expect(got.Header.Get("Trace")).To(Equal("1"))
+		})
+
+		It("should template the subject and deduplicate by the message id", func() {
+			_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			msgId := `${! json("id") }`
+			p, err := runtime.OpenProducer(&model.ProducerStep{
+				Nats:   natsConfig(),
+				Stream: &model.ProducerStepStream{Subject: `orders.${! meta("region") }`},
+				Headers: &model.ProducerStepHeaders{
+					Propagate: []string{},
+					Static:    model.ProducerStepHeadersStatic{"Source": "crm"},
+					MsgId:     &msgId,
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			defer p.Close()
+
+			for range 2 {
+				msg := runtime.NewMessage("in", []byte(`{"id":"o-1"}`))
+				msg.Headers.Set("region", "eu")
+				Expect(p.Publish(ctx, msg)).To(Succeed())
+			}
+
+			stream, err := js.Stream(ctx, "ORDERS")
+			Expect(err).ToNot(HaveOccurred())
+			stored, err := stream.GetLastMsgForSubject(ctx, "orders.eu")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.Sequence).To(BeEquivalentTo(1))
+			Expect(stored.Header.Get("Source")).To(Equal("crm"))
+			Expect(stored.Header.Get("Nats-Msg-Id")).To(Equal("o-1"))
+			Expect(stored.Header.Get("region")).To(BeEmpty())
+
+			Expect(p.Publish(ctx, runtime.NewMessage("in", []byte(`{"id":"o-2"}`)))).To(MatchError(ContainSubstring("failed to derive the subject")))
+		})
+
+		It("should put on the key of a bucket", func() {
+			kv, err := js.CreateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: "state"})
+			Expect(err).ToNot(HaveOccurred())
+
+			p, err := runtime.OpenProducer(&model.ProducerStep{Nats: natsConfig(), Kv: &model.ProducerStepKv{Bucket: "state", Key: "latest"}})
+			Expect(err).ToNot(HaveOccurred())
+			defer p.Close()
+
+			Expect(p.Publish(ctx, runtime.NewMessage("", []byte("v1")))).To(Succeed())
+
+			entry, err := kv.Get(ctx, "latest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(entry.Value())).To(Equal("v1"))
+		})
+
+		It("should move messages from a consumer to a stream with the threads of the step", func() {
+			_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "IN", Subjects: []string{"in.>"}})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = js.CreateStream(ctx, jetstream.StreamConfig{Name: "OUT", Subjects: []string{"out"}})
+			Expect(err).ToNot(HaveOccurred())
+			for i := range 20 {
+				_, err := js.Publish(ctx, fmt.Sprintf("in.%d", i), []byte("msg"))
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			msgs, err := runtime.OpenConsumer(ctx, &model.ConsumerStep{Nats: natsConfig(), Stream: &model.ConsumerStepStream{Subject: "in.>"}}, runtime.WithDurable("mover"))
+			Expect(err).ToNot(HaveOccurred())
+
+			p, err := runtime.OpenProducer(&model.ProducerStep{Nats: natsConfig(), Stream: &model.ProducerStepStream{Subject: "out"}, Threads: 4})
+			Expect(err).ToNot(HaveOccurred())
+			defer p.Close()
+			Expect(p.Threads()).To(Equal(4))
+
+			rctx, cancel := context.WithCancel(ctx)
+			done := make(chan error, 1)
+			go func() { done <- p.Run(rctx, msgs) }()
+
+			out, err := js.Stream(ctx, "OUT")
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(func() uint64 {
+				info, err := out.Info(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				return info.State.Msgs
+			}).Should(BeEquivalentTo(20))
+
+			Eventually(func() int {
+				c, err := js.Consumer(ctx, "IN", "mover")
+				Expect(err).ToNot(HaveOccurred())
+				info, err := c.Info(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				return info.NumAckPending
+			}).Should(BeZero())
+
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		It("should require a destination", func() {
+			_, err := runtime.OpenProducer(&model.ProducerStep{Nats: natsConfig()})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("StreamConsumerConfig", func() {
+	It("should default to an explicitly acknowledged consumer of all messages", func() {
+		cfg, err := runtime.StreamConsumerConfig(&model.ConsumerStepStream{Subject: "orders.>"}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg).To(Equal(jetstream.ConsumerConfig{
+			FilterSubject: "orders.>",
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			DeliverPolicy: jetstream.DeliverAllPolicy,
+		}))
+	})
+
+	It("should prefer the given durable name", func() {
+		stream := builders.ConsumerStepStream("orders.>").Durable("from-step").Build()
+
+		cfg, err := runtime.StreamConsumerConfig(&stream, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Durable).To(Equal("from-step"))
+
+		cfg, err = runtime.StreamConsumerConfig(&stream, "from-option")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.Durable).To(Equal("from-option"))
+	})
+
+	It("should start at a time", func() {
+		start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		stream := builders.ConsumerStepStream("orders.>").StartTime(start).AckPolicy(model.ConsumerStepStreamAckPolicyNone).Build()
+
+		cfg, err := runtime.StreamConsumerConfig(&stream, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cfg.DeliverPolicy).To(Equal(jetstream.DeliverByStartTimePolicy))
+		Expect(cfg.OptStartTime.Equal(start)).To(BeTrue())
+		Expect(cfg.AckPolicy).To(Equal(jetstream.AckNonePolicy))
+	})
+
+	It("should reject incomplete configurations", func() {
+		_, err := runtime.StreamConsumerConfig(&model.ConsumerStepStream{Subject: "orders.>", DeliverPolicy: model.ConsumerStepStreamDeliverPolicyByStartSequence}, "")
+		Expect(err).To(MatchError(ContainSubstring("requires opt_start_seq")))
+
+		_, err = runtime.StreamConsumerConfig(&model.ConsumerStepStream{Subject: "orders.>", Bind: true}, "")
+		Expect(err).To(MatchError(ContainSubstring("requires a durable name")))
+
+		wait := "soon"
+		_, err = runtime.StreamConsumerConfig(&model.ConsumerStepStream{Subject: "orders.>", AckWait: &wait}, "")
+		Expect(err).To(MatchError(ContainSubstring("invalid ack_wait")))
+	})
+})
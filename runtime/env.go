@@ -10,4 +10,7 @@ const (
 	InstanceEnvVar  = "NEX_WORKLOAD_ID"
 
 	LogLevelEnvVar = "CONNECT_LOG_LEVEL"
+
+	MetricsPortEnvVar = "CONNECT_METRICS_PORT"
+	MetricsPathEnvVar = "CONNECT_METRICS_PATH"
 )
@@ -0,0 +1,175 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/model"
+)
+
+// Interpolate resolves the ${! ... } interpolations of s from the message. The runtime supports meta("name") and
+// @name reading a header and json("path") reading a field of the JSON payload, with a dot separated path. Runtimes
+// like wombat support the full bloblang language.
+func Interpolate(s string, msg *Message) (string, error) {
+	var result strings.Builder
+	for {
+		start := strings.Index(s, "${!")
+		if start < 0 {
+			result.WriteString(s)
+			return result.String(), nil
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated interpolation in %q", s)
+		}
+
+		value, err := interpolation(strings.TrimSpace(s[start+3:start+end]), msg)
+		if err != nil {
+			return "", err
+		}
+
+		result.WriteString(s[:start])
+		result.WriteString(value)
+		s = s[start+end+1:]
+	}
+}
+
+func interpolation(expr string, msg *Message) (string, error) {
+	switch {
+	case strings.HasPrefix(expr, "@"):
+		return headerValue(msg.Headers, expr[1:])
+	case strings.HasPrefix(expr, "meta(") && strings.HasSuffix(expr, ")"):
+		name, err := stringArgument(expr, "meta(")
+		if err != nil {
+			return "", err
+		}
+		return headerValue(msg.Headers, name)
+	case strings.HasPrefix(expr, "json(") && strings.HasSuffix(expr, ")"):
+		path, err := stringArgument(expr, "json(")
+		if err != nil {
+			return "", err
+		}
+		return jsonValue(msg.Data, path)
+	default:
+		return "", fmt.Errorf("unsupported interpolation %q", expr)
+	}
+}
+
+func stringArgument(expr string, prefix string) (string, error) {
+	arg := strings.TrimSpace(expr[len(prefix) : len(expr)-1])
+	var s string
+	if err := json.Unmarshal([]byte(arg), &s); err != nil {
+		return "", fmt.Errorf("invalid argument of %q: expected a quoted string", expr)
+	}
+	return s, nil
+}
+
+func headerValue(h nats.Header, name string) (string, error) {
+	for k, v := range h {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return v[0], nil
+		}
+	}
+	return "", fmt.Errorf("header %q is not set", name)
+}
+
+func jsonValue(data []byte, path string) (string, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", fmt.Errorf("the payload is not JSON: %w", err)
+	}
+
+	if path != "" {
+		for _, field := range strings.Split(path, ".") {
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("field %q of the payload is not set", path)
+			}
+			if value, ok = obj[field]; !ok {
+				return "", fmt.Errorf("field %q of the payload is not set", path)
+			}
+		}
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// headerMapping is the headers configuration of a consumer or producer step.
+type headerMapping struct {
+	propagate []string
+	static    map[string]string
+	msgId     string
+}
+
+func consumerHeaders(h *model.ConsumerStepHeaders) *headerMapping {
+	if h == nil {
+		return nil
+	}
+	return &headerMapping{propagate: h.Propagate, static: h.Static}
+}
+
+func producerHeaders(h *model.ProducerStepHeaders) *headerMapping {
+	if h == nil {
+		return nil
+	}
+	m := &headerMapping{propagate: h.Propagate, static: h.Static}
+	if h.MsgId != nil {
+		m.msgId = *h.MsgId
+	}
+	return m
+}
+
+// apply returns the headers of the message which are propagated, with the static headers and the Nats-Msg-Id
+// added. The headers of the message are left untouched.
+func (m *headerMapping) apply(msg *Message) (nats.Header, error) {
+	if m == nil {
+		return msg.Headers, nil
+	}
+
+	result := nats.Header{}
+	for k, v := range msg.Headers {
+		if m.propagates(k) {
+			result[k] = v
+		}
+	}
+	for k, v := range m.static {
+		result.Set(k, v)
+	}
+
+	if m.msgId != "" {
+		id, err := Interpolate(m.msgId, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive the message id: %w", err)
+		}
+		result.Set(jetstream.MsgIDHeader, id)
+	}
+
+	return result, nil
+}
+
+// propagates reports whether the header matches one of the propagate patterns, or whether there are none.
+func (m *headerMapping) propagates(name string) bool {
+	if m.propagate == nil {
+		return true
+	}
+	for _, pattern := range m.propagate {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+		} else if strings.EqualFold(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package runtime_test
+
+import (
+	"github.com/synadia-io/connect/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Interpolate", func() {
+	msg := runtime.NewMessage("orders", []byte(`{"id":42,"customer":{"region":"eu"}}`))
+	msg.Headers.Set("Region", "us")
+
+	It("should resolve headers and payload fields", func() {
+		Expect(runtime.Interpolate(`orders.${! meta("region") }`, msg)).To(Equal("orders.us"))
+		Expect(runtime.Interpolate(`orders.${!@Region}.new`, msg)).To(Equal("orders.us.new"))
+		Expect(runtime.Interpolate(`${! json("customer.region") }-${! json("id") }`, msg)).To(Equal("eu-42"))
+		Expect(runtime.Interpolate("orders.new", msg)).To(Equal("orders.new"))
+	})
+
+	It("should fail for missing values", func() {
+		_, err := runtime.Interpolate(`${! meta("tenant") }`, msg)
+		Expect(err).To(MatchError(ContainSubstring(`header "tenant" is not set`)))
+
+		_, err = runtime.Interpolate(`${! json("customer.name") }`, msg)
+		Expect(err).To(MatchError(ContainSubstring("is not set")))
+	})
+
+	It("should reject unsupported expressions", func() {
+		_, err := runtime.Interpolate(`${! uuid_v4() }`, msg)
+		Expect(err).To(MatchError(ContainSubstring("unsupported interpolation")))
+
+		_, err = runtime.Interpolate(`orders.${! meta("region")`, msg)
+		Expect(err).To(MatchError(ContainSubstring("unterminated")))
+	})
+})
@@ -0,0 +1,148 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultMetricsPath is the path of the metrics endpoint unless the runtime metrics specify one.
+const DefaultMetricsPath = "/metrics"
+
+// Watch makes the readiness of the runtime depend on the workload foundation: the runtime is only ready while
+// every watched foundation is running.
+func (r *Runtime) Watch(w *WorkloadFoundation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.foundations = append(r.foundations, w)
+}
+
+// IsReady reports whether the workload is launched, not shutting down and all watched foundations are running.
+func (r *Runtime) IsReady() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.launched || r.draining || r.closed {
+		return false
+	}
+	for _, w := range r.foundations {
+		if !w.IsRunning() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsHealthy reports whether none of the connections created by NatsConfig closed before the runtime did.
+func (r *Runtime) IsHealthy() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return true
+	}
+	for _, c := range r.conns {
+		if c.nc.Status() == nats.CLOSED {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler returns the handler serving /healthz, /readyz and the metrics endpoint. An invalid metrics path falls back
+// to DefaultMetricsPath.
+func (r *Runtime) Handler() http.Handler {
+	path := DefaultMetricsPath
+	if r.Metrics != nil && r.Metrics.Path != nil && *r.Metrics.Path != "" {
+		p, err := metricsPath(*r.Metrics.Path)
+		if err != nil {
+			r.Logger.Warn("serving metrics on the default path", "path", DefaultMetricsPath, "error", err)
+		} else {
+			path = p
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, r.IsHealthy())
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, r.IsReady())
+	})
+	mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.writeMetrics(w)
+	})
+	return mux
+}
+
+// metricsPath returns the path with a leading slash, rejecting the paths of the probes and those http.ServeMux
+// cannot register as a plain path.
+func metricsPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if path == "/healthz" || path == "/readyz" {
+		return "", fmt.Errorf("metrics path %q is taken by the probes", path)
+	}
+	if strings.ContainsAny(path, " \t\r\n{}?#") {
+		return "", fmt.Errorf("invalid metrics path %q", path)
+	}
+	return path, nil
+}
+
+func writeProbe(w http.ResponseWriter, ok bool) {
+	if !ok {
+		http.Error(w, "not ok", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = fmt.Fprintln(w, "ok")
+}
+
+// serve starts serving the handler on the metrics port if the runtime has metrics configured.
+func (r *Runtime) serve() error {
+	if r.Metrics == nil {
+		return nil
+	}
+
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", r.Metrics.Port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on metrics port %d: %w", r.Metrics.Port, err)
+	}
+
+	srv := &http.Server{Handler: r.Handler(), ReadHeaderTimeout: 5 * time.Second}
+
+	r.mu.Lock()
+	r.server = srv
+	r.mu.Unlock()
+
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			r.Logger.Error("metrics server failed", "error", err)
+		}
+	}()
+
+	r.Logger.Debug("serving health and metrics", "address", l.Addr().String())
+	return nil
+}
+
+func (r *Runtime) shutdownServer() {
+	r.mu.Lock()
+	srv := r.server
+	r.server = nil
+	r.mu.Unlock()
+
+	if srv == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(ctx)
+}
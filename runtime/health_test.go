@@ -0,0 +1,153 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func probe(h http.Handler, path string) (int, string) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func freePort() int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+var _ = Describe("Health and metrics", func() {
+	It("should be ready while the watched foundations run", func() {
+		rt := runtime.NewRuntime()
+		h := rt.Handler()
+
+		code, _ := probe(h, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+		err := rt.Launch(context.Background(), func(ctx context.Context, rt *runtime.Runtime, steps model.Steps) error {
+			var w runtime.WorkloadFoundation
+			rt.Watch(&w)
+
+			code, _ := probe(h, "/readyz")
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+			w.Started(ctx)
+			code, body := probe(h, "/readyz")
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body).To(Equal("ok\n"))
+
+			w.Stopped()
+			code, _ = probe(h, "/readyz")
+			Expect(code).To(Equal(http.StatusServiceUnavailable))
+			return nil
+		}, emptySteps)
+		Expect(err).ToNot(HaveOccurred())
+
+		code, _ = probe(h, "/readyz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should no longer be ready once asked to stop", func() {
+		rt := runtime.NewRuntime()
+		h := rt.Handler()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := rt.Launch(ctx, func(ctx context.Context, rt *runtime.Runtime, steps model.Steps) error {
+			Expect(rt.IsReady()).To(BeTrue())
+			cancel()
+			Eventually(func() int { code, _ := probe(h, "/readyz"); return code }).Should(Equal(http.StatusServiceUnavailable))
+			return nil
+		}, emptySteps)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should be unhealthy when a connection closed", func() {
+		ns := startServer(&server.Options{})
+		rt := runtime.NewRuntime(runtime.WithNatsUrl(ns.ClientURL()))
+		DeferCleanup(rt.Close)
+		h := rt.Handler()
+
+		nc, err := rt.NatsConfig()
+		Expect(err).ToNot(HaveOccurred())
+
+		code, _ := probe(h, "/healthz")
+		Expect(code).To(Equal(http.StatusOK))
+
+		nc.Close()
+		code, _ = probe(h, "/healthz")
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("should count messages, errors and latency per step", func() {
+		rt := runtime.NewRuntime(runtime.WithGroup("my-connector"), runtime.WithInstance("inst-1"))
+
+		source := rt.Step("source")
+		source.Observe(3*time.Millisecond, nil)
+		source.Observe(200*time.Millisecond, errors.New("boom"))
+		rt.Step("sink").Record(time.Now(), nil)
+
+		Expect(rt.Step("source").Messages()).To(BeEquivalentTo(2))
+		Expect(rt.Step("source").Errors()).To(BeEquivalentTo(1))
+
+		code, body := probe(rt.Handler(), "/metrics")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(ContainSubstring(`connect_runtime_ready{connector="my-connector",instance="inst-1"} 0`))
+		Expect(body).To(ContainSubstring(`connect_step_messages_total{connector="my-connector",instance="inst-1",step="source"} 2`))
+		Expect(body).To(ContainSubstring(`connect_step_messages_total{connector="my-connector",instance="inst-1",step="sink"} 1`))
+		Expect(body).To(ContainSubstring(`connect_step_errors_total{connector="my-connector",instance="inst-1",step="source"} 1`))
+		Expect(body).To(ContainSubstring(`connect_step_latency_seconds_bucket{connector="my-connector",instance="inst-1",step="source",le="0.005"} 1`))
+		Expect(body).To(ContainSubstring(`connect_step_latency_seconds_bucket{connector="my-connector",instance="inst-1",step="source",le="0.25"} 2`))
+		Expect(body).To(ContainSubstring(`connect_step_latency_seconds_count{connector="my-connector",instance="inst-1",step="source"} 2`))
+	})
+
+	It("should fall back to the default metrics path for invalid paths", func() {
+		for _, path := range []string{"/healthz", "/my metrics"} {
+			rt := runtime.NewRuntime(runtime.WithMetrics(&model.RuntimeMetrics{Path: &path}), runtime.WithLogger(slog.New(slog.DiscardHandler)))
+
+			var h http.Handler
+			Expect(func() { h = rt.Handler() }).ToNot(Panic())
+			code, _ := probe(h, "/metrics")
+			Expect(code).To(Equal(http.StatusOK), path)
+			code, _ = probe(h, "/healthz")
+			Expect(code).To(Equal(http.StatusOK), path)
+		}
+	})
+
+	It("should serve on the port and path of the runtime metrics while launched", func() {
+		path := "/custom"
+		port := freePort()
+		rt := runtime.NewRuntime(runtime.WithMetrics(&model.RuntimeMetrics{Port: port, Path: &path}))
+
+		base := fmt.Sprintf("http://127.0.0.1:%d", port)
+		err := rt.Launch(context.Background(), func(ctx context.Context, rt *runtime.Runtime, steps model.Steps) error {
+			for _, p := range []string{"/healthz", "/readyz", "/custom"} {
+				resp, err := http.Get(base + p)
+				Expect(err).ToNot(HaveOccurred())
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK), p)
+			}
+			return nil
+		}, emptySteps)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = http.Get(base + "/healthz")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,176 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the step latency histogram.
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// StepMetrics counts the messages, errors and latency of a step. It is safe for concurrent use.
+type StepMetrics struct {
+	mu       sync.Mutex
+	messages uint64
+	errors   uint64
+	buckets  []uint64
+	sum      float64
+}
+
+func newStepMetrics() *StepMetrics {
+	return &StepMetrics{buckets: make([]uint64, len(latencyBuckets))}
+}
+
+// Record counts a message handled since start, and an error if err is not nil.
+func (s *StepMetrics) Record(start time.Time, err error) {
+	s.Observe(time.Since(start), err)
+}
+
+// Observe counts a message which took the given time to handle, and an error if err is not nil.
+func (s *StepMetrics) Observe(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages++
+	if err != nil {
+		s.errors++
+	}
+
+	seconds := latency.Seconds()
+	s.sum += seconds
+	for i, le := range latencyBuckets {
+		if seconds <= le {
+			s.buckets[i]++
+		}
+	}
+}
+
+// Messages returns the number of messages handled by the step.
+func (s *StepMetrics) Messages() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages
+}
+
+// Errors returns the number of messages the step failed to handle.
+func (s *StepMetrics) Errors() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errors
+}
+
+// Step returns the metrics of the named step, creating them on first use.
+func (r *Runtime) Step(name string) *StepMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.steps == nil {
+		r.steps = map[string]*StepMetrics{}
+	}
+	s, ok := r.steps[name]
+	if !ok {
+		s = newStepMetrics()
+		r.steps[name] = s
+	}
+	return s
+}
+
+// writeMetrics writes the metrics of the runtime in the Prometheus text format.
+func (r *Runtime) writeMetrics(w io.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.steps))
+	for name := range r.steps {
+		names = append(names, name)
+	}
+	steps := r.steps
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	base := r.metricLabels()
+
+	ready := 0
+	if r.IsReady() {
+		ready = 1
+	}
+	fmt.Fprintln(w, "# HELP connect_runtime_ready Whether the workload is ready.")
+	fmt.Fprintln(w, "# TYPE connect_runtime_ready gauge")
+	fmt.Fprintf(w, "connect_runtime_ready%s %d\n", formatLabels(base), ready)
+
+	type snapshot struct {
+		labels   [][2]string
+		messages uint64
+		errors   uint64
+		buckets  []uint64
+		sum      float64
+	}
+	snaps := make([]snapshot, 0, len(names))
+	for _, name := range names {
+		s := steps[name]
+		s.mu.Lock()
+		snaps = append(snaps, snapshot{
+			labels:   append(append([][2]string{}, base...), [2]string{"step", name}),
+			messages: s.messages,
+			errors:   s.errors,
+			buckets:  append([]uint64(nil), s.buckets...),
+			sum:      s.sum,
+		})
+		s.mu.Unlock()
+	}
+
+	fmt.Fprintln(w, "# HELP connect_step_messages_total Messages handled by a step.")
+	fmt.Fprintln(w, "# TYPE connect_step_messages_total counter")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "connect_step_messages_total%s %d\n", formatLabels(s.labels), s.messages)
+	}
+
+	fmt.Fprintln(w, "# HELP connect_step_errors_total Messages a step failed to handle.")
+	fmt.Fprintln(w, "# TYPE connect_step_errors_total counter")
+	for _, s := range snaps {
+		fmt.Fprintf(w, "connect_step_errors_total%s %d\n", formatLabels(s.labels), s.errors)
+	}
+
+	fmt.Fprintln(w, "# HELP connect_step_latency_seconds Time a step took to handle a message.")
+	fmt.Fprintln(w, "# TYPE connect_step_latency_seconds histogram")
+	for _, s := range snaps {
+		for i, le := range latencyBuckets {
+			labels := append(append([][2]string{}, s.labels...), [2]string{"le", strconv.FormatFloat(le, 'g', -1, 64)})
+			fmt.Fprintf(w, "connect_step_latency_seconds_bucket%s %d\n", formatLabels(labels), s.buckets[i])
+		}
+		labels := append(append([][2]string{}, s.labels...), [2]string{"le", "+Inf"})
+		fmt.Fprintf(w, "connect_step_latency_seconds_bucket%s %d\n", formatLabels(labels), s.messages)
+		fmt.Fprintf(w, "connect_step_latency_seconds_sum%s %s\n", formatLabels(s.labels), strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "connect_step_latency_seconds_count%s %d\n", formatLabels(s.labels), s.messages)
+	}
+}
+
+func (r *Runtime) metricLabels() [][2]string {
+	var labels [][2]string
+	if r.Namespace != "" {
+		labels = append(labels, [2]string{"namespace", r.Namespace})
+	}
+	if r.Connector != "" {
+		labels = append(labels, [2]string{"connector", r.Connector})
+	}
+	if r.Instance != "" {
+		labels = append(labels, [2]string{"instance", r.Instance})
+	}
+	return labels
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatLabels(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, l[0], labelEscaper.Replace(l[1]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
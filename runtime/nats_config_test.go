@@ -0,0 +1,69 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nkeys"
+	"github.com/synadia-io/connect/builders"
+	"github.com/synadia-io/connect/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConnectStep", func() {
+	It("should authenticate with a user and password", func() {
+		ns := startServer(&server.Options{Username: "app", Password: "s3cret"})
+
+		nc, err := runtime.ConnectStep(builders.NatsConfig().Url(ns.ClientURL()).UserInfo("app", "s3cret").Build())
+		Expect(err).ToNot(HaveOccurred())
+		nc.Close()
+
+		_, err = runtime.ConnectStep(builders.NatsConfig().Url(ns.ClientURL()).UserInfo("app", "wrong").Build())
+		Expect(err).To(MatchError(ContainSubstring("Authorization Violation")))
+	})
+
+	It("should authenticate with a token", func() {
+		ns := startServer(&server.Options{Authorization: "t0ken"})
+
+		nc, err := runtime.ConnectStep(builders.NatsConfig().Url(ns.ClientURL()).Token("t0ken").Build())
+		Expect(err).ToNot(HaveOccurred())
+		nc.Close()
+	})
+
+	It("should authenticate with the nkey seed of a file", func() {
+		kp, err := nkeys.CreateUser()
+		Expect(err).ToNot(HaveOccurred())
+		pub, err := kp.PublicKey()
+		Expect(err).ToNot(HaveOccurred())
+		seed, err := kp.Seed()
+		Expect(err).ToNot(HaveOccurred())
+
+		file := filepath.Join(GinkgoT().TempDir(), "user.nk")
+		Expect(os.WriteFile(file, seed, 0600)).To(Succeed())
+
+		ns := startServer(&server.Options{Nkeys: []*server.NkeyUser{{Nkey: pub}}})
+
+		nc, err := runtime.ConnectStep(builders.NatsConfig().Url(ns.ClientURL()).Nkey(file).Build())
+		Expect(err).ToNot(HaveOccurred())
+		nc.Close()
+	})
+
+	It("should read credentials from the secret", func() {
+		_, err := runtime.ConnectStep(builders.NatsConfig().CredsSecret("CONNECT_TEST_MISSING_CREDS").Build())
+		Expect(err).To(MatchError("the secret CONNECT_TEST_MISSING_CREDS holding the credentials is not set"))
+
+		GinkgoT().Setenv("CONNECT_TEST_CREDS", "not credentials")
+		_, err = runtime.ConnectStep(builders.NatsConfig().CredsSecret("CONNECT_TEST_CREDS").Build())
+		Expect(err).To(MatchError(ContainSubstring("invalid credentials in secret CONNECT_TEST_CREDS")))
+	})
+
+	It("should require TLS when configured", func() {
+		ns := startServer(&server.Options{})
+
+		_, err := runtime.ConnectStep(builders.NatsConfig().Url(ns.ClientURL()).InsecureSkipVerify().Build())
+		Expect(err).To(HaveOccurred())
+	})
+})
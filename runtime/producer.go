@@ -0,0 +1,166 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/model"
+)
+
+// Producer writes messages as described by a producer step.
+type Producer struct {
+	step    *model.ProducerStep
+	nc      *nats.Conn
+	js      jetstream.JetStream
+	kv      jetstream.KeyValue
+	threads int
+	headers *headerMapping
+	errors  *ErrorHandler
+	schema  *schemaEnforcer
+}
+
+// OpenProducer connects to the NATS server of the producer step. Messages are published to the core or stream
+// subject of the step, or put on the key of its key-value bucket. Run drops or dead-letters the messages which do not
+// match the schema of the step.
+func OpenProducer(step *model.ProducerStep, opts ...StepOpt) (*Producer, error) {
+	if step == nil {
+		return nil, fmt.Errorf("no producer step")
+	}
+	if step.Core == nil && step.Stream == nil && step.Kv == nil {
+		return nil, fmt.Errorf("the producer step has no core, stream or kv configuration")
+	}
+	o := newStepOptions(opts)
+
+	nc, err := ConnectStep(step.Nats, o.natsOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Producer{step: step, nc: nc, threads: max(step.Threads, 1), headers: producerHeaders(step.Headers), errors: o.errorHandler}
+
+	if step.Stream != nil || step.Kv != nil {
+		if p.js, err = jetstream.New(nc); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+
+	if step.Kv != nil {
+		if p.kv, err = p.js.KeyValue(context.Background(), step.Kv.Bucket); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("failed to open bucket %s: %w", step.Kv.Bucket, err)
+		}
+	}
+
+	if p.schema, err = newSchemaEnforcer(context.Background(), nc, step.Schema, "producer", o.errorHandler); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Threads returns the number of messages Run writes concurrently.
+func (p *Producer) Threads() int {
+	return p.threads
+}
+
+// Publish writes a single message. The subject is interpolated from the message and the headers are mapped as
+// configured by the step. Stream publishes wait for the acknowledgement of the stream.
+func (p *Producer) Publish(ctx context.Context, msg *Message) error {
+	if p.step.Kv != nil {
+		_, err := p.kv.Put(ctx, p.step.Kv.Key, msg.Data)
+		return err
+	}
+
+	var subject string
+	if p.step.Stream != nil {
+		subject = p.step.Stream.Subject
+	} else {
+		subject = p.step.Core.Subject
+	}
+	subject, err := Interpolate(subject, msg)
+	if err != nil {
+		return fmt.Errorf("failed to derive the subject: %w", err)
+	}
+
+	headers, err := p.headers.apply(msg)
+	if err != nil {
+		return err
+	}
+
+	out := &nats.Msg{Subject: subject, Header: headers, Data: msg.Data}
+	if p.step.Stream != nil {
+		_, err = p.js.PublishMsg(ctx, out)
+		return err
+	}
+	return p.nc.PublishMsg(out)
+}
+
+// Run writes the messages with as many concurrent writers as the step has threads, acknowledging each message once
+// written or dead-lettered by the error handler of the producer. A message which could not be written is naked and
+// stops Run with the error. Run returns when the channel is closed or the context is done.
+func (p *Producer) Run(ctx context.Context, msgs <-chan *Message) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for range p.threads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-msgs:
+					if !ok {
+						return
+					}
+
+					if err := p.write(ctx, msg); err != nil {
+						_ = msg.Nak()
+						cancel(fmt.Errorf("failed to write message: %w", err))
+						return
+					}
+
+					if err := msg.Ack(); err != nil {
+						cancel(fmt.Errorf("failed to acknowledge message: %w", err))
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+func (p *Producer) write(ctx context.Context, msg *Message) error {
+	if valid, err := p.schema.check(ctx, msg); !valid {
+		return err
+	}
+
+	if p.errors == nil {
+		return p.Publish(ctx, msg)
+	}
+	return p.errors.Handle(ctx, msg, p.Publish)
+}
+
+// Close drains the connection of the producer, waiting for pending core messages to be flushed.
+func (p *Producer) Close() error {
+	if err := p.nc.Flush(); err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		p.nc.Close()
+		return err
+	}
+	p.nc.Close()
+	return nil
+}
@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/model"
+)
+
+// Headers set on dead-lettered messages.
+const (
+	// ErrorHeader holds the error of the last delivery attempt.
+	ErrorHeader = "Connect-Error"
+	// ErrorStepHeader holds the step which failed to deliver the message, sink or producer, or the consumer or
+	// producer whose schema the message does not match.
+	ErrorStepHeader = "Connect-Error-Step"
+	// ErrorAttemptsHeader holds the number of delivery attempts.
+	ErrorAttemptsHeader = "Connect-Error-Attempts"
+)
+
+// Defaults of the errors section of the steps.
+const (
+	DefaultMaxRetries = 3
+	DefaultBackoff    = time.Second
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// ErrorHandler retries the failed deliveries of a sink or producer with exponential backoff and dead-letters the
+// messages which still fail, as described by the errors section of the steps.
+type ErrorHandler struct {
+	step       string
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	deadLetter *Producer
+}
+
+// NewErrorHandler creates the handler for the deliveries of the named step. Without a policy deliveries are not
+// retried. The options apply to the connection of the dead letter producer.
+func NewErrorHandler(policy *model.ErrorPolicy, step string, opts ...StepOpt) (*ErrorHandler, error) {
+	h := &ErrorHandler{step: step, backoff: DefaultBackoff, maxBackoff: DefaultMaxBackoff}
+	if policy == nil {
+		return h, nil
+	}
+
+	h.maxRetries = DefaultMaxRetries
+	if policy.MaxRetries != nil {
+		h.maxRetries = *policy.MaxRetries
+	}
+	if h.maxRetries < 0 {
+		return nil, fmt.Errorf("max_retries must not be negative")
+	}
+
+	var err error
+	if policy.Backoff != "" {
+		if h.backoff, err = time.ParseDuration(policy.Backoff); err != nil {
+			return nil, fmt.Errorf("invalid backoff %q: %w", policy.Backoff, err)
+		}
+	}
+	if policy.MaxBackoff != "" {
+		if h.maxBackoff, err = time.ParseDuration(policy.MaxBackoff); err != nil {
+			return nil, fmt.Errorf("invalid max_backoff %q: %w", policy.MaxBackoff, err)
+		}
+	}
+
+	if policy.DeadLetter != nil {
+		if h.deadLetter, err = OpenProducer(policy.DeadLetter, opts...); err != nil {
+			return nil, fmt.Errorf("failed to open the dead letter producer: %w", err)
+		}
+	}
+
+	return h, nil
+}
+
+// Handle delivers the message, retrying failed attempts. A message which still fails after the last retry is
+// published to the dead letter producer with the error headers set. Handle returns nil once the message is delivered
+// or dead-lettered, and the last error otherwise, e.g. without a dead letter producer.
+func (h *ErrorHandler) Handle(ctx context.Context, msg *Message, deliver func(context.Context, *Message) error) error {
+	attempts := 0
+	for {
+		attempts++
+		err := deliver(ctx, msg)
+		if err == nil {
+			return nil
+		}
+
+		if attempts > h.maxRetries {
+			return h.deadLetterMessage(ctx, msg, h.step, attempts, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(h.delay(attempts)):
+		}
+	}
+}
+
+// delay returns the backoff before the given retry, doubling from the initial backoff up to the maximum.
+func (h *ErrorHandler) delay(retry int) time.Duration {
+	d := h.backoff
+	for i := 1; i < retry && d < h.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, h.maxBackoff)
+}
+
+// Reject publishes a message which is not delivered at all to the dead letter producer, e.g. one which does not match
+// the schema of the named step. Its attempts header is 0. Without a dead letter producer the cause is returned.
+func (h *ErrorHandler) Reject(ctx context.Context, msg *Message, step string, cause error) error {
+	return h.deadLetterMessage(ctx, msg, step, 0, cause)
+}
+
+func (h *ErrorHandler) deadLetterMessage(ctx context.Context, msg *Message, step string, attempts int, cause error) error {
+	if h.deadLetter == nil {
+		return cause
+	}
+
+	headers := nats.Header{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers.Set(ErrorHeader, cause.Error())
+	headers.Set(ErrorStepHeader, step)
+	headers.Set(ErrorAttemptsHeader, strconv.Itoa(attempts))
+
+	if err := h.deadLetter.Publish(ctx, &Message{Subject: msg.Subject, Headers: headers, Data: msg.Data}); err != nil {
+		return fmt.Errorf("failed to dead-letter message failing with %q: %w", cause, err)
+	}
+	return nil
+}
+
+// Close closes the dead letter producer.
+func (h *ErrorHandler) Close() error {
+	if h.deadLetter == nil {
+		return nil
+	}
+	return h.deadLetter.Close()
+}
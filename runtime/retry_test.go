@@ -0,0 +1,147 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ErrorHandler", func() {
+	var ns *server.Server
+	var nc *nats.Conn
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ns = startServer(&server.Options{JetStream: true, StoreDir: GinkgoT().TempDir()})
+
+		var err error
+		nc, err = nats.Connect(ns.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
+	policy := func(maxRetries int, deadLetter *model.ProducerStep) *model.ErrorPolicy {
+		return &model.ErrorPolicy{MaxRetries: &maxRetries, Backoff: "1ms", MaxBackoff: "5ms", DeadLetter: deadLetter}
+	}
+
+	failing := func(failures int) (func(context.Context, *runtime.Message) error, *int) {
+		attempts := 0
+		return func(context.Context, *runtime.Message) error {
+			attempts++
+			if attempts <= failures {
+				return errors.New("sink unavailable")
+			}
+			return nil
+		}, &attempts
+	}
+
+	It("should retry until the message is delivered", func() {
+		h, err := runtime.NewErrorHandler(policy(3, nil), "sink")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(h.Close)
+
+		deliver, attempts := failing(2)
+		Expect(h.Handle(ctx, runtime.NewMessage("orders", []byte("order")), deliver)).To(Succeed())
+		Expect(*attempts).To(Equal(3))
+	})
+
+	It("should return the last error without a dead letter producer", func() {
+		h, err := runtime.NewErrorHandler(policy(1, nil), "sink")
+		Expect(err).ToNot(HaveOccurred())
+
+		deliver, attempts := failing(5)
+		Expect(h.Handle(ctx, runtime.NewMessage("orders", []byte("order")), deliver)).To(MatchError("sink unavailable"))
+		Expect(*attempts).To(Equal(2))
+	})
+
+	It("should retry the default number of times when max_retries is not set", func() {
+		h, err := runtime.NewErrorHandler(&model.ErrorPolicy{Backoff: "1ms", MaxBackoff: "5ms"}, "sink")
+		Expect(err).ToNot(HaveOccurred())
+
+		deliver, attempts := failing(10)
+		Expect(h.Handle(ctx, runtime.NewMessage("orders", []byte("order")), deliver)).ToNot(Succeed())
+		Expect(*attempts).To(Equal(runtime.DefaultMaxRetries + 1))
+	})
+
+	It("should not retry without a policy", func() {
+		h, err := runtime.NewErrorHandler(nil, "sink")
+		Expect(err).ToNot(HaveOccurred())
+
+		deliver, attempts := failing(1)
+		Expect(h.Handle(ctx, runtime.NewMessage("orders", []byte("order")), deliver)).ToNot(Succeed())
+		Expect(*attempts).To(Equal(1))
+	})
+
+	It("should dead-letter messages which still fail", func() {
+		sub, err := nc.SubscribeSync("dlq.orders")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nc.Flush()).To(Succeed())
+
+		deadLetter := &model.ProducerStep{Nats: model.NatsConfig{Url: ns.ClientURL()}, Core: &model.ProducerStepCore{Subject: "dlq.orders"}}
+		h, err := runtime.NewErrorHandler(policy(2, deadLetter), "sink")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(h.Close)
+
+		msg := runtime.NewMessage("orders", []byte("poison"))
+		msg.Headers.Set("Tenant", "acme")
+
+		deliver, _ := failing(5)
+		Expect(h.Handle(ctx, msg, deliver)).To(Succeed())
+
+		dead, err := sub.NextMsg(time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(dead.Data)).To(Equal("poison"))
+		Expect(dead.Header.Get("Tenant")).To(Equal("acme"))
+		Expect(dead.Header.Get(runtime.ErrorHeader)).To(Equal("sink unavailable"))
+		Expect(dead.Header.Get(runtime.ErrorStepHeader)).To(Equal("sink"))
+		Expect(dead.Header.Get(runtime.ErrorAttemptsHeader)).To(Equal("3"))
+		Expect(msg.Headers.Get(runtime.ErrorHeader)).To(BeEmpty())
+	})
+
+	It("should dead-letter the messages a producer fails to write", func() {
+		sub, err := nc.SubscribeSync("dlq.orders")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nc.Flush()).To(Succeed())
+
+		deadLetter := &model.ProducerStep{Nats: model.NatsConfig{Url: ns.ClientURL()}, Core: &model.ProducerStepCore{Subject: "dlq.orders"}}
+		h, err := runtime.NewErrorHandler(policy(1, deadLetter), "producer")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(h.Close)
+
+		// there is no stream for the subject, so every publish fails
+		p, err := runtime.OpenProducer(&model.ProducerStep{Nats: model.NatsConfig{Url: ns.ClientURL()}, Stream: &model.ProducerStepStream{Subject: "missing"}, Threads: 1}, runtime.WithErrorHandler(h))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(p.Close)
+
+		msgs := make(chan *runtime.Message, 1)
+		msgs <- runtime.NewMessage("orders", []byte("order"))
+		close(msgs)
+		Expect(p.Run(ctx, msgs)).To(Succeed())
+
+		dead, err := sub.NextMsg(time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(dead.Header.Get(runtime.ErrorStepHeader)).To(Equal("producer"))
+		Expect(dead.Header.Get(runtime.ErrorAttemptsHeader)).To(Equal("2"))
+	})
+
+	It("should reject invalid policies", func() {
+		_, err := runtime.NewErrorHandler(&model.ErrorPolicy{Backoff: "soon"}, "sink")
+		Expect(err).To(MatchError(ContainSubstring("invalid backoff")))
+
+		negative := -1
+		_, err = runtime.NewErrorHandler(&model.ErrorPolicy{MaxRetries: &negative}, "sink")
+		Expect(err).To(MatchError(ContainSubstring("must not be negative")))
+	})
+})
@@ -5,14 +5,24 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
 	"github.com/synadia-io/connect/model"
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultDrainTimeout is how long Close waits for the connections of the runtime to drain.
+const DefaultDrainTimeout = 30 * time.Second
+
 type Opt func(*Runtime)
 
 func WithLogLevel(level slog.Level) Opt {
@@ -57,12 +67,27 @@ func WithNatsSeed(seed string) Opt {
 	}
 }
 
+// WithLogger sets the logger to use instead of the JSON logger writing to stderr at LogLevel.
 func WithLogger(logger *slog.Logger) Opt {
 	return func(r *Runtime) {
 		r.Logger = logger
 	}
 }
 
+// WithMetrics serves /healthz, /readyz and the Prometheus metrics on the port and path of the runtime metrics.
+func WithMetrics(metrics *model.RuntimeMetrics) Opt {
+	return func(r *Runtime) {
+		r.Metrics = metrics
+	}
+}
+
+// WithDrainTimeout sets how long Close waits for the connections to drain.
+func WithDrainTimeout(timeout time.Duration) Opt {
+	return func(r *Runtime) {
+		r.DrainTimeout = timeout
+	}
+}
+
 func FromEnv() (*Runtime, error) {
 	opts := []Opt{
 		WithNamespace(os.Getenv(NamespaceEnvVar)),
@@ -73,18 +98,28 @@ func FromEnv() (*Runtime, error) {
 	}
 
 	if ll := os.Getenv(LogLevelEnvVar); ll != "" {
-		switch strings.ToLower(os.Getenv(LogLevelEnvVar)) {
-		case "debug":
-			opts = append(opts, WithLogLevel(slog.LevelDebug))
-		case "info":
-			opts = append(opts, WithLogLevel(slog.LevelInfo))
-		case "warn":
-			opts = append(opts, WithLogLevel(slog.LevelWarn))
-		case "error":
-			opts = append(opts, WithLogLevel(slog.LevelError))
+		if level, err := ParseLogLevel(ll); err == nil {
+			opts = append(opts, WithLogLevel(level))
 		}
 	}
 
+	if port := os.Getenv(MetricsPortEnvVar); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metrics port %q: %w", port, err)
+		}
+
+		metrics := &model.RuntimeMetrics{Port: p}
+		if path := os.Getenv(MetricsPathEnvVar); path != "" {
+			path, err := metricsPath(path)
+			if err != nil {
+				return nil, err
+			}
+			metrics.Path = &path
+		}
+		opts = append(opts, WithMetrics(metrics))
+	}
+
 	if jwt := os.Getenv(NatsJwtVar); jwt != "" {
 		j, err := base64.StdEncoding.DecodeString(jwt)
 		if err != nil {
@@ -97,16 +132,34 @@ func FromEnv() (*Runtime, error) {
 	return NewRuntime(opts...), nil
 }
 
+// ParseLogLevel parses the value of the log level environment variable.
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q", level)
+	}
+}
+
 func NewRuntime(opts ...Opt) *Runtime {
 	result := &Runtime{
-		LogLevel: slog.LevelDebug,
-		Logger:   slog.Default(),
+		LogLevel:     slog.LevelDebug,
+		DrainTimeout: DefaultDrainTimeout,
 	}
 
 	for _, opt := range opts {
 		opt(result)
 	}
 
+	result.Logger = result.logger()
+
 	return result
 }
 
@@ -117,24 +170,149 @@ type Runtime struct {
 	Connector string
 	Instance  string
 
-	NatsUrl  string
-	NatsJwt  string
+	// NatsUrl is the url of the NATS servers to connect to
+	NatsUrl string
+	// NatsJwt is the decoded user JWT to connect with
+	NatsJwt string
+	// NatsSeed is the nkey seed to connect with
 	NatsSeed string
 
 	// LogLevel is the log level for the runtime
 	LogLevel slog.Level
 
-	// Logger is the logger for the runtime and only set after launch
+	// Logger is the logger for the runtime, carrying the namespace, connector and instance of the workload
 	Logger *slog.Logger
+
+	// DrainTimeout is how long Close waits for the connections to drain
+	DrainTimeout time.Duration
+
+	// Metrics is where health and metrics are served, if set
+	Metrics *model.RuntimeMetrics
+
+	mu          sync.Mutex
+	conns       []*connection
+	steps       map[string]*StepMetrics
+	foundations []*WorkloadFoundation
+	server      *http.Server
+	launched    bool
+	draining    bool
+	closed      bool
 }
 
+type connection struct {
+	nc     *nats.Conn
+	closed chan struct{}
+}
+
+// logger returns the configured logger, or a JSON logger on stderr at LogLevel, with the workload attributes.
+func (r *Runtime) logger() *slog.Logger {
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: r.LogLevel}))
+	}
+
+	var attrs []any
+	if r.Namespace != "" {
+		attrs = append(attrs, "namespace", r.Namespace)
+	}
+	if r.Connector != "" {
+		attrs = append(attrs, "connector", r.Connector)
+	}
+	if r.Instance != "" {
+		attrs = append(attrs, "instance", r.Instance)
+	}
+	return logger.With(attrs...)
+}
+
+// NatsOptions returns the options to connect to NATS with the credentials of the runtime. Disconnects and
+// reconnects are logged and the connection keeps reconnecting until it is closed.
+func (r *Runtime) NatsOptions() ([]nats.Option, error) {
+	opts := []nats.Option{
+		nats.MaxReconnects(-1),
+		nats.DrainTimeout(r.DrainTimeout),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			if err != nil {
+				r.Logger.Warn("disconnected from nats", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			r.Logger.Info("reconnected to nats", "url", nc.ConnectedUrlRedacted())
+		}),
+		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			if sub != nil {
+				r.Logger.Error("nats error", "subject", sub.Subject, "error", err)
+				return
+			}
+			r.Logger.Error("nats error", "error", err)
+		}),
+	}
+
+	if name := r.connectionName(); name != "" {
+		opts = append(opts, nats.Name(name))
+	}
+
+	switch {
+	case r.NatsJwt != "":
+		opts = append(opts, nats.UserJWTAndSeed(r.NatsJwt, r.NatsSeed))
+	case r.NatsSeed != "":
+		kp, err := nkeys.FromSeed([]byte(r.NatsSeed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid nats seed: %w", err)
+		}
+		pub, err := kp.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid nats seed: %w", err)
+		}
+		opts = append(opts, nats.Nkey(pub, kp.Sign))
+	}
+
+	return opts, nil
+}
+
+func (r *Runtime) connectionName() string {
+	if r.Connector == "" {
+		return ""
+	}
+	if r.Instance == "" {
+		return r.Connector
+	}
+	return fmt.Sprintf("%s-%s", r.Connector, r.Instance)
+}
+
+// NatsConfig connects to NATS using the url and credentials of the runtime. The connection is drained by Close.
 func (r *Runtime) NatsConfig() (*nats.Conn, error) {
+	opts, err := r.NatsOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	url := r.NatsUrl
+	if url == "" {
+		url = nats.DefaultURL
+	}
+
+	c := &connection{closed: make(chan struct{})}
+	opts = append(opts, nats.ClosedHandler(func(*nats.Conn) { close(c.closed) }))
 
-	return nats.Connect(os.Getenv(NatsUrlVar),
-		nats.UserJWTAndSeed(os.Getenv(NatsJwtVar), os.Getenv(NatsSeedVar)),
-	)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, fmt.Errorf("runtime is closed")
+	}
+
+	nc, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	c.nc = nc
+	r.conns = append(r.conns, c)
+
+	return nc, nil
 }
 
+// Launch decodes the base64 encoded steps and runs the workload. The context given to the workload is cancelled on
+// SIGTERM or SIGINT so it can drain; once the workload returns the runtime is closed. Health and metrics are served
+// while the workload runs if the runtime has metrics configured.
 func (r *Runtime) Launch(ctx context.Context, workload Workload, cfg string) error {
 	cfgb, err := base64.StdEncoding.DecodeString(cfg)
 	if err != nil {
@@ -147,9 +325,56 @@ func (r *Runtime) Launch(ctx context.Context, workload Workload, cfg string) err
 		return fmt.Errorf("failed to decode connector config: %w", err)
 	}
 
-	r.Logger = slog.Default()
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if err := r.serve(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.launched = true
+	r.mu.Unlock()
+
+	// the workload is no longer ready once it was asked to stop
+	context.AfterFunc(ctx, func() {
+		r.mu.Lock()
+		r.draining = true
+		r.mu.Unlock()
+	})
 
-	return workload(ctx, r, steps)
+	err = workload(ctx, r, steps)
+	r.Close()
+	return err
 }
 
-func (r *Runtime) Close() {}
+// Close drains the connections created by NatsConfig, waiting up to DrainTimeout for them to close, and stops
+// serving health and metrics.
+func (r *Runtime) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	conns := r.conns
+	r.conns = nil
+	r.mu.Unlock()
+
+	timeout := time.After(r.DrainTimeout + time.Second)
+	for _, c := range conns {
+		if err := c.nc.Drain(); err != nil {
+			c.nc.Close()
+		}
+	}
+	for _, c := range conns {
+		select {
+		case <-c.closed:
+		case <-timeout:
+			r.Logger.Warn("timed out draining nats connection")
+			c.nc.Close()
+		}
+	}
+
+	r.shutdownServer()
+}
@@ -0,0 +1,105 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/validation"
+)
+
+// LoadMessageSchema compiles the schema of a consumer or producer step. Inline definitions are used as they are,
+// files are read relative to the working directory and objects are read from the object store through the
+// connection.
+func LoadMessageSchema(ctx context.Context, nc *nats.Conn, schema *model.MessageSchema) (validation.MessageSchema, error) {
+	var definition []byte
+	var err error
+	switch {
+	case schema.Inline != nil:
+		definition = []byte(*schema.Inline)
+	case schema.File != nil:
+		if definition, err = os.ReadFile(*schema.File); err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+	case schema.ObjectStore != nil:
+		if definition, err = readSchemaObject(ctx, nc, schema.ObjectStore); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("the schema has no inline, file or object_store definition")
+	}
+
+	message := ""
+	if schema.Message != nil {
+		message = *schema.Message
+	}
+
+	compiled, err := validation.CompileMessageSchema(string(schema.Format), definition, message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s schema: %w", schema.Format, err)
+	}
+	return compiled, nil
+}
+
+func readSchemaObject(ctx context.Context, nc *nats.Conn, object *model.MessageSchemaObjectStore) ([]byte, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := js.ObjectStore(ctx, object.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object store %s: %w", object.Bucket, err)
+	}
+
+	definition, err := store.GetBytes(ctx, object.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s from object store %s: %w", object.Name, object.Bucket, err)
+	}
+	return definition, nil
+}
+
+// schemaEnforcer drops or dead-letters the messages of a step which do not match its schema.
+type schemaEnforcer struct {
+	schema validation.MessageSchema
+	step   string
+	drop   bool
+	errors *ErrorHandler
+}
+
+func newSchemaEnforcer(ctx context.Context, nc *nats.Conn, schema *model.MessageSchema, step string, errors *ErrorHandler) (*schemaEnforcer, error) {
+	if schema == nil {
+		return nil, nil
+	}
+
+	compiled, err := LoadMessageSchema(ctx, nc, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &schemaEnforcer{schema: compiled, step: step, drop: schema.OnFailure == model.MessageSchemaOnFailureDrop, errors: errors}
+	if !e.drop && (errors == nil || errors.deadLetter == nil) {
+		return nil, fmt.Errorf("schema on_failure %s requires an error handler with a dead letter producer", model.MessageSchemaOnFailureDeadLetter)
+	}
+	return e, nil
+}
+
+// check reports whether the message matches the schema. Messages which do not are dropped or dead-lettered, and the
+// error is returned if dead-lettering failed.
+func (e *schemaEnforcer) check(ctx context.Context, msg *Message) (bool, error) {
+	if e == nil {
+		return true, nil
+	}
+
+	cause := e.schema.Validate(msg.Data)
+	if cause == nil {
+		return true, nil
+	}
+	if e.drop {
+		return false, nil
+	}
+	return false, e.errors.Reject(ctx, msg, e.step, fmt.Errorf("the message does not match the schema: %w", cause))
+}
@@ -0,0 +1,124 @@
+package runtime_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schemas", func() {
+	var ns *server.Server
+	var nc *nats.Conn
+	var js jetstream.JetStream
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ns = startServer(&server.Options{JetStream: true, StoreDir: GinkgoT().TempDir()})
+
+		var err error
+		nc, err = nats.Connect(ns.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		js, err = jetstream.New(nc)
+		Expect(err).ToNot(HaveOccurred())
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+	})
+
+	order := `{"type": "object", "required": ["id"]}`
+
+	It("should dead-letter the consumed messages which do not match", func() {
+		dlq, err := nc.SubscribeSync("orders.invalid")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nc.Flush()).To(Succeed())
+
+		deadLetter := &model.ProducerStep{Nats: model.NatsConfig{Url: ns.ClientURL()}, Core: &model.ProducerStepCore{Subject: "orders.invalid"}}
+		h, err := runtime.NewErrorHandler(&model.ErrorPolicy{DeadLetter: deadLetter}, "sink")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(h.Close)
+
+		step := &model.ConsumerStep{
+			Nats:   model.NatsConfig{Url: ns.ClientURL()},
+			Core:   &model.ConsumerStepCore{Subject: "orders"},
+			Schema: &model.MessageSchema{Format: model.MessageSchemaFormatJsonSchema, Inline: &order},
+		}
+		msgs, err := runtime.OpenConsumer(ctx, step, runtime.WithErrorHandler(h))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(nc.Publish("orders", []byte(`{"total": 3}`))).To(Succeed())
+		Expect(nc.Publish("orders", []byte(`{"id": 1}`))).To(Succeed())
+
+		Expect(string(receive(msgs).Data)).To(Equal(`{"id": 1}`))
+
+		dead, err := dlq.NextMsg(time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(dead.Data)).To(Equal(`{"total": 3}`))
+		Expect(dead.Header.Get(runtime.ErrorStepHeader)).To(Equal("consumer"))
+		Expect(dead.Header.Get(runtime.ErrorAttemptsHeader)).To(Equal("0"))
+		Expect(dead.Header.Get(runtime.ErrorHeader)).To(ContainSubstring(`missing property 'id'`))
+	})
+
+	It("should require a dead letter producer to dead-letter messages", func() {
+		step := &model.ConsumerStep{
+			Nats:   model.NatsConfig{Url: ns.ClientURL()},
+			Core:   &model.ConsumerStepCore{Subject: "orders"},
+			Schema: &model.MessageSchema{Format: model.MessageSchemaFormatJsonSchema, Inline: &order},
+		}
+		_, err := runtime.OpenConsumer(ctx, step)
+		Expect(err).To(MatchError(ContainSubstring("requires an error handler with a dead letter producer")))
+	})
+
+	It("should drop the produced messages which do not match a schema of the object store", func() {
+		store, err := js.CreateObjectStore(ctx, jetstream.ObjectStoreConfig{Bucket: "schemas"})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = store.PutBytes(ctx, "order.json", []byte(order))
+		Expect(err).ToNot(HaveOccurred())
+
+		sub, err := nc.SubscribeSync("out")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nc.Flush()).To(Succeed())
+
+		p, err := runtime.OpenProducer(&model.ProducerStep{
+			Nats: model.NatsConfig{Url: ns.ClientURL()},
+			Core: &model.ProducerStepCore{Subject: "out"},
+			Schema: &model.MessageSchema{
+				Format:      model.MessageSchemaFormatJsonSchema,
+				ObjectStore: &model.MessageSchemaObjectStore{Bucket: "schemas", Name: "order.json"},
+				OnFailure:   model.MessageSchemaOnFailureDrop,
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer p.Close()
+
+		msgs := make(chan *runtime.Message, 2)
+		msgs <- runtime.NewMessage("in", []byte(`not json`))
+		msgs <- runtime.NewMessage("in", []byte(`{"id": 2}`))
+		close(msgs)
+		Expect(p.Run(ctx, msgs)).To(Succeed())
+
+		got, err := sub.NextMsg(time.Second)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(got.Data)).To(Equal(`{"id": 2}`))
+		_, err = sub.NextMsg(100 * time.Millisecond)
+		Expect(err).To(MatchError(nats.ErrTimeout))
+	})
+
+	It("should fail for schemas missing from the object store", func() {
+		_, err := runtime.LoadMessageSchema(ctx, nc, &model.MessageSchema{
+			Format:      model.MessageSchemaFormatAvro,
+			ObjectStore: &model.MessageSchemaObjectStore{Bucket: "schemas", Name: "order.avsc"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("failed to open object store schemas")))
+	})
+})
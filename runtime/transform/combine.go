@@ -0,0 +1,134 @@
+package transform
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// defaultCombinePath names the archive entries when the step has no path. The schema defaults the path to ",".
+const defaultCombinePath = "${!index}"
+
+type combine struct {
+	format model.CombineTransformerStepFormat
+	path   string
+}
+
+func newCombine(step model.CombineTransformerStep) (Transformer, error) {
+	c := &combine{format: step.Format, path: step.Path}
+	if c.format == "" {
+		c.format = model.CombineTransformerStepFormatJsonArray
+	}
+	if c.path == "" || c.path == "," {
+		c.path = defaultCombinePath
+	}
+
+	switch c.format {
+	case model.CombineTransformerStepFormatJsonArray,
+		model.CombineTransformerStepFormatLines,
+		model.CombineTransformerStepFormatTar,
+		model.CombineTransformerStepFormatZip:
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unsupported combine format %q", c.format)
+	}
+}
+
+// Transform combines the batch into a single message with the subject and headers of the first message.
+func (c *combine) Transform(_ context.Context, batch []*runtime.Message) ([]*runtime.Message, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	data, err := c.combine(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine %d messages into %s: %w", len(batch), c.format, err)
+	}
+	return []*runtime.Message{derive(batch[0], data)}, nil
+}
+
+func (c *combine) combine(batch []*runtime.Message) ([]byte, error) {
+	switch c.format {
+	case model.CombineTransformerStepFormatJsonArray:
+		items := make([]json.RawMessage, 0, len(batch))
+		for _, msg := range batch {
+			if json.Valid(msg.Data) {
+				items = append(items, msg.Data)
+				continue
+			}
+			// payloads which are not JSON are added as strings
+			b, err := json.Marshal(string(msg.Data))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, b)
+		}
+		return json.Marshal(items)
+	case model.CombineTransformerStepFormatLines:
+		lines := make([][]byte, 0, len(batch))
+		for _, msg := range batch {
+			lines = append(lines, msg.Data)
+		}
+		return bytes.Join(lines, []byte("\n")), nil
+	case model.CombineTransformerStepFormatTar:
+		var buf bytes.Buffer
+		w := tar.NewWriter(&buf)
+		for i, msg := range batch {
+			hdr := &tar.Header{Name: c.entryName(i, msg), Mode: 0o644, Size: int64(len(msg.Data))}
+			if err := w.WriteHeader(hdr); err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(msg.Data); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for i, msg := range batch {
+			f, err := w.Create(c.entryName(i, msg))
+			if err != nil {
+				return nil, err
+			}
+			if _, err := f.Write(msg.Data); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// entryName expands the path of an archive entry. ${!index} is the position of the message in the batch,
+// ${!subject} its subject and ${!key} its key header. Paths without ${!index} get the index appended to stay unique.
+func (c *combine) entryName(i int, msg *runtime.Message) string {
+	index := strconv.Itoa(i)
+	path := c.path
+	if !strings.Contains(path, "${!index}") {
+		path += "_${!index}"
+	}
+
+	return strings.NewReplacer(
+		"${!index}", index,
+		"${!subject}", msg.Subject,
+		"${!key}", msg.Headers.Get(KeyHeader),
+	).Replace(path)
+}
+
+func (c *combine) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package transform_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+	"github.com/synadia-io/connect/runtime/transform"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func combine(step model.CombineTransformerStep, data ...string) []*runtime.Message {
+	t, err := transform.New(model.TransformerStep{Combine: &step})
+	Expect(err).ToNot(HaveOccurred())
+
+	batch := make([]*runtime.Message, len(data))
+	for i, d := range data {
+		batch[i] = runtime.NewMessage("in", []byte(d))
+	}
+
+	msgs, err := t.Transform(context.Background(), batch)
+	Expect(err).ToNot(HaveOccurred())
+	return msgs
+}
+
+var _ = Describe("Combine", func() {
+	It("should combine into a json array", func() {
+		msgs := combine(model.CombineTransformerStep{Format: "json_array"}, `{"a":1}`, `2`, `not json`)
+		Expect(payloads(msgs)).To(Equal([]string{`[{"a":1},2,"not json"]`}))
+		Expect(msgs[0].Subject).To(Equal("in"))
+	})
+
+	It("should combine into lines", func() {
+		msgs := combine(model.CombineTransformerStep{Format: "lines"}, "one", "two")
+		Expect(payloads(msgs)).To(Equal([]string{"one\ntwo"}))
+	})
+
+	It("should name the entries of an archive with the path", func() {
+		msgs := combine(model.CombineTransformerStep{Format: "tar", Path: "${!subject}/${!index}.json"}, "first", "second")
+		Expect(msgs).To(HaveLen(1))
+
+		r := tar.NewReader(bytes.NewReader(msgs[0].Data))
+		var names, contents []string
+		for {
+			hdr, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			b, err := io.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+			names = append(names, hdr.Name)
+			contents = append(contents, string(b))
+		}
+		Expect(names).To(Equal([]string{"in/0.json", "in/1.json"}))
+		Expect(contents).To(Equal([]string{"first", "second"}))
+	})
+
+	It("should round trip with explode", func() {
+		msgs := combine(model.CombineTransformerStep{Format: "zip"}, "first", "second")
+
+		exploded, err := explode(model.ExplodeTransformerStep{Format: "zip"}, msgs[0].Data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payloads(exploded)).To(Equal([]string{"first", "second"}))
+		Expect(exploded[0].Headers.Get(transform.KeyHeader)).To(Equal("0"))
+	})
+
+	It("should drop empty batches", func() {
+		Expect(combine(model.CombineTransformerStep{Format: "lines"})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,214 @@
+package transform
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// KeyHeader holds the key of a json_map entry or the file name of an archive entry.
+const KeyHeader = "Connect-Key"
+
+type explode struct {
+	format    model.ExplodeTransformerStepFormat
+	delimiter rune
+}
+
+func newExplode(step model.ExplodeTransformerStep) (Transformer, error) {
+	e := &explode{format: step.Format, delimiter: ','}
+	if e.format == "" {
+		e.format = model.ExplodeTransformerStepFormatJsonArray
+	}
+
+	if step.Delimiter != "" {
+		runes := []rune(step.Delimiter)
+		if len(runes) != 1 {
+			return nil, fmt.Errorf("the csv delimiter must be a single character, got %q", step.Delimiter)
+		}
+		e.delimiter = runes[0]
+	}
+
+	switch e.format {
+	case model.ExplodeTransformerStepFormatCsv,
+		model.ExplodeTransformerStepFormatJsonArray,
+		model.ExplodeTransformerStepFormatJsonMap,
+		model.ExplodeTransformerStepFormatJsonDocuments,
+		model.ExplodeTransformerStepFormatLines,
+		model.ExplodeTransformerStepFormatTar,
+		model.ExplodeTransformerStepFormatZip:
+		return e, nil
+	default:
+		return nil, fmt.Errorf("unsupported explode format %q", e.format)
+	}
+}
+
+func (e *explode) Transform(ctx context.Context, batch []*runtime.Message) ([]*runtime.Message, error) {
+	return perMessage(ctx, batch, func(_ context.Context, msg *runtime.Message) ([]*runtime.Message, error) {
+		parts, err := e.explode(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to explode %s payload: %w", e.format, err)
+		}
+		return parts, nil
+	})
+}
+
+func (e *explode) explode(msg *runtime.Message) ([]*runtime.Message, error) {
+	switch e.format {
+	case model.ExplodeTransformerStepFormatCsv:
+		return e.explodeCsv(msg)
+	case model.ExplodeTransformerStepFormatJsonArray:
+		var items []json.RawMessage
+		if err := json.Unmarshal(msg.Data, &items); err != nil {
+			return nil, err
+		}
+		result := make([]*runtime.Message, 0, len(items))
+		for _, item := range items {
+			result = append(result, derive(msg, item))
+		}
+		return result, nil
+	case model.ExplodeTransformerStepFormatJsonMap:
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(msg.Data, &entries); err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		result := make([]*runtime.Message, 0, len(keys))
+		for _, k := range keys {
+			part := derive(msg, entries[k])
+			part.Headers.Set(KeyHeader, k)
+			result = append(result, part)
+		}
+		return result, nil
+	case model.ExplodeTransformerStepFormatJsonDocuments:
+		dec := json.NewDecoder(bytes.NewReader(msg.Data))
+		var result []*runtime.Message
+		for {
+			var doc json.RawMessage
+			if err := dec.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					return result, nil
+				}
+				return nil, err
+			}
+			result = append(result, derive(msg, doc))
+		}
+	case model.ExplodeTransformerStepFormatLines:
+		scanner := bufio.NewScanner(bytes.NewReader(msg.Data))
+		scanner.Buffer(make([]byte, 64*1024), len(msg.Data)+1)
+		var result []*runtime.Message
+		for scanner.Scan() {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			result = append(result, derive(msg, bytes.Clone(scanner.Bytes())))
+		}
+		return result, scanner.Err()
+	case model.ExplodeTransformerStepFormatTar:
+		return explodeTar(msg)
+	default:
+		return explodeZip(msg)
+	}
+}
+
+// explodeCsv turns every row after the header row into a JSON object keyed by the header.
+func (e *explode) explodeCsv(msg *runtime.Message) ([]*runtime.Message, error) {
+	r := csv.NewReader(bytes.NewReader(msg.Data))
+	r.Comma = e.delimiter
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	result := make([]*runtime.Message, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		obj := make(map[string]string, len(header))
+		for i, col := range header {
+			obj[col] = row[i]
+		}
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, derive(msg, b))
+	}
+	return result, nil
+}
+
+func explodeTar(msg *runtime.Message) ([]*runtime.Message, error) {
+	r := tar.NewReader(bytes.NewReader(msg.Data))
+	var result []*runtime.Message
+	for {
+		hdr, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		part := derive(msg, b)
+		part.Headers.Set(KeyHeader, hdr.Name)
+		result = append(result, part)
+	}
+}
+
+func explodeZip(msg *runtime.Message) ([]*runtime.Message, error) {
+	r, err := zip.NewReader(bytes.NewReader(msg.Data), int64(len(msg.Data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*runtime.Message
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		part := derive(msg, b)
+		part.Headers.Set(KeyHeader, f.Name)
+		result = append(result, part)
+	}
+	return result, nil
+}
+
+func (e *explode) Close() error {
+	return nil
+}
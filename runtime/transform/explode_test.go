@@ -0,0 +1,85 @@
+package transform_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+	"github.com/synadia-io/connect/runtime/transform"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func payloads(msgs []*runtime.Message) []string {
+	result := make([]string, len(msgs))
+	for i, msg := range msgs {
+		result[i] = string(msg.Data)
+	}
+	return result
+}
+
+func explode(step model.ExplodeTransformerStep, data []byte) ([]*runtime.Message, error) {
+	t, err := transform.New(model.TransformerStep{Explode: &step})
+	Expect(err).ToNot(HaveOccurred())
+
+	msg := runtime.NewMessage("in", data)
+	msg.Headers.Set("Trace", "1")
+	return transform.Apply(context.Background(), t, msg)
+}
+
+var _ = Describe("Explode", func() {
+	DescribeTable("formats",
+		func(step model.ExplodeTransformerStep, data string, expected []string) {
+			msgs, err := explode(step, []byte(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(msgs)).To(Equal(expected))
+			for _, msg := range msgs {
+				Expect(msg.Subject).To(Equal("in"))
+				Expect(msg.Headers.Get("Trace")).To(Equal("1"))
+			}
+		},
+		Entry("json array", model.ExplodeTransformerStep{Format: "json_array"}, `[1, {"a": "b"}, "c"]`, []string{`1`, `{"a": "b"}`, `"c"`}),
+		Entry("json documents", model.ExplodeTransformerStep{Format: "json_documents"}, "{\"a\":1}\n{\"a\":2} {\"a\":3}", []string{`{"a":1}`, `{"a":2}`, `{"a":3}`}),
+		Entry("lines", model.ExplodeTransformerStep{Format: "lines"}, "one\ntwo\n\nthree\n", []string{"one", "two", "three"}),
+		Entry("csv", model.ExplodeTransformerStep{Format: "csv", Delimiter: ","}, "name,age\nalice,30\nbob,40\n", []string{`{"age":"30","name":"alice"}`, `{"age":"40","name":"bob"}`}),
+		Entry("csv with delimiter", model.ExplodeTransformerStep{Format: "csv", Delimiter: ";"}, "name;age\nalice;30\n", []string{`{"age":"30","name":"alice"}`}),
+	)
+
+	It("should set the key of json map entries", func() {
+		msgs, err := explode(model.ExplodeTransformerStep{Format: "json_map"}, []byte(`{"b": 2, "a": 1}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payloads(msgs)).To(Equal([]string{"1", "2"}))
+		Expect(msgs[0].Headers.Get(transform.KeyHeader)).To(Equal("a"))
+		Expect(msgs[1].Headers.Get(transform.KeyHeader)).To(Equal("b"))
+	})
+
+	It("should explode the files of a zip archive", func() {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for _, name := range []string{"a.txt", "b.txt"} {
+			f, err := w.Create(name)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.Write([]byte("content of " + name))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(w.Close()).To(Succeed())
+
+		msgs, err := explode(model.ExplodeTransformerStep{Format: "zip"}, buf.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(payloads(msgs)).To(Equal([]string{"content of a.txt", "content of b.txt"}))
+		Expect(msgs[1].Headers.Get(transform.KeyHeader)).To(Equal("b.txt"))
+	})
+
+	It("should fail on payloads of another format", func() {
+		_, err := explode(model.ExplodeTransformerStep{Format: "json_array"}, []byte(`{"a": 1}`))
+		Expect(err).To(MatchError(ContainSubstring("failed to explode json_array payload")))
+	})
+
+	It("should reject delimiters of more than one character", func() {
+		_, err := transform.New(model.TransformerStep{Explode: &model.ExplodeTransformerStep{Format: "csv", Delimiter: "||"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
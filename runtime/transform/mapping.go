@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// MappingFunc applies a compiled mapping to a message. Returning nil drops the message.
+type MappingFunc func(ctx context.Context, msg *runtime.Message) (*runtime.Message, error)
+
+// Evaluator compiles the source code of a mapping transformer. The platform runtimes evaluate bloblang; other
+// languages can be plugged in with WithEvaluator.
+type Evaluator interface {
+	Compile(sourcecode string) (MappingFunc, error)
+}
+
+// EvaluatorFunc adapts a function to an Evaluator.
+type EvaluatorFunc func(sourcecode string) (MappingFunc, error)
+
+func (f EvaluatorFunc) Compile(sourcecode string) (MappingFunc, error) {
+	return f(sourcecode)
+}
+
+type mapping struct {
+	fn MappingFunc
+}
+
+func newMapping(step model.MappingTransformerStep, o *options) (Transformer, error) {
+	if o.evaluator == nil {
+		return nil, fmt.Errorf("mapping transformers need an evaluator")
+	}
+
+	fn, err := o.evaluator.Compile(step.Sourcecode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile mapping: %w", err)
+	}
+	return &mapping{fn: fn}, nil
+}
+
+func (m *mapping) Transform(ctx context.Context, batch []*runtime.Message) ([]*runtime.Message, error) {
+	return perMessage(ctx, batch, func(ctx context.Context, msg *runtime.Message) ([]*runtime.Message, error) {
+		out, err := m.fn(ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("mapping failed: %w", err)
+		}
+		if out == nil {
+			return nil, nil
+		}
+		return []*runtime.Message{out}, nil
+	})
+}
+
+func (m *mapping) Close() error {
+	return nil
+}
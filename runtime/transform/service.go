@@ -0,0 +1,205 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+	"golang.org/x/time/rate"
+)
+
+// Headers set by NATS services to report an error instead of a response.
+const (
+	ServiceErrorHeader     = "Nats-Service-Error"
+	ServiceErrorCodeHeader = "Nats-Service-Error-Code"
+)
+
+// defaultServiceTimeout is the timeout of service calls without one.
+const defaultServiceTimeout = 5 * time.Second
+
+// defaultRateLimitPeriod is the period of rate limits without one.
+const defaultRateLimitPeriod = time.Second
+
+type service struct {
+	endpoint string
+	timeout  time.Duration
+	limiter  *rate.Limiter
+	batching *model.Batching
+	nc       *nats.Conn
+	owned    bool
+}
+
+func newService(step model.ServiceTransformerStep, o *options) (Transformer, error) {
+	timeout := defaultServiceTimeout
+	if step.Timeout != "" {
+		d, err := time.ParseDuration(step.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service timeout %q: %w", step.Timeout, err)
+		}
+		timeout = d
+	}
+
+	s := &service{endpoint: step.Endpoint, timeout: timeout, batching: step.Batching, nc: o.conn}
+	if step.RateLimit != nil {
+		limiter, err := newLimiter(*step.RateLimit)
+		if err != nil {
+			return nil, err
+		}
+		s.limiter = limiter
+	}
+
+	if s.nc == nil {
+		nc, err := runtime.ConnectStep(step.Nats, o.natsOptions...)
+		if err != nil {
+			return nil, err
+		}
+		s.nc, s.owned = nc, true
+	}
+	return s, nil
+}
+
+// newLimiter creates a token bucket refilled with count tokens per period, holding up to burst tokens.
+func newLimiter(limit model.RateLimit) (*rate.Limiter, error) {
+	if limit.Count < 1 {
+		return nil, fmt.Errorf("rate_limit count must be positive")
+	}
+
+	period := defaultRateLimitPeriod
+	if limit.Period != "" {
+		d, err := time.ParseDuration(limit.Period)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid rate_limit period %q", limit.Period)
+		}
+		period = d
+	}
+
+	burst := limit.Count
+	if limit.Burst != nil {
+		burst = *limit.Burst
+	}
+	if burst < 1 {
+		return nil, fmt.Errorf("rate_limit burst must be positive")
+	}
+
+	return rate.NewLimiter(rate.Limit(float64(limit.Count)/period.Seconds()), burst), nil
+}
+
+// Transform calls the service for each message, or with batching for each batch the messages are split into.
+func (s *service) Transform(ctx context.Context, batch []*runtime.Message) ([]*runtime.Message, error) {
+	if s.batching == nil {
+		return perMessage(ctx, batch, s.call)
+	}
+
+	var result []*runtime.Message
+	for _, part := range s.split(batch) {
+		out, err := s.callBatch(ctx, part)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, out...)
+	}
+	return result, nil
+}
+
+// split divides the messages into batches of at most the count and byte size of the batching. The period does not
+// apply, the messages are already at hand.
+func (s *service) split(batch []*runtime.Message) [][]*runtime.Message {
+	var parts [][]*runtime.Message
+	var part []*runtime.Message
+	size := 0
+	for _, msg := range batch {
+		part = append(part, msg)
+		size += len(msg.Data)
+		if (s.batching.Count != nil && len(part) >= *s.batching.Count) || (s.batching.ByteSize != nil && size >= *s.batching.ByteSize) {
+			parts = append(parts, part)
+			part, size = nil, 0
+		}
+	}
+	if len(part) > 0 {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// call sends the message to the endpoint and replaces its payload and headers with those of the response.
+func (s *service) call(ctx context.Context, msg *runtime.Message) ([]*runtime.Message, error) {
+	resp, err := s.request(ctx, 1, &nats.Msg{Subject: s.endpoint, Header: msg.Headers, Data: msg.Data})
+	if err != nil {
+		return nil, err
+	}
+
+	out := runtime.NewMessage(msg.Subject, resp.Data)
+	for k, v := range resp.Header {
+		out.Headers[k] = v
+	}
+	return []*runtime.Message{out}, nil
+}
+
+// callBatch sends the payloads of the batch to the endpoint as a JSON array, with the headers of the first message.
+// The service answers with a JSON array holding the payloads of the resulting messages, which get the subject of the
+// first message and the headers of the response.
+func (s *service) callBatch(ctx context.Context, batch []*runtime.Message) ([]*runtime.Message, error) {
+	data, err := (&combine{format: model.CombineTransformerStepFormatJsonArray}).combine(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine %d messages for service %s: %w", len(batch), s.endpoint, err)
+	}
+
+	resp, err := s.request(ctx, len(batch), &nats.Msg{Subject: s.endpoint, Header: batch[0].Headers, Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(resp.Data, &items); err != nil {
+		return nil, fmt.Errorf("service %s did not answer the batch with a JSON array: %w", s.endpoint, err)
+	}
+
+	result := make([]*runtime.Message, 0, len(items))
+	for _, item := range items {
+		out := runtime.NewMessage(batch[0].Subject, item)
+		for k, v := range resp.Header {
+			out.Headers[k] = v
+		}
+		result = append(result, out)
+	}
+	return result, nil
+}
+
+// request sends the request carrying n messages to the endpoint. With a rate limit, it waits for the turn of the
+// messages before the timeout of the call starts.
+func (s *service) request(ctx context.Context, n int, req *nats.Msg) (*nats.Msg, error) {
+	if s.limiter != nil {
+		// a batch without a count may hold more messages than the limiter allows at once
+		for n > 0 {
+			take := min(n, s.limiter.Burst())
+			if err := s.limiter.WaitN(ctx, take); err != nil {
+				return nil, fmt.Errorf("service %s rate limit: %w", s.endpoint, err)
+			}
+			n -= take
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	resp, err := s.nc.RequestMsgWithContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("service %s failed: %w", s.endpoint, err)
+	}
+
+	if desc := resp.Header.Get(ServiceErrorHeader); desc != "" {
+		return nil, fmt.Errorf("service %s failed: %s (%s)", s.endpoint, desc, resp.Header.Get(ServiceErrorCodeHeader))
+	}
+	return resp, nil
+}
+
+func (s *service) Close() error {
+	if s.owned {
+		s.nc.Close()
+	}
+	return nil
+}
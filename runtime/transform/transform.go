@@ -0,0 +1,145 @@
+// Package transform executes the transformer steps of a connector on messages, with the semantics of the platform
+// runtimes. It lets custom runtimes and local tools transform messages without the runtime images.
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+)
+
+// Transformer transforms batches of messages. Most transformers handle each message on its own; combine turns the
+// batch into a single message.
+type Transformer interface {
+	// Transform returns the messages resulting from the batch. An empty result drops the batch.
+	Transform(ctx context.Context, batch []*runtime.Message) ([]*runtime.Message, error)
+	// Close releases the connections of the transformer.
+	Close() error
+}
+
+// Option configures the transformers created by New.
+type Option func(*options)
+
+type options struct {
+	evaluator   Evaluator
+	conn        *nats.Conn
+	natsOptions []nats.Option
+}
+
+// WithEvaluator sets the evaluator compiling the source code of mapping transformers.
+func WithEvaluator(e Evaluator) Option {
+	return func(o *options) {
+		o.evaluator = e
+	}
+}
+
+// WithConn makes service transformers send their requests over the connection instead of connecting to the NATS
+// server of their step.
+func WithConn(nc *nats.Conn) Option {
+	return func(o *options) {
+		o.conn = nc
+	}
+}
+
+// WithNatsOptions adds options to the connections of service transformers.
+func WithNatsOptions(opts ...nats.Option) Option {
+	return func(o *options) {
+		o.natsOptions = append(o.natsOptions, opts...)
+	}
+}
+
+// New creates the transformer described by the step.
+func New(step model.TransformerStep, opts ...Option) (Transformer, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newTransformer(step, o)
+}
+
+func newTransformer(step model.TransformerStep, o *options) (Transformer, error) {
+	switch {
+	case step.Mapping != nil:
+		return newMapping(*step.Mapping, o)
+	case step.Explode != nil:
+		return newExplode(*step.Explode)
+	case step.Combine != nil:
+		return newCombine(*step.Combine)
+	case step.Service != nil:
+		return newService(*step.Service, o)
+	case step.Composite != nil:
+		return newComposite(*step.Composite, o)
+	default:
+		return nil, fmt.Errorf("the transformer step has no mapping, explode, combine, service or composite configuration")
+	}
+}
+
+// Apply transforms a single message.
+func Apply(ctx context.Context, t Transformer, msg *runtime.Message) ([]*runtime.Message, error) {
+	return t.Transform(ctx, []*runtime.Message{msg})
+}
+
+// composite runs its transformers in sequence, each on the output of the previous one.
+type composite struct {
+	steps []Transformer
+}
+
+func newComposite(step model.CompositeTransformerStep, o *options) (Transformer, error) {
+	c := &composite{}
+	for i, s := range step.Sequential {
+		t, err := newTransformer(s, o)
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("composite step %d: %w", i, err)
+		}
+		c.steps = append(c.steps, t)
+	}
+	return c, nil
+}
+
+func (c *composite) Transform(ctx context.Context, batch []*runtime.Message) ([]*runtime.Message, error) {
+	var err error
+	for i, t := range c.steps {
+		if len(batch) == 0 {
+			return nil, nil
+		}
+		if batch, err = t.Transform(ctx, batch); err != nil {
+			return nil, fmt.Errorf("composite step %d: %w", i, err)
+		}
+	}
+	return batch, nil
+}
+
+func (c *composite) Close() error {
+	var errs []error
+	for _, t := range c.steps {
+		errs = append(errs, t.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// perMessage applies a function to each message of the batch.
+func perMessage(ctx context.Context, batch []*runtime.Message, fn func(context.Context, *runtime.Message) ([]*runtime.Message, error)) ([]*runtime.Message, error) {
+	var result []*runtime.Message
+	for _, msg := range batch {
+		out, err := fn(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, out...)
+	}
+	return result, nil
+}
+
+// derive creates a message with the subject and a copy of the headers of the original.
+func derive(original *runtime.Message, data []byte) *runtime.Message {
+	msg := runtime.NewMessage(original.Subject, data)
+	for k, v := range original.Headers {
+		msg.Headers[k] = append([]string(nil), v...)
+	}
+	return msg
+}
@@ -0,0 +1,246 @@
+package transform_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
+	"github.com/synadia-io/connect/runtime/transform"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// upper is an evaluator understanding the mappings "upper" and "drop".
+var upper = transform.EvaluatorFunc(func(sourcecode string) (transform.MappingFunc, error) {
+	switch sourcecode {
+	case "upper":
+		return func(_ context.Context, msg *runtime.Message) (*runtime.Message, error) {
+			return runtime.NewMessage(msg.Subject, []byte(strings.ToUpper(string(msg.Data)))), nil
+		}, nil
+	case "drop":
+		return func(context.Context, *runtime.Message) (*runtime.Message, error) { return nil, nil }, nil
+	default:
+		return nil, context.Canceled
+	}
+})
+
+var _ = Describe("Transform", func() {
+	Describe("mapping", func() {
+		It("should apply the mapping with the evaluator", func() {
+			t, err := transform.New(model.TransformerStep{Mapping: &model.MappingTransformerStep{Sourcecode: "upper"}}, transform.WithEvaluator(upper))
+			Expect(err).ToNot(HaveOccurred())
+
+			msgs, err := transform.Apply(context.Background(), t, runtime.NewMessage("in", []byte("hello")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(msgs)).To(Equal([]string{"HELLO"}))
+		})
+
+		It("should drop messages the mapping returns nothing for", func() {
+			t, err := transform.New(model.TransformerStep{Mapping: &model.MappingTransformerStep{Sourcecode: "drop"}}, transform.WithEvaluator(upper))
+			Expect(err).ToNot(HaveOccurred())
+
+			msgs, err := transform.Apply(context.Background(), t, runtime.NewMessage("in", []byte("hello")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(msgs).To(BeEmpty())
+		})
+
+		It("should require an evaluator", func() {
+			_, err := transform.New(model.TransformerStep{Mapping: &model.MappingTransformerStep{Sourcecode: "upper"}})
+			Expect(err).To(MatchError(ContainSubstring("evaluator")))
+		})
+
+		It("should fail on mappings the evaluator cannot compile", func() {
+			_, err := transform.New(model.TransformerStep{Mapping: &model.MappingTransformerStep{Sourcecode: "???"}}, transform.WithEvaluator(upper))
+			Expect(err).To(MatchError(ContainSubstring("failed to compile mapping")))
+		})
+	})
+
+	Describe("composite", func() {
+		It("should run the transformers in sequence", func() {
+			t, err := transform.New(model.TransformerStep{Composite: &model.CompositeTransformerStep{Sequential: []model.TransformerStep{
+				{Explode: &model.ExplodeTransformerStep{Format: "lines"}},
+				{Mapping: &model.MappingTransformerStep{Sourcecode: "upper"}},
+				{Combine: &model.CombineTransformerStep{Format: "json_array"}},
+			}}}, transform.WithEvaluator(upper))
+			Expect(err).ToNot(HaveOccurred())
+			defer t.Close()
+
+			msgs, err := transform.Apply(context.Background(), t, runtime.NewMessage("in", []byte("a\nb")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(msgs)).To(Equal([]string{`["A","B"]`}))
+		})
+	})
+
+	Describe("service", func() {
+		var nc *nats.Conn
+		var url string
+
+		BeforeEach(func() {
+			ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+			Expect(err).ToNot(HaveOccurred())
+			ns.Start()
+			Expect(ns.ReadyForConnections(5 * time.Second)).To(BeTrue())
+			DeferCleanup(ns.Shutdown)
+			url = ns.ClientURL()
+
+			nc, err = nats.Connect(url)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(nc.Close)
+
+			_, err = nc.Subscribe("svc.upper", func(msg *nats.Msg) {
+				resp := nats.NewMsg(msg.Reply)
+				resp.Data = []byte(strings.ToUpper(string(msg.Data)))
+				resp.Header.Set("Served-By", "upper")
+				_ = msg.RespondMsg(resp)
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = nc.Subscribe("svc.fail", func(msg *nats.Msg) {
+				resp := nats.NewMsg(msg.Reply)
+				resp.Header.Set(transform.ServiceErrorHeader, "bad input")
+				resp.Header.Set(transform.ServiceErrorCodeHeader, "400")
+				_ = msg.RespondMsg(resp)
+			})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = nc.Subscribe("svc.slow", func(*nats.Msg) {})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.Flush()).To(Succeed())
+		})
+
+		service := func(endpoint string, timeout string) transform.Transformer {
+			t, err := transform.New(model.TransformerStep{Service: &model.ServiceTransformerStep{
+				Endpoint: endpoint,
+				Nats:     model.NatsConfig{Url: url},
+				Timeout:  timeout,
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(t.Close)
+			return t
+		}
+
+		It("should replace the message with the response", func() {
+			msgs, err := transform.Apply(context.Background(), service("svc.upper", "1s"), runtime.NewMessage("in", []byte("hello")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(msgs)).To(Equal([]string{"HELLO"}))
+			Expect(msgs[0].Subject).To(Equal("in"))
+			Expect(msgs[0].Headers.Get("Served-By")).To(Equal("upper"))
+		})
+
+		It("should report service errors", func() {
+			_, err := transform.Apply(context.Background(), service("svc.fail", "1s"), runtime.NewMessage("in", []byte("hello")))
+			Expect(err).To(MatchError(ContainSubstring("bad input (400)")))
+		})
+
+		It("should time out", func() {
+			start := time.Now()
+			_, err := transform.Apply(context.Background(), service("svc.slow", "100ms"), runtime.NewMessage("in", []byte("hello")))
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+
+		It("should limit the rate of the calls", func() {
+			burst := 2
+			t, err := transform.New(model.TransformerStep{Service: &model.ServiceTransformerStep{
+				Endpoint:  "svc.upper",
+				Nats:      model.NatsConfig{Url: url},
+				RateLimit: &model.RateLimit{Count: 20, Period: "1s", Burst: &burst},
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(t.Close)
+
+			batch := []*runtime.Message{
+				runtime.NewMessage("in", []byte("a")),
+				runtime.NewMessage("in", []byte("b")),
+				runtime.NewMessage("in", []byte("c")),
+				runtime.NewMessage("in", []byte("d")),
+			}
+
+			// the burst is spent on the first two calls, the others wait 50ms each
+			start := time.Now()
+			msgs, err := t.Transform(context.Background(), batch)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(msgs)).To(Equal([]string{"A", "B", "C", "D"}))
+			Expect(time.Since(start)).To(BeNumerically(">=", 80*time.Millisecond))
+		})
+
+		It("should send the messages in batches", func() {
+			requests := make(chan string, 4)
+			_, err := nc.Subscribe("svc.batch", func(msg *nats.Msg) {
+				requests <- string(msg.Data)
+				resp := nats.NewMsg(msg.Reply)
+				resp.Data = []byte(strings.ToUpper(string(msg.Data)))
+				resp.Header.Set("Served-By", "batch")
+				_ = msg.RespondMsg(resp)
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.Flush()).To(Succeed())
+
+			count := 2
+			t, err := transform.New(model.TransformerStep{Service: &model.ServiceTransformerStep{
+				Endpoint: "svc.batch",
+				Nats:     model.NatsConfig{Url: url},
+				Batching: &model.Batching{Count: &count},
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(t.Close)
+
+			msgs, err := t.Transform(context.Background(), []*runtime.Message{
+				runtime.NewMessage("in", []byte(`{"id":"a"}`)),
+				runtime.NewMessage("in", []byte("b")),
+				runtime.NewMessage("in", []byte(`{"id":"c"}`)),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(payloads(msgs)).To(Equal([]string{`{"ID":"A"}`, `"B"`, `{"ID":"C"}`}))
+			Expect(msgs[2].Subject).To(Equal("in"))
+			Expect(msgs[2].Headers.Get("Served-By")).To(Equal("batch"))
+
+			Expect(requests).To(Receive(Equal(`[{"id":"a"},"b"]`)))
+			Expect(requests).To(Receive(Equal(`[{"id":"c"}]`)))
+			Expect(requests).ToNot(Receive())
+		})
+
+		It("should require a JSON array in answer to a batch", func() {
+			_, err := nc.Subscribe("svc.single", func(msg *nats.Msg) {
+				_ = msg.Respond([]byte(`{"id":"a"}`))
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nc.Flush()).To(Succeed())
+
+			count := 2
+			t, err := transform.New(model.TransformerStep{Service: &model.ServiceTransformerStep{
+				Endpoint: "svc.single",
+				Nats:     model.NatsConfig{Url: url},
+				Batching: &model.Batching{Count: &count},
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(t.Close)
+
+			_, err = transform.Apply(context.Background(), t, runtime.NewMessage("in", []byte(`{"id":"a"}`)))
+			Expect(err).To(MatchError(ContainSubstring("did not answer the batch with a JSON array")))
+		})
+
+		It("should reject invalid rate limits", func() {
+			_, err := transform.New(model.TransformerStep{Service: &model.ServiceTransformerStep{
+				Endpoint:  "svc",
+				Nats:      model.NatsConfig{Url: url},
+				RateLimit: &model.RateLimit{Count: 10, Period: "often"},
+			}})
+			Expect(err).To(MatchError(ContainSubstring("invalid rate_limit period")))
+		})
+
+		It("should reject invalid timeouts", func() {
+			_, err := transform.New(model.TransformerStep{Service: &model.ServiceTransformerStep{Endpoint: "svc", Nats: model.NatsConfig{Url: url}, Timeout: "soon"}})
+			Expect(err).To(MatchError(ContainSubstring("invalid service timeout")))
+		})
+	})
+
+	It("should require a transformer", func() {
+		_, err := transform.New(model.TransformerStep{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,37 @@
+package builders
+
+import (
+	"time"
+
+	"github.com/synadia-io/connect/spec"
+)
+
+type BatchingBuilder struct {
+	res *spec.BatchingSpec
+}
+
+func Batching() *BatchingBuilder {
+	return &BatchingBuilder{
+		res: &spec.BatchingSpec{},
+	}
+}
+
+func (b *BatchingBuilder) Count(v int) *BatchingBuilder {
+	b.res.Count = &v
+	return b
+}
+
+func (b *BatchingBuilder) ByteSize(v int) *BatchingBuilder {
+	b.res.ByteSize = &v
+	return b
+}
+
+func (b *BatchingBuilder) Period(d time.Duration) *BatchingBuilder {
+	p := d.String()
+	b.res.Period = &p
+	return b
+}
+
+func (b *BatchingBuilder) Build() spec.BatchingSpec {
+	return *b.res
+}
@@ -0,0 +1,63 @@
+package builders
+
+import (
+	"github.com/synadia-io/connect/spec"
+)
+
+type BrokerStepBuilder struct {
+	res *spec.BrokerStepSpec
+}
+
+func BrokerStep() *BrokerStepBuilder {
+	return &BrokerStepBuilder{
+		res: &spec.BrokerStepSpec{
+			Pattern: spec.BrokerStepSpecPatternFanOut,
+		},
+	}
+}
+
+func (b *BrokerStepBuilder) Pattern(pattern spec.BrokerStepSpecPattern) *BrokerStepBuilder {
+	b.res.Pattern = pattern
+	return b
+}
+
+func (b *BrokerStepBuilder) Output(v *OutputStepBuilder) *BrokerStepBuilder {
+	b.res.Outputs = append(b.res.Outputs, v.Build())
+	return b
+}
+
+func (b *BrokerStepBuilder) Build() spec.BrokerStepSpec {
+	return *b.res
+}
+
+type OutputStepBuilder struct {
+	res *spec.OutputStepSpec
+}
+
+func OutputStep() *OutputStepBuilder {
+	return &OutputStepBuilder{
+		res: &spec.OutputStepSpec{},
+	}
+}
+
+func (b *OutputStepBuilder) Transformer(v *TransformerStepBuilder) *OutputStepBuilder {
+	r := v.Build()
+	b.res.Transformer = &r
+	return b
+}
+
+func (b *OutputStepBuilder) Sink(v *SinkStepBuilder) *OutputStepBuilder {
+	r := v.Build()
+	b.res.Sink = &r
+	return b
+}
+
+func (b *OutputStepBuilder) Producer(v *ProducerStepBuilder) *OutputStepBuilder {
+	r := v.Build()
+	b.res.Producer = &r
+	return b
+}
+
+func (b *OutputStepBuilder) Build() spec.OutputStepSpec {
+	return *b.res
+}
@@ -0,0 +1,32 @@
+package builders
+
+import (
+	"github.com/synadia-io/connect/spec"
+)
+
+type ConsumerStepHeadersBuilder struct {
+	res *spec.ConsumerStepSpecHeaders
+}
+
+func ConsumerStepHeaders() *ConsumerStepHeadersBuilder {
+	return &ConsumerStepHeadersBuilder{
+		res: &spec.ConsumerStepSpecHeaders{},
+	}
+}
+
+func (b *ConsumerStepHeadersBuilder) Propagate(names ...string) *ConsumerStepHeadersBuilder {
+	b.res.Propagate = append([]string{}, names...)
+	return b
+}
+
+func (b *ConsumerStepHeadersBuilder) Static(key string, value string) *ConsumerStepHeadersBuilder {
+	if b.res.Static == nil {
+		b.res.Static = make(map[string]string)
+	}
+	b.res.Static[key] = value
+	return b
+}
+
+func (b *ConsumerStepHeadersBuilder) Build() spec.ConsumerStepSpecHeaders {
+	return *b.res
+}
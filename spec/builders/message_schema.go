@@ -0,0 +1,50 @@
+package builders
+
+import (
+	"github.com/synadia-io/connect/spec"
+)
+
+type MessageSchemaBuilder struct {
+	res *spec.MessageSchemaSpec
+}
+
+func MessageSchema(format spec.MessageSchemaSpecFormat) *MessageSchemaBuilder {
+	return &MessageSchemaBuilder{
+		res: &spec.MessageSchemaSpec{
+			Format:    format,
+			OnFailure: spec.MessageSchemaSpecOnFailureDeadLetter,
+		},
+	}
+}
+
+func (b *MessageSchemaBuilder) Inline(v string) *MessageSchemaBuilder {
+	b.res.Inline = &v
+	return b
+}
+
+func (b *MessageSchemaBuilder) File(v string) *MessageSchemaBuilder {
+	b.res.File = &v
+	return b
+}
+
+func (b *MessageSchemaBuilder) ObjectStore(bucket string, name string) *MessageSchemaBuilder {
+	b.res.ObjectStore = &spec.MessageSchemaSpecObjectStore{
+		Bucket: bucket,
+		Name:   name,
+	}
+	return b
+}
+
+func (b *MessageSchemaBuilder) Message(v string) *MessageSchemaBuilder {
+	b.res.Message = &v
+	return b
+}
+
+func (b *MessageSchemaBuilder) OnFailure(v spec.MessageSchemaSpecOnFailure) *MessageSchemaBuilder {
+	b.res.OnFailure = v
+	return b
+}
+
+func (b *MessageSchemaBuilder) Build() spec.MessageSchemaSpec {
+	return *b.res
+}
@@ -0,0 +1,37 @@
+package builders
+
+import (
+	"github.com/synadia-io/connect/spec"
+)
+
+type ProducerStepHeadersBuilder struct {
+	res *spec.ProducerStepSpecHeaders
+}
+
+func ProducerStepHeaders() *ProducerStepHeadersBuilder {
+	return &ProducerStepHeadersBuilder{
+		res: &spec.ProducerStepSpecHeaders{},
+	}
+}
+
+func (b *ProducerStepHeadersBuilder) Propagate(names ...string) *ProducerStepHeadersBuilder {
+	b.res.Propagate = append([]string{}, names...)
+	return b
+}
+
+func (b *ProducerStepHeadersBuilder) Static(key string, value string) *ProducerStepHeadersBuilder {
+	if b.res.Static == nil {
+		b.res.Static = make(map[string]string)
+	}
+	b.res.Static[key] = value
+	return b
+}
+
+func (b *ProducerStepHeadersBuilder) MsgId(v string) *ProducerStepHeadersBuilder {
+	b.res.MsgId = &v
+	return b
+}
+
+func (b *ProducerStepHeadersBuilder) Build() spec.ProducerStepSpecHeaders {
+	return *b.res
+}
@@ -0,0 +1,33 @@
+package builders
+
+import (
+	"github.com/synadia-io/connect/spec"
+)
+
+type RouterStepBuilder struct {
+	res *spec.RouterStepSpec
+}
+
+func RouterStep() *RouterStepBuilder {
+	return &RouterStepBuilder{
+		res: &spec.RouterStepSpec{},
+	}
+}
+
+func (b *RouterStepBuilder) Route(condition string, v *OutputStepBuilder) *RouterStepBuilder {
+	b.res.Routes = append(b.res.Routes, spec.RouteStepSpec{
+		Condition: condition,
+		Output:    v.Build(),
+	})
+	return b
+}
+
+func (b *RouterStepBuilder) Default(v *OutputStepBuilder) *RouterStepBuilder {
+	r := v.Build()
+	b.res.Default = &r
+	return b
+}
+
+func (b *RouterStepBuilder) Build() spec.RouterStepSpec {
+	return *b.res
+}
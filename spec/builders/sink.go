@@ -48,6 +48,18 @@ func (b *SinkStepBuilder) SetBool(key string, value bool) *SinkStepBuilder {
 	return b
 }
 
+func (b *SinkStepBuilder) RateLimit(v *RateLimitBuilder) *SinkStepBuilder {
+	r := v.Build()
+	b.res.RateLimit = &r
+	return b
+}
+
+func (b *SinkStepBuilder) Batching(v *BatchingBuilder) *SinkStepBuilder {
+	r := v.Build()
+	b.res.Batching = &r
+	return b
+}
+
 func (b *SinkStepBuilder) Build() spec.SinkStepSpec {
 	return *b.res
 }
@@ -11,15 +11,34 @@ type NatsConfigSpec struct {
 	// Whether authentication is enabled
 	AuthEnabled bool `json:"auth_enabled,omitempty" yaml:"auth_enabled,omitempty" mapstructure:"auth_enabled,omitempty"`
 
+	// The credentials of the user, with a JWT and seed. Exactly one of file or
+	// secret is required
+	Creds *NatsConfigSpecCreds `json:"creds,omitempty" yaml:"creds,omitempty" mapstructure:"creds,omitempty"`
+
 	// The JWT token used during authentication. Only applicable if auth_enabled is
 	// true
 	Jwt *string `json:"jwt,omitempty" yaml:"jwt,omitempty" mapstructure:"jwt,omitempty"`
 
+	// The file with the nkey seed to authenticate with
+	Nkey *string `json:"nkey,omitempty" yaml:"nkey,omitempty" mapstructure:"nkey,omitempty"`
+
+	// The password of the user
+	Password *string `json:"password,omitempty" yaml:"password,omitempty" mapstructure:"password,omitempty"`
+
 	// The seed used during authentication. Only applicable if auth_enabled is true
 	Seed *string `json:"seed,omitempty" yaml:"seed,omitempty" mapstructure:"seed,omitempty"`
 
+	// The TLS settings of the connection
+	Tls *NatsConfigSpecTls `json:"tls,omitempty" yaml:"tls,omitempty" mapstructure:"tls,omitempty"`
+
+	// The token to authenticate with
+	Token *string `json:"token,omitempty" yaml:"token,omitempty" mapstructure:"token,omitempty"`
+
 	// The url of the nats server to connect to
 	Url string `json:"url" yaml:"url" mapstructure:"url"`
+
+	// The user to authenticate as
+	User *string `json:"user,omitempty" yaml:"user,omitempty" mapstructure:"user,omitempty"`
 }
 
 // UnmarshalJSON implements json.Unmarshaler.
@@ -42,3 +61,48 @@ func (j *NatsConfigSpec) UnmarshalJSON(value []byte) error {
 	*j = NatsConfigSpec(plain)
 	return nil
 }
+
+// The credentials of the user, with a JWT and seed. Exactly one of file or secret
+// is required
+type NatsConfigSpecCreds struct {
+	// The credentials file
+	File *string `json:"file,omitempty" yaml:"file,omitempty" mapstructure:"file,omitempty"`
+
+	// The name of the secret holding the content of the credentials file, provided
+	// to the connector as an environment variable of the same name
+	Secret *string `json:"secret,omitempty" yaml:"secret,omitempty" mapstructure:"secret,omitempty"`
+}
+
+// The TLS settings of the connection
+type NatsConfigSpecTls struct {
+	// The file with the certificate authorities the server certificate is verified
+	// with
+	CaFile *string `json:"ca_file,omitempty" yaml:"ca_file,omitempty" mapstructure:"ca_file,omitempty"`
+
+	// The file with the client certificate, for mutual TLS
+	CertFile *string `json:"cert_file,omitempty" yaml:"cert_file,omitempty" mapstructure:"cert_file,omitempty"`
+
+	// Whether the server certificate is accepted without verifying it
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty" mapstructure:"insecure_skip_verify,omitempty"`
+
+	// The file with the key of the client certificate
+	KeyFile *string `json:"key_file,omitempty" yaml:"key_file,omitempty" mapstructure:"key_file,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *NatsConfigSpecTls) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	type Plain NatsConfigSpecTls
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["insecure_skip_verify"]; !ok || v == nil {
+		plain.InsecureSkipVerify = false
+	}
+	*j = NatsConfigSpecTls(plain)
+	return nil
+}
@@ -8,6 +8,83 @@ import (
 	"reflect"
 )
 
+// Groups messages into batches which a step handles at once. A batch is complete
+// once any of the limits is reached
+type BatchingSpec struct {
+	// The total size of the payloads in a batch in bytes
+	ByteSize *int `json:"byte_size,omitempty" yaml:"byte_size,omitempty" mapstructure:"byte_size,omitempty"`
+
+	// The number of messages in a batch
+	Count *int `json:"count,omitempty" yaml:"count,omitempty" mapstructure:"count,omitempty"`
+
+	// The period after which an incomplete batch is handled
+	Period *string `json:"period,omitempty" yaml:"period,omitempty" mapstructure:"period,omitempty"`
+}
+
+// Several outputs the messages are delivered to, replacing the sink or producer
+type BrokerStepSpec struct {
+	// The outputs the messages are delivered to
+	Outputs []OutputStepSpec `json:"outputs" yaml:"outputs" mapstructure:"outputs"`
+
+	// How messages are delivered to the outputs. fan_out delivers every message to
+	// all outputs, round_robin to one output after the other and fallback to the
+	// first output which accepts it
+	Pattern BrokerStepSpecPattern `json:"pattern,omitempty" yaml:"pattern,omitempty" mapstructure:"pattern,omitempty"`
+}
+
+type BrokerStepSpecPattern string
+
+const BrokerStepSpecPatternFallback BrokerStepSpecPattern = "fallback"
+const BrokerStepSpecPatternFanOut BrokerStepSpecPattern = "fan_out"
+const BrokerStepSpecPatternRoundRobin BrokerStepSpecPattern = "round_robin"
+
+var enumValues_BrokerStepSpecPattern = []interface{}{
+	"fan_out",
+	"round_robin",
+	"fallback",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BrokerStepSpecPattern) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_BrokerStepSpecPattern {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_BrokerStepSpecPattern, v)
+	}
+	*j = BrokerStepSpecPattern(v)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *BrokerStepSpec) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["outputs"]; raw != nil && !ok {
+		return fmt.Errorf("field outputs in BrokerStepSpec: required")
+	}
+	type Plain BrokerStepSpec
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["pattern"]; !ok || v == nil {
+		plain.Pattern = "fan_out"
+	}
+	*j = BrokerStepSpec(plain)
+	return nil
+}
+
 type ConnectorSpec struct {
 	// A description of the connector
 	Description string `json:"description" yaml:"description" mapstructure:"description"`
@@ -49,12 +126,18 @@ type ConsumerStepSpec struct {
 	// The configuration for reading from Core NATS subjects
 	Core *ConsumerStepSpecCore `json:"core,omitempty" yaml:"core,omitempty" mapstructure:"core,omitempty"`
 
+	// The headers of the messages read from NATS
+	Headers *ConsumerStepSpecHeaders `json:"headers,omitempty" yaml:"headers,omitempty" mapstructure:"headers,omitempty"`
+
 	// The configuration for reading from the NATS KV store
 	Kv *ConsumerStepSpecKv `json:"kv,omitempty" yaml:"kv,omitempty" mapstructure:"kv,omitempty"`
 
 	// Nats corresponds to the JSON schema field "nats".
 	Nats NatsConfigSpec `json:"nats" yaml:"nats" mapstructure:"nats"`
 
+	// Schema corresponds to the JSON schema field "schema".
+	Schema *MessageSchemaSpec `json:"schema,omitempty" yaml:"schema,omitempty" mapstructure:"schema,omitempty"`
+
 	// The configuration for reading from JetStream streams
 	Stream *ConsumerStepSpecStream `json:"stream,omitempty" yaml:"stream,omitempty" mapstructure:"stream,omitempty"`
 }
@@ -86,6 +169,19 @@ func (j *ConsumerStepSpecCore) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// The headers of the messages read from NATS
+type ConsumerStepSpecHeaders struct {
+	// The headers which are kept, matched case-insensitively. A trailing * matches
+	// any suffix. Without it all headers are kept
+	Propagate []string `json:"propagate,omitempty" yaml:"propagate,omitempty" mapstructure:"propagate,omitempty"`
+
+	// Headers added to every message
+	Static ConsumerStepSpecHeadersStatic `json:"static,omitempty" yaml:"static,omitempty" mapstructure:"static,omitempty"`
+}
+
+// Headers added to every message
+type ConsumerStepSpecHeadersStatic map[string]string
+
 // The configuration for reading from the NATS KV store
 type ConsumerStepSpecKv struct {
 	// The bucket to use when reading from the KV store
@@ -118,10 +214,115 @@ func (j *ConsumerStepSpecKv) UnmarshalJSON(value []byte) error {
 
 // The configuration for reading from JetStream streams
 type ConsumerStepSpecStream struct {
+	// How messages are acknowledged
+	AckPolicy ConsumerStepSpecStreamAckPolicy `json:"ack_policy,omitempty" yaml:"ack_policy,omitempty" mapstructure:"ack_policy,omitempty"`
+
+	// How long the server waits for an acknowledgement before redelivering a
+	// message, e.g. 30s
+	AckWait *string `json:"ack_wait,omitempty" yaml:"ack_wait,omitempty" mapstructure:"ack_wait,omitempty"`
+
+	// Bind to the existing durable consumer instead of creating or updating it
+	Bind bool `json:"bind,omitempty" yaml:"bind,omitempty" mapstructure:"bind,omitempty"`
+
+	// Where in the stream to start delivering messages
+	DeliverPolicy ConsumerStepSpecStreamDeliverPolicy `json:"deliver_policy,omitempty" yaml:"deliver_policy,omitempty" mapstructure:"deliver_policy,omitempty"`
+
+	// The name of the durable consumer, allowing the connector to resume where it
+	// left off after a restart
+	Durable *string `json:"durable,omitempty" yaml:"durable,omitempty" mapstructure:"durable,omitempty"`
+
+	// Additional subjects of the stream to consume from
+	FilterSubjects []string `json:"filter_subjects,omitempty" yaml:"filter_subjects,omitempty" mapstructure:"filter_subjects,omitempty"`
+
+	// The maximum number of messages delivered but not yet acknowledged
+	MaxAckPending *int `json:"max_ack_pending,omitempty" yaml:"max_ack_pending,omitempty" mapstructure:"max_ack_pending,omitempty"`
+
+	// The maximum number of times a message is delivered
+	MaxDeliver *int `json:"max_deliver,omitempty" yaml:"max_deliver,omitempty" mapstructure:"max_deliver,omitempty"`
+
+	// The stream sequence to start at when the deliver policy is by_start_sequence
+	OptStartSeq *int `json:"opt_start_seq,omitempty" yaml:"opt_start_seq,omitempty" mapstructure:"opt_start_seq,omitempty"`
+
+	// The RFC3339 time to start at when the deliver policy is by_start_time
+	OptStartTime *string `json:"opt_start_time,omitempty" yaml:"opt_start_time,omitempty" mapstructure:"opt_start_time,omitempty"`
+
+	// The name of the stream, looked up by subject if not set
+	Stream *string `json:"stream,omitempty" yaml:"stream,omitempty" mapstructure:"stream,omitempty"`
+
 	// The subject to consume from
 	Subject string `json:"subject" yaml:"subject" mapstructure:"subject"`
 }
 
+type ConsumerStepSpecStreamAckPolicy string
+
+const ConsumerStepSpecStreamAckPolicyAll ConsumerStepSpecStreamAckPolicy = "all"
+const ConsumerStepSpecStreamAckPolicyExplicit ConsumerStepSpecStreamAckPolicy = "explicit"
+const ConsumerStepSpecStreamAckPolicyNone ConsumerStepSpecStreamAckPolicy = "none"
+
+var enumValues_ConsumerStepSpecStreamAckPolicy = []interface{}{
+	"explicit",
+	"all",
+	"none",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ConsumerStepSpecStreamAckPolicy) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_ConsumerStepSpecStreamAckPolicy {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_ConsumerStepSpecStreamAckPolicy, v)
+	}
+	*j = ConsumerStepSpecStreamAckPolicy(v)
+	return nil
+}
+
+type ConsumerStepSpecStreamDeliverPolicy string
+
+const ConsumerStepSpecStreamDeliverPolicyAll ConsumerStepSpecStreamDeliverPolicy = "all"
+const ConsumerStepSpecStreamDeliverPolicyByStartSequence ConsumerStepSpecStreamDeliverPolicy = "by_start_sequence"
+const ConsumerStepSpecStreamDeliverPolicyByStartTime ConsumerStepSpecStreamDeliverPolicy = "by_start_time"
+const ConsumerStepSpecStreamDeliverPolicyLast ConsumerStepSpecStreamDeliverPolicy = "last"
+const ConsumerStepSpecStreamDeliverPolicyLastPerSubject ConsumerStepSpecStreamDeliverPolicy = "last_per_subject"
+const ConsumerStepSpecStreamDeliverPolicyNew ConsumerStepSpecStreamDeliverPolicy = "new"
+
+var enumValues_ConsumerStepSpecStreamDeliverPolicy = []interface{}{
+	"all",
+	"last",
+	"new",
+	"by_start_sequence",
+	"by_start_time",
+	"last_per_subject",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ConsumerStepSpecStreamDeliverPolicy) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_ConsumerStepSpecStreamDeliverPolicy {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_ConsumerStepSpecStreamDeliverPolicy, v)
+	}
+	*j = ConsumerStepSpecStreamDeliverPolicy(v)
+	return nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler.
 func (j *ConsumerStepSpecStream) UnmarshalJSON(value []byte) error {
 	var raw map[string]interface{}
@@ -136,6 +337,15 @@ func (j *ConsumerStepSpecStream) UnmarshalJSON(value []byte) error {
 	if err := json.Unmarshal(value, &plain); err != nil {
 		return err
 	}
+	if v, ok := raw["ack_policy"]; !ok || v == nil {
+		plain.AckPolicy = "explicit"
+	}
+	if v, ok := raw["bind"]; !ok || v == nil {
+		plain.Bind = false
+	}
+	if v, ok := raw["deliver_policy"]; !ok || v == nil {
+		plain.DeliverPolicy = "all"
+	}
 	*j = ConsumerStepSpecStream(plain)
 	return nil
 }
@@ -158,17 +368,221 @@ func (j *ConsumerStepSpec) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// How messages the sink or producer fails to deliver are retried and
+// dead-lettered
+type ErrorPolicySpec struct {
+	// The delay before the first retry, doubled for every next retry
+	Backoff string `json:"backoff,omitempty" yaml:"backoff,omitempty" mapstructure:"backoff,omitempty"`
+
+	// The producer receiving the messages which still fail after the last retry.
+	// Without it these messages are nacked
+	DeadLetter *ProducerStepSpec `json:"dead_letter,omitempty" yaml:"dead_letter,omitempty" mapstructure:"dead_letter,omitempty"`
+
+	// The maximum delay between retries
+	MaxBackoff string `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty" mapstructure:"max_backoff,omitempty"`
+
+	// The number of times delivery of a message is retried before it is
+	// dead-lettered
+	MaxRetries *int `json:"max_retries,omitempty" yaml:"max_retries,omitempty" mapstructure:"max_retries,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ErrorPolicySpec) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	type Plain ErrorPolicySpec
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["backoff"]; !ok || v == nil {
+		plain.Backoff = "1s"
+	}
+	if v, ok := raw["max_backoff"]; !ok || v == nil {
+		plain.MaxBackoff = "30s"
+	}
+	if v, ok := raw["max_retries"]; !ok || v == nil {
+		maxRetries := 3
+		plain.MaxRetries = &maxRetries
+	}
+	*j = ErrorPolicySpec(plain)
+	return nil
+}
+
+// The schema every message of a step is validated against
+type MessageSchemaSpec struct {
+	// The path of the file holding the schema definition
+	File *string `json:"file,omitempty" yaml:"file,omitempty" mapstructure:"file,omitempty"`
+
+	// The format of the schema definition
+	Format MessageSchemaSpecFormat `json:"format" yaml:"format" mapstructure:"format"`
+
+	// The schema definition
+	Inline *string `json:"inline,omitempty" yaml:"inline,omitempty" mapstructure:"inline,omitempty"`
+
+	// The fully qualified name of the protobuf message the payload is encoded as
+	Message *string `json:"message,omitempty" yaml:"message,omitempty" mapstructure:"message,omitempty"`
+
+	// The NATS object store object holding the schema definition
+	ObjectStore *MessageSchemaSpecObjectStore `json:"object_store,omitempty" yaml:"object_store,omitempty" mapstructure:"object_store,omitempty"`
+
+	// What happens to messages which do not match the schema. dead_letter publishes
+	// them to the dead letter producer of the errors section, drop acknowledges and
+	// discards them
+	OnFailure MessageSchemaSpecOnFailure `json:"on_failure,omitempty" yaml:"on_failure,omitempty" mapstructure:"on_failure,omitempty"`
+}
+
+type MessageSchemaSpecFormat string
+
+const MessageSchemaSpecFormatAvro MessageSchemaSpecFormat = "avro"
+const MessageSchemaSpecFormatJsonSchema MessageSchemaSpecFormat = "json_schema"
+const MessageSchemaSpecFormatProtobuf MessageSchemaSpecFormat = "protobuf"
+
+var enumValues_MessageSchemaSpecFormat = []interface{}{
+	"json_schema",
+	"avro",
+	"protobuf",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchemaSpecFormat) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_MessageSchemaSpecFormat {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_MessageSchemaSpecFormat, v)
+	}
+	*j = MessageSchemaSpecFormat(v)
+	return nil
+}
+
+// The NATS object store object holding the schema definition
+type MessageSchemaSpecObjectStore struct {
+	// The object store bucket
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+
+	// The name of the object
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchemaSpecObjectStore) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["bucket"]; raw != nil && !ok {
+		return fmt.Errorf("field bucket in MessageSchemaSpecObjectStore: required")
+	}
+	if _, ok := raw["name"]; raw != nil && !ok {
+		return fmt.Errorf("field name in MessageSchemaSpecObjectStore: required")
+	}
+	type Plain MessageSchemaSpecObjectStore
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	*j = MessageSchemaSpecObjectStore(plain)
+	return nil
+}
+
+type MessageSchemaSpecOnFailure string
+
+const MessageSchemaSpecOnFailureDeadLetter MessageSchemaSpecOnFailure = "dead_letter"
+const MessageSchemaSpecOnFailureDrop MessageSchemaSpecOnFailure = "drop"
+
+var enumValues_MessageSchemaSpecOnFailure = []interface{}{
+	"dead_letter",
+	"drop",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchemaSpecOnFailure) UnmarshalJSON(value []byte) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_MessageSchemaSpecOnFailure {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_MessageSchemaSpecOnFailure, v)
+	}
+	*j = MessageSchemaSpecOnFailure(v)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *MessageSchemaSpec) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["format"]; raw != nil && !ok {
+		return fmt.Errorf("field format in MessageSchemaSpec: required")
+	}
+	type Plain MessageSchemaSpec
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["on_failure"]; !ok || v == nil {
+		plain.OnFailure = "dead_letter"
+	}
+	*j = MessageSchemaSpec(plain)
+	return nil
+}
+
+// An output of a broker, a sink or producer with an optional transformer for the
+// messages delivered to it
+type OutputStepSpec struct {
+	// Producer corresponds to the JSON schema field "producer".
+	Producer *ProducerStepSpec `json:"producer,omitempty" yaml:"producer,omitempty" mapstructure:"producer,omitempty"`
+
+	// Sink corresponds to the JSON schema field "sink".
+	Sink *SinkStepSpec `json:"sink,omitempty" yaml:"sink,omitempty" mapstructure:"sink,omitempty"`
+
+	// The transformer applied only to the messages delivered to this output
+	Transformer *TransformerStepSpec `json:"transformer,omitempty" yaml:"transformer,omitempty" mapstructure:"transformer,omitempty"`
+}
+
 // The producer writing messages to NATS
 type ProducerStepSpec struct {
+	// Batching corresponds to the JSON schema field "batching".
+	Batching *BatchingSpec `json:"batching,omitempty" yaml:"batching,omitempty" mapstructure:"batching,omitempty"`
+
 	// The configuration for writing to Core NATS subjects
 	Core *ProducerStepSpecCore `json:"core,omitempty" yaml:"core,omitempty" mapstructure:"core,omitempty"`
 
+	// The headers of the messages written to NATS
+	Headers *ProducerStepSpecHeaders `json:"headers,omitempty" yaml:"headers,omitempty" mapstructure:"headers,omitempty"`
+
 	// The configuration for writing to the NATS KV store
 	Kv *ProducerStepSpecKv `json:"kv,omitempty" yaml:"kv,omitempty" mapstructure:"kv,omitempty"`
 
 	// Nats corresponds to the JSON schema field "nats".
 	Nats NatsConfigSpec `json:"nats" yaml:"nats" mapstructure:"nats"`
 
+	// RateLimit corresponds to the JSON schema field "rate_limit".
+	RateLimit *RateLimitSpec `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" mapstructure:"rate_limit,omitempty"`
+
+	// Schema corresponds to the JSON schema field "schema".
+	Schema *MessageSchemaSpec `json:"schema,omitempty" yaml:"schema,omitempty" mapstructure:"schema,omitempty"`
+
 	// The configuration for writing to JetStream streams
 	Stream *ProducerStepSpecStream `json:"stream,omitempty" yaml:"stream,omitempty" mapstructure:"stream,omitempty"`
 
@@ -178,7 +592,8 @@ type ProducerStepSpec struct {
 
 // The configuration for writing to Core NATS subjects
 type ProducerStepSpecCore struct {
-	// The subject to send data to
+	// The subject to send data to. It can be templated from the message, e.g.
+	// orders.${! meta("region") }
 	Subject string `json:"subject" yaml:"subject" mapstructure:"subject"`
 }
 
@@ -200,6 +615,23 @@ func (j *ProducerStepSpecCore) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// The headers of the messages written to NATS
+type ProducerStepSpecHeaders struct {
+	// The Nats-Msg-Id header JetStream deduplicates messages by, templated from the
+	// message, e.g. ${! json("id") }
+	MsgId *string `json:"msg_id,omitempty" yaml:"msg_id,omitempty" mapstructure:"msg_id,omitempty"`
+
+	// The headers which are kept, matched case-insensitively. A trailing * matches
+	// any suffix. Without it all headers are kept
+	Propagate []string `json:"propagate,omitempty" yaml:"propagate,omitempty" mapstructure:"propagate,omitempty"`
+
+	// Headers added to every message
+	Static ProducerStepSpecHeadersStatic `json:"static,omitempty" yaml:"static,omitempty" mapstructure:"static,omitempty"`
+}
+
+// Headers added to every message
+type ProducerStepSpecHeadersStatic map[string]string
+
 // The configuration for writing to the NATS KV store
 type ProducerStepSpecKv struct {
 	// The bucket to use when writing to the KV store
@@ -232,7 +664,8 @@ func (j *ProducerStepSpecKv) UnmarshalJSON(value []byte) error {
 
 // The configuration for writing to JetStream streams
 type ProducerStepSpecStream struct {
-	// The subject to send data to
+	// The subject to send data to. It can be templated from the message, e.g.
+	// orders.${! meta("region") }
 	Subject string `json:"subject" yaml:"subject" mapstructure:"subject"`
 }
 
@@ -275,11 +708,111 @@ func (j *ProducerStepSpec) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// Limits the number of messages a step handles per period
+type RateLimitSpec struct {
+	// The number of messages which may be handled at once. Without it the count is
+	// the burst
+	Burst *int `json:"burst,omitempty" yaml:"burst,omitempty" mapstructure:"burst,omitempty"`
+
+	// The number of messages handled per period
+	Count int `json:"count" yaml:"count" mapstructure:"count"`
+
+	// The period the count applies to
+	Period string `json:"period,omitempty" yaml:"period,omitempty" mapstructure:"period,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *RateLimitSpec) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["count"]; raw != nil && !ok {
+		return fmt.Errorf("field count in RateLimitSpec: required")
+	}
+	type Plain RateLimitSpec
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["period"]; !ok || v == nil {
+		plain.Period = "1s"
+	}
+	*j = RateLimitSpec(plain)
+	return nil
+}
+
+// A route of a router
+type RouteStepSpec struct {
+	// The bloblang query deciding whether a message takes the route, e.g. this.type
+	// == "order" or @kind == "refund"
+	Condition string `json:"condition" yaml:"condition" mapstructure:"condition"`
+
+	// Output corresponds to the JSON schema field "output".
+	Output OutputStepSpec `json:"output" yaml:"output" mapstructure:"output"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *RouteStepSpec) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["condition"]; raw != nil && !ok {
+		return fmt.Errorf("field condition in RouteStepSpec: required")
+	}
+	if _, ok := raw["output"]; raw != nil && !ok {
+		return fmt.Errorf("field output in RouteStepSpec: required")
+	}
+	type Plain RouteStepSpec
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	*j = RouteStepSpec(plain)
+	return nil
+}
+
+// Routes each message to the output of the first route whose condition matches
+// it, replacing the sink or producer
+type RouterStepSpec struct {
+	// The output receiving the messages no route matches. Without it these messages
+	// are dropped
+	Default *OutputStepSpec `json:"default,omitempty" yaml:"default,omitempty" mapstructure:"default,omitempty"`
+
+	// The routes, evaluated in order
+	Routes []RouteStepSpec `json:"routes" yaml:"routes" mapstructure:"routes"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *RouterStepSpec) UnmarshalJSON(value []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["routes"]; raw != nil && !ok {
+		return fmt.Errorf("field routes in RouterStepSpec: required")
+	}
+	type Plain RouterStepSpec
+	var plain Plain
+	if err := json.Unmarshal(value, &plain); err != nil {
+		return err
+	}
+	*j = RouterStepSpec(plain)
+	return nil
+}
+
 // The external system that is the target for the messages
 type SinkStepSpec struct {
+	// Batching corresponds to the JSON schema field "batching".
+	Batching *BatchingSpec `json:"batching,omitempty" yaml:"batching,omitempty" mapstructure:"batching,omitempty"`
+
 	// The configuration of the sink step
 	Config SinkStepSpecConfig `json:"config" yaml:"config" mapstructure:"config"`
 
+	// RateLimit corresponds to the JSON schema field "rate_limit".
+	RateLimit *RateLimitSpec `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" mapstructure:"rate_limit,omitempty"`
+
 	// The type of the sink step. This should be a sink that is included in the
 	// connector's runtime
 	Type string `json:"type" yaml:"type" mapstructure:"type"`
@@ -344,12 +877,21 @@ func (j *SourceStepSpec) UnmarshalJSON(value []byte) error {
 }
 
 type StepsSpec struct {
+	// Broker corresponds to the JSON schema field "broker".
+	Broker *BrokerStepSpec `json:"broker,omitempty" yaml:"broker,omitempty" mapstructure:"broker,omitempty"`
+
 	// Consumer corresponds to the JSON schema field "consumer".
 	Consumer *ConsumerStepSpec `json:"consumer,omitempty" yaml:"consumer,omitempty" mapstructure:"consumer,omitempty"`
 
+	// Errors corresponds to the JSON schema field "errors".
+	Errors *ErrorPolicySpec `json:"errors,omitempty" yaml:"errors,omitempty" mapstructure:"errors,omitempty"`
+
 	// Producer corresponds to the JSON schema field "producer".
 	Producer *ProducerStepSpec `json:"producer,omitempty" yaml:"producer,omitempty" mapstructure:"producer,omitempty"`
 
+	// Router corresponds to the JSON schema field "router".
+	Router *RouterStepSpec `json:"router,omitempty" yaml:"router,omitempty" mapstructure:"router,omitempty"`
+
 	// Sink corresponds to the JSON schema field "sink".
 	Sink *SinkStepSpec `json:"sink,omitempty" yaml:"sink,omitempty" mapstructure:"sink,omitempty"`
 
@@ -563,12 +1105,18 @@ func (j *TransformerStepSpecMapping) UnmarshalJSON(value []byte) error {
 
 // A service transformer sends each message to a nats service to be transformed
 type TransformerStepSpecService struct {
+	// Batching corresponds to the JSON schema field "batching".
+	Batching *BatchingSpec `json:"batching,omitempty" yaml:"batching,omitempty" mapstructure:"batching,omitempty"`
+
 	// The nats subject on which the service is receiving requests
 	Endpoint string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"`
 
 	// Nats corresponds to the JSON schema field "nats".
 	Nats NatsConfigSpec `json:"nats" yaml:"nats" mapstructure:"nats"`
 
+	// RateLimit corresponds to the JSON schema field "rate_limit".
+	RateLimit *RateLimitSpec `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" mapstructure:"rate_limit,omitempty"`
+
 	// The timeout for the service call
 	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty" mapstructure:"timeout,omitempty"`
 }
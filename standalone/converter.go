@@ -1,20 +1,35 @@
 package standalone
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/synadia-io/connect/model"
+	"github.com/synadia-io/connect/runtime"
 )
 
 // ConfigConverter converts Synadia Connect steps to runtime-specific configuration
 type ConfigConverter interface {
 	ConvertSteps(steps model.Steps) (string, error)
 	GetRuntimeArgs() []string
+	// GetRuntimeEnv returns the environment variables the last converted configuration refers to
+	GetRuntimeEnv() map[string]string
 }
 
 // WombatConverter converts Synadia Connect steps to Wombat configuration
-type WombatConverter struct{}
+type WombatConverter struct {
+	// env holds the credentials of the NATS configurations, which the configuration refers to by variable
+	env map[string]string
+}
 
 func NewWombatConverter() *WombatConverter {
 	return &WombatConverter{}
@@ -22,6 +37,7 @@ func NewWombatConverter() *WombatConverter {
 
 func (c *WombatConverter) ConvertSteps(steps model.Steps) (string, error) {
 	var config strings.Builder
+	c.env = map[string]string{}
 
 	// Build Wombat configuration
 	if steps.Source != nil {
@@ -44,35 +60,66 @@ func (c *WombatConverter) ConvertSteps(steps model.Steps) (string, error) {
 		config.WriteString("\n")
 	}
 
+	// Messages flagged by the schema of the consumer skip the transformer on their way to the dead letter producer
+	deadLetter := steps.Consumer != nil && steps.Consumer.Schema != nil && steps.Consumer.Schema.OnFailure != model.MessageSchemaOnFailureDrop
+
 	// Add processors for transformers
 	if steps.Transformer != nil {
-		processorConfig, err := c.convertTransformer(*steps.Transformer)
+		processorConfig, err := c.convertTransformer(*steps.Transformer, transformerLabel)
 		if err != nil {
 			return "", fmt.Errorf("failed to convert transformer: %w", err)
 		}
+		if deadLetter {
+			processorConfig = "    - switch:\n" +
+				fmt.Sprintf("        - check: %s\n", strconv.Quote("@"+schemaFailureMeta+` != "true"`)) +
+				"          processors:\n" +
+				indent(processorConfig, 8)
+		}
 		config.WriteString("pipeline:\n")
 		config.WriteString("  processors:\n")
 		config.WriteString(processorConfig)
 		config.WriteString("\n")
 	}
 
-	if steps.Sink != nil {
-		sinkConfig, err := c.convertSink(*steps.Sink)
-		if err != nil {
+	var outputConfig string
+	var err error
+	switch {
+	case steps.Sink != nil:
+		if outputConfig, err = c.convertOutput(model.OutputStep{Sink: steps.Sink}, steps.Errors, "sink"); err != nil {
 			return "", fmt.Errorf("failed to convert sink: %w", err)
 		}
-		config.WriteString("output:\n")
-		config.WriteString(sinkConfig)
-		config.WriteString("\n")
+	case steps.Producer != nil:
+		if outputConfig, err = c.convertOutput(model.OutputStep{Producer: steps.Producer}, steps.Errors, "producer"); err != nil {
+			return "", fmt.Errorf("failed to convert producer: %w", err)
+		}
+	case steps.Router != nil:
+		if outputConfig, err = c.convertRouter(*steps.Router, steps.Errors); err != nil {
+			return "", fmt.Errorf("failed to convert router: %w", err)
+		}
+	case steps.Broker != nil:
+		if outputConfig, err = c.convertBroker(*steps.Broker, steps.Errors); err != nil {
+			return "", fmt.Errorf("failed to convert broker: %w", err)
+		}
 	}
 
-	if steps.Producer != nil {
-		producerConfig, err := c.convertProducer(*steps.Producer)
-		if err != nil {
-			return "", fmt.Errorf("failed to convert producer: %w", err)
+	if outputConfig != "" {
+		if deadLetter {
+			if outputConfig, err = c.convertSchemaDeadLetter(steps.Errors, "consumer", outputConfig); err != nil {
+				return "", fmt.Errorf("failed to convert consumer schema: %w", err)
+			}
 		}
 		config.WriteString("output:\n")
-		config.WriteString(producerConfig)
+		config.WriteString(outputConfig)
+		config.WriteString("\n")
+	}
+
+	rateLimits, err := c.convertRateLimits(steps)
+	if err != nil {
+		return "", err
+	}
+	if rateLimits != "" {
+		config.WriteString("rate_limit_resources:\n")
+		config.WriteString(rateLimits)
 		config.WriteString("\n")
 	}
 
@@ -84,6 +131,26 @@ func (c *WombatConverter) GetRuntimeArgs() []string {
 	return []string{}
 }
 
+// GetRuntimeEnv returns the credentials of the NATS configurations by the variables the configuration refers to, which
+// wombat substitutes when it loads the configuration. They have to be set in the environment of the runtime.
+func (c *WombatConverter) GetRuntimeEnv() map[string]string {
+	return maps.Clone(c.env)
+}
+
+// secretEnv stores the value in an environment variable named after the kind of credential and returns the reference
+// to it. The same value is stored once.
+func (c *WombatConverter) secretEnv(kind, value string) string {
+	for name, v := range c.env {
+		if v == value && strings.HasPrefix(name, "CONNECT_NATS_"+kind+"_") {
+			return "${" + name + "}"
+		}
+	}
+
+	name := fmt.Sprintf("CONNECT_NATS_%s_%d", kind, len(c.env))
+	c.env[name] = value
+	return "${" + name + "}"
+}
+
 func (c *WombatConverter) convertSource(source model.SourceStep) (string, error) {
 	switch source.Type {
 	case "http":
@@ -126,8 +193,13 @@ func (c *WombatConverter) convertFileSource(source model.SourceStep) (string, er
 }
 
 func (c *WombatConverter) convertConsumer(consumer model.ConsumerStep) (string, error) {
+	urls, err := c.convertNatsUrls(consumer.Nats)
+	if err != nil {
+		return "", err
+	}
+
 	config := "  nats:\n"
-	config += fmt.Sprintf("    urls: [\"%s\"]\n", consumer.Nats.Url)
+	config += urls
 
 	if consumer.Core != nil {
 		config += fmt.Sprintf("    subject: \"%s\"\n", consumer.Core.Subject)
@@ -136,8 +208,7 @@ func (c *WombatConverter) convertConsumer(consumer model.ConsumerStep) (string,
 		}
 	} else if consumer.Stream != nil {
 		config += fmt.Sprintf("    subject: \"%s\"\n", consumer.Stream.Subject)
-		config += "    stream:\n"
-		config += "      enabled: true\n"
+		config += c.convertConsumerStream(*consumer.Stream)
 	} else if consumer.Kv != nil {
 		config += fmt.Sprintf("    kv_bucket: \"%s\"\n", consumer.Kv.Bucket)
 		if consumer.Kv.Key != "" {
@@ -145,18 +216,78 @@ func (c *WombatConverter) convertConsumer(consumer model.ConsumerStep) (string,
 		}
 	}
 
-	// Add auth if configured
-	if consumer.Nats.AuthEnabled {
-		if consumer.Nats.Jwt != nil && consumer.Nats.Seed != nil {
-			config += "    auth:\n"
-			config += fmt.Sprintf("      nkey_file: \"%s\"\n", *consumer.Nats.Seed)
-			config += fmt.Sprintf("      user_jwt: \"%s\"\n", *consumer.Nats.Jwt)
+	auth, err := c.convertNatsAuth(consumer.Nats)
+	if err != nil {
+		return "", err
+	}
+	config += auth
+
+	var processors string
+	if consumer.Schema != nil {
+		schema, err := c.convertMessageSchema(*consumer.Schema)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert schema: %w", err)
+		}
+		processors += schema
+	}
+	if consumer.Headers != nil {
+		headers, err := c.convertConsumerHeaders(*consumer.Headers)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert headers: %w", err)
 		}
+		processors += headers
+	}
+	if processors != "" {
+		config += "  processors:\n"
+		config += processors
 	}
 
 	return config, nil
 }
 
+// convertConsumerStream converts the JetStream consumer settings, leaving out those which are not set so the
+// runtime defaults apply.
+func (c *WombatConverter) convertConsumerStream(stream model.ConsumerStepStream) string {
+	config := "    stream:\n"
+	config += "      enabled: true\n"
+
+	if stream.Stream != nil {
+		config += fmt.Sprintf("      name: \"%s\"\n", *stream.Stream)
+	}
+	if stream.Durable != nil {
+		config += fmt.Sprintf("      durable: \"%s\"\n", *stream.Durable)
+	}
+	if stream.Bind {
+		config += "      bind: true\n"
+	}
+	if len(stream.FilterSubjects) > 0 {
+		config += fmt.Sprintf("      filter_subjects: [\"%s\"]\n", strings.Join(stream.FilterSubjects, "\", \""))
+	}
+	if stream.DeliverPolicy != "" {
+		config += fmt.Sprintf("      deliver_policy: \"%s\"\n", stream.DeliverPolicy)
+	}
+	if stream.OptStartSeq != nil {
+		config += fmt.Sprintf("      opt_start_seq: %d\n", *stream.OptStartSeq)
+	}
+	if stream.OptStartTime != nil {
+		config += fmt.Sprintf("      opt_start_time: \"%s\"\n", *stream.OptStartTime)
+	}
+	if stream.AckPolicy != "" {
+		config += fmt.Sprintf("      ack_policy: \"%s\"\n", stream.AckPolicy)
+	}
+	if stream.AckWait != nil {
+		config += fmt.Sprintf("      ack_wait: \"%s\"\n", *stream.AckWait)
+	}
+	if stream.MaxDeliver != nil {
+		config += fmt.Sprintf("      max_deliver: %d\n", *stream.MaxDeliver)
+	}
+	if stream.MaxAckPending != nil {
+		config += fmt.Sprintf("      max_ack_pending: %d\n", *stream.MaxAckPending)
+	}
+
+	return config
+}
+
 func (c *WombatConverter) convertSink(sink model.SinkStep) (string, error) {
 	switch sink.Type {
 	case "http":
@@ -165,6 +296,8 @@ func (c *WombatConverter) convertSink(sink model.SinkStep) (string, error) {
 		return c.convertFileSink(sink)
 	case "database":
 		return c.convertDatabaseSink(sink)
+	case "stdout":
+		return c.convertStdoutSink(sink)
 	default:
 		return "", fmt.Errorf("unsupported sink type: %s", sink.Type)
 	}
@@ -198,6 +331,18 @@ func (c *WombatConverter) convertFileSink(sink model.SinkStep) (string, error) {
 	return config, nil
 }
 
+func (c *WombatConverter) convertStdoutSink(sink model.SinkStep) (string, error) {
+	config := "  stdout:\n"
+
+	if codec, ok := sink.Config["codec"]; ok {
+		config += fmt.Sprintf("    codec: \"%v\"\n", codec)
+	} else {
+		config += "    codec: \"lines\"\n"
+	}
+
+	return config, nil
+}
+
 func (c *WombatConverter) convertDatabaseSink(sink model.SinkStep) (string, error) {
 	config := "  sql_insert:\n"
 
@@ -217,13 +362,18 @@ func (c *WombatConverter) convertDatabaseSink(sink model.SinkStep) (string, erro
 }
 
 func (c *WombatConverter) convertProducer(producer model.ProducerStep) (string, error) {
+	urls, err := c.convertNatsUrls(producer.Nats)
+	if err != nil {
+		return "", err
+	}
+
 	config := "  nats:\n"
-	config += fmt.Sprintf("    urls: [\"%s\"]\n", producer.Nats.Url)
+	config += urls
 
 	if producer.Core != nil {
-		config += fmt.Sprintf("    subject: \"%s\"\n", producer.Core.Subject)
+		config += fmt.Sprintf("    subject: %s\n", strconv.Quote(producer.Core.Subject))
 	} else if producer.Stream != nil {
-		config += fmt.Sprintf("    subject: \"%s\"\n", producer.Stream.Subject)
+		config += fmt.Sprintf("    subject: %s\n", strconv.Quote(producer.Stream.Subject))
 		config += "    stream:\n"
 		config += "      enabled: true\n"
 	} else if producer.Kv != nil {
@@ -233,26 +383,659 @@ func (c *WombatConverter) convertProducer(producer model.ProducerStep) (string,
 		}
 	}
 
-	// Add auth if configured
-	if producer.Nats.AuthEnabled {
-		if producer.Nats.Jwt != nil && producer.Nats.Seed != nil {
-			config += "    auth:\n"
-			config += fmt.Sprintf("      nkey_file: \"%s\"\n", *producer.Nats.Seed)
-			config += fmt.Sprintf("      user_jwt: \"%s\"\n", *producer.Nats.Jwt)
+	if producer.Core != nil || producer.Stream != nil {
+		config += c.convertProducerHeaders(producer.Headers)
+	}
+
+	auth, err := c.convertNatsAuth(producer.Nats)
+	if err != nil {
+		return "", err
+	}
+	config += auth
+
+	return config, nil
+}
+
+// convertNatsUrls converts the url of the configuration. Wombat only reads the user and password or token from the
+// url, so the url refers to an environment variable holding them rather than showing them in the configuration.
+func (c *WombatConverter) convertNatsUrls(cfg model.NatsConfig) (string, error) {
+	natsUrl := cfg.Url
+	if cfg.User != nil || cfg.Token != nil {
+		u, err := url.Parse(cfg.Url)
+		if err != nil {
+			return "", fmt.Errorf("invalid nats url: %w", err)
+		}
+
+		var userInfo *url.Userinfo
+		switch {
+		case cfg.User != nil && cfg.Password != nil:
+			userInfo = url.UserPassword(*cfg.User, *cfg.Password)
+		case cfg.User != nil:
+			userInfo = url.User(*cfg.User)
+		default:
+			userInfo = url.User(*cfg.Token)
+		}
+
+		u.User = nil
+		natsUrl = fmt.Sprintf("%s://%s@%s", u.Scheme, c.secretEnv("USERINFO", userInfo.String()), strings.TrimPrefix(u.String(), u.Scheme+"://"))
+	}
+	return fmt.Sprintf("    urls: [%s]\n", strconv.Quote(natsUrl)), nil
+}
+
+// convertNatsAuth converts the TLS settings and the JWT and seed, credentials file or nkey of the configuration. The
+// JWT and seed are passed in environment variables like the user and password. Wombat cannot read credentials from a
+// secret.
+func (c *WombatConverter) convertNatsAuth(cfg model.NatsConfig) (string, error) {
+	var config string
+
+	if cfg.Tls != nil {
+		config += "    tls:\n"
+		config += "      enabled: true\n"
+		if cfg.Tls.InsecureSkipVerify {
+			config += "      skip_cert_verify: true\n"
+		}
+		if cfg.Tls.CaFile != nil {
+			config += fmt.Sprintf("      root_cas_file: %s\n", strconv.Quote(*cfg.Tls.CaFile))
+		}
+		if cfg.Tls.CertFile != nil && cfg.Tls.KeyFile != nil {
+			config += "      client_certs:\n"
+			config += fmt.Sprintf("        - cert_file: %s\n", strconv.Quote(*cfg.Tls.CertFile))
+			config += fmt.Sprintf("          key_file: %s\n", strconv.Quote(*cfg.Tls.KeyFile))
+		}
+	}
+
+	switch {
+	case cfg.AuthEnabled && cfg.Jwt != nil && cfg.Seed != nil:
+		config += "    auth:\n"
+		config += fmt.Sprintf("      user_jwt: %s\n", strconv.Quote(c.secretEnv("JWT", *cfg.Jwt)))
+		config += fmt.Sprintf("      user_nkey_seed: %s\n", strconv.Quote(c.secretEnv("SEED", *cfg.Seed)))
+	case cfg.Creds != nil && cfg.Creds.Secret != nil:
+		return "", fmt.Errorf("credentials from a secret are not supported by wombat, use a creds file")
+	case cfg.Creds != nil && cfg.Creds.File != nil:
+		config += "    auth:\n"
+		config += fmt.Sprintf("      user_credentials_file: %s\n", strconv.Quote(*cfg.Creds.File))
+	case cfg.Nkey != nil:
+		config += "    auth:\n"
+		config += fmt.Sprintf("      nkey_file: %s\n", strconv.Quote(*cfg.Nkey))
+	}
+
+	return config, nil
+}
+
+// convertBroker converts the outputs of the broker into a list of outputs with their own processors. The fallback
+// pattern maps to the fallback output, the other patterns to the broker output. The errors section applies to every
+// output on its own.
+func (c *WombatConverter) convertBroker(broker model.BrokerStep, policy *model.ErrorPolicy) (string, error) {
+	var outputs strings.Builder
+	for i, output := range broker.Outputs {
+		outputConfig, err := c.convertOutput(output, policy, brokerOutputLabel(i))
+		if err != nil {
+			return "", fmt.Errorf("failed to convert output %d: %w", i, err)
+		}
+		outputs.WriteString(listItem(outputConfig))
+	}
+
+	switch broker.Pattern {
+	case model.BrokerStepPatternFallback:
+		return "  fallback:\n" + outputs.String(), nil
+	case "", model.BrokerStepPatternFanOut, model.BrokerStepPatternRoundRobin:
+		pattern := broker.Pattern
+		if pattern == "" {
+			pattern = model.BrokerStepPatternFanOut
+		}
+
+		config := "  broker:\n"
+		config += fmt.Sprintf("    pattern: \"%s\"\n", pattern)
+		config += "    outputs:\n"
+		config += indent(outputs.String(), 2)
+		return config, nil
+	default:
+		return "", fmt.Errorf("unsupported broker pattern: %s", broker.Pattern)
+	}
+}
+
+// convertRouter converts the routes into the cases of a switch output, checking the conditions in order. The default
+// route becomes the last case, without a check.
+func (c *WombatConverter) convertRouter(router model.RouterStep, policy *model.ErrorPolicy) (string, error) {
+	config := "  switch:\n"
+	config += "    cases:\n"
+
+	for i, route := range router.Routes {
+		outputConfig, err := c.convertOutput(route.Output, policy, routeLabel(i))
+		if err != nil {
+			return "", fmt.Errorf("failed to convert route %d: %w", i, err)
+		}
+		config += fmt.Sprintf("      - check: %s\n", strconv.Quote(route.Condition))
+		config += "        output:\n"
+		config += indent(outputConfig, 8)
+	}
+
+	if router.Default != nil {
+		outputConfig, err := c.convertOutput(*router.Default, policy, defaultRouteLabel)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert default route: %w", err)
+		}
+		config += "      - output:\n"
+		config += indent(outputConfig, 8)
+	}
+
+	return config, nil
+}
+
+// convertOutput converts a sink or producer, the output of a broker or router, with the transformer of the output as
+// its processors. Batches are formed by a broker wrapping the output, within the retries of the errors section. A
+// rate limit refers to the resource with the given label. The schema of a producer is checked after the transformer,
+// and the messages which do not match go to the dead letter producer unless they are dropped.
+func (c *WombatConverter) convertOutput(output model.OutputStep, policy *model.ErrorPolicy, label string) (string, error) {
+	var config, step string
+	var limit *model.RateLimit
+	var batching *model.Batching
+	var schema *model.MessageSchema
+	var err error
+	switch {
+	case output.Sink != nil:
+		step, limit, batching = "sink", output.Sink.RateLimit, output.Sink.Batching
+		config, err = c.convertSink(*output.Sink)
+	case output.Producer != nil:
+		step, limit, batching, schema = "producer", output.Producer.RateLimit, output.Producer.Batching, output.Producer.Schema
+		config, err = c.convertProducer(*output.Producer)
+	default:
+		return "", fmt.Errorf("output must have either a sink or producer")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if batching != nil {
+		config = c.convertBatching(*batching, config)
+	}
+
+	if policy != nil {
+		if config, err = c.convertErrors(*policy, step, config); err != nil {
+			return "", fmt.Errorf("failed to convert errors: %w", err)
+		}
+	}
+
+	if schema != nil && schema.OnFailure != model.MessageSchemaOnFailureDrop {
+		if config, err = c.convertSchemaDeadLetter(policy, step, config); err != nil {
+			return "", err
+		}
+	}
+
+	var processors string
+	if limit != nil {
+		processors += "    - rate_limit:\n"
+		processors += fmt.Sprintf("        resource: \"%s\"\n", label)
+	}
+	if output.Transformer != nil {
+		transformer, err := c.convertTransformer(*output.Transformer, outputTransformerLabel(label))
+		if err != nil {
+			return "", fmt.Errorf("failed to convert transformer: %w", err)
+		}
+		processors += transformer
+	}
+	if schema != nil {
+		validation, err := c.convertMessageSchema(*schema)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert schema: %w", err)
+		}
+		processors += validation
+	}
+	if processors != "" {
+		config += "  processors:\n"
+		config += processors
+	}
+
+	return config, nil
+}
+
+// convertBatching wraps the output in a broker which batches its messages.
+func (c *WombatConverter) convertBatching(batching model.Batching, output string) string {
+	config := "  broker:\n"
+	config += "    outputs:\n"
+	config += indent(listItem(output), 2)
+	config += "    batching:\n"
+	if batching.Count != nil {
+		config += fmt.Sprintf("      count: %d\n", *batching.Count)
+	}
+	if batching.ByteSize != nil {
+		config += fmt.Sprintf("      byte_size: %d\n", *batching.ByteSize)
+	}
+	if batching.Period != nil {
+		config += fmt.Sprintf("      period: \"%s\"\n", *batching.Period)
+	}
+	return config
+}
+
+// convertRateLimits converts the rate limits of the sinks, producers and service transformers into local rate limit
+// resources, labelled like the processors of convertOutput and convertServiceTransformer refer to them.
+func (c *WombatConverter) convertRateLimits(steps model.Steps) (string, error) {
+	var config strings.Builder
+	var errs []error
+	add := func(label string, limit *model.RateLimit) {
+		if limit == nil {
+			return
+		}
+		resource, err := rateLimitResource(label, *limit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid rate limit of %s: %w", label, err))
+			return
+		}
+		config.WriteString(resource)
+	}
+
+	var addTransformer func(label string, transformer *model.TransformerStep)
+	addTransformer = func(label string, transformer *model.TransformerStep) {
+		switch {
+		case transformer == nil:
+		case transformer.Service != nil:
+			add(label, transformer.Service.RateLimit)
+		case transformer.Composite != nil:
+			for i := range transformer.Composite.Sequential {
+				addTransformer(compositeLabel(label, i), &transformer.Composite.Sequential[i])
+			}
+		}
+	}
+
+	addOutput := func(label string, output model.OutputStep) {
+		addTransformer(outputTransformerLabel(label), output.Transformer)
+		switch {
+		case output.Sink != nil:
+			add(label, output.Sink.RateLimit)
+		case output.Producer != nil:
+			add(label, output.Producer.RateLimit)
+		}
+	}
+
+	addTransformer(transformerLabel, steps.Transformer)
+	if steps.Sink != nil {
+		addOutput("sink", model.OutputStep{Sink: steps.Sink})
+	}
+	if steps.Producer != nil {
+		addOutput("producer", model.OutputStep{Producer: steps.Producer})
+	}
+	if steps.Broker != nil {
+		for i, output := range steps.Broker.Outputs {
+			addOutput(brokerOutputLabel(i), output)
+		}
+	}
+	if steps.Router != nil {
+		for i, route := range steps.Router.Routes {
+			addOutput(routeLabel(i), route.Output)
+		}
+		if steps.Router.Default != nil {
+			addOutput(defaultRouteLabel, *steps.Router.Default)
+		}
+	}
+
+	return config.String(), errors.Join(errs...)
+}
+
+// rateLimitResource converts a rate limit into a local rate limit resource. Wombat has no notion of a burst, it allows
+// the count at once every interval. A burst other than the count is approximated by allowing the burst at once every
+// time the count would have refilled it, so 100 per minute with a burst of 10 becomes 10 every 6s.
+func rateLimitResource(label string, limit model.RateLimit) (string, error) {
+	period := limit.Period
+	if period == "" {
+		period = "1s"
+	}
+
+	count, interval := limit.Count, period
+	if limit.Burst != nil && *limit.Burst != limit.Count {
+		d, err := time.ParseDuration(period)
+		if err != nil {
+			return "", fmt.Errorf("invalid period %q: %w", period, err)
 		}
+		count = *limit.Burst
+		interval = (d * time.Duration(count) / time.Duration(limit.Count)).String()
 	}
 
+	config := fmt.Sprintf("  - label: \"%s\"\n", label)
+	config += "    local:\n"
+	config += fmt.Sprintf("      count: %d\n", count)
+	config += fmt.Sprintf("      interval: \"%s\"\n", interval)
 	return config, nil
 }
 
-func (c *WombatConverter) convertTransformer(transformer model.TransformerStep) (string, error) {
+const (
+	defaultRouteLabel = "default_route"
+	transformerLabel  = "transformer"
+)
+
+func brokerOutputLabel(i int) string {
+	return fmt.Sprintf("broker_output_%d", i)
+}
+
+func routeLabel(i int) string {
+	return fmt.Sprintf("route_%d", i)
+}
+
+func outputTransformerLabel(output string) string {
+	return output + "_transformer"
+}
+
+func compositeLabel(composite string, i int) string {
+	return fmt.Sprintf("%s_%d", composite, i)
+}
+
+// convertProducerHeaders writes the metadata matching the propagate patterns as headers, case-insensitively like the
+// runtime, and adds the static headers and the Nats-Msg-Id. Without propagate patterns the metadata is left to the
+// defaults of wombat.
+func (c *WombatConverter) convertProducerHeaders(headers *model.ProducerStepHeaders) string {
+	var config string
+	if headers != nil && headers.Propagate != nil {
+		patterns := []string{}
+		for _, p := range headers.Propagate {
+			patterns = append(patterns, strconv.Quote("(?i)^"+headerPattern(p)+"$"))
+		}
+		config += "    metadata:\n"
+		config += fmt.Sprintf("      include_patterns: [%s]\n", strings.Join(patterns, ", "))
+	}
+
+	if headers == nil || (len(headers.Static) == 0 && headers.MsgId == nil) {
+		return config
+	}
+
+	config += "    headers:\n"
+	for _, k := range slices.Sorted(maps.Keys(headers.Static)) {
+		config += fmt.Sprintf("      %s: %s\n", strconv.Quote(k), strconv.Quote(headers.Static[k]))
+	}
+	if headers.MsgId != nil {
+		config += fmt.Sprintf("      %s: %s\n", strconv.Quote(jetstream.MsgIDHeader), strconv.Quote(*headers.MsgId))
+	}
+	return config
+}
+
+// convertConsumerHeaders converts the headers of the consumer into the processors of the input, dropping the metadata
+// which is not propagated and adding the static headers. The nats_ metadata of the input is always kept.
+func (c *WombatConverter) convertConsumerHeaders(headers model.ConsumerStepHeaders) (string, error) {
+	var lines []string
+	if headers.Propagate != nil {
+		patterns := []string{}
+		for _, p := range headers.Propagate {
+			patterns = append(patterns, headerPattern(p))
+		}
+		lines = append(lines, fmt.Sprintf(`meta = @.filter(kv -> kv.key.has_prefix("nats_") || kv.key.re_match(%s))`,
+			strconv.Quote("(?i)^("+strings.Join(patterns, "|")+")$")))
+	}
+	for _, k := range slices.Sorted(maps.Keys(headers.Static)) {
+		lines = append(lines, fmt.Sprintf(`meta %s = %s`, strconv.Quote(k), strconv.Quote(headers.Static[k])))
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	processors, err := c.convertMappingTransformer(model.MappingTransformerStep{Sourcecode: strings.Join(lines, "\n")})
+	if err != nil {
+		return "", err
+	}
+	return processors, nil
+}
+
+// headerPattern turns a propagate pattern into a regular expression, matching any suffix for a trailing *.
+func headerPattern(pattern string) string {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return regexp.QuoteMeta(prefix) + ".*"
+	}
+	return regexp.QuoteMeta(pattern)
+}
+
+// convertErrors wraps the output in a retry with exponential backoff and, with a dead letter producer, a fallback
+// to the producer which sets the error headers. Without retries the output is used as it is, since wombat retries
+// forever when max_retries is 0. An unset max_retries retries runtime.DefaultMaxRetries times.
+func (c *WombatConverter) convertErrors(policy model.ErrorPolicy, step string, output string) (string, error) {
+	maxRetries := runtime.DefaultMaxRetries
+	if policy.MaxRetries != nil {
+		maxRetries = *policy.MaxRetries
+	}
+	backoff := policy.Backoff
+	if backoff == "" {
+		backoff = "1s"
+	}
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff == "" {
+		maxBackoff = "30s"
+	}
+
+	retry := output
+	if maxRetries > 0 {
+		retry = "  retry:\n"
+		retry += fmt.Sprintf("    max_retries: %d\n", maxRetries)
+		retry += "    backoff:\n"
+		retry += fmt.Sprintf("      initial_interval: \"%s\"\n", backoff)
+		retry += fmt.Sprintf("      max_interval: \"%s\"\n", maxBackoff)
+		retry += "    output:\n"
+		retry += indent(output, 4)
+	}
+
+	if policy.DeadLetter == nil {
+		return retry, nil
+	}
+
+	deadLetter, err := c.convertProducer(*policy.DeadLetter)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert dead letter producer: %w", err)
+	}
+
+	headers, err := c.convertMappingTransformer(model.MappingTransformerStep{Sourcecode: strings.Join([]string{
+		fmt.Sprintf(`meta "%s" = @fallback_error`, runtime.ErrorHeader),
+		fmt.Sprintf(`meta "%s" = "%s"`, runtime.ErrorStepHeader, step),
+		fmt.Sprintf(`meta "%s" = "%d"`, runtime.ErrorAttemptsHeader, maxRetries+1),
+	}, "\n")})
+	if err != nil {
+		return "", err
+	}
+
+	config := "  fallback:\n"
+	config += listItem(retry)
+	config += listItem(deadLetter)
+	config += "      processors:\n"
+	config += indent(headers, 4)
+	return config, nil
+}
+
+// schemaFailureMeta is the metadata flagging the messages which did not match a schema sent to the dead letter
+// producer, telling them apart from the messages failed by a transformer.
+const schemaFailureMeta = "connect_schema_failure"
+
+// convertMessageSchema converts a schema into processors flagging the messages which do not match with an error, and
+// deleting them if they are dropped. Otherwise, the messages which were not failed before are checked and the ones
+// which do not match are flagged with schemaFailureMeta. Avro and Protobuf payloads are decoded in a branch, which
+// leaves the message as it is. Wombat reads the schemas itself, so only inline and file schemas are supported, and
+// only files for Protobuf.
+func (c *WombatConverter) convertMessageSchema(schema model.MessageSchema) (string, error) {
+	if schema.ObjectStore != nil {
+		return "", fmt.Errorf("object store schemas are not supported by wombat, fetch the schema with 'connect schema get' and use a file")
+	}
+
+	var source string
+	switch {
+	case schema.Inline != nil:
+		source = fmt.Sprintf("schema: %s\n", strconv.Quote(*schema.Inline))
+	case schema.File != nil:
+		source = fmt.Sprintf("schema_path: %s\n", strconv.Quote("file://"+*schema.File))
+	default:
+		return "", fmt.Errorf("the schema has no inline or file definition")
+	}
+
+	var config string
+	switch schema.Format {
+	case model.MessageSchemaFormatJsonSchema:
+		config = "    - json_schema:\n"
+		config += indent(source, 8)
+	case model.MessageSchemaFormatAvro:
+		config = "    - branch:\n"
+		config += "        processors:\n"
+		config += "          - avro:\n"
+		config += "              operator: \"from_json\"\n"
+		config += "              encoding: \"textual\"\n"
+		config += indent(source, 14)
+	case model.MessageSchemaFormatProtobuf:
+		if schema.File == nil {
+			return "", fmt.Errorf("inline protobuf schemas are not supported by wombat, use a file")
+		}
+		message := ""
+		if schema.Message != nil {
+			message = *schema.Message
+		}
+		config = "    - branch:\n"
+		config += "        processors:\n"
+		config += "          - protobuf:\n"
+		config += "              operator: \"to_json\"\n"
+		config += fmt.Sprintf("              message: %s\n", strconv.Quote(message))
+		config += fmt.Sprintf("              import_paths: [%s]\n", strconv.Quote(filepath.Dir(*schema.File)))
+	default:
+		return "", fmt.Errorf("unsupported schema format: %s", schema.Format)
+	}
+
+	if schema.OnFailure == model.MessageSchemaOnFailureDrop {
+		drop, err := c.convertMappingTransformer(model.MappingTransformerStep{Sourcecode: "root = if errored() { deleted() }"})
+		if err != nil {
+			return "", err
+		}
+		return config + drop, nil
+	}
+
+	flag, err := c.convertMappingTransformer(model.MappingTransformerStep{
+		Sourcecode: fmt.Sprintf(`meta %s = if errored() { "true" }`, schemaFailureMeta),
+	})
+	if err != nil {
+		return "", err
+	}
+	return "    - switch:\n" +
+		"        - check: \"!errored()\"\n" +
+		"          processors:\n" +
+		indent(config+flag, 8), nil
+}
+
+// convertSchemaDeadLetter wraps the output in a switch sending the messages flagged by the schema of the step to the
+// dead letter producer of the errors section, with the error headers set in place of the flag.
+func (c *WombatConverter) convertSchemaDeadLetter(policy *model.ErrorPolicy, step string, output string) (string, error) {
+	if policy == nil || policy.DeadLetter == nil {
+		return "", fmt.Errorf("schema on_failure %s requires a dead_letter producer in the errors section", model.MessageSchemaOnFailureDeadLetter)
+	}
+
+	deadLetter, err := c.convertProducer(*policy.DeadLetter)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert dead letter producer: %w", err)
+	}
+
+	headers, err := c.convertMappingTransformer(model.MappingTransformerStep{Sourcecode: strings.Join([]string{
+		fmt.Sprintf(`meta "%s" = error()`, runtime.ErrorHeader),
+		fmt.Sprintf(`meta "%s" = "%s"`, runtime.ErrorStepHeader, step),
+		fmt.Sprintf(`meta "%s" = "0"`, runtime.ErrorAttemptsHeader),
+		fmt.Sprintf(`meta %s = deleted()`, schemaFailureMeta),
+	}, "\n")})
+	if err != nil {
+		return "", err
+	}
+
+	config := "  switch:\n"
+	config += "    cases:\n"
+	config += fmt.Sprintf("      - check: %s\n", strconv.Quote("@"+schemaFailureMeta+` == "true"`))
+	config += "        output:\n"
+	config += indent(deadLetter, 8)
+	config += "          processors:\n"
+	config += indent(headers, 8)
+	config += "      - output:\n"
+	config += indent(output, 8)
+	return config, nil
+}
+
+// indent indents every line of the configuration by n spaces.
+func indent(config string, n int) string {
+	prefix := strings.Repeat(" ", n)
+	lines := strings.SplitAfter(config, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// listItem turns a configuration indented by 2 spaces into an item of a list indented by 4 spaces.
+func listItem(config string) string {
+	return "    - " + strings.TrimPrefix(indent(config, 4), "      ")
+}
+
+// convertTransformer converts a transformer into processors. The label names the rate limit resource of a service
+// transformer, and is extended with the position of the transformers within a composite.
+func (c *WombatConverter) convertTransformer(transformer model.TransformerStep, label string) (string, error) {
 	if transformer.Mapping != nil {
 		return c.convertMappingTransformer(*transformer.Mapping)
 	}
+	if transformer.Service != nil {
+		return c.convertServiceTransformer(*transformer.Service, label)
+	}
+	if transformer.Composite != nil {
+		var config strings.Builder
+		for i, t := range transformer.Composite.Sequential {
+			processor, err := c.convertTransformer(t, compositeLabel(label, i))
+			if err != nil {
+				return "", err
+			}
+			config.WriteString(processor)
+		}
+		return config.String(), nil
+	}
 	// Add other transformer types as needed
 	return "", fmt.Errorf("unsupported transformer type")
 }
 
+// convertServiceTransformer converts a service transformer into a request to the service, replacing the message with
+// the response. A rate limit refers to the resource with the given label and counts messages, not requests. With
+// batching the messages are collected into batches, each sent as a single JSON array whose answer is split again.
+func (c *WombatConverter) convertServiceTransformer(service model.ServiceTransformerStep, label string) (string, error) {
+	urls, err := c.convertNatsUrls(service.Nats)
+	if err != nil {
+		return "", err
+	}
+	auth, err := c.convertNatsAuth(service.Nats)
+	if err != nil {
+		return "", err
+	}
+
+	timeout := service.Timeout
+	if timeout == "" {
+		timeout = "5s"
+	}
+
+	var config string
+	if service.RateLimit != nil {
+		config += "    - rate_limit:\n"
+		config += fmt.Sprintf("        resource: \"%s\"\n", label)
+	}
+
+	request := "    - nats_request_reply:\n"
+	request += indent(urls, 4)
+	request += fmt.Sprintf("        subject: %s\n", strconv.Quote(service.Endpoint))
+	request += fmt.Sprintf("        timeout: \"%s\"\n", timeout)
+	request += indent(auth, 4)
+	if service.Batching == nil {
+		return config + request, nil
+	}
+
+	config += "    - batched:\n"
+	config += "        policy:\n"
+	if service.Batching.Count != nil {
+		config += fmt.Sprintf("          count: %d\n", *service.Batching.Count)
+	}
+	if service.Batching.ByteSize != nil {
+		config += fmt.Sprintf("          byte_size: %d\n", *service.Batching.ByteSize)
+	}
+	if service.Batching.Period != nil {
+		config += fmt.Sprintf("          period: \"%s\"\n", *service.Batching.Period)
+	}
+	config += "        processors:\n"
+	config += "          - archive:\n"
+	config += "              format: json_array\n"
+	config += indent(request, 6)
+	config += "          - unarchive:\n"
+	config += "              format: json_array\n"
+	return config, nil
+}
+
 func (c *WombatConverter) convertMappingTransformer(mapping model.MappingTransformerStep) (string, error) {
 	config := "    - mapping: |\n"
 	// Add proper indentation to the mapping source code
@@ -0,0 +1,758 @@
+package standalone
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/builders"
+	"github.com/synadia-io/connect/convert"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("WombatConverter", func() {
+	natsConfig := model.NatsConfig{Url: "nats://localhost:4222"}
+	sink := &model.SinkStep{Type: "stdout"}
+	retries := func(n int) *int { return &n }
+
+	It("should convert a stream consumer with the defaults", func() {
+		stream := &model.ConsumerStepStream{Subject: "orders.>"}
+
+		config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: &model.ConsumerStep{Nats: natsConfig, Stream: stream}, Sink: sink})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(ContainSubstring("    subject: \"orders.>\"\n    stream:\n      enabled: true\n\n"))
+	})
+
+	It("should convert the jetstream consumer configuration", func() {
+		stream := builders.ConsumerStepStream("orders.>").
+			Stream("ORDERS").
+			Bind("outlet").
+			FilterSubjects("returns.>", "refunds.>").
+			StartSequence(42).
+			AckWait(30 * time.Second).
+			MaxDeliver(5).
+			MaxAckPending(100).
+			Build()
+
+		config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: &model.ConsumerStep{Nats: natsConfig, Stream: &stream}, Sink: sink})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(ContainSubstring(`    stream:
+      enabled: true
+      name: "ORDERS"
+      durable: "outlet"
+      bind: true
+      filter_subjects: ["returns.>", "refunds.>"]
+      deliver_policy: "by_start_sequence"
+      opt_start_seq: 42
+      ack_policy: "explicit"
+      ack_wait: "30s"
+      max_deliver: 5
+      max_ack_pending: 100
+`))
+	})
+
+	It("should keep the jetstream consumer configuration through the spec", func() {
+		stream := builders.ConsumerStepStream("orders.>").
+			Durable("outlet").
+			StartTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)).
+			AckPolicy(model.ConsumerStepStreamAckPolicyNone).
+			Build()
+		steps := model.Steps{Consumer: &model.ConsumerStep{Nats: natsConfig, Stream: &stream}, Sink: sink}
+
+		Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+	})
+
+	Describe("errors", func() {
+		consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}}
+		http := &model.SinkStep{Type: "http", Config: model.SinkStepConfig{"url": "http://localhost"}}
+
+		It("should retry the output", func() {
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer: consumer,
+				Sink:     http,
+				Errors:   &model.ErrorPolicy{MaxRetries: retries(5), Backoff: "100ms"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  retry:
+    max_retries: 5
+    backoff:
+      initial_interval: "100ms"
+      max_interval: "30s"
+    output:
+      http_client:
+        url: "http://localhost"
+        verb: "POST"
+`))
+		})
+
+		It("should fall back to the dead letter producer", func() {
+			deadLetter := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ProducerStepCore("orders.dlq")).
+				Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer: consumer,
+				Sink:     http,
+				Errors:   &model.ErrorPolicy{MaxRetries: retries(2), Backoff: "1s", MaxBackoff: "10s", DeadLetter: &deadLetter},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  fallback:
+    - retry:
+        max_retries: 2
+        backoff:
+          initial_interval: "1s"
+          max_interval: "10s"
+        output:
+          http_client:
+            url: "http://localhost"
+            verb: "POST"
+    - nats:
+        urls: ["nats://localhost:4222"]
+        subject: "orders.dlq"
+      processors:
+        - mapping: |
+            meta "Connect-Error" = @fallback_error
+            meta "Connect-Error-Step" = "sink"
+            meta "Connect-Error-Attempts" = "3"
+`))
+		})
+
+		It("should not retry the output without retries", func() {
+			deadLetter := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ProducerStepCore("orders.dlq")).
+				Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer: consumer,
+				Sink:     http,
+				Errors:   &model.ErrorPolicy{MaxRetries: retries(0), DeadLetter: &deadLetter},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).ToNot(ContainSubstring("retry:"))
+			Expect(config).To(ContainSubstring(`output:
+  fallback:
+    - http_client:
+        url: "http://localhost"
+        verb: "POST"
+    - nats:
+`))
+			Expect(config).To(ContainSubstring(`meta "Connect-Error-Attempts" = "1"`))
+		})
+
+		It("should keep the errors section through the spec", func() {
+			deadLetter := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Stream(builders.ProducerStepStream("orders.dlq")).
+				Build()
+			steps := model.Steps{
+				Consumer: consumer,
+				Sink:     http,
+				Errors:   &model.ErrorPolicy{MaxRetries: retries(2), Backoff: "1s", MaxBackoff: "10s", DeadLetter: &deadLetter},
+			}
+
+			Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+		})
+	})
+
+	Describe("broker", func() {
+		consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}}
+		stdout := builders.OutputStep().Sink(builders.SinkStep("stdout"))
+		mirror := builders.OutputStep().
+			Transformer(builders.TransformerStep().Mapping(builders.MappingTransformerStep("root = this"))).
+			Producer(builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).Core(builders.ProducerStepCore("orders.copy")))
+
+		It("should fan out to every output with its own processors", func() {
+			broker := builders.BrokerStep().Output(stdout).Output(mirror).Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Broker: &broker})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  broker:
+    pattern: "fan_out"
+    outputs:
+      - stdout:
+          codec: "lines"
+      - nats:
+          urls: ["nats://localhost:4222"]
+          subject: "orders.copy"
+        processors:
+          - mapping: |
+              root = this
+`))
+		})
+
+		It("should convert the fallback pattern to a fallback output", func() {
+			broker := builders.BrokerStep().Pattern(model.BrokerStepPatternFallback).Output(mirror).Output(stdout).Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Broker: &broker})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  fallback:
+    - nats:
+        urls: ["nats://localhost:4222"]
+        subject: "orders.copy"
+      processors:
+        - mapping: |
+            root = this
+    - stdout:
+        codec: "lines"
+`))
+		})
+
+		It("should retry every output", func() {
+			broker := builders.BrokerStep().Pattern(model.BrokerStepPatternRoundRobin).Output(stdout).Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer: consumer,
+				Broker:   &broker,
+				Errors:   &model.ErrorPolicy{MaxRetries: retries(1), Backoff: "1s", MaxBackoff: "1s"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  broker:
+    pattern: "round_robin"
+    outputs:
+      - retry:
+          max_retries: 1
+          backoff:
+            initial_interval: "1s"
+            max_interval: "1s"
+          output:
+            stdout:
+              codec: "lines"
+`))
+		})
+
+		It("should keep the broker through the spec", func() {
+			broker := builders.BrokerStep().Output(stdout).Output(mirror).Build()
+			steps := model.Steps{Consumer: consumer, Broker: &broker}
+
+			Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+		})
+	})
+
+	Describe("router", func() {
+		consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "events"}}
+		orders := builders.OutputStep().
+			Transformer(builders.TransformerStep().Mapping(builders.MappingTransformerStep("root = this.order"))).
+			Producer(builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).Core(builders.ProducerStepCore("orders")))
+		stdout := builders.OutputStep().Sink(builders.SinkStep("stdout"))
+
+		It("should convert the routes into switch cases", func() {
+			router := builders.RouterStep().
+				Route(`this.type == "order"`, orders).
+				Default(stdout).
+				Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Router: &router})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  switch:
+    cases:
+      - check: "this.type == \"order\""
+        output:
+          nats:
+            urls: ["nats://localhost:4222"]
+            subject: "orders"
+          processors:
+            - mapping: |
+                root = this.order
+      - output:
+          stdout:
+            codec: "lines"
+`))
+		})
+
+		It("should keep the router through the spec", func() {
+			router := builders.RouterStep().Route(`@kind == "refund"`, stdout).Default(orders).Build()
+			steps := model.Steps{Consumer: consumer, Router: &router}
+
+			Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+		})
+	})
+
+	Describe("headers", func() {
+		It("should filter the consumed metadata and add static headers", func() {
+			consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}, Headers: &model.ConsumerStepHeaders{
+				Propagate: []string{"Trace-Id", "X-*"},
+				Static:    model.ConsumerStepHeadersStatic{"Source": "crm"},
+			}}
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Sink: sink})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`input:
+  nats:
+    urls: ["nats://localhost:4222"]
+    subject: "orders"
+  processors:
+    - mapping: |
+        meta = @.filter(kv -> kv.key.has_prefix("nats_") || kv.key.re_match("(?i)^(Trace-Id|X-.*)$"))
+        meta "Source" = "crm"
+`))
+		})
+
+		It("should template the subject and set the produced headers", func() {
+			source := &model.SourceStep{Type: "http", Config: model.SourceStepConfig{}}
+			msgId := `${! json("id") }`
+			producer := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Stream(builders.ProducerStepStream(`orders.${! meta("region") }`)).
+				Headers(builders.ProducerStepHeaders().Propagate("Trace-Id").Static("Source", "crm").MsgId(msgId)).
+				Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Source: source, Producer: &producer})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  nats:
+    urls: ["nats://localhost:4222"]
+    subject: "orders.${! meta(\"region\") }"
+    stream:
+      enabled: true
+    metadata:
+      include_patterns: ["(?i)^Trace-Id$"]
+    headers:
+      "Source": "crm"
+      "Nats-Msg-Id": "${! json(\"id\") }"
+`))
+		})
+
+		It("should keep the headers through the spec", func() {
+			msgId := `${! meta("id") }`
+			steps := model.Steps{
+				Consumer: &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}, Headers: &model.ConsumerStepHeaders{
+					Propagate: []string{"Trace-Id"},
+				}},
+				Producer: &model.ProducerStep{Nats: natsConfig, Stream: &model.ProducerStepStream{Subject: "copy"}, Headers: &model.ProducerStepHeaders{
+					Static: model.ProducerStepHeadersStatic{"Source": "crm"},
+					MsgId:  &msgId,
+				}},
+			}
+
+			Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+		})
+	})
+
+	Describe("throttling", func() {
+		consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}}
+		http := builders.SinkStep("http").SetString("url", "http://localhost")
+
+		It("should rate limit the sink with a resource", func() {
+			limited := http.RateLimit(builders.RateLimit(10).Period(time.Minute)).Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Sink: &limited})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  http_client:
+    url: "http://localhost"
+    verb: "POST"
+  processors:
+    - rate_limit:
+        resource: "sink"
+`))
+			Expect(config).To(ContainSubstring(`rate_limit_resources:
+  - label: "sink"
+    local:
+      count: 10
+      interval: "1m0s"
+`))
+		})
+
+		It("should batch the messages within the retries", func() {
+			batched := builders.SinkStep("stdout").Batching(builders.Batching().Count(100).Period(time.Second)).Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer: consumer,
+				Sink:     &batched,
+				Errors:   &model.ErrorPolicy{MaxRetries: retries(1), Backoff: "1s", MaxBackoff: "1s"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`output:
+  retry:
+    max_retries: 1
+    backoff:
+      initial_interval: "1s"
+      max_interval: "1s"
+    output:
+      broker:
+        outputs:
+          - stdout:
+              codec: "lines"
+        batching:
+          count: 100
+          period: "1s"
+`))
+		})
+
+		It("should label the rate limits of broker outputs", func() {
+			limited := builders.SinkStep("stdout").RateLimit(builders.RateLimit(5))
+			broker := builders.BrokerStep().
+				Output(builders.OutputStep().Sink(builders.SinkStep("stdout"))).
+				Output(builders.OutputStep().Sink(limited)).
+				Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Broker: &broker})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`        processors:
+          - rate_limit:
+              resource: "broker_output_1"
+`))
+			Expect(config).To(ContainSubstring(`rate_limit_resources:
+  - label: "broker_output_1"
+    local:
+      count: 5
+      interval: "1s"
+`))
+		})
+
+		It("should approximate the burst of a rate limit", func() {
+			limited := http.RateLimit(builders.RateLimit(100).Period(time.Minute).Burst(10)).Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Sink: &limited})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`rate_limit_resources:
+  - label: "sink"
+    local:
+      count: 10
+      interval: "6s"
+`))
+		})
+
+		It("should rate limit the service transformers with a resource", func() {
+			service := builders.ServiceTransformerStep("svc.enrich", builders.NatsConfig().Url("nats://localhost:4222")).
+				Timeout("1s").
+				RateLimit(builders.RateLimit(5))
+			transformer := builders.TransformerStep().Composite(builders.CompositeTransformerStep().Sequential(
+				builders.TransformerStep().Mapping(builders.MappingTransformerStep("root = this")),
+				builders.TransformerStep().Service(service),
+			)).Build()
+			sink := http.Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Transformer: &transformer, Sink: &sink})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`pipeline:
+  processors:
+    - mapping: |
+        root = this
+    - rate_limit:
+        resource: "transformer_1"
+    - nats_request_reply:
+        urls: ["nats://localhost:4222"]
+        subject: "svc.enrich"
+        timeout: "1s"
+`))
+			Expect(config).To(ContainSubstring(`rate_limit_resources:
+  - label: "transformer_1"
+    local:
+      count: 5
+      interval: "1s"
+`))
+		})
+
+		It("should send batches to the service transformers", func() {
+			service := builders.ServiceTransformerStep("svc.enrich", builders.NatsConfig().Url("nats://localhost:4222")).
+				RateLimit(builders.RateLimit(20)).
+				Batching(builders.Batching().Count(10).Period(time.Second))
+			transformer := builders.TransformerStep().Service(service).Build()
+			sink := http.Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Transformer: &transformer, Sink: &sink})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`pipeline:
+  processors:
+    - rate_limit:
+        resource: "transformer"
+    - batched:
+        policy:
+          count: 10
+          period: "1s"
+        processors:
+          - archive:
+              format: json_array
+          - nats_request_reply:
+              urls: ["nats://localhost:4222"]
+              subject: "svc.enrich"
+              timeout: "5s"
+          - unarchive:
+              format: json_array
+`))
+		})
+
+		It("should keep the rate limits and batching through the spec", func() {
+			limited := http.RateLimit(builders.RateLimit(10).Burst(20)).Batching(builders.Batching().Count(10)).Build()
+			producer := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ProducerStepCore("orders.copy")).
+				RateLimit(builders.RateLimit(100).Period(time.Minute)).
+				Build()
+			transformer := builders.TransformerStep().Service(
+				builders.ServiceTransformerStep("svc.enrich", builders.NatsConfig().Url("nats://localhost:4222")).
+					Batching(builders.Batching().ByteSize(1024)),
+			).Build()
+			steps := model.Steps{Consumer: consumer, Transformer: &transformer, Broker: &model.BrokerStep{
+				Pattern: model.BrokerStepPatternFanOut,
+				Outputs: []model.OutputStep{{Sink: &limited}, {Producer: &producer}},
+			}}
+
+			Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+		})
+	})
+
+	Describe("schemas", func() {
+		order := `{"type": "object", "required": ["id"]}`
+		deadLetter := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+			Core(builders.ProducerStepCore("orders.invalid")).
+			Build()
+
+		It("should drop the consumed messages which do not match", func() {
+			consumer := builders.ConsumerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ConsumerStepCore("orders")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatJsonSchema).Inline(order).OnFailure(model.MessageSchemaOnFailureDrop)).
+				Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: &consumer, Sink: sink})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`  processors:
+    - json_schema:
+        schema: "{\"type\": \"object\", \"required\": [\"id\"]}"
+    - mapping: |
+        root = if errored() { deleted() }
+`))
+		})
+
+		It("should send the consumed messages which do not match to the dead letter producer", func() {
+			consumer := builders.ConsumerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ConsumerStepCore("orders")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatAvro).File("schemas/order.avsc")).
+				Build()
+			transformer := builders.TransformerStep().Mapping(builders.MappingTransformerStep("root = this")).Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer:    &consumer,
+				Transformer: &transformer,
+				Sink:        sink,
+				Errors:      &model.ErrorPolicy{DeadLetter: &deadLetter},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`  processors:
+    - switch:
+        - check: "!errored()"
+          processors:
+            - branch:
+                processors:
+                  - avro:
+                      operator: "from_json"
+                      encoding: "textual"
+                      schema_path: "file://schemas/order.avsc"
+            - mapping: |
+                meta connect_schema_failure = if errored() { "true" }
+`))
+			Expect(config).To(ContainSubstring(`pipeline:
+  processors:
+    - switch:
+        - check: "@connect_schema_failure != \"true\""
+          processors:
+            - mapping: |
+                root = this
+`))
+			Expect(config).To(ContainSubstring(`output:
+  switch:
+    cases:
+      - check: "@connect_schema_failure == \"true\""
+        output:
+          nats:
+            urls: ["nats://localhost:4222"]
+            subject: "orders.invalid"
+`))
+			Expect(config).To(ContainSubstring(`          processors:
+            - mapping: |
+                meta "Connect-Error" = error()
+                meta "Connect-Error-Step" = "consumer"
+                meta "Connect-Error-Attempts" = "0"
+                meta connect_schema_failure = deleted()
+      - output:
+          fallback:
+`))
+		})
+
+		It("should send the produced messages which do not match to the dead letter producer", func() {
+			producer := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ProducerStepCore("orders.copy")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatJsonSchema).Inline(order)).
+				Build()
+			transformer := builders.TransformerStep().Mapping(builders.MappingTransformerStep("root = this")).Build()
+			consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}}
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer:    consumer,
+				Transformer: &transformer,
+				Producer:    &producer,
+				Errors:      &model.ErrorPolicy{DeadLetter: &deadLetter},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`- check: "@connect_schema_failure == \"true\""`))
+			Expect(config).To(ContainSubstring(`meta "Connect-Error-Step" = "producer"`))
+			Expect(config).To(ContainSubstring(`pipeline:
+  processors:
+    - mapping: |
+        root = this
+`))
+			Expect(config).To(ContainSubstring(`  processors:
+    - switch:
+        - check: "!errored()"
+          processors:
+            - json_schema:
+                schema: "{\"type\": \"object\", \"required\": [\"id\"]}"
+            - mapping: |
+                meta connect_schema_failure = if errored() { "true" }
+`))
+		})
+
+		It("should check the schema of a producer after its transformer", func() {
+			producer := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ProducerStepCore("orders.copy")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatProtobuf).File("schemas/order.proto").Message("shop.Order").OnFailure(model.MessageSchemaOnFailureDrop)).
+				Build()
+			consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}}
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Producer: &producer})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`  processors:
+    - branch:
+        processors:
+          - protobuf:
+              operator: "to_json"
+              message: "shop.Order"
+              import_paths: ["schemas"]
+    - mapping: |
+        root = if errored() { deleted() }
+`))
+		})
+
+		It("should require a dead letter producer", func() {
+			consumer := builders.ConsumerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ConsumerStepCore("orders")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatJsonSchema).Inline(order)).
+				Build()
+
+			_, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: &consumer, Sink: sink})
+			Expect(err).To(MatchError(ContainSubstring("requires a dead_letter producer in the errors section")))
+		})
+
+		It("should not support schemas of the object store", func() {
+			consumer := builders.ConsumerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ConsumerStepCore("orders")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatJsonSchema).ObjectStore("schemas", "order.json")).
+				Build()
+
+			_, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: &consumer, Sink: sink})
+			Expect(err).To(MatchError(ContainSubstring("connect schema get")))
+		})
+
+		It("should keep the schemas through the spec", func() {
+			consumer := builders.ConsumerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ConsumerStepCore("orders")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatAvro).ObjectStore("schemas", "order.avsc")).
+				Build()
+			producer := builders.ProducerStep(builders.NatsConfig().Url("nats://localhost:4222")).
+				Core(builders.ProducerStepCore("orders.copy")).
+				Schema(builders.MessageSchema(model.MessageSchemaFormatProtobuf).File("order.proto").Message("Order").OnFailure(model.MessageSchemaOnFailureDrop)).
+				Build()
+			steps := model.Steps{Consumer: &consumer, Producer: &producer, Errors: &model.ErrorPolicy{MaxRetries: retries(0), DeadLetter: &deadLetter}}
+
+			Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+		})
+	})
+
+	Describe("NATS configuration", func() {
+		It("should convert the TLS settings and credentials file", func() {
+			nats := builders.NatsConfig().Url("tls://nats.example.com:4222").
+				RootCAs("/certs/ca.pem").
+				ClientCert("/certs/client.pem", "/certs/client-key.pem").
+				CredsFile("/creds/user.creds").
+				Build()
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{
+				Consumer: &model.ConsumerStep{Nats: nats, Core: &model.ConsumerStepCore{Subject: "orders"}},
+				Sink:     sink,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`    tls:
+      enabled: true
+      root_cas_file: "/certs/ca.pem"
+      client_certs:
+        - cert_file: "/certs/client.pem"
+          key_file: "/certs/client-key.pem"
+    auth:
+      user_credentials_file: "/creds/user.creds"
+`))
+		})
+
+		It("should convert the nkey of a producer", func() {
+			producer := builders.ProducerStep(builders.NatsConfig().Nkey("/keys/user.nk").InsecureSkipVerify()).
+				Core(builders.ProducerStepCore("orders.copy")).
+				Build()
+			consumer := &model.ConsumerStep{Nats: natsConfig, Core: &model.ConsumerStepCore{Subject: "orders"}}
+
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Producer: &producer})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`    tls:
+      enabled: true
+      skip_cert_verify: true
+    auth:
+      nkey_file: "/keys/user.nk"
+`))
+		})
+
+		It("should pass the user and password or token in an environment variable of the url", func() {
+			converter := NewWombatConverter()
+			consumer := &model.ConsumerStep{Nats: builders.NatsConfig().UserInfo("app", "s3cr@t").Build(), Core: &model.ConsumerStepCore{Subject: "orders"}}
+			producer := &model.ProducerStep{Nats: builders.NatsConfig().UserInfo("app", "s3cr@t").Build(), Core: &model.ProducerStepCore{Subject: "copy"}}
+			config, err := converter.ConvertSteps(model.Steps{Consumer: consumer, Producer: producer})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`urls: ["nats://${CONNECT_NATS_USERINFO_0}@localhost:4222"]`))
+			Expect(config).ToNot(ContainSubstring("s3cr"))
+			Expect(converter.GetRuntimeEnv()).To(Equal(map[string]string{"CONNECT_NATS_USERINFO_0": "app:s3cr%40t"}))
+
+			consumer.Nats = builders.NatsConfig().Token("t0ken").Build()
+			config, err = converter.ConvertSteps(model.Steps{Consumer: consumer, Sink: sink})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`urls: ["nats://${CONNECT_NATS_USERINFO_0}@localhost:4222"]`))
+			Expect(converter.GetRuntimeEnv()).To(Equal(map[string]string{"CONNECT_NATS_USERINFO_0": "t0ken"}))
+		})
+
+		It("should pass the JWT and seed in environment variables", func() {
+			converter := NewWombatConverter()
+			nats := builders.NatsConfig().Url("nats://localhost:4222").Auth("eyJ0", "SUAB").Build()
+
+			config, err := converter.ConvertSteps(model.Steps{Consumer: &model.ConsumerStep{Nats: nats, Core: &model.ConsumerStepCore{Subject: "orders"}}, Sink: sink})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`    auth:
+      user_jwt: "${CONNECT_NATS_JWT_0}"
+      user_nkey_seed: "${CONNECT_NATS_SEED_1}"
+`))
+			Expect(converter.GetRuntimeEnv()).To(Equal(map[string]string{"CONNECT_NATS_JWT_0": "eyJ0", "CONNECT_NATS_SEED_1": "SUAB"}))
+		})
+
+		It("should quote the file paths", func() {
+			consumer := &model.ConsumerStep{Nats: builders.NatsConfig().CredsFile(`/creds/"user".creds`).Build(), Core: &model.ConsumerStepCore{Subject: "orders"}}
+			config, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Sink: sink})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(ContainSubstring(`user_credentials_file: "/creds/\"user\".creds"`))
+		})
+
+		It("should not support credentials from a secret", func() {
+			consumer := &model.ConsumerStep{Nats: builders.NatsConfig().CredsSecret("NATS_CREDS").Build(), Core: &model.ConsumerStepCore{Subject: "orders"}}
+			_, err := NewWombatConverter().ConvertSteps(model.Steps{Consumer: consumer, Sink: sink})
+			Expect(err).To(MatchError(ContainSubstring("use a creds file")))
+		})
+
+		It("should keep the NATS configuration through the spec", func() {
+			nats := builders.NatsConfig().RootCAs("ca.pem").ClientCert("client.pem", "client-key.pem").CredsSecret("NATS_CREDS").Build()
+			steps := model.Steps{
+				Consumer: &model.ConsumerStep{Nats: nats, Core: &model.ConsumerStepCore{Subject: "orders"}},
+				Producer: &model.ProducerStep{Nats: builders.NatsConfig().UserInfo("app", "s3cret").Build(), Core: &model.ProducerStepCore{Subject: "copy"}},
+			}
+
+			Expect(convert.ConvertStepsFromSpec(convert.ConvertStepsToSpec(steps))).To(Equal(steps))
+		})
+	})
+})
@@ -0,0 +1,39 @@
+package standalone
+
+import (
+	"fmt"
+
+	"github.com/synadia-io/connect/model"
+)
+
+// CaptureMapping turns each message into a JSON line with its subject, headers and payload. Payloads which are
+// JSON are embedded, others are kept as a string.
+const CaptureMapping = `root.subject = @nats_subject | ""
+root.headers = @
+root.payload = content().parse_json().catch(content().string())`
+
+// DryRunSteps rewrites the steps of a connector to capture what it would emit: the sink, producer, broker or router is
+// replaced by a sink writing each message as a JSON line to stdout. The source or consumer is left untouched.
+func DryRunSteps(steps model.Steps) (model.Steps, error) {
+	if steps.Source == nil && steps.Consumer == nil {
+		return model.Steps{}, fmt.Errorf("the connector has no source or consumer")
+	}
+
+	capture := model.TransformerStep{Mapping: &model.MappingTransformerStep{Sourcecode: CaptureMapping}}
+
+	result := model.Steps{
+		Source:   steps.Source,
+		Consumer: steps.Consumer,
+		Sink:     &model.SinkStep{Type: "stdout", Config: model.SinkStepConfig{"codec": "lines"}},
+	}
+
+	if steps.Transformer != nil {
+		result.Transformer = &model.TransformerStep{Composite: &model.CompositeTransformerStep{
+			Sequential: []model.TransformerStep{*steps.Transformer, capture},
+		}}
+	} else {
+		result.Transformer = &capture
+	}
+
+	return result, nil
+}
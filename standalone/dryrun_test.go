@@ -0,0 +1,46 @@
+package standalone
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/model"
+)
+
+var _ = Describe("DryRunSteps", func() {
+	consumer := &model.ConsumerStep{Nats: model.NatsConfig{Url: "nats://localhost:4222"}, Core: &model.ConsumerStepCore{Subject: "in"}}
+	producer := &model.ProducerStep{Nats: model.NatsConfig{Url: "nats://localhost:4222"}, Core: &model.ProducerStepCore{Subject: "out"}}
+
+	It("should replace the producer with a capture sink", func() {
+		steps, err := DryRunSteps(model.Steps{Consumer: consumer, Producer: producer})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(steps.Consumer).To(Equal(consumer))
+		Expect(steps.Producer).To(BeNil())
+		Expect(steps.Sink.Type).To(Equal("stdout"))
+		Expect(steps.Transformer.Mapping.Sourcecode).To(Equal(CaptureMapping))
+	})
+
+	It("should capture after the transformer", func() {
+		transformer := &model.TransformerStep{Mapping: &model.MappingTransformerStep{Sourcecode: "root = this"}}
+		source := &model.SourceStep{Type: "http", Config: model.SourceStepConfig{"address": ":8080"}}
+
+		steps, err := DryRunSteps(model.Steps{Source: source, Transformer: transformer, Sink: &model.SinkStep{Type: "http"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(steps.Source).To(Equal(source))
+		Expect(steps.Sink.Type).To(Equal("stdout"))
+		Expect(steps.Transformer.Composite.Sequential).To(HaveLen(2))
+		Expect(steps.Transformer.Composite.Sequential[0]).To(Equal(*transformer))
+		Expect(steps.Transformer.Composite.Sequential[1].Mapping.Sourcecode).To(Equal(CaptureMapping))
+
+		config, err := NewWombatConverter().ConvertSteps(steps)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(ContainSubstring("output:\n  stdout:\n    codec: \"lines\"\n"))
+		Expect(config).To(ContainSubstring("        root = this\n    - mapping: |\n        root.subject = @nats_subject"))
+	})
+
+	It("should require a source or consumer", func() {
+		_, err := DryRunSteps(model.Steps{Producer: producer})
+		Expect(err).To(HaveOccurred())
+	})
+})
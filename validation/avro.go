@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// avroSchema is a compiled Avro schema validating payloads in the JSON encoding of Avro, the encoding the avro
+// processor of wombat reads with from_json. Values of unions other than null are wrapped in an object naming their
+// branch, like {"string": "text"}.
+type avroSchema struct {
+	codec *goavro.Codec
+}
+
+func compileAvroSchema(definition []byte) (*avroSchema, error) {
+	codec, err := goavro.NewCodec(string(definition))
+	if err != nil {
+		return nil, err
+	}
+	return &avroSchema{codec: codec}, nil
+}
+
+// Validate validates a payload in the JSON encoding of Avro.
+func (s *avroSchema) Validate(data []byte) error {
+	_, rest, err := s.codec.NativeFromTextual(data)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return fmt.Errorf("unexpected data after the value")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// jsonSchema is a compiled JSON Schema. Schemas without a $schema keyword are compiled as draft 2020-12. Only local
+// $ref references are resolved; remote schemas are never loaded.
+type jsonSchema struct {
+	schema *jsonschema.Schema
+}
+
+// jsonSchemaURL is the location the definition is compiled at, which its local $ref references are resolved against.
+const jsonSchemaURL = "urn:connect:message-schema"
+
+var jsonSchemaPrinter = message.NewPrinter(language.English)
+
+func compileJsonSchema(definition []byte) (*jsonSchema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(definition))
+	if err != nil {
+		return nil, fmt.Errorf("the definition is not JSON: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := c.AddResource(jsonSchemaURL, doc); err != nil {
+		return nil, err
+	}
+
+	schema, err := c.Compile(jsonSchemaURL)
+	var invalid *jsonschema.SchemaValidationError
+	var unsupported *jsonschema.LoadURLError
+	switch {
+	case errors.As(err, &invalid):
+		if verr, ok := invalid.Err.(*jsonschema.ValidationError); ok {
+			return nil, fmt.Errorf("the definition is not a valid schema: %w", validationError(verr))
+		}
+		return nil, err
+	case errors.As(err, &unsupported):
+		return nil, fmt.Errorf("only local $ref references are supported, %s cannot be loaded", unsupported.URL)
+	case err != nil:
+		return nil, err
+	}
+
+	// a schema referencing itself without consuming any of the value can never be satisfied
+	var verr *jsonschema.ValidationError
+	if errors.As(schema.Validate(nil), &verr) && hasRefCycle(verr) {
+		return nil, fmt.Errorf("the $ref references of the schema form a cycle")
+	}
+	return &jsonSchema{schema: schema}, nil
+}
+
+// Validate validates a JSON payload.
+func (s *jsonSchema) Validate(data []byte) error {
+	v, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("the payload is not JSON: %w", err)
+	}
+
+	err = s.schema.Validate(v)
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+	return validationError(verr)
+}
+
+// validationError reports the first failing keyword, pointing at the offending value. Combinators with several
+// failing branches are reported as they are, rather than as the error of one of their branches.
+func validationError(verr *jsonschema.ValidationError) error {
+	for len(verr.Causes) > 0 && (len(verr.Causes) == 1 || isValidationGroup(verr.ErrorKind)) {
+		verr = verr.Causes[0]
+	}
+	return schemaError(jsonPointer(verr.InstanceLocation), "%s", verr.ErrorKind.LocalizedString(jsonSchemaPrinter))
+}
+
+func hasRefCycle(verr *jsonschema.ValidationError) bool {
+	if _, ok := verr.ErrorKind.(*kind.RefCycle); ok {
+		return true
+	}
+	return slices.ContainsFunc(verr.Causes, hasRefCycle)
+}
+
+// isValidationGroup reports whether the error only groups the errors of the keywords of a schema.
+func isValidationGroup(k jsonschema.ErrorKind) bool {
+	switch k.(type) {
+	case *kind.Schema, *kind.Group, *kind.Reference, *kind.AllOf:
+		return true
+	}
+	return false
+}
+
+func jsonPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return b.String()
+}
+
+// schemaError reports where in the payload it does not match the schema, as a JSON pointer.
+func schemaError(at string, format string, args ...any) error {
+	if at == "" {
+		at = "/"
+	}
+	return fmt.Errorf("%s: %s", at, fmt.Sprintf(format, args...))
+}
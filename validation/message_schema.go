@@ -0,0 +1,109 @@
+package validation
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/synadia-io/connect/spec"
+)
+
+// MessageSchema validates the payloads of messages against a compiled schema definition.
+type MessageSchema interface {
+	// Validate returns an error describing the first part of the payload which does not match the schema.
+	Validate(data []byte) error
+}
+
+// CompileMessageSchema compiles a JSON Schema, Avro or Protobuf definition. JSON Schema and Avro schemas validate JSON
+// payloads, using the JSON encoding of Avro for unions. Protobuf definitions validate payloads in the binary wire
+// format as the given message, which is required for them and ignored otherwise.
+func CompileMessageSchema(format string, definition []byte, message string) (MessageSchema, error) {
+	switch format {
+	case string(spec.MessageSchemaSpecFormatJsonSchema):
+		return compileJsonSchema(definition)
+	case string(spec.MessageSchemaSpecFormatAvro):
+		return compileAvroSchema(definition)
+	case string(spec.MessageSchemaSpecFormatProtobuf):
+		return compileProtobufSchema(definition, message)
+	default:
+		return nil, fmt.Errorf("unknown schema format %q", format)
+	}
+}
+
+// ValidateMessage validates a payload against the schema of a step without connecting to NATS, e.g. to check sample
+// messages before deploying a connector. Schemas stored in an object store are not available offline, they can be
+// fetched with connect schema get and referenced as a file instead.
+func (v *Validator) ValidateMessage(schema spec.MessageSchemaSpec, data []byte) error {
+	if schema.ObjectStore != nil {
+		return fmt.Errorf("schemas stored in the object store cannot be validated offline")
+	}
+
+	definition, err := LoadMessageSchemaDefinition(schema)
+	if err != nil {
+		return err
+	}
+
+	message := ""
+	if schema.Message != nil {
+		message = *schema.Message
+	}
+
+	compiled, err := CompileMessageSchema(string(schema.Format), definition, message)
+	if err != nil {
+		return fmt.Errorf("invalid %s schema: %w", schema.Format, err)
+	}
+
+	return compiled.Validate(data)
+}
+
+// LoadMessageSchemaDefinition returns the inline definition of the schema or reads it from its file.
+func LoadMessageSchemaDefinition(schema spec.MessageSchemaSpec) ([]byte, error) {
+	switch {
+	case schema.Inline != nil:
+		return []byte(*schema.Inline), nil
+	case schema.File != nil:
+		data, err := os.ReadFile(*schema.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("the schema has no inline or file definition")
+	}
+}
+
+// validateMessageSchema validates the schema block of a consumer or producer. Inline definitions are compiled, the
+// definitions of files and object stores are loaded by the runtime.
+func validateMessageSchema(schema spec.MessageSchemaSpec) error {
+	sources := 0
+	for _, set := range []bool{schema.Inline != nil, schema.File != nil, schema.ObjectStore != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("schema requires exactly one of inline, file or object_store")
+	}
+
+	if schema.ObjectStore != nil && (schema.ObjectStore.Bucket == "" || schema.ObjectStore.Name == "") {
+		return fmt.Errorf("schema object_store requires a bucket and name")
+	}
+
+	message := ""
+	if schema.Message != nil {
+		message = *schema.Message
+	}
+	if schema.Format == spec.MessageSchemaSpecFormatProtobuf && message == "" {
+		return fmt.Errorf("protobuf schema requires the message the payload is encoded as")
+	}
+	if schema.Format != spec.MessageSchemaSpecFormatProtobuf && message != "" {
+		return fmt.Errorf("schema message only applies to protobuf schemas")
+	}
+
+	if schema.Inline != nil {
+		if _, err := CompileMessageSchema(string(schema.Format), []byte(*schema.Inline), message); err != nil {
+			return fmt.Errorf("invalid %s schema: %w", schema.Format, err)
+		}
+	}
+
+	return nil
+}
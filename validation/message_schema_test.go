@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/synadia-io/connect/spec"
+)
+
+var _ = Describe("MessageSchema", func() {
+	Describe("json_schema", func() {
+		order := `{
+			"type": "object",
+			"required": ["id", "items"],
+			"properties": {
+				"id": {"type": "string", "pattern": "^ord-"},
+				"total": {"type": "number", "minimum": 0},
+				"status": {"enum": ["new", "paid"]},
+				"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/item"}}
+			},
+			"additionalProperties": false,
+			"definitions": {
+				"item": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string"}, "quantity": {"type": "integer"}}}
+			}
+		}`
+
+		var schema MessageSchema
+
+		BeforeEach(func() {
+			var err error
+			schema, err = CompileMessageSchema("json_schema", []byte(order), "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should accept matching payloads", func() {
+			Expect(schema.Validate([]byte(`{"id": "ord-1", "total": 12.5, "status": "paid", "items": [{"sku": "a", "quantity": 2}]}`))).To(Succeed())
+		})
+
+		It("should report where payloads do not match", func() {
+			Expect(schema.Validate([]byte(`{"items": []}`))).To(MatchError(`/: missing property 'id'`))
+			Expect(schema.Validate([]byte(`{"id": "1", "items": [{"sku": "a"}]}`))).To(MatchError(ContainSubstring(`/id: '1' does not match pattern`)))
+			Expect(schema.Validate([]byte(`{"id": "ord-1", "items": [{"sku": "a", "quantity": 1.5}]}`))).To(MatchError("/items/0/quantity: got number, want integer"))
+			Expect(schema.Validate([]byte(`{"id": "ord-1", "items": [{"sku": "a"}], "status": "lost"}`))).To(MatchError(`/status: value must be one of 'new', 'paid'`))
+			Expect(schema.Validate([]byte(`{"id": "ord-1", "items": [{"sku": "a"}], "note": "x"}`))).To(MatchError(`/: additional properties 'note' not allowed`))
+			Expect(schema.Validate([]byte(`{"id": "ord-1", "items": []}`))).To(MatchError("/items: minItems: got 0, want 1"))
+			Expect(schema.Validate([]byte(`not json`))).To(MatchError(ContainSubstring("the payload is not JSON")))
+		})
+
+		It("should support combinators", func() {
+			s, err := CompileMessageSchema("json_schema", []byte(`{"oneOf": [{"type": "string"}, {"type": "integer"}], "not": {"const": "none"}}`), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Validate([]byte(`"x"`))).To(Succeed())
+			Expect(s.Validate([]byte(`3`))).To(Succeed())
+			Expect(s.Validate([]byte(`true`))).To(MatchError("/: 'oneOf' failed, none matched"))
+			Expect(s.Validate([]byte(`"none"`))).To(MatchError("/: 'not' failed"))
+		})
+
+		It("should reject invalid definitions", func() {
+			_, err := CompileMessageSchema("json_schema", []byte(`{"type": "text"}`), "")
+			Expect(err).To(MatchError(ContainSubstring("the definition is not a valid schema: /type")))
+
+			_, err = CompileMessageSchema("json_schema", []byte(`{"$ref": "#/definitions/missing"}`), "")
+			Expect(err).To(MatchError(ContainSubstring(`"urn:connect:message-schema#/definitions/missing" not found`)))
+
+			_, err = CompileMessageSchema("json_schema", []byte(`{"$ref": "https://example.com/order.json"}`), "")
+			Expect(err).To(MatchError("only local $ref references are supported, https://example.com/order.json cannot be loaded"))
+
+			_, err = CompileMessageSchema("json_schema", []byte(`{"$ref": "#"}`), "")
+			Expect(err).To(MatchError("the $ref references of the schema form a cycle"))
+
+			_, err = CompileMessageSchema("json_schema", []byte(`{"allOf": [{"$ref": "#"}]}`), "")
+			Expect(err).To(MatchError("the $ref references of the schema form a cycle"))
+		})
+
+		It("should validate recursive schemas", func() {
+			s, err := CompileMessageSchema("json_schema", []byte(`{"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#"}}}}`), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Validate([]byte(`{"children": [{"children": []}]}`))).To(Succeed())
+			Expect(s.Validate([]byte(`{"children": [{"children": 3}]}`))).To(MatchError("/children/0/children: got number, want array"))
+		})
+
+		It("should enforce every keyword of the schema", func() {
+			for definition, payload := range map[string]string{
+				`{"uniqueItems": true}`: `[1, 1]`,
+				`{"multipleOf": 2}`:     `3`,
+				`{"minProperties": 1}`:  `{}`,
+				`{"if": {"required": ["a"]}, "then": {"required": ["b"]}}`:    `{"a": 1}`,
+				`{"dependentRequired": {"a": ["b"]}}`:                         `{"a": 1}`,
+				`{"contains": {"type": "string"}}`:                            `[1, 2]`,
+				`{"propertyNames": {"pattern": "^[a-z]+$"}}`:                  `{"A": 1}`,
+				`{"prefixItems": [{"type": "integer"}], "items": false}`:      `[1, 2]`,
+				`{"unevaluatedProperties": false, "properties": {"a": true}}`: `{"a": 1, "b": 2}`,
+			} {
+				s, err := CompileMessageSchema("json_schema", []byte(definition), "")
+				Expect(err).ToNot(HaveOccurred(), definition)
+				Expect(s.Validate([]byte(payload))).To(HaveOccurred(), definition)
+			}
+		})
+	})
+
+	Describe("avro", func() {
+		order := `{
+			"type": "record", "name": "Order", "namespace": "shop",
+			"fields": [
+				{"name": "id", "type": "long"},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "PAID"]}},
+				{"name": "note", "type": ["null", "string"], "default": null},
+				{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [{"name": "sku", "type": "string"}]}}},
+				{"name": "parent", "type": ["null", "Order"], "default": null}
+			]
+		}`
+
+		var schema MessageSchema
+
+		BeforeEach(func() {
+			var err error
+			schema, err = CompileMessageSchema("avro", []byte(order), "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should accept payloads in the avro JSON encoding", func() {
+			Expect(schema.Validate([]byte(`{"id": 1, "status": "NEW", "lines": [{"sku": "a"}]}`))).To(Succeed())
+			Expect(schema.Validate([]byte(`{"id": 2, "status": "PAID", "note": {"string": "gift"}, "lines": [], "parent": {"shop.Order": {"id": 1, "status": "NEW", "lines": []}}}`))).To(Succeed())
+		})
+
+		It("should report where payloads do not match", func() {
+			Expect(schema.Validate([]byte(`{"status": "NEW", "lines": []}`))).To(MatchError(ContainSubstring(`only found 4 of 5 fields`)))
+			Expect(schema.Validate([]byte(`{"id": 1, "status": "LOST", "lines": []}`))).To(MatchError(ContainSubstring(`"LOST"`)))
+			Expect(schema.Validate([]byte(`{"id": 1, "status": "NEW", "lines": [], "note": "gift"}`))).To(MatchError(ContainSubstring(`cannot decode textual union`)))
+			Expect(schema.Validate([]byte(`{"id": 1, "status": "NEW", "lines": [{"sku": 3}]}`))).To(MatchError(ContainSubstring(`for key: "sku"`)))
+			Expect(schema.Validate([]byte(`{"id": 1, "status": "NEW", "lines": [], "extra": true}`))).To(MatchError(ContainSubstring(`cannot determine codec: "extra"`)))
+		})
+
+		It("should reject invalid definitions", func() {
+			_, err := CompileMessageSchema("avro", []byte(`{"type": "record", "name": "Order", "fields": [{"name": "customer", "type": "Customer"}]}`), "")
+			Expect(err).To(MatchError(ContainSubstring(`unknown type name: "Customer"`)))
+
+			_, err = CompileMessageSchema("avro", []byte(`{"type": "enum", "name": "Status"}`), "")
+			Expect(err).To(MatchError(ContainSubstring("ought to have symbols")))
+		})
+	})
+
+	Describe("protobuf", func() {
+		proto := `
+			syntax = "proto3";
+			package shop;
+
+			// an order
+			message Order {
+				string id = 1;
+				repeated int64 quantities = 2 [packed = true];
+				Customer customer = 3;
+				map<string, Customer> contacts = 4;
+				Status status = 5;
+
+				enum Status {
+					NEW = 0;
+					PAID = 1;
+				}
+			}
+
+			message Customer {
+				string name = 1;
+			}
+		`
+
+		field := func(number int, wireType int, value []byte) []byte {
+			b := binary.AppendUvarint(nil, uint64(number<<3|wireType))
+			if wireType == 2 {
+				b = binary.AppendUvarint(b, uint64(len(value)))
+			}
+			return append(b, value...)
+		}
+
+		var schema MessageSchema
+
+		BeforeEach(func() {
+			var err error
+			schema, err = CompileMessageSchema("protobuf", []byte(proto), "shop.Order")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should accept payloads in the wire format", func() {
+			customer := field(1, 2, []byte("Ada"))
+			var payload []byte
+			payload = append(payload, field(1, 2, []byte("ord-1"))...)
+			payload = append(payload, field(2, 2, []byte{1, 2, 3})...)
+			payload = append(payload, field(2, 0, []byte{4})...)
+			payload = append(payload, field(3, 2, customer)...)
+			payload = append(payload, field(4, 2, append(field(1, 2, []byte("billing")), field(2, 2, customer)...))...)
+			payload = append(payload, field(5, 0, []byte{1})...)
+			payload = append(payload, field(9, 5, []byte{0, 0, 0, 0})...)
+
+			Expect(schema.Validate(payload)).To(Succeed())
+		})
+
+		It("should report where payloads do not match", func() {
+			Expect(schema.Validate(field(1, 0, []byte{1}))).To(MatchError("/id: unexpected wire type 0 for string"))
+			Expect(schema.Validate(field(3, 2, field(1, 2, []byte{0xff})))).To(MatchError(ContainSubstring("invalid UTF-8")))
+			Expect(schema.Validate([]byte{0x0a, 0x05, 'a'})).To(MatchError(ContainSubstring("cannot parse invalid wire-format data")))
+		})
+
+		It("should check required proto2 fields", func() {
+			s, err := CompileMessageSchema("protobuf", []byte(`syntax = "proto2"; message Ping { required int32 seq = 1; optional string note = 2; }`), "Ping")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Validate(field(1, 0, []byte{7}))).To(Succeed())
+			Expect(s.Validate(field(2, 2, []byte("x")))).To(MatchError(ContainSubstring("required field Ping.seq not set")))
+		})
+
+		It("should reject invalid definitions", func() {
+			_, err := CompileMessageSchema("protobuf", []byte(proto), "")
+			Expect(err).To(MatchError(ContainSubstring("message the payload is encoded as is required")))
+
+			_, err = CompileMessageSchema("protobuf", []byte(proto), "shop.Invoice")
+			Expect(err).To(MatchError(`message "shop.Invoice" is not defined`))
+
+			_, err = CompileMessageSchema("protobuf", []byte(`syntax = "proto3"; message Order { Money total = 1; }`), "Order")
+			Expect(err).To(MatchError(ContainSubstring("unknown type Money")))
+		})
+	})
+
+	Describe("ValidateMessage", func() {
+		It("should validate payloads against schema files offline", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "order.json")
+			Expect(os.WriteFile(file, []byte(`{"type": "object", "required": ["id"]}`), 0644)).To(Succeed())
+
+			schema := spec.MessageSchemaSpec{Format: spec.MessageSchemaSpecFormatJsonSchema, File: &file}
+			Expect(NewValidator().ValidateMessage(schema, []byte(`{"id": 1}`))).To(Succeed())
+			Expect(NewValidator().ValidateMessage(schema, []byte(`{}`))).To(MatchError(ContainSubstring("missing property 'id'")))
+		})
+
+		It("should not validate against object store schemas", func() {
+			schema := spec.MessageSchemaSpec{Format: spec.MessageSchemaSpecFormatAvro, ObjectStore: &spec.MessageSchemaSpecObjectStore{Bucket: "schemas", Name: "order"}}
+			Expect(NewValidator().ValidateMessage(schema, []byte(`{}`))).To(MatchError(ContainSubstring("cannot be validated offline")))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protobufSchema is a compiled Protobuf definition validating payloads in the binary wire format as one of its
+// messages. The definition is a single .proto file, which may import the well-known types of google/protobuf.
+// Strings must be valid UTF-8 in proto3 files, required proto2 fields must be present and known fields must have the
+// wire type of their declared type. Unknown fields are accepted, like protobuf parsers do.
+type protobufSchema struct {
+	message protoreflect.MessageDescriptor
+}
+
+// protobufSchemaFile is the name the definition is compiled as.
+const protobufSchemaFile = "schema.proto"
+
+func compileProtobufSchema(definition []byte, message string) (*protobufSchema, error) {
+	if message == "" {
+		return nil, fmt.Errorf("the message the payload is encoded as is required")
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{protobufSchemaFile: string(definition)}),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), protobufSchemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	d := files[0].FindDescriptorByName(protoreflect.FullName(strings.TrimPrefix(message, ".")))
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("message %q is not defined", message)
+	}
+	return &protobufSchema{message: md}, nil
+}
+
+// Validate validates a payload in the binary wire format.
+func (s *protobufSchema) Validate(data []byte) error {
+	msg := dynamicpb.NewMessage(s.message)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return schemaError("", "%s", err)
+	}
+	return checkProtoWireTypes(msg, "")
+}
+
+// checkProtoWireTypes reports known fields with the wrong wire type, which protobuf parsers keep as unknown fields
+// rather than failing on them.
+func checkProtoWireTypes(m protoreflect.Message, at string) error {
+	unknown := m.GetUnknown()
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return schemaError(at, "%s", protowire.ParseError(n))
+		}
+		if fd := m.Descriptor().Fields().ByNumber(num); fd != nil {
+			return schemaError(at+"/"+string(fd.Name()), "unexpected wire type %d for %s", typ, fd.Kind())
+		}
+		n = protowire.ConsumeFieldValue(num, typ, unknown[n:]) + n
+		if n < 0 {
+			return schemaError(at, "%s", protowire.ParseError(n))
+		}
+		unknown = unknown[n:]
+	}
+
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		at := at + "/" + string(fd.Name())
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+					err = checkProtoWireTypes(v.Message(), at+"/"+k.String())
+					return err == nil
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := 0; i < v.List().Len() && err == nil; i++ {
+					err = checkProtoWireTypes(v.List().Get(i).Message(), fmt.Sprintf("%s/%d", at, i))
+				}
+			}
+		case fd.Message() != nil:
+			err = checkProtoWireTypes(v.Message(), at)
+		}
+		return err == nil
+	})
+	return err
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/synadia-io/connect/spec"
 	"gopkg.in/yaml.v3"
@@ -77,14 +79,24 @@ func (v *Validator) validateSteps(steps spec.StepsSpec) error {
 	hasSink := steps.Sink != nil
 	hasConsumer := steps.Consumer != nil
 	hasProducer := steps.Producer != nil
+	hasBroker := steps.Broker != nil
+	hasRouter := steps.Router != nil
 
 	// Must have at least one source/consumer and one sink/producer
 	if !hasSource && !hasConsumer {
 		return fmt.Errorf("connector must have either a source or consumer step")
 	}
 
-	if !hasSink && !hasProducer {
-		return fmt.Errorf("connector must have either a sink or producer step")
+	if !hasSink && !hasProducer && !hasBroker && !hasRouter {
+		return fmt.Errorf("connector must have either a sink, producer, broker or router step")
+	}
+
+	if hasBroker && (hasSink || hasProducer) {
+		return fmt.Errorf("connector with a broker step cannot have a sink or producer step")
+	}
+
+	if hasRouter && (hasSink || hasProducer || hasBroker) {
+		return fmt.Errorf("connector with a router step cannot have a sink, producer or broker step")
 	}
 
 	// Validate individual steps
@@ -112,9 +124,69 @@ func (v *Validator) validateSteps(steps spec.StepsSpec) error {
 		}
 	}
 
+	if steps.Transformer != nil {
+		if err := v.validateTransformerStep(*steps.Transformer); err != nil {
+			return fmt.Errorf("invalid transformer step: %w", err)
+		}
+	}
+
+	if steps.Broker != nil {
+		if err := v.validateBrokerStep(*steps.Broker); err != nil {
+			return fmt.Errorf("invalid broker step: %w", err)
+		}
+	}
+
+	if steps.Router != nil {
+		if err := v.validateRouterStep(*steps.Router); err != nil {
+			return fmt.Errorf("invalid router step: %w", err)
+		}
+	}
+
+	if steps.Errors != nil {
+		if err := v.validateErrors(*steps.Errors); err != nil {
+			return fmt.Errorf("invalid errors section: %w", err)
+		}
+	}
+
+	hasDeadLetter := steps.Errors != nil && steps.Errors.DeadLetter != nil
+	for _, schema := range messageSchemas(steps) {
+		if schema.OnFailure != spec.MessageSchemaSpecOnFailureDrop && !hasDeadLetter {
+			return fmt.Errorf("schema on_failure %s requires a dead_letter producer in the errors section", spec.MessageSchemaSpecOnFailureDeadLetter)
+		}
+	}
+
 	return nil
 }
 
+// messageSchemas returns the schemas of the consumer and of the producers of the steps.
+func messageSchemas(steps spec.StepsSpec) []spec.MessageSchemaSpec {
+	var schemas []spec.MessageSchemaSpec
+	add := func(producer *spec.ProducerStepSpec) {
+		if producer != nil && producer.Schema != nil {
+			schemas = append(schemas, *producer.Schema)
+		}
+	}
+
+	if steps.Consumer != nil && steps.Consumer.Schema != nil {
+		schemas = append(schemas, *steps.Consumer.Schema)
+	}
+	add(steps.Producer)
+	if steps.Broker != nil {
+		for _, output := range steps.Broker.Outputs {
+			add(output.Producer)
+		}
+	}
+	if steps.Router != nil {
+		for _, route := range steps.Router.Routes {
+			add(route.Output.Producer)
+		}
+		if steps.Router.Default != nil {
+			add(steps.Router.Default.Producer)
+		}
+	}
+	return schemas
+}
+
 func (v *Validator) validateSourceStep(source spec.SourceStepSpec) error {
 	if source.Type == "" {
 		return fmt.Errorf("source type is required")
@@ -126,6 +198,32 @@ func (v *Validator) validateSinkStep(sink spec.SinkStepSpec) error {
 	if sink.Type == "" {
 		return fmt.Errorf("sink type is required")
 	}
+
+	if err := validateThrottling(sink.RateLimit, sink.Batching); err != nil {
+		return fmt.Errorf("invalid sink: %w", err)
+	}
+
+	return nil
+}
+
+func (v *Validator) validateTransformerStep(transformer spec.TransformerStepSpec) error {
+	if transformer.Service != nil {
+		if err := validateThrottling(transformer.Service.RateLimit, transformer.Service.Batching); err != nil {
+			return fmt.Errorf("invalid service transformer: %w", err)
+		}
+		if err := validateNatsConfig(transformer.Service.Nats); err != nil {
+			return fmt.Errorf("invalid service transformer NATS configuration: %w", err)
+		}
+	}
+
+	if transformer.Composite != nil {
+		for _, t := range transformer.Composite.Sequential {
+			if err := v.validateTransformerStep(t); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -133,6 +231,9 @@ func (v *Validator) validateConsumerStep(consumer spec.ConsumerStepSpec) error {
 	if consumer.Nats.Url == "" {
 		return fmt.Errorf("consumer NATS URL is required")
 	}
+	if err := validateNatsConfig(consumer.Nats); err != nil {
+		return fmt.Errorf("invalid consumer NATS configuration: %w", err)
+	}
 
 	hasCore := consumer.Core != nil
 	hasStream := consumer.Stream != nil
@@ -146,14 +247,154 @@ func (v *Validator) validateConsumerStep(consumer spec.ConsumerStepSpec) error {
 		return fmt.Errorf("consumer core subject is required")
 	}
 
-	if hasStream && consumer.Stream.Subject == "" {
-		return fmt.Errorf("consumer stream subject is required")
+	if hasStream {
+		if err := v.validateConsumerStream(*consumer.Stream); err != nil {
+			return err
+		}
 	}
 
 	if hasKv && consumer.Kv.Bucket == "" {
 		return fmt.Errorf("consumer kv bucket is required")
 	}
 
+	if consumer.Headers != nil {
+		if err := validateHeaders(consumer.Headers.Propagate, consumer.Headers.Static); err != nil {
+			return fmt.Errorf("invalid consumer headers: %w", err)
+		}
+	}
+
+	if consumer.Schema != nil {
+		if err := validateMessageSchema(*consumer.Schema); err != nil {
+			return fmt.Errorf("invalid consumer schema: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateConsumerStream(stream spec.ConsumerStepSpecStream) error {
+	if stream.Subject == "" {
+		return fmt.Errorf("consumer stream subject is required")
+	}
+
+	if stream.Bind && (stream.Durable == nil || *stream.Durable == "") {
+		return fmt.Errorf("consumer stream durable is required to bind to an existing consumer")
+	}
+
+	switch stream.DeliverPolicy {
+	case spec.ConsumerStepSpecStreamDeliverPolicyByStartSequence:
+		if stream.OptStartSeq == nil || *stream.OptStartSeq < 1 {
+			return fmt.Errorf("consumer stream opt_start_seq is required for deliver policy %s", stream.DeliverPolicy)
+		}
+	case spec.ConsumerStepSpecStreamDeliverPolicyByStartTime:
+		if stream.OptStartTime == nil {
+			return fmt.Errorf("consumer stream opt_start_time is required for deliver policy %s", stream.DeliverPolicy)
+		}
+		if _, err := time.Parse(time.RFC3339, *stream.OptStartTime); err != nil {
+			return fmt.Errorf("consumer stream opt_start_time must be an RFC3339 time: %w", err)
+		}
+	}
+
+	if stream.AckWait != nil {
+		if _, err := time.ParseDuration(*stream.AckWait); err != nil {
+			return fmt.Errorf("consumer stream ack_wait must be a duration: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateBrokerStep(broker spec.BrokerStepSpec) error {
+	if len(broker.Outputs) == 0 {
+		return fmt.Errorf("broker must have at least one output")
+	}
+
+	for i, output := range broker.Outputs {
+		if err := v.validateOutputStep(output); err != nil {
+			return fmt.Errorf("invalid output %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateRouterStep(router spec.RouterStepSpec) error {
+	if len(router.Routes) == 0 {
+		return fmt.Errorf("router must have at least one route")
+	}
+
+	for i, route := range router.Routes {
+		if route.Condition == "" {
+			return fmt.Errorf("invalid route %d: condition is required", i)
+		}
+		if err := v.validateOutputStep(route.Output); err != nil {
+			return fmt.Errorf("invalid route %d: %w", i, err)
+		}
+	}
+
+	if router.Default != nil {
+		if err := v.validateOutputStep(*router.Default); err != nil {
+			return fmt.Errorf("invalid default route: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateOutputStep(output spec.OutputStepSpec) error {
+	hasSink := output.Sink != nil
+	hasProducer := output.Producer != nil
+
+	if hasSink == hasProducer {
+		return fmt.Errorf("output must have either a sink or producer")
+	}
+
+	if output.Transformer != nil {
+		if err := v.validateTransformerStep(*output.Transformer); err != nil {
+			return err
+		}
+	}
+
+	if hasSink {
+		if err := v.validateSinkStep(*output.Sink); err != nil {
+			return err
+		}
+	}
+
+	if hasProducer {
+		if err := v.validateProducerStep(*output.Producer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateErrors(policy spec.ErrorPolicySpec) error {
+	if policy.MaxRetries != nil && *policy.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative")
+	}
+
+	backoff, err := time.ParseDuration(policy.Backoff)
+	if err != nil {
+		return fmt.Errorf("backoff must be a duration: %w", err)
+	}
+
+	maxBackoff, err := time.ParseDuration(policy.MaxBackoff)
+	if err != nil {
+		return fmt.Errorf("max_backoff must be a duration: %w", err)
+	}
+
+	if maxBackoff < backoff {
+		return fmt.Errorf("max_backoff must not be shorter than backoff")
+	}
+
+	if policy.DeadLetter != nil {
+		if err := v.validateProducerStep(*policy.DeadLetter); err != nil {
+			return fmt.Errorf("invalid dead letter producer: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -161,6 +402,9 @@ func (v *Validator) validateProducerStep(producer spec.ProducerStepSpec) error {
 	if producer.Nats.Url == "" {
 		return fmt.Errorf("producer NATS URL is required")
 	}
+	if err := validateNatsConfig(producer.Nats); err != nil {
+		return fmt.Errorf("invalid producer NATS configuration: %w", err)
+	}
 
 	hasCore := producer.Core != nil
 	hasStream := producer.Stream != nil
@@ -174,17 +418,175 @@ func (v *Validator) validateProducerStep(producer spec.ProducerStepSpec) error {
 		return fmt.Errorf("producer core subject is required")
 	}
 
+	if hasCore {
+		if err := validateInterpolation(producer.Core.Subject); err != nil {
+			return fmt.Errorf("invalid producer core subject: %w", err)
+		}
+	}
+
 	if hasStream && producer.Stream.Subject == "" {
 		return fmt.Errorf("producer stream subject is required")
 	}
 
+	if hasStream {
+		if err := validateInterpolation(producer.Stream.Subject); err != nil {
+			return fmt.Errorf("invalid producer stream subject: %w", err)
+		}
+	}
+
 	if hasKv && producer.Kv.Bucket == "" {
 		return fmt.Errorf("producer kv bucket is required")
 	}
 
+	if hasKv && producer.Batching != nil {
+		return fmt.Errorf("producer kv cannot batch messages, every message overwrites the key")
+	}
+
+	if err := validateThrottling(producer.RateLimit, producer.Batching); err != nil {
+		return fmt.Errorf("invalid producer: %w", err)
+	}
+
+	if producer.Schema != nil {
+		if err := validateMessageSchema(*producer.Schema); err != nil {
+			return fmt.Errorf("invalid producer schema: %w", err)
+		}
+	}
+
+	if producer.Headers != nil {
+		if hasKv {
+			return fmt.Errorf("producer kv does not write headers")
+		}
+
+		if err := validateHeaders(producer.Headers.Propagate, producer.Headers.Static); err != nil {
+			return fmt.Errorf("invalid producer headers: %w", err)
+		}
+
+		if producer.Headers.MsgId != nil {
+			if !hasStream {
+				return fmt.Errorf("producer headers msg_id requires a stream producer")
+			}
+			if err := validateInterpolation(*producer.Headers.MsgId); err != nil {
+				return fmt.Errorf("invalid producer headers msg_id: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateNatsConfig validates the TLS settings and authentication of a NATS configuration, of which only one method
+// can be used.
+func validateNatsConfig(cfg spec.NatsConfigSpec) error {
+	if cfg.Tls != nil && (cfg.Tls.CertFile == nil) != (cfg.Tls.KeyFile == nil) {
+		return fmt.Errorf("tls cert_file and key_file must be set together")
+	}
+	if cfg.Creds != nil && (cfg.Creds.File == nil) == (cfg.Creds.Secret == nil) {
+		return fmt.Errorf("creds requires exactly one of file or secret")
+	}
+	if cfg.Password != nil && cfg.User == nil {
+		return fmt.Errorf("password requires a user")
+	}
+
+	methods := 0
+	for _, set := range []bool{cfg.AuthEnabled, cfg.Creds != nil, cfg.Nkey != nil, cfg.User != nil, cfg.Token != nil} {
+		if set {
+			methods++
+		}
+	}
+	if methods > 1 {
+		return fmt.Errorf("only one of auth_enabled, creds, nkey, user or token can be used to authenticate")
+	}
+
 	return nil
 }
 
+// validateThrottling validates the rate limit and batching of a step. The rate limit counts messages while a batch
+// is handled at once, so a batch must fit in the burst of the rate limit.
+func validateThrottling(limit *spec.RateLimitSpec, batching *spec.BatchingSpec) error {
+	if limit != nil {
+		if limit.Count < 1 {
+			return fmt.Errorf("rate_limit count must be positive")
+		}
+		if err := validatePeriod("rate_limit", limit.Period); err != nil {
+			return err
+		}
+		if limit.Burst != nil && *limit.Burst < 1 {
+			return fmt.Errorf("rate_limit burst must be positive")
+		}
+	}
+
+	if batching != nil {
+		if batching.Count == nil && batching.ByteSize == nil && batching.Period == nil {
+			return fmt.Errorf("batching requires a count, byte_size or period")
+		}
+		if batching.Count != nil && *batching.Count < 2 {
+			return fmt.Errorf("batching count must be at least 2, a batch of a single message disables batching")
+		}
+		if batching.ByteSize != nil && *batching.ByteSize < 1 {
+			return fmt.Errorf("batching byte_size must be positive")
+		}
+		if batching.Period != nil {
+			if err := validatePeriod("batching", *batching.Period); err != nil {
+				return err
+			}
+		}
+	}
+
+	if limit != nil && batching != nil && batching.Count != nil {
+		burst := limit.Count
+		if limit.Burst != nil {
+			burst = *limit.Burst
+		}
+		if *batching.Count > burst {
+			return fmt.Errorf("batching count %d exceeds the rate_limit burst of %d messages", *batching.Count, burst)
+		}
+	}
+
+	return nil
+}
+
+func validatePeriod(section string, period string) error {
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return fmt.Errorf("%s period must be a duration: %w", section, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("%s period must be positive", section)
+	}
+	return nil
+}
+
+func validateHeaders(propagate []string, static map[string]string) error {
+	for _, name := range propagate {
+		if name == "" {
+			return fmt.Errorf("propagate must not contain empty names")
+		}
+	}
+
+	for name := range static {
+		if name == "" {
+			return fmt.Errorf("static must not contain empty names")
+		}
+	}
+
+	return nil
+}
+
+// validateInterpolation checks that every ${! interpolation of the value is terminated.
+func validateInterpolation(value string) error {
+	for {
+		start := strings.Index(value, "${!")
+		if start < 0 {
+			return nil
+		}
+		end := strings.Index(value[start:], "}")
+		if end < 0 {
+			return fmt.Errorf("unterminated interpolation in %q", value)
+		}
+		value = value[start+end+1:]
+	}
+}
+
 func (v *Validator) ValidateFileExists(filePath string) error {
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return fmt.Errorf("file does not exist: %s", filePath)
@@ -148,4 +148,545 @@ description: Missing spec field
 			Expect(err.Error()).To(ContainSubstring("spec field is required"))
 		})
 	})
+
+	Describe("stream consumers", func() {
+		validate := func(stream string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: A stream outlet
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: nats://localhost:4222
+      stream:
+` + stream + `
+    sink:
+      type: stdout
+      config: {}
+`
+			filePath := filepath.Join(tempDir, "stream.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		It("should accept a full jetstream consumer configuration", func() {
+			Expect(validate(`        subject: orders.>
+        stream: ORDERS
+        durable: outlet
+        filter_subjects: [returns.>]
+        deliver_policy: by_start_time
+        opt_start_time: "2025-01-02T03:04:05Z"
+        ack_policy: explicit
+        ack_wait: 30s
+        max_deliver: 5
+        max_ack_pending: 100`)).To(Succeed())
+		})
+
+		It("should reject unknown policies", func() {
+			Expect(validate(`        subject: orders.>
+        deliver_policy: sometimes`)).To(MatchError(ContainSubstring("invalid value")))
+		})
+
+		It("should require a start sequence", func() {
+			Expect(validate(`        subject: orders.>
+        deliver_policy: by_start_sequence`)).To(MatchError(ContainSubstring("opt_start_seq is required")))
+		})
+
+		It("should require a durable to bind", func() {
+			Expect(validate(`        subject: orders.>
+        bind: true`)).To(MatchError(ContainSubstring("durable is required")))
+		})
+
+		It("should reject invalid durations", func() {
+			Expect(validate(`        subject: orders.>
+        ack_wait: soon`)).To(MatchError(ContainSubstring("ack_wait must be a duration")))
+		})
+	})
+
+	Describe("errors section", func() {
+		validate := func(errors string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: An outlet with a dead letter queue
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: nats://localhost:4222
+      core:
+        subject: orders
+    sink:
+      type: http
+      config: {}
+    errors:
+` + errors + `
+`
+			filePath := filepath.Join(tempDir, "errors.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		It("should accept retries with a dead letter producer", func() {
+			Expect(validate(`      max_retries: 5
+      backoff: 500ms
+      max_backoff: 1m
+      dead_letter:
+        nats:
+          url: nats://localhost:4222
+        stream:
+          subject: orders.dlq`)).To(Succeed())
+		})
+
+		It("should apply the defaults", func() {
+			Expect(validate(`      max_retries: 1`)).To(Succeed())
+		})
+
+		It("should reject invalid backoffs", func() {
+			Expect(validate(`      backoff: soon`)).To(MatchError(ContainSubstring("backoff must be a duration")))
+			Expect(validate(`      backoff: 1m
+      max_backoff: 1s`)).To(MatchError(ContainSubstring("must not be shorter")))
+		})
+
+		It("should validate the dead letter producer", func() {
+			Expect(validate(`      dead_letter:
+        nats:
+          url: nats://localhost:4222`)).To(MatchError(ContainSubstring("invalid dead letter producer")))
+		})
+	})
+
+	Describe("broker step", func() {
+		validate := func(steps string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: An outlet copying orders to several outputs
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: nats://localhost:4222
+      core:
+        subject: orders
+` + steps + `
+`
+			filePath := filepath.Join(tempDir, "broker.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		It("should accept outputs with their own transformers", func() {
+			Expect(validate(`    broker:
+      pattern: fan_out
+      outputs:
+        - sink:
+            type: aws_s3
+            config: {}
+        - transformer:
+            mapping:
+              sourcecode: root = this
+          producer:
+            nats:
+              url: nats://localhost:4222
+            core:
+              subject: orders.copy`)).To(Succeed())
+		})
+
+		It("should reject unknown patterns", func() {
+			Expect(validate(`    broker:
+      pattern: broadcast
+      outputs:
+        - sink:
+            type: stdout
+            config: {}`)).To(MatchError(ContainSubstring("invalid value")))
+		})
+
+		It("should require outputs", func() {
+			Expect(validate(`    broker:
+      outputs: []`)).To(MatchError(ContainSubstring("at least one output")))
+		})
+
+		It("should require either a sink or producer per output", func() {
+			Expect(validate(`    broker:
+      outputs:
+        - transformer:
+            mapping:
+              sourcecode: root = this`)).To(MatchError(ContainSubstring("invalid output 0: output must have either a sink or producer")))
+		})
+
+		It("should not be combined with a sink", func() {
+			Expect(validate(`    sink:
+      type: stdout
+      config: {}
+    broker:
+      outputs:
+        - sink:
+            type: stdout
+            config: {}`)).To(MatchError(ContainSubstring("cannot have a sink or producer")))
+		})
+	})
+
+	Describe("router step", func() {
+		validate := func(steps string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: An outlet routing orders by type
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: nats://localhost:4222
+      core:
+        subject: events
+` + steps + `
+`
+			filePath := filepath.Join(tempDir, "router.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		It("should accept routes with a default", func() {
+			Expect(validate(`    router:
+      routes:
+        - condition: this.type == "order"
+          output:
+            producer:
+              nats:
+                url: nats://localhost:4222
+              core:
+                subject: orders
+        - condition: '@kind == "refund"'
+          output:
+            sink:
+              type: http
+              config: {}
+      default:
+        sink:
+          type: stdout
+          config: {}`)).To(Succeed())
+		})
+
+		It("should require routes", func() {
+			Expect(validate(`    router:
+      routes: []`)).To(MatchError(ContainSubstring("at least one route")))
+		})
+
+		It("should require a condition", func() {
+			Expect(validate(`    router:
+      routes:
+        - condition: ""
+          output:
+            sink:
+              type: stdout
+              config: {}`)).To(MatchError(ContainSubstring("invalid route 0: condition is required")))
+		})
+
+		It("should validate every route target", func() {
+			Expect(validate(`    router:
+      routes:
+        - condition: this.type == "order"
+          output:
+            producer:
+              nats:
+                url: nats://localhost:4222`)).To(MatchError(ContainSubstring("invalid route 0: producer must have either core, stream, or kv configuration")))
+
+			Expect(validate(`    router:
+      routes:
+        - condition: this.type == "order"
+          output:
+            sink:
+              type: stdout
+              config: {}
+      default:
+        transformer:
+          mapping:
+            sourcecode: root = this`)).To(MatchError(ContainSubstring("invalid default route: output must have either a sink or producer")))
+		})
+
+		It("should not be combined with a sink", func() {
+			Expect(validate(`    sink:
+      type: stdout
+      config: {}
+    router:
+      routes:
+        - condition: this.type == "order"
+          output:
+            sink:
+              type: stdout
+              config: {}`)).To(MatchError(ContainSubstring("cannot have a sink, producer or broker")))
+		})
+	})
+
+	Describe("headers", func() {
+		validate := func(consumerHeaders string, producer string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: A bridge carrying headers
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: nats://localhost:4222
+      core:
+        subject: orders
+` + consumerHeaders + `
+    producer:
+      nats:
+        url: nats://localhost:4222
+` + producer + `
+`
+			filePath := filepath.Join(tempDir, "headers.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		It("should accept header mappings and templated subjects", func() {
+			Expect(validate(`      headers:
+        propagate: [Trace-Id, X-*]
+        static:
+          Source: crm`, `      stream:
+        subject: orders.${! meta("region") }
+      headers:
+        propagate: [Trace-Id]
+        msg_id: ${! json("id") }`)).To(Succeed())
+		})
+
+		It("should require a stream producer for the message id", func() {
+			Expect(validate("", `      core:
+        subject: orders.copy
+      headers:
+        msg_id: ${! json("id") }`)).To(MatchError(ContainSubstring("msg_id requires a stream producer")))
+		})
+
+		It("should reject headers for kv producers", func() {
+			Expect(validate("", `      kv:
+        bucket: orders
+        key: latest
+      headers:
+        static:
+          Source: crm`)).To(MatchError(ContainSubstring("producer kv does not write headers")))
+		})
+
+		It("should reject unterminated interpolations", func() {
+			Expect(validate("", `      core:
+        subject: orders.${! meta("region")`)).To(MatchError(ContainSubstring("unterminated interpolation")))
+		})
+
+		It("should reject empty header names", func() {
+			Expect(validate(`      headers:
+        propagate: [""]`, `      core:
+        subject: orders.copy`)).To(MatchError(ContainSubstring("invalid consumer headers")))
+		})
+	})
+
+	Describe("rate limits and batching", func() {
+		validate := func(transformer string, sink string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: An outlet writing to a metered API
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: nats://localhost:4222
+      core:
+        subject: orders
+` + transformer + `
+    sink:
+      type: http
+      config: {}
+` + sink + `
+`
+			filePath := filepath.Join(tempDir, "throttling.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		It("should accept rate limits and batches", func() {
+			Expect(validate(`    transformer:
+      service:
+        endpoint: enrich
+        nats:
+          url: nats://localhost:4222
+        rate_limit:
+          count: 100`, `      rate_limit:
+        count: 10
+        period: 1m
+        burst: 20
+      batching:
+        count: 20
+        byte_size: 1048576
+        period: 5s`)).To(Succeed())
+		})
+
+		It("should reject invalid rate limits", func() {
+			Expect(validate("", `      rate_limit:
+        count: 0`)).To(MatchError(ContainSubstring("rate_limit count must be positive")))
+			Expect(validate("", `      rate_limit:
+        count: 10
+        period: 0s`)).To(MatchError(ContainSubstring("rate_limit period must be positive")))
+		})
+
+		It("should reject empty batches", func() {
+			Expect(validate("", `      batching: {}`)).To(MatchError(ContainSubstring("batching requires a count, byte_size or period")))
+			Expect(validate("", `      batching:
+        count: 1`)).To(MatchError(ContainSubstring("at least 2")))
+		})
+
+		It("should reject batches exceeding the burst", func() {
+			Expect(validate("", `      rate_limit:
+        count: 10
+      batching:
+        count: 50`)).To(MatchError(ContainSubstring("exceeds the rate_limit burst of 10 messages")))
+		})
+
+		It("should validate service transformers", func() {
+			Expect(validate(`    transformer:
+      composite:
+        sequential:
+          - service:
+              endpoint: enrich
+              nats:
+                url: nats://localhost:4222
+              rate_limit:
+                count: 10
+                period: soon`, "")).To(MatchError(ContainSubstring("invalid service transformer: rate_limit period must be a duration")))
+
+			Expect(validate(`    transformer:
+      service:
+        endpoint: enrich
+        nats:
+          url: nats://localhost:4222
+        batching:
+          period: soon`, "")).To(MatchError(ContainSubstring("invalid service transformer: batching period must be a duration")))
+		})
+	})
+
+	Describe("schemas", func() {
+		validate := func(schema string, errors string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: An inlet enforcing the schema of orders
+  runtime_id: wombat
+  steps:
+    source:
+      type: http_server
+      config: {}
+    producer:
+      nats:
+        url: nats://localhost:4222
+      stream:
+        subject: orders
+      schema:
+` + schema + `
+` + errors + `
+`
+			filePath := filepath.Join(tempDir, "schema.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		deadLetter := `    errors:
+      dead_letter:
+        nats:
+          url: nats://localhost:4222
+        core:
+          subject: orders.invalid`
+
+		It("should accept schemas", func() {
+			Expect(validate(`        format: json_schema
+        inline: '{"type": "object", "required": ["id"]}'`, deadLetter)).To(Succeed())
+			Expect(validate(`        format: protobuf
+        message: shop.Order
+        object_store:
+          bucket: schemas
+          name: order.proto
+        on_failure: drop`, "")).To(Succeed())
+		})
+
+		It("should require exactly one definition", func() {
+			Expect(validate(`        format: avro
+        on_failure: drop`, "")).To(MatchError(ContainSubstring("exactly one of inline, file or object_store")))
+			Expect(validate(`        format: avro
+        inline: '"string"'
+        file: order.avsc
+        on_failure: drop`, "")).To(MatchError(ContainSubstring("exactly one of inline, file or object_store")))
+		})
+
+		It("should compile inline definitions", func() {
+			Expect(validate(`        format: avro
+        inline: '{"type": "record", "name": "Order"}'
+        on_failure: drop`, "")).To(MatchError(ContainSubstring(`invalid producer schema: invalid avro schema: Record "Order" ought to have fields key`)))
+		})
+
+		It("should require the message of protobuf schemas", func() {
+			Expect(validate(`        format: protobuf
+        file: order.proto
+        on_failure: drop`, "")).To(MatchError(ContainSubstring("protobuf schema requires the message")))
+		})
+
+		It("should require a dead letter producer to dead-letter invalid messages", func() {
+			Expect(validate(`        format: json_schema
+        file: order.json`, "")).To(MatchError(ContainSubstring("schema on_failure dead_letter requires a dead_letter producer")))
+		})
+	})
+
+	Describe("NATS configuration", func() {
+		validate := func(nats string) error {
+			connectorFile := `
+type: connector
+spec:
+  description: An outlet connecting with TLS
+  runtime_id: wombat
+  steps:
+    consumer:
+      nats:
+        url: tls://localhost:4222
+` + nats + `
+      core:
+        subject: orders
+    sink:
+      type: stdout
+      config: {}
+`
+			filePath := filepath.Join(tempDir, "nats.yml")
+			Expect(os.WriteFile(filePath, []byte(connectorFile), 0644)).To(Succeed())
+			return validator.ValidateConnectorFile(filePath)
+		}
+
+		It("should accept TLS with an authentication method", func() {
+			Expect(validate(`        tls:
+          ca_file: ca.pem
+          cert_file: client.pem
+          key_file: client-key.pem
+        creds:
+          file: user.creds`)).To(Succeed())
+			Expect(validate(`        user: app
+        password: s3cret`)).To(Succeed())
+		})
+
+		It("should require the client certificate and key together", func() {
+			Expect(validate(`        tls:
+          cert_file: client.pem`)).To(MatchError(ContainSubstring("invalid consumer NATS configuration: tls cert_file and key_file must be set together")))
+		})
+
+		It("should require exactly one source of credentials", func() {
+			Expect(validate(`        creds: {}`)).To(MatchError(ContainSubstring("creds requires exactly one of file or secret")))
+			Expect(validate(`        creds:
+          file: user.creds
+          secret: NATS_CREDS`)).To(MatchError(ContainSubstring("creds requires exactly one of file or secret")))
+		})
+
+		It("should reject several authentication methods", func() {
+			Expect(validate(`        nkey: user.nk
+        token: t0ken`)).To(MatchError(ContainSubstring("only one of auth_enabled, creds, nkey, user or token")))
+			Expect(validate(`        password: s3cret`)).To(MatchError(ContainSubstring("password requires a user")))
+		})
+	})
 })